@@ -221,6 +221,21 @@ func TextAndHash(data []byte) ([]byte, string) {
 	return hasher.Sum(nil), msg
 }
 
+// DataHash returns the hash that wallets sign in SignData for data of the given mimetype, which is
+// keccak256(data). The data of MimetypeIstanbulHeader is the RLP encoding of an Istanbul header with a
+// clean seal, and the proposer seal signs the keccak256 of its hash, so it is hashed twice.
+func DataHash(mimeType string, data []byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(data)
+	hash := hasher.Sum(nil)
+	if mimeType == MimetypeIstanbulHeader {
+		hasher.Reset()
+		hasher.Write(hash)
+		hash = hasher.Sum(nil)
+	}
+	return hash
+}
+
 // WalletEventType represents the different event types that can be fired by
 // the wallet subscription subsystem.
 type WalletEventType int
@@ -26,7 +26,6 @@ import (
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -159,9 +158,9 @@ func (w *keystoreWallet) GenerateProofOfPossessionBLS(account accounts.Account,
 	return w.keystore.GenerateProofOfPossessionBLS(account, address)
 }
 
-// SignData signs keccak256(data). The mimetype parameter describes the type of data being signed
+// SignData signs keccak256(data), see accounts.DataHash. The mimetype parameter describes the type of data being signed
 func (w *keystoreWallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
-	return w.signHash(account, crypto.Keccak256(data))
+	return w.signHash(account, accounts.DataHash(mimeType, data))
 }
 
 // SignHash implements accounts.Wallet, attempting to sign the given hash with
@@ -174,7 +173,7 @@ func (w *keystoreWallet) SignHash(account accounts.Account, hash []byte) ([]byte
 	return w.signHash(account, hash)
 }
 
-// SignDataWithPassphrase signs keccak256(data). The mimetype parameter describes the type of data being signed
+// SignDataWithPassphrase signs keccak256(data), see accounts.DataHash. The mimetype parameter describes the type of data being signed
 func (w *keystoreWallet) SignDataWithPassphrase(account accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
 	// Make sure the requested account is contained within
 	if !w.Contains(account) {
@@ -182,7 +181,7 @@ func (w *keystoreWallet) SignDataWithPassphrase(account accounts.Account, passph
 		return nil, accounts.ErrUnknownAccount
 	}
 	// Account seems valid, request the keystore to sign
-	return w.keystore.SignHashWithPassphrase(account, passphrase, crypto.Keccak256(data))
+	return w.keystore.SignHashWithPassphrase(account, passphrase, accounts.DataHash(mimeType, data))
 }
 
 func (w *keystoreWallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
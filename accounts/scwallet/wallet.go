@@ -700,7 +700,7 @@ func (w *Wallet) SelfDerive(bases []accounts.DerivationPath, chain ethereum.Chai
 // the needed details via SignDataWithPassphrase, or by other means (e.g. unlock
 // the account in a keystore).
 func (w *Wallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
-	return w.signHash(account, crypto.Keccak256(data))
+	return w.signHash(account, accounts.DataHash(mimeType, data))
 }
 
 // SignHash implements accounts.Wallet, attempting to sign the given hash with
@@ -752,7 +752,7 @@ func (w *Wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID
 // It looks up the account specified either solely via its address contained within,
 // or optionally with the aid of any location metadata from the embedded URL field.
 func (w *Wallet) SignDataWithPassphrase(account accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
-	return w.signHashWithPassphrase(account, passphrase, crypto.Keccak256(data))
+	return w.signHashWithPassphrase(account, passphrase, accounts.DataHash(mimeType, data))
 }
 
 func (w *Wallet) signHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
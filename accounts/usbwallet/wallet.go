@@ -611,9 +611,9 @@ func (w *wallet) GenerateProofOfPossessionBLS(account accounts.Account, address
 	return nil, nil, accounts.ErrNotSupported
 }
 
-// SignData signs keccak256(data). The mimetype parameter describes the type of data being signed
+// SignData signs keccak256(data), see accounts.DataHash. The mimetype parameter describes the type of data being signed
 func (w *wallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
-	return w.signHash(account, crypto.Keccak256(data))
+	return w.signHash(account, accounts.DataHash(mimeType, data))
 }
 
 // SignHash implements accounts.Wallet, attempting to sign the given hash with
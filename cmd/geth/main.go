@@ -138,12 +138,48 @@ var (
 		utils.EVMInterpreterFlag,
 		configFileFlag,
 		utils.IstanbulRequestTimeoutFlag,
+		utils.IstanbulMaxRequestTimeoutFlag,
+		utils.IstanbulProposalTimeoutFlag,
 		utils.IstanbulBlockPeriodFlag,
+		utils.IstanbulAllowedClockSkewFlag,
+		utils.IstanbulMaxFutureBlockTimeFlag,
+		utils.IstanbulBlockTimeCatchupRateFlag,
 		utils.IstanbulProposerPolicyFlag,
 		utils.IstanbulLookbackWindowFlag,
+		utils.IstanbulMinValidatorsToStartFlag,
+		utils.IstanbulConsensusCatchupThresholdFlag,
+		utils.IstanbulAggregatedSealCacheSizeFlag,
+		utils.IstanbulMessageVerifyWorkersFlag,
+		utils.IstanbulPrioritizeConsensusMsgsFlag,
+		utils.IstanbulMaxFutureMessagesFlag,
+		utils.IstanbulMaxFutureMessagesPerSenderFlag,
+		utils.IstanbulSignerTimeoutFlag,
+		utils.IstanbulRoundStateHistorySizeFlag,
+		utils.IstanbulRoundStateRetentionFlag,
 		utils.IstanbulReplicaFlag,
+		utils.IstanbulShadowValidatorFlag,
+		utils.IstanbulObserverModeFlag,
+		utils.IstanbulSingleValidatorModeFlag,
+		utils.IstanbulSelfProposalFailureThresholdFlag,
+		utils.IstanbulSelfProposalCooldownFlag,
+		utils.IstanbulGracefulShutdownTimeoutFlag,
+		utils.IstanbulHaltOnSafetyViolationFlag,
+		utils.IstanbulHealthLogIntervalFlag,
+		utils.IstanbulEquivocationPolicyFlag,
+		utils.IstanbulRoundChangeDeterministicOffsetFlag,
+		utils.IstanbulAggregateRoundChangeFlag,
 		utils.AnnounceQueryEnodeGossipPeriodFlag,
 		utils.AnnounceAggressiveQueryEnodeGossipOnEnablementFlag,
+		utils.AnnounceAggressiveMinPeersFlag,
+		utils.AnnounceGossipPeriodPerValidatorFlag,
+		utils.AnnounceMaxQueryEnodeGossipPeriodFlag,
+		utils.AnnounceMaxMessagesPerMinuteFlag,
+		utils.AnnounceGossipCoveragePeriodsFlag,
+		utils.AnnounceMessageTTLFlag,
+		utils.AnnounceAdaptiveGossipFlag,
+		utils.AnnounceAllowlistFlag,
+		utils.AnnounceRelayEndpointFlag,
+		utils.AnnounceStrictVersionCertificatesFlag,
 		utils.PingIPFromPacketFlag,
 		utils.UseInMemoryDiscoverTableFlag,
 		utils.VersionCheckFlag,
@@ -154,6 +190,8 @@ var (
 		utils.ProxyEnodeURLPairsFlag,
 		utils.ProxyEnodeURLPairsLegacyFlag,
 		utils.ProxyAllowPrivateIPFlag,
+		utils.ProxyHealthCheckIntervalFlag,
+		utils.ProxyPreferProxyPeersFlag,
 	}
 
 	rpcFlags = []cli.Flag{
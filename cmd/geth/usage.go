@@ -228,10 +228,36 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "ISTANBUL",
 		Flags: []cli.Flag{
 			utils.IstanbulRequestTimeoutFlag,
+			utils.IstanbulMaxRequestTimeoutFlag,
+			utils.IstanbulProposalTimeoutFlag,
 			utils.IstanbulBlockPeriodFlag,
+			utils.IstanbulAllowedClockSkewFlag,
+			utils.IstanbulMaxFutureBlockTimeFlag,
+			utils.IstanbulBlockTimeCatchupRateFlag,
 			utils.IstanbulProposerPolicyFlag,
 			utils.IstanbulLookbackWindowFlag,
+			utils.IstanbulMinValidatorsToStartFlag,
+			utils.IstanbulConsensusCatchupThresholdFlag,
+			utils.IstanbulAggregatedSealCacheSizeFlag,
+			utils.IstanbulMessageVerifyWorkersFlag,
+			utils.IstanbulPrioritizeConsensusMsgsFlag,
+			utils.IstanbulMaxFutureMessagesFlag,
+			utils.IstanbulMaxFutureMessagesPerSenderFlag,
+			utils.IstanbulSignerTimeoutFlag,
+			utils.IstanbulRoundStateHistorySizeFlag,
+			utils.IstanbulRoundStateRetentionFlag,
 			utils.IstanbulReplicaFlag,
+			utils.IstanbulShadowValidatorFlag,
+			utils.IstanbulObserverModeFlag,
+			utils.IstanbulSingleValidatorModeFlag,
+			utils.IstanbulSelfProposalFailureThresholdFlag,
+			utils.IstanbulSelfProposalCooldownFlag,
+			utils.IstanbulGracefulShutdownTimeoutFlag,
+			utils.IstanbulHaltOnSafetyViolationFlag,
+			utils.IstanbulHealthLogIntervalFlag,
+			utils.IstanbulEquivocationPolicyFlag,
+			utils.IstanbulRoundChangeDeterministicOffsetFlag,
+			utils.IstanbulAggregateRoundChangeFlag,
 		},
 	},
 	{
@@ -239,6 +265,16 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.AnnounceQueryEnodeGossipPeriodFlag,
 			utils.AnnounceAggressiveQueryEnodeGossipOnEnablementFlag,
+			utils.AnnounceAggressiveMinPeersFlag,
+			utils.AnnounceGossipPeriodPerValidatorFlag,
+			utils.AnnounceMaxQueryEnodeGossipPeriodFlag,
+			utils.AnnounceMaxMessagesPerMinuteFlag,
+			utils.AnnounceGossipCoveragePeriodsFlag,
+			utils.AnnounceMessageTTLFlag,
+			utils.AnnounceAdaptiveGossipFlag,
+			utils.AnnounceAllowlistFlag,
+			utils.AnnounceRelayEndpointFlag,
+			utils.AnnounceStrictVersionCertificatesFlag,
 		},
 	},
 	{
@@ -251,6 +287,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ProxyEnodeURLPairsFlag,
 			utils.ProxyEnodeURLPairsLegacyFlag,
 			utils.ProxyAllowPrivateIPFlag,
+			utils.ProxyHealthCheckIntervalFlag,
+			utils.ProxyPreferProxyPeersFlag,
 		},
 	},
 	{
@@ -727,11 +727,36 @@ var (
 		Usage: "Timeout for each Istanbul round in milliseconds",
 		Value: eth.DefaultConfig.Istanbul.RequestTimeout,
 	}
+	IstanbulMaxRequestTimeoutFlag = cli.Uint64Flag{
+		Name:  "istanbul.maxrequesttimeout",
+		Usage: "Maximum timeout for Istanbul rounds after the first in milliseconds, capping the exponential backoff (0 = no cap)",
+		Value: eth.DefaultConfig.Istanbul.MaxRequestTimeout,
+	}
+	IstanbulProposalTimeoutFlag = cli.Uint64Flag{
+		Name:  "istanbul.proposaltimeout",
+		Usage: "Time (in milliseconds) within which the proposer must send its proposal before validators move to the next round, must be smaller than the request timeout (0 = wait for the request timeout)",
+		Value: eth.DefaultConfig.Istanbul.ProposalTimeout,
+	}
 	IstanbulBlockPeriodFlag = cli.Uint64Flag{
 		Name:  "istanbul.blockperiod",
 		Usage: "Default minimum difference between two consecutive block's timestamps in seconds",
 		Value: eth.DefaultConfig.Istanbul.BlockPeriod,
 	}
+	IstanbulAllowedClockSkewFlag = cli.Uint64Flag{
+		Name:  "istanbul.allowedclockskew",
+		Usage: "Time in seconds that a proposal's timestamp may be ahead of the local clock and still be accepted right away, must be smaller than the block period (0 = wait for every future proposal)",
+		Value: eth.DefaultConfig.Istanbul.AllowedClockSkew,
+	}
+	IstanbulMaxFutureBlockTimeFlag = cli.Uint64Flag{
+		Name:  "istanbul.maxfutureblocktime",
+		Usage: "Time in seconds that a proposal's timestamp may be ahead of the local clock at most, proposals further ahead are rejected and lead to a round change, must not be smaller than the allowed clock skew (0 = no bound)",
+		Value: eth.DefaultConfig.Istanbul.MaxFutureBlockTime,
+	}
+	IstanbulBlockTimeCatchupRateFlag = cli.Uint64Flag{
+		Name:  "istanbul.blocktimecatchuprate",
+		Usage: "Maximum time in seconds by which a proposed block's timestamp may exceed its parent's plus the block period when the chain is behind the local clock, so that blocks keep being proposed a block period apart until their timestamps catch up (0 = move the timestamp to the local clock right away)",
+		Value: eth.DefaultConfig.Istanbul.BlockTimeCatchupRate,
+	}
 	IstanbulProposerPolicyFlag = cli.Uint64Flag{
 		Name:  "istanbul.proposerpolicy",
 		Usage: "Default minimum difference between two consecutive block's timestamps in seconds",
@@ -742,10 +767,108 @@ var (
 		Usage: "A validator's signature must be absent for this many consecutive blocks to be considered down for the uptime score",
 		Value: eth.DefaultConfig.Istanbul.LookbackWindow,
 	}
+	IstanbulMinValidatorsToStartFlag = cli.Uint64Flag{
+		Name:  "istanbul.minvalidatorstostart",
+		Usage: "Minimum number of connected, announce-verified validators (including this one) before proposing or changing rounds (0 = no minimum)",
+		Value: eth.DefaultConfig.Istanbul.MinValidatorsToStart,
+	}
+	IstanbulConsensusCatchupThresholdFlag = cli.Uint64Flag{
+		Name:  "istanbul.consensuscatchupthreshold",
+		Usage: "Number of sequences more than F validators must be ahead of this node for it to stop changing rounds and sync the missing blocks right away (0 = no catch-up)",
+		Value: eth.DefaultConfig.Istanbul.ConsensusCatchupThreshold,
+	}
+	IstanbulAggregatedSealCacheSizeFlag = cli.Uint64Flag{
+		Name:  "istanbul.aggregatedsealcachesize",
+		Usage: "Number of verified aggregated block seals to remember, so that re-verifying the same seal is skipped (0 = no cache)",
+		Value: eth.DefaultConfig.Istanbul.AggregatedSealCacheSize,
+	}
+	IstanbulMessageVerifyWorkersFlag = cli.Uint64Flag{
+		Name:  "istanbul.messageverifyworkers",
+		Usage: "Number of received consensus messages whose signature is verified in parallel (0 = number of CPUs)",
+		Value: eth.DefaultConfig.Istanbul.MessageVerifyWorkers,
+	}
+	IstanbulMaxFutureMessagesFlag = cli.Uint64Flag{
+		Name:  "istanbul.maxfuturemessages",
+		Usage: "Maximum number of future consensus messages buffered until their sequence or round is reached",
+		Value: eth.DefaultConfig.Istanbul.MaxFutureMessages,
+	}
+	IstanbulMaxFutureMessagesPerSenderFlag = cli.Uint64Flag{
+		Name:  "istanbul.maxfuturemessagespersender",
+		Usage: "Maximum number of future consensus messages buffered from one validator",
+		Value: eth.DefaultConfig.Istanbul.MaxFutureMessagesPerSender,
+	}
+	IstanbulSignerTimeoutFlag = cli.Uint64Flag{
+		Name:  "istanbul.signertimeout",
+		Usage: "Maximum time in milliseconds to wait for the signer, e.g. an external signer set with --signer, to sign a consensus message or seal, must be smaller than the request timeout (0 = wait indefinitely)",
+		Value: eth.DefaultConfig.Istanbul.SignerTimeout,
+	}
+	IstanbulRoundStateHistorySizeFlag = cli.Uint64Flag{
+		Name:  "istanbul.roundstatehistorysize",
+		Usage: "Number of round states, one per consensus state transition, kept for istanbul_dumpRoundStateHistory (0 = no history)",
+		Value: eth.DefaultConfig.Istanbul.RoundStateHistorySize,
+	}
+	IstanbulRoundStateRetentionFlag = cli.Uint64Flag{
+		Name:  "istanbul.roundstateretention",
+		Usage: "Number of committed sequences whose round states are kept in the round states DB, older ones are periodically pruned (0 = keep every round state)",
+		Value: eth.DefaultConfig.Istanbul.RoundStateRetention,
+	}
 	IstanbulReplicaFlag = cli.BoolFlag{
 		Name:  "istanbul.replica",
 		Usage: "Run this node as a validator replica. Must be paired with --mine. Use the RPCs to enable participation in consensus.",
 	}
+	IstanbulShadowValidatorFlag = cli.BoolFlag{
+		Name:  "istanbul.shadowvalidator",
+		Usage: "Run consensus without sending consensus messages, proposals or blocks, logging the proposals this node would have made. Must be paired with --mine.",
+	}
+	IstanbulObserverModeFlag = cli.BoolFlag{
+		Name:  "istanbul.observermode",
+		Usage: "Interpret the received consensus messages to track rounds, commits and participation, reported by istanbul.observerStats, without validating. Can't be paired with --mine.",
+	}
+	IstanbulSingleValidatorModeFlag = cli.BoolFlag{
+		Name:  "istanbul.singlevalidatormode",
+		Usage: "Commit blocks right away without running the full consensus when this node is the only validator, for local development. Ignored with more than one validator. Must be paired with --mine.",
+	}
+	IstanbulSelfProposalFailureThresholdFlag = cli.Uint64Flag{
+		Name:  "istanbul.selfproposalfailurethreshold",
+		Usage: "Number of consecutive turns in which this node's proposal wasn't committed after which it pauses proposing (0 = never pause)",
+		Value: eth.DefaultConfig.Istanbul.SelfProposalFailureThreshold,
+	}
+	IstanbulSelfProposalCooldownFlag = cli.Uint64Flag{
+		Name:  "istanbul.selfproposalcooldown",
+		Usage: "Number of blocks for which this node pauses proposing after repeated failed proposals",
+		Value: eth.DefaultConfig.Istanbul.SelfProposalCooldown,
+	}
+	IstanbulGracefulShutdownTimeoutFlag = cli.Uint64Flag{
+		Name:  "istanbul.gracefulshutdowntimeout",
+		Usage: "Maximum time in milliseconds to wait on shutdown for the current sequence to be committed before stopping validating, and then for the pending announce messages to be sent. Zero stops right away",
+		Value: eth.DefaultConfig.Istanbul.GracefulShutdownTimeout,
+	}
+	IstanbulHaltOnSafetyViolationFlag = cli.BoolFlag{
+		Name:  "istanbul.haltonsafetyviolation",
+		Usage: "Stop validating and refuse to produce or accept blocks once a block with a valid aggregated seal that conflicts with the local chain is received, until the node is restarted",
+	}
+	IstanbulHealthLogIntervalFlag = cli.Uint64Flag{
+		Name:  "istanbul.healthloginterval",
+		Usage: "Number of blocks between two one line summaries of the consensus health in the log (0 = disabled)",
+		Value: eth.DefaultConfig.Istanbul.HealthLogInterval,
+	}
+	IstanbulPrioritizeConsensusMsgsFlag = cli.BoolFlag{
+		Name:  "istanbul.prioritizeconsensusmsgs",
+		Usage: "Queue received consensus and announce messages separately, and only handle announce messages while no consensus message is waiting, so that consensus messages aren't delayed behind announce gossip",
+	}
+	IstanbulEquivocationPolicyFlag = cli.Uint64Flag{
+		Name:  "istanbul.equivocationpolicy",
+		Usage: "How to respond to two conflicting proposals of the proposer of the same round, whose evidence is recorded either way: 0 to continue with the first proposal, 1 to move to the next round right away",
+		Value: uint64(eth.DefaultConfig.Istanbul.EquivocationPolicy),
+	}
+	IstanbulRoundChangeDeterministicOffsetFlag = cli.BoolFlag{
+		Name:  "istanbul.roundchangedeterministicoffset",
+		Usage: "Shorten each round change resend interval by an offset derived from this node's address instead of a random one, within the resend jitter. Gives every validator a stable, reproducible phase in the resend schedule",
+	}
+	IstanbulAggregateRoundChangeFlag = cli.BoolFlag{
+		Name:  "istanbul.aggregateroundchange",
+		Usage: "Send the first round change message for a round only to the proposer of that round, which aggregates them in its proposal, instead of broadcasting it. Reduces the consensus messages on large validator sets",
+	}
 
 	// Announce settings
 	AnnounceQueryEnodeGossipPeriodFlag = cli.Uint64Flag{
@@ -757,6 +880,52 @@ var (
 		Name:  "announce.aggressivequeryenodegossiponenablement",
 		Usage: "Specifies if this node should aggressively query enodes on announce enablement",
 	}
+	AnnounceAggressiveMinPeersFlag = cli.Uint64Flag{
+		Name:  "announce.aggressiveminpeers",
+		Usage: "Minimum number of connected validator peers before the aggressive query enode gossip on announce enablement starts, it is deferred until then (0 = don't wait)",
+		Value: eth.DefaultConfig.Istanbul.AnnounceAggressiveMinPeers,
+	}
+	AnnounceGossipPeriodPerValidatorFlag = cli.Uint64Flag{
+		Name:  "announce.gossipperiodpervalidator",
+		Usage: "Time duration (in seconds) added to the query enode gossip period for each elected validator (0 = don't scale the period)",
+		Value: eth.DefaultConfig.Istanbul.AnnounceGossipPeriodPerValidator,
+	}
+	AnnounceMaxQueryEnodeGossipPeriodFlag = cli.Uint64Flag{
+		Name:  "announce.maxqueryenodegossipperiod",
+		Usage: "Maximum time duration (in seconds) between gossiped query enode messages when the period is scaled with the number of elected validators",
+		Value: eth.DefaultConfig.Istanbul.AnnounceMaxQueryEnodeGossipPeriod,
+	}
+	AnnounceMaxMessagesPerMinuteFlag = cli.Uint64Flag{
+		Name:  "announce.maxmessagesperminute",
+		Usage: "Maximum number of announce messages handled per minute from a non-validator peer, validator peers get a higher limit (0 = no limit)",
+		Value: eth.DefaultConfig.Istanbul.AnnounceMaxMessagesPerMinute,
+	}
+	AnnounceGossipCoveragePeriodsFlag = cli.Uint64Flag{
+		Name:  "announce.gossipcoverageperiods",
+		Usage: "Number of query enode gossip periods over which the non-elected validators are queried in rotating slices. Zero or one queries every validator every period",
+		Value: eth.DefaultConfig.Istanbul.AnnounceGossipCoveragePeriods,
+	}
+	AnnounceMessageTTLFlag = cli.Uint64Flag{
+		Name:  "announce.messagettl",
+		Usage: "Maximum age (in seconds) of a received query enode message, older messages are dropped and not regossiped (0 = no limit)",
+		Value: eth.DefaultConfig.Istanbul.AnnounceMessageTTL,
+	}
+	AnnounceAdaptiveGossipFlag = cli.BoolFlag{
+		Name:  "announce.adaptivegossip",
+		Usage: "Lower the number of additional non-elected validators gossiped to while consensus messages take long to be handled, and restore it once they don't",
+	}
+	AnnounceAllowlistFlag = cli.StringFlag{
+		Name:  "announce.allowlist",
+		Usage: "Comma separated validator addresses whose query enode messages are answered (default = all validators)",
+	}
+	AnnounceRelayEndpointFlag = cli.StringFlag{
+		Name:  "announce.relayendpoint",
+		Usage: "HTTP(S) URL of a relay through which enode certificates are exchanged with validators that can't be reached directly (default = no relay)",
+	}
+	AnnounceStrictVersionCertificatesFlag = cli.BoolFlag{
+		Name:  "announce.strictversioncertificates",
+		Usage: "Drop received version certificates whose version is lower than the highest one seen for their validator",
+	}
 
 	// Proxy node settings
 	ProxyFlag = cli.BoolFlag{
@@ -792,6 +961,16 @@ var (
 		Name:  "proxy.allowprivateip",
 		Usage: "Specifies whether private IP is allowed for external facing proxy enodeURL",
 	}
+
+	ProxyHealthCheckIntervalFlag = cli.Uint64Flag{
+		Name:  "proxy.healthcheckinterval",
+		Usage: "Time duration (in seconds) between health checks of the proxied validator's connections to its proxies",
+		Value: eth.DefaultConfig.Istanbul.ProxyHealthCheckInterval,
+	}
+	ProxyPreferProxyPeersFlag = cli.BoolFlag{
+		Name:  "proxy.preferproxypeers",
+		Usage: "Specifies whether validators connecting directly to this proxied validator are treated as regular peers while a proxy is connected, relying on the proxies to relay their messages",
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -1406,21 +1585,136 @@ func setIstanbul(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(IstanbulRequestTimeoutFlag.Name) {
 		cfg.Istanbul.RequestTimeout = ctx.GlobalUint64(IstanbulRequestTimeoutFlag.Name)
 	}
+	if ctx.GlobalIsSet(IstanbulMaxRequestTimeoutFlag.Name) {
+		cfg.Istanbul.MaxRequestTimeout = ctx.GlobalUint64(IstanbulMaxRequestTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulProposalTimeoutFlag.Name) {
+		cfg.Istanbul.ProposalTimeout = ctx.GlobalUint64(IstanbulProposalTimeoutFlag.Name)
+	}
 	if ctx.GlobalIsSet(IstanbulBlockPeriodFlag.Name) {
 		cfg.Istanbul.BlockPeriod = ctx.GlobalUint64(IstanbulBlockPeriodFlag.Name)
 	}
+	if ctx.GlobalIsSet(IstanbulAllowedClockSkewFlag.Name) {
+		cfg.Istanbul.AllowedClockSkew = ctx.GlobalUint64(IstanbulAllowedClockSkewFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulMaxFutureBlockTimeFlag.Name) {
+		cfg.Istanbul.MaxFutureBlockTime = ctx.GlobalUint64(IstanbulMaxFutureBlockTimeFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulBlockTimeCatchupRateFlag.Name) {
+		cfg.Istanbul.BlockTimeCatchupRate = ctx.GlobalUint64(IstanbulBlockTimeCatchupRateFlag.Name)
+	}
 	if ctx.GlobalIsSet(IstanbulLookbackWindowFlag.Name) {
 		cfg.Istanbul.LookbackWindow = ctx.GlobalUint64(IstanbulLookbackWindowFlag.Name)
 	}
+	if ctx.GlobalIsSet(IstanbulMinValidatorsToStartFlag.Name) {
+		cfg.Istanbul.MinValidatorsToStart = ctx.GlobalUint64(IstanbulMinValidatorsToStartFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulConsensusCatchupThresholdFlag.Name) {
+		cfg.Istanbul.ConsensusCatchupThreshold = ctx.GlobalUint64(IstanbulConsensusCatchupThresholdFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulAggregatedSealCacheSizeFlag.Name) {
+		cfg.Istanbul.AggregatedSealCacheSize = ctx.GlobalUint64(IstanbulAggregatedSealCacheSizeFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulMessageVerifyWorkersFlag.Name) {
+		cfg.Istanbul.MessageVerifyWorkers = ctx.GlobalUint64(IstanbulMessageVerifyWorkersFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulPrioritizeConsensusMsgsFlag.Name) {
+		cfg.Istanbul.PrioritizeConsensusMsgs = ctx.GlobalBool(IstanbulPrioritizeConsensusMsgsFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulMaxFutureMessagesFlag.Name) {
+		cfg.Istanbul.MaxFutureMessages = ctx.GlobalUint64(IstanbulMaxFutureMessagesFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulMaxFutureMessagesPerSenderFlag.Name) {
+		cfg.Istanbul.MaxFutureMessagesPerSender = ctx.GlobalUint64(IstanbulMaxFutureMessagesPerSenderFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulSignerTimeoutFlag.Name) {
+		cfg.Istanbul.SignerTimeout = ctx.GlobalUint64(IstanbulSignerTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulRoundStateHistorySizeFlag.Name) {
+		cfg.Istanbul.RoundStateHistorySize = ctx.GlobalUint64(IstanbulRoundStateHistorySizeFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulRoundStateRetentionFlag.Name) {
+		cfg.Istanbul.RoundStateRetention = ctx.GlobalUint64(IstanbulRoundStateRetentionFlag.Name)
+	}
 	if ctx.GlobalIsSet(IstanbulProposerPolicyFlag.Name) {
 		cfg.Istanbul.ProposerPolicy = istanbul.ProposerPolicy(ctx.GlobalUint64(IstanbulProposerPolicyFlag.Name))
 	}
+	if ctx.GlobalIsSet(AnnounceQueryEnodeGossipPeriodFlag.Name) {
+		cfg.Istanbul.AnnounceQueryEnodeGossipPeriod = ctx.GlobalUint64(AnnounceQueryEnodeGossipPeriodFlag.Name)
+	}
+	if ctx.GlobalIsSet(AnnounceAggressiveMinPeersFlag.Name) {
+		cfg.Istanbul.AnnounceAggressiveMinPeers = ctx.GlobalUint64(AnnounceAggressiveMinPeersFlag.Name)
+	}
+	if ctx.GlobalIsSet(AnnounceGossipPeriodPerValidatorFlag.Name) {
+		cfg.Istanbul.AnnounceGossipPeriodPerValidator = ctx.GlobalUint64(AnnounceGossipPeriodPerValidatorFlag.Name)
+	}
+	if ctx.GlobalIsSet(AnnounceMaxQueryEnodeGossipPeriodFlag.Name) {
+		cfg.Istanbul.AnnounceMaxQueryEnodeGossipPeriod = ctx.GlobalUint64(AnnounceMaxQueryEnodeGossipPeriodFlag.Name)
+	}
+	if ctx.GlobalIsSet(AnnounceMaxMessagesPerMinuteFlag.Name) {
+		cfg.Istanbul.AnnounceMaxMessagesPerMinute = ctx.GlobalUint64(AnnounceMaxMessagesPerMinuteFlag.Name)
+	}
+	if ctx.GlobalIsSet(AnnounceGossipCoveragePeriodsFlag.Name) {
+		cfg.Istanbul.AnnounceGossipCoveragePeriods = ctx.GlobalUint64(AnnounceGossipCoveragePeriodsFlag.Name)
+	}
+	if ctx.GlobalIsSet(AnnounceMessageTTLFlag.Name) {
+		cfg.Istanbul.AnnounceMessageTTL = ctx.GlobalUint64(AnnounceMessageTTLFlag.Name)
+	}
+	if ctx.GlobalIsSet(AnnounceAdaptiveGossipFlag.Name) {
+		cfg.Istanbul.AnnounceAdaptiveGossip = ctx.GlobalBool(AnnounceAdaptiveGossipFlag.Name)
+	}
+	if ctx.GlobalIsSet(AnnounceAllowlistFlag.Name) {
+		for _, address := range strings.Split(ctx.GlobalString(AnnounceAllowlistFlag.Name), ",") {
+			if trimmed := strings.TrimSpace(address); !common.IsHexAddress(trimmed) {
+				Fatalf("Invalid address in --%s: %s", AnnounceAllowlistFlag.Name, trimmed)
+			} else {
+				cfg.Istanbul.AnnounceAllowlist = append(cfg.Istanbul.AnnounceAllowlist, common.HexToAddress(trimmed))
+			}
+		}
+	}
+	if ctx.GlobalIsSet(AnnounceRelayEndpointFlag.Name) {
+		cfg.Istanbul.AnnounceRelayEndpoint = ctx.GlobalString(AnnounceRelayEndpointFlag.Name)
+	}
+	if ctx.GlobalIsSet(AnnounceStrictVersionCertificatesFlag.Name) {
+		cfg.Istanbul.StrictVersionCertificates = ctx.GlobalBool(AnnounceStrictVersionCertificatesFlag.Name)
+	}
 	cfg.Istanbul.ReplicaStateDBPath = stack.ResolvePath(cfg.Istanbul.ReplicaStateDBPath)
 	cfg.Istanbul.ValidatorEnodeDBPath = stack.ResolvePath(cfg.Istanbul.ValidatorEnodeDBPath)
 	cfg.Istanbul.VersionCertificateDBPath = stack.ResolvePath(cfg.Istanbul.VersionCertificateDBPath)
 	cfg.Istanbul.RoundStateDBPath = stack.ResolvePath(cfg.Istanbul.RoundStateDBPath)
 	cfg.Istanbul.Validator = ctx.GlobalIsSet(MiningEnabledFlag.Name)
 	cfg.Istanbul.Replica = ctx.GlobalIsSet(IstanbulReplicaFlag.Name)
+	cfg.Istanbul.ShadowValidator = ctx.GlobalIsSet(IstanbulShadowValidatorFlag.Name)
+	if ctx.GlobalIsSet(IstanbulSingleValidatorModeFlag.Name) {
+		cfg.Istanbul.SingleValidatorMode = ctx.GlobalBool(IstanbulSingleValidatorModeFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulObserverModeFlag.Name) {
+		cfg.Istanbul.ObserverMode = ctx.GlobalBool(IstanbulObserverModeFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulSelfProposalFailureThresholdFlag.Name) {
+		cfg.Istanbul.SelfProposalFailureThreshold = ctx.GlobalUint64(IstanbulSelfProposalFailureThresholdFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulSelfProposalCooldownFlag.Name) {
+		cfg.Istanbul.SelfProposalCooldown = ctx.GlobalUint64(IstanbulSelfProposalCooldownFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulGracefulShutdownTimeoutFlag.Name) {
+		cfg.Istanbul.GracefulShutdownTimeout = ctx.GlobalUint64(IstanbulGracefulShutdownTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulHaltOnSafetyViolationFlag.Name) {
+		cfg.Istanbul.HaltOnSafetyViolation = ctx.GlobalBool(IstanbulHaltOnSafetyViolationFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulHealthLogIntervalFlag.Name) {
+		cfg.Istanbul.HealthLogInterval = ctx.GlobalUint64(IstanbulHealthLogIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulEquivocationPolicyFlag.Name) {
+		cfg.Istanbul.EquivocationPolicy = istanbul.EquivocationPolicy(ctx.GlobalUint64(IstanbulEquivocationPolicyFlag.Name))
+	}
+	if ctx.GlobalIsSet(IstanbulRoundChangeDeterministicOffsetFlag.Name) {
+		cfg.Istanbul.RoundChangeDeterministicOffset = ctx.GlobalBool(IstanbulRoundChangeDeterministicOffsetFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulAggregateRoundChangeFlag.Name) {
+		cfg.Istanbul.AggregateRoundChange = ctx.GlobalBool(IstanbulAggregateRoundChangeFlag.Name)
+	}
 }
 
 func setProxyP2PConfig(ctx *cli.Context, proxyCfg *p2p.Config) {
@@ -1522,6 +1816,14 @@ func SetProxyConfig(ctx *cli.Context, nodeCfg *node.Config, ethCfg *eth.Config)
 			}
 		}
 
+		if ctx.GlobalIsSet(ProxyHealthCheckIntervalFlag.Name) {
+			ethCfg.Istanbul.ProxyHealthCheckInterval = ctx.GlobalUint64(ProxyHealthCheckIntervalFlag.Name)
+		}
+
+		if ctx.GlobalIsSet(ProxyPreferProxyPeersFlag.Name) {
+			ethCfg.Istanbul.PreferProxyPeers = ctx.GlobalBool(ProxyPreferProxyPeersFlag.Name)
+		}
+
 		if !ctx.GlobalBool(NoDiscoverFlag.Name) {
 			Fatalf("Option --%s must be used if option --%s is used", NoDiscoverFlag.Name, ProxiedFlag.Name)
 		}
@@ -53,6 +53,9 @@ func (b *MockBroadcaster) FindPeers(targets map[enode.ID]bool, purpose p2p.Purpo
 	return make(map[enode.ID]consensus.Peer)
 }
 
+func (b *MockBroadcaster) Synchronise() {
+}
+
 type MockP2PServer struct {
 	Node *enode.Node
 }
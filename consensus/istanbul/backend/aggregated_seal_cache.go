@@ -0,0 +1,85 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core/types"
+	blscrypto "github.com/ethereum/go-ethereum/crypto/bls"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// aggregatedSealCache remembers the aggregated seals whose BLS signature was verified, so that verifying
+// the same seal again (e.g. when a header is verified both on import and by VerifySeal) skips the pairing.
+// A nil cache remembers nothing.
+type aggregatedSealCache struct {
+	verified *lru.Cache
+
+	hitMeter  metrics.Meter
+	missMeter metrics.Meter
+}
+
+func newAggregatedSealCache(size uint64) (*aggregatedSealCache, error) {
+	if size == 0 {
+		return nil, nil
+	}
+	verified, err := lru.New(int(size))
+	if err != nil {
+		return nil, err
+	}
+	return &aggregatedSealCache{
+		verified:  verified,
+		hitMeter:  metrics.NewRegisteredMeter("consensus/istanbul/backend/aggregatedseal/cache/hit", nil),
+		missMeter: metrics.NewRegisteredMeter("consensus/istanbul/backend/aggregatedseal/cache/miss", nil),
+	}, nil
+}
+
+// aggregatedSealCacheKey returns the key under which the verification of an aggregated seal is cached.
+// Besides the block hash and the signer bitmap, the key covers the signature and the public keys and
+// weights of the validators, so that a seal is never considered verified against a validator set it
+// wasn't verified with.
+func aggregatedSealCacheKey(headerHash common.Hash, aggregatedSeal types.IstanbulAggregatedSeal, validators istanbul.ValidatorSet) common.Hash {
+	publicKeys := make([]blscrypto.SerializedPublicKey, validators.Size())
+	weights := make([]uint64, validators.Size())
+	for i, val := range validators.List() {
+		publicKeys[i] = val.BLSPublicKey()
+		weights[i] = validators.GetWeight(val.Address())
+	}
+	return istanbul.RLPHash([]interface{}{headerHash, aggregatedSeal.Round, aggregatedSeal.Bitmap, aggregatedSeal.Signature, publicKeys, weights})
+}
+
+// isVerified returns whether the seal with the given key was verified before.
+func (c *aggregatedSealCache) isVerified(key common.Hash) bool {
+	if c == nil {
+		return false
+	}
+	if c.verified.Contains(key) {
+		c.hitMeter.Mark(1)
+		return true
+	}
+	c.missMeter.Mark(1)
+	return false
+}
+
+// markVerified records that the seal with the given key was verified.
+func (c *aggregatedSealCache) markVerified(key common.Hash) {
+	if c != nil {
+		c.verified.Add(key, struct{}{})
+	}
+}
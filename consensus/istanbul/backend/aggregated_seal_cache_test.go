@@ -0,0 +1,82 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
+	"github.com/ethereum/go-ethereum/core/types"
+	blscrypto "github.com/ethereum/go-ethereum/crypto/bls"
+)
+
+func TestAggregatedSealCache(t *testing.T) {
+	headerHash := common.HexToHash("0x01")
+	seal := types.IstanbulAggregatedSeal{Bitmap: big.NewInt(3), Signature: []byte{1, 2, 3}, Round: big.NewInt(0)}
+	validators := validator.NewSet([]istanbul.ValidatorData{
+		{Address: common.HexToAddress("0x01"), BLSPublicKey: blscrypto.SerializedPublicKey{1}},
+		{Address: common.HexToAddress("0x02"), BLSPublicKey: blscrypto.SerializedPublicKey{2}},
+	})
+	key := aggregatedSealCacheKey(headerHash, seal, validators)
+
+	// A nil cache never reports a seal as verified
+	var disabled *aggregatedSealCache
+	disabled.markVerified(key)
+	if disabled.isVerified(key) {
+		t.Errorf("disabled cache reported a verified seal")
+	}
+
+	cache, err := newAggregatedSealCache(2)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	if cache.isVerified(key) {
+		t.Errorf("seal reported as verified before it was")
+	}
+	cache.markVerified(key)
+	if !cache.isVerified(key) {
+		t.Errorf("verified seal not found in the cache")
+	}
+
+	// The same seal signed by other keys, e.g. after the validator set changed, must be verified again
+	otherValidators := validator.NewSet([]istanbul.ValidatorData{
+		{Address: common.HexToAddress("0x01"), BLSPublicKey: blscrypto.SerializedPublicKey{1}},
+		{Address: common.HexToAddress("0x03"), BLSPublicKey: blscrypto.SerializedPublicKey{3}},
+	})
+	if cache.isVerified(aggregatedSealCacheKey(headerHash, seal, otherValidators)) {
+		t.Errorf("seal reported as verified for another validator set")
+	}
+	// Nor can a seal verified before the validators were weighted skip the quorum check
+	weighted := validators.Copy()
+	weighted.SetWeights(map[common.Address]uint64{common.HexToAddress("0x02"): 5})
+	if cache.isVerified(aggregatedSealCacheKey(headerHash, seal, weighted)) {
+		t.Errorf("seal reported as verified for other validator weights")
+	}
+	otherRound := seal
+	otherRound.Round = big.NewInt(1)
+	if cache.isVerified(aggregatedSealCacheKey(headerHash, otherRound, validators)) {
+		t.Errorf("seal reported as verified for another round")
+	}
+	otherBitmap := seal
+	otherBitmap.Bitmap = big.NewInt(5)
+	if cache.isVerified(aggregatedSealCacheKey(headerHash, otherBitmap, validators)) {
+		t.Errorf("seal reported as verified for another bitmap")
+	}
+}
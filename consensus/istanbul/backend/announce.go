@@ -17,6 +17,7 @@
 package backend
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/hex"
@@ -24,6 +25,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"sort"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
@@ -52,6 +54,8 @@ var (
 	errInvalidEnodeCertMsgMapInconsistentVersion = errors.New("invalid enode certificate message map because of inconsistent version")
 
 	errNodeMissingEnodeCertificate = errors.New("Node is missing enode certificate")
+
+	errExpiredQueryEnodeMessage = errors.New("query enode message is older than the announce message TTL")
 )
 
 // QueryEnodeGossipFrequencyState specifies how frequently to gossip query enode messages
@@ -66,7 +70,8 @@ const (
 	// be partitioned with the broader p2p network. We want to give that p2p network some time to connect to the broader p2p network.
 	HighFreqAfterFirstPeerState
 
-	// LowFreqState will send out an query every config.AnnounceQueryEnodeGossipPeriod seconds
+	// LowFreqState will send out an query every config.AnnounceQueryEnodeGossipPeriod seconds, scaled with the
+	// validator set size if config.AnnounceGossipPeriodPerValidator is set
 	LowFreqState
 )
 
@@ -76,6 +81,7 @@ const (
 // 3) Periodically prune announce-related data structures
 // 4) Gossip announce messages periodically when requested
 // 5) Update announce version when requested
+// 6) Periodically fetch the announce messages relayed to this node, if it uses an announce relay
 func (sb *Backend) announceThread() {
 	logger := sb.logger.New("func", "announceThread")
 
@@ -88,12 +94,25 @@ func (sb *Backend) announceThread() {
 	// Occasionally share the entire version certificate table with all peers
 	shareVersionCertificatesTicker := time.NewTicker(5 * time.Minute)
 	pruneAnnounceDataStructuresTicker := time.NewTicker(10 * time.Minute)
+	adaptiveGossipTicker := time.NewTicker(adaptiveGossipAdjustPeriod)
+	// Fetch the announce messages relayed to this node, if it uses an announce relay
+	var announceRelayTickerCh <-chan time.Time
+	if sb.usesAnnounceRelay() {
+		announceRelayTicker := time.NewTicker(announceRelayPollPeriod)
+		defer announceRelayTicker.Stop()
+		announceRelayTickerCh = announceRelayTicker.C
+	}
 
 	var queryEnodeTicker *time.Ticker
 	var queryEnodeTickerCh <-chan time.Time
 	var queryEnodeFrequencyState QueryEnodeGossipFrequencyState
 	var currentQueryEnodeTickerDuration time.Duration
+	// The query enode period to use in the LowFreqState. Recomputed on every new epoch.
+	lowFreqQueryEnodeTickerDuration := sb.queryEnodeGossipPeriod()
+	logger.Info("Query enode gossip period", "period", lowFreqQueryEnodeTickerDuration)
 	var numQueryEnodesInHighFreqAfterFirstPeerState int
+	// Whether the aggressive query enode gossip waits for AnnounceAggressiveMinPeers validator peers
+	var aggressiveQueryEnodeDeferred bool
 	// TODO: this can be removed once we have more faith in this protocol
 	var updateAnnounceVersionTicker *time.Ticker
 	var updateAnnounceVersionTickerCh <-chan time.Time
@@ -150,14 +169,18 @@ func (sb *Backend) announceThread() {
 					sb.startGossipQueryEnodeTask()
 				})
 
-				if sb.config.AnnounceAggressiveQueryEnodeGossipOnEnablement {
+				aggressiveQueryEnodeDeferred = sb.config.AnnounceAggressiveQueryEnodeGossipOnEnablement && !sb.hasAggressiveQueryEnodePeers()
+				if aggressiveQueryEnodeDeferred {
+					logger.Info("Deferring aggressive query enode gossip until enough validator peers are connected", "min_peers", sb.config.AnnounceAggressiveMinPeers)
+				}
+				if sb.config.AnnounceAggressiveQueryEnodeGossipOnEnablement && !aggressiveQueryEnodeDeferred {
 					queryEnodeFrequencyState = HighFreqBeforeFirstPeerState
 					// Send an query enode message once a minute
 					currentQueryEnodeTickerDuration = 1 * time.Minute
 					numQueryEnodesInHighFreqAfterFirstPeerState = 0
 				} else {
 					queryEnodeFrequencyState = LowFreqState
-					currentQueryEnodeTickerDuration = time.Duration(sb.config.AnnounceQueryEnodeGossipPeriod) * time.Second
+					currentQueryEnodeTickerDuration = lowFreqQueryEnodeTickerDuration
 				}
 
 				// Enable periodic gossiping by setting announceGossipTickerCh to non nil value
@@ -174,7 +197,21 @@ func (sb *Backend) announceThread() {
 				queryEnodeTicker.Stop()
 				queryEnodeTickerCh = nil
 				querying = false
+				aggressiveQueryEnodeDeferred = false
 				logger.Trace("Disabled periodic gossiping of announce message (query mode)")
+
+			} else if querying && aggressiveQueryEnodeDeferred && sb.hasAggressiveQueryEnodePeers() {
+				logger.Info("Starting deferred aggressive query enode gossip", "min_peers", sb.config.AnnounceAggressiveMinPeers)
+
+				queryEnodeFrequencyState = HighFreqBeforeFirstPeerState
+				currentQueryEnodeTickerDuration = 1 * time.Minute
+				numQueryEnodesInHighFreqAfterFirstPeerState = 0
+				queryEnodeTicker.Stop()
+				queryEnodeTicker = time.NewTicker(currentQueryEnodeTickerDuration)
+				queryEnodeTickerCh = queryEnodeTicker.C
+				sb.startGossipQueryEnodeTask()
+
+				aggressiveQueryEnodeDeferred = false
 			}
 
 			if shouldAnnounce && !announcing {
@@ -234,9 +271,9 @@ func (sb *Backend) announceThread() {
 					numQueryEnodesInHighFreqAfterFirstPeerState++
 
 				case LowFreqState:
-					if currentQueryEnodeTickerDuration != time.Duration(sb.config.AnnounceQueryEnodeGossipPeriod)*time.Second {
+					if currentQueryEnodeTickerDuration != lowFreqQueryEnodeTickerDuration {
 						// Reset the ticker
-						currentQueryEnodeTickerDuration = time.Duration(sb.config.AnnounceQueryEnodeGossipPeriod) * time.Second
+						currentQueryEnodeTickerDuration = lowFreqQueryEnodeTickerDuration
 						queryEnodeTicker.Stop()
 						queryEnodeTicker = time.NewTicker(currentQueryEnodeTickerDuration)
 						queryEnodeTickerCh = queryEnodeTicker.C
@@ -257,14 +294,63 @@ func (sb *Backend) announceThread() {
 				updateAnnounceVersionFunc()
 			}
 
+		case <-sb.announceNewEpochCh:
+			// The validator set size may have changed, so recompute the query enode gossip period.
+			// If it changed while in the LowFreqState, the ticker is reset right away.  Otherwise it
+			// will be picked up once the LowFreqState is entered.
+			if period := sb.queryEnodeGossipPeriod(); period != lowFreqQueryEnodeTickerDuration {
+				logger.Info("Query enode gossip period changed", "old period", lowFreqQueryEnodeTickerDuration, "new period", period)
+				lowFreqQueryEnodeTickerDuration = period
+				if querying && queryEnodeFrequencyState == LowFreqState {
+					currentQueryEnodeTickerDuration = lowFreqQueryEnodeTickerDuration
+					queryEnodeTicker.Stop()
+					queryEnodeTicker = time.NewTicker(currentQueryEnodeTickerDuration)
+					queryEnodeTickerCh = queryEnodeTicker.C
+				}
+			}
+
 		case <-pruneAnnounceDataStructuresTicker.C:
 			if err := sb.pruneAnnounceDataStructures(); err != nil {
 				logger.Warn("Error in pruning announce data structures", "err", err)
 			}
 
+		case <-adaptiveGossipTicker.C:
+			if sb.config.AnnounceAdaptiveGossip {
+				if previous, effective := sb.adaptiveGossip.adjust(); effective != previous {
+					logger.Info("Adjusted the number of additional validators to gossip to", "previous", previous, "effective", effective, "configured", sb.config.AnnounceAdditionalValidatorsToGossip)
+				}
+			}
+
+		case <-announceRelayTickerCh:
+			if shouldQuery {
+				go sb.pollAnnounceRelay()
+			}
+
+		case drained := <-sb.announceDrainCh:
+			// Send the query enode message and announce version update still pending, so that peers don't keep
+			// stale enode info until this node announces again after a restart
+			select {
+			case <-sb.generateAndGossipQueryEnodeCh:
+				if shouldQuery {
+					if _, err := sb.generateAndGossipQueryEnode(sb.GetAnnounceVersion(), queryEnodeFrequencyState == LowFreqState); err != nil {
+						logger.Warn("Error in generating and gossiping queryEnode", "err", err)
+					}
+				}
+			default:
+			}
+			select {
+			case <-sb.updateAnnounceVersionCh:
+				if shouldAnnounce {
+					updateAnnounceVersionFunc()
+				}
+			default:
+			}
+			close(drained)
+
 		case <-sb.announceThreadQuit:
 			checkIfShouldAnnounceTicker.Stop()
 			pruneAnnounceDataStructuresTicker.Stop()
+			adaptiveGossipTicker.Stop()
 			if querying {
 				queryEnodeTicker.Stop()
 
@@ -272,9 +358,52 @@ func (sb *Backend) announceThread() {
 			if announcing {
 				updateAnnounceVersionTicker.Stop()
 			}
+			sb.announceStats.setQueryEnodeGossip(false, 0, false)
 			return
 		}
+
+		sb.announceStats.setQueryEnodeGossip(querying, currentQueryEnodeTickerDuration, querying && queryEnodeFrequencyState != LowFreqState)
+	}
+}
+
+// queryEnodeGossipPeriod returns the period between query enode messages in the LowFreqState.
+// If config.AnnounceGossipPeriodPerValidator is set, the period is scaled with the number of
+// currently elected validators.
+func (sb *Backend) queryEnodeGossipPeriod() time.Duration {
+	numValidators := 0
+	if sb.config.AnnounceGossipPeriodPerValidator > 0 {
+		block := sb.currentBlock()
+		numValidators = sb.getValidators(block.Number().Uint64(), block.Hash()).Size()
+	}
+	return scaledQueryEnodeGossipPeriod(sb.config, numValidators)
+}
+
+// scaledQueryEnodeGossipPeriod computes AnnounceQueryEnodeGossipPeriod + AnnounceGossipPeriodPerValidator * numValidators,
+// clamped to AnnounceMaxQueryEnodeGossipPeriod.  If AnnounceGossipPeriodPerValidator is zero, the period is not scaled.
+func scaledQueryEnodeGossipPeriod(config *istanbul.Config, numValidators int) time.Duration {
+	period := config.AnnounceQueryEnodeGossipPeriod
+	if config.AnnounceGossipPeriodPerValidator > 0 {
+		period += config.AnnounceGossipPeriodPerValidator * uint64(numValidators)
+		if config.AnnounceMaxQueryEnodeGossipPeriod > 0 && period > config.AnnounceMaxQueryEnodeGossipPeriod {
+			period = config.AnnounceMaxQueryEnodeGossipPeriod
+		}
 	}
+	return time.Duration(period) * time.Second
+}
+
+// hasAggressiveQueryEnodePeers returns whether this node is connected to enough validator peers for the
+// aggressive query enode gossip on announce enablement to be useful. A proxied validator is only connected
+// to validators through its proxies, so it doesn't wait for validator peers.
+func (sb *Backend) hasAggressiveQueryEnodePeers() bool {
+	if sb.config.AnnounceAggressiveMinPeers == 0 || sb.IsProxiedValidator() {
+		return true
+	}
+	return enoughAggressiveQueryEnodePeers(sb.config, len(sb.broadcaster.FindPeers(nil, p2p.ValidatorPurpose)))
+}
+
+// enoughAggressiveQueryEnodePeers returns whether numValidatorPeers reaches config.AnnounceAggressiveMinPeers.
+func enoughAggressiveQueryEnodePeers(config *istanbul.Config, numValidatorPeers int) bool {
+	return uint64(numValidatorPeers) >= config.AnnounceAggressiveMinPeers
 }
 
 // startGossipQueryEnodeTask will schedule a task for the announceThread to
@@ -305,7 +434,7 @@ func (sb *Backend) shouldParticipateInAnnounce() (bool, error) {
 // 1)  lastQueryEnodeGossiped
 // 2)  valEnodeTable
 // 3)  lastVersionCertificatesGossiped
-// 4)  versionCertificateTable
+// 4)  versionCertificateTable, where entries are kept until they are older than config.VersionCertificateTTL
 func (sb *Backend) pruneAnnounceDataStructures() error {
 	logger := sb.logger.New("func", "pruneAnnounceDataStructures")
 
@@ -338,10 +467,16 @@ func (sb *Backend) pruneAnnounceDataStructures() error {
 	}
 	sb.lastVersionCertificatesGossipedMu.Unlock()
 
-	if err := sb.versionCertificateTable.Prune(validatorConnSet); err != nil {
+	var expiry time.Time
+	if sb.config.VersionCertificateTTL > 0 {
+		expiry = time.Now().Add(-time.Duration(sb.config.VersionCertificateTTL) * time.Second)
+	}
+	pruned, err := sb.versionCertificateTable.Prune(validatorConnSet, expiry)
+	if err != nil {
 		logger.Trace("Error in pruning versionCertificateTable", "err", err)
 		return err
 	}
+	sb.versionCertificatesPrunedMeter.Mark(int64(pruned))
 
 	return nil
 }
@@ -464,6 +599,9 @@ func (sb *Backend) generateAndGossipQueryEnode(version uint, enforceRetryBackoff
 	if err != nil {
 		return nil, err
 	}
+	if sb.config.AnnounceGossipCoveragePeriods > 1 {
+		valEnodeEntries = sb.rotateQueryEnodeValEnodeEntries(valEnodeEntries)
+	}
 
 	valAddresses := make([]common.Address, len(valEnodeEntries))
 	for i, valEnodeEntry := range valEnodeEntries {
@@ -513,6 +651,7 @@ func (sb *Backend) generateAndGossipQueryEnode(version uint, enforceRetryBackoff
 		if err = sb.Gossip(payload, istanbul.QueryEnodeMsg); err != nil {
 			return nil, err
 		}
+		sb.announceStats.markQueryEnodeMsgSent()
 
 		if err = sb.valEnodeTable.UpdateQueryEnodeStats(valEnodeEntries); err != nil {
 			return nil, err
@@ -561,6 +700,57 @@ func (sb *Backend) getQueryEnodeValEnodeEntries(enforceRetryBackoff bool) ([]*is
 	return queryEnodeValEnodeEntries, nil
 }
 
+// rotateQueryEnodeValEnodeEntries filters the given entries down to the validators to query in the current
+// query enode gossip period, and moves on to the next period.
+// Note that this function must ONLY be called by the announceThread.
+func (sb *Backend) rotateQueryEnodeValEnodeEntries(valEnodeEntries []*istanbul.AddressEntry) []*istanbul.AddressEntry {
+	currentBlock := sb.currentBlock()
+	elected := make(map[common.Address]bool)
+	for _, val := range sb.getValidators(currentBlock.Number().Uint64(), currentBlock.Hash()).List() {
+		elected[val.Address()] = true
+	}
+	addresses := make([]common.Address, len(valEnodeEntries))
+	for i, valEnodeEntry := range valEnodeEntries {
+		addresses[i] = valEnodeEntry.Address
+	}
+
+	subset := queryEnodeGossipSubset(addresses, elected, sb.config.AnnounceGossipCoveragePeriods, sb.queryEnodeGossipPeriodIndex)
+	sb.queryEnodeGossipPeriodIndex++
+
+	var rotatedValEnodeEntries []*istanbul.AddressEntry
+	for _, valEnodeEntry := range valEnodeEntries {
+		if subset[valEnodeEntry.Address] {
+			rotatedValEnodeEntries = append(rotatedValEnodeEntries, valEnodeEntry)
+		}
+	}
+	return rotatedValEnodeEntries
+}
+
+// queryEnodeGossipSubset returns the validators to query in the gossip period with the given index. The elected
+// validators are always included. The other validators are sorted by address and split into coveragePeriods
+// slices, and each period takes the next slice, so every validator is included within coveragePeriods
+// consecutive periods.
+func queryEnodeGossipSubset(addresses []common.Address, elected map[common.Address]bool, coveragePeriods uint64, periodIndex uint64) map[common.Address]bool {
+	subset := make(map[common.Address]bool)
+	var notElected []common.Address
+	for _, address := range addresses {
+		if elected[address] {
+			subset[address] = true
+		} else {
+			notElected = append(notElected, address)
+		}
+	}
+	sort.Slice(notElected, func(i, j int) bool { return bytes.Compare(notElected[i][:], notElected[j][:]) < 0 })
+
+	slice := periodIndex % coveragePeriods
+	for i, address := range notElected {
+		if uint64(i)%coveragePeriods == slice {
+			subset[address] = true
+		}
+	}
+	return subset
+}
+
 // generateQueryEnodeMsg returns a queryEnode message from this node with a given version.
 // A query enode message contains a number of individual enode queries, each of which is intended
 // for a single recipient validator. A query contains of this nodes external enode URL, to which
@@ -659,6 +849,7 @@ func (sb *Backend) handleQueryEnodeMsg(addr common.Address, peer consensus.Peer,
 		return err
 	}
 	logger.Trace("Handling a queryEnode message", "from", msg.Address)
+	sb.announceStats.markQueryEnodeMsgReceived()
 
 	// Check if the sender is within the validator connection set
 	validatorConnSet, err := sb.RetrieveValidatorConnSet()
@@ -681,6 +872,13 @@ func (sb *Backend) handleQueryEnodeMsg(addr common.Address, peer consensus.Peer,
 
 	logger = logger.New("msgAddress", msg.Address, "msgVersion", qeData.Version)
 
+	// Drop stale messages, so that they are neither answered nor regossiped any further
+	if sb.isExpiredQueryEnode(qeData.Timestamp, time.Now()) {
+		logger.Debug("Dropping expired queryEnode message", "msgTimestamp", qeData.Timestamp, "ttl", sb.config.AnnounceMessageTTL)
+		sb.queryEnodeMsgsExpiredMeter.Mark(1)
+		return errExpiredQueryEnodeMessage
+	}
+
 	// Do some validation checks on the queryEnodeData
 	if isValid, err := sb.validateQueryEnode(msg.Address, &qeData); !isValid || err != nil {
 		logger.Warn("Validation of queryEnode message failed", "isValid", isValid, "err", err)
@@ -693,6 +891,12 @@ func (sb *Backend) handleQueryEnodeMsg(addr common.Address, peer consensus.Peer,
 		logger.Warn("Error in checking if should process queryEnode", err)
 	}
 
+	// On a permissioned network, only answer the validators in the allowlist
+	if shouldProcess && !sb.isAnnounceAllowed(msg.Address) {
+		logger.Debug("Not answering queryEnode message from a validator outside the announce allowlist")
+		shouldProcess = false
+	}
+
 	if shouldProcess {
 		logger.Trace("Processing an queryEnode message", "queryEnode records", qeData.EncryptedEnodeURLs)
 		for _, encEnodeURL := range qeData.EncryptedEnodeURLs {
@@ -718,6 +922,7 @@ func (sb *Backend) handleQueryEnodeMsg(addr common.Address, peer consensus.Peer,
 				logger.Warn("Error answering an announce msg", "target node", node.URLv4(), "error", err)
 				return err
 			}
+			sb.announceStats.markEnodeURLResolved()
 
 			break
 		}
@@ -759,6 +964,7 @@ func (sb *Backend) answerQueryEnodeMsg(address common.Address, node *enode.Node,
 		if err := sb.Multicast([]common.Address{address}, payload, istanbul.EnodeCertificateMsg, false); err != nil {
 			return err
 		}
+		sb.relayEnodeCertificate(address, payload)
 	}
 
 	// Upsert regardless to account for the case that the target is a non-ValidatorPurpose
@@ -805,6 +1011,62 @@ func (sb *Backend) validateQueryEnode(msgAddress common.Address, qeData *queryEn
 	return true, nil
 }
 
+// isAnnounceAllowed returns whether this node answers the queryEnode messages of the given validator,
+// i.e. whether the validator is in AnnounceAllowlist or the allowlist is empty.
+func (sb *Backend) isAnnounceAllowed(address common.Address) bool {
+	if len(sb.config.AnnounceAllowlist) == 0 {
+		return true
+	}
+	for _, allowed := range sb.config.AnnounceAllowlist {
+		if allowed == address {
+			return true
+		}
+	}
+	return false
+}
+
+// isExpiredQueryEnode returns whether a queryEnode message with the given timestamp is older than
+// AnnounceMessageTTL. A message timestamped in the future, e.g. by a validator whose clock is ahead,
+// is not expired.
+func (sb *Backend) isExpiredQueryEnode(msgTimestamp uint, now time.Time) bool {
+	if sb.config.AnnounceMessageTTL == 0 {
+		return false
+	}
+	age := now.Unix() - int64(msgTimestamp)
+	return age > int64(sb.config.AnnounceMessageTTL)
+}
+
+// isVersionCertificateInValidityWindow returns whether a version certificate was issued within the
+// last VersionCertificateValidity seconds, and no later than versionCertificateAllowedSkew from now.
+// A version certificate's version is the unix timestamp at which the validator signed it, so a
+// replayed stale certificate falls out of the window even for a node that never saw a newer one.
+func (sb *Backend) isVersionCertificateInValidityWindow(version uint, now time.Time) bool {
+	if sb.config.VersionCertificateValidity == 0 {
+		return true
+	}
+	age := now.Unix() - int64(version)
+	return age <= int64(sb.config.VersionCertificateValidity) && age >= -versionCertificateAllowedSkew
+}
+
+// isVersionCertificateMonotonic returns whether a version certificate's version isn't lower than the highest
+// one seen for its validator, and records it as the highest one if so. Unlike the version certificate table,
+// whose entries are pruned, the highest versions are remembered while the node runs, so that an older
+// certificate can't be replayed once the newer one was pruned. A validator that restarts issues a certificate
+// with a new, higher version, which is accepted.
+func (sb *Backend) isVersionCertificateMonotonic(address common.Address, version uint) bool {
+	sb.highestVersionCertificateVersionsMu.Lock()
+	defer sb.highestVersionCertificateVersionsMu.Unlock()
+	highest := sb.highestVersionCertificateVersions[address]
+	if entry, err := sb.versionCertificateTable.Get(address); err == nil && entry.Version > highest {
+		highest = entry.Version
+	}
+	if version < highest {
+		return false
+	}
+	sb.highestVersionCertificateVersions[address] = version
+	return true
+}
+
 // regossipQueryEnode will regossip a received queryEnode message.
 // If this node regossiped a queryEnode from the same source address within the last
 // 5 minutes, then it won't regossip. This is to prevent a malicious validator from
@@ -840,12 +1102,16 @@ func (sb *Backend) regossipQueryEnode(msg *istanbul.Message, msgTimestamp uint,
 	return nil
 }
 
+// versionCertificateAllowedSkew is how far in the future a received version certificate may have been
+// issued, to allow for the clocks of validators being ahead.
+const versionCertificateAllowedSkew = 5 * 60
+
 // Used as a salt when signing versionCertificate. This is to account for
 // the unlikely case where a different signed struct with the same field types
 // is used elsewhere and shared with other nodes. If that were to happen, a
 // malicious node could try sending the other struct where this struct is used,
 // or vice versa. This ensures that the signature is only valid for this struct.
-var versionCertificateSalt = []byte("versionCertificate")
+var versionCertificateSalt = istanbul.VersionCertificateSalt
 
 // versionCertificate is a signed message from a validator indicating the most
 // recent version of its enode.
@@ -1053,6 +1319,15 @@ func (sb *Backend) handleVersionCertificatesMsg(addr common.Address, peer consen
 			logger.Debug("Found duplicate version certificate in message", "address", versionCertificate.Address)
 			continue
 		}
+		if !sb.isVersionCertificateInValidityWindow(versionCertificate.Version, time.Now()) {
+			logger.Debug("Found version certificate outside its validity window", "address", versionCertificate.Address, "version", versionCertificate.Version)
+			sb.versionCertificatesOutsideMeter.Mark(1)
+			continue
+		}
+		if sb.config.StrictVersionCertificates && !sb.isVersionCertificateMonotonic(versionCertificate.Address, versionCertificate.Version) {
+			logger.Debug("Dropping version certificate older than one already seen", "address", versionCertificate.Address, "version", versionCertificate.Version)
+			continue
+		}
 		validAddresses[versionCertificate.Address] = true
 		validEntries = append(validEntries, versionCertificate.Entry())
 	}
@@ -0,0 +1,91 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// adaptiveGossipAdjustPeriod is the period over which the consensus message latency is averaged
+	// before adjusting the number of additional validators to gossip to
+	adaptiveGossipAdjustPeriod = 30 * time.Second
+	// adaptiveGossipLatencyThreshold is the average time a consensus message waits to be handled by
+	// the core above which the node is considered under load
+	adaptiveGossipLatencyThreshold = 200 * time.Millisecond
+)
+
+// adaptiveGossip lowers the number of additional non-elected validators that announce messages are
+// gossiped to while consensus messages wait too long to be handled, and restores it once they don't.
+type adaptiveGossip struct {
+	configured   int64 // AnnounceAdditionalValidatorsToGossip
+	effective    int64
+	totalLatency time.Duration // The summed latency of the messages handled in the current period
+	messages     int
+	mu           sync.Mutex
+}
+
+func newAdaptiveGossip(configured int64) *adaptiveGossip {
+	return &adaptiveGossip{configured: configured, effective: configured}
+}
+
+// observeLatency records the time a consensus message waited to be handled by the core.
+func (g *adaptiveGossip) observeLatency(latency time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.totalLatency += latency
+	g.messages++
+}
+
+// adjust halves the effective number of additional validators if the average latency of the period
+// that just ended is above adaptiveGossipLatencyThreshold, and otherwise moves it back towards the
+// configured number by a quarter of it. It returns the previous and the new effective number.
+func (g *adaptiveGossip) adjust() (int64, int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	previous := g.effective
+	if g.messages > 0 && g.totalLatency/time.Duration(g.messages) > adaptiveGossipLatencyThreshold {
+		g.effective /= 2
+	} else if g.effective < g.configured {
+		step := g.configured / 4
+		if step == 0 {
+			step = 1
+		}
+		g.effective += step
+		if g.effective > g.configured {
+			g.effective = g.configured
+		}
+	}
+	g.totalLatency, g.messages = 0, 0
+	return previous, g.effective
+}
+
+func (g *adaptiveGossip) value() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.effective
+}
+
+// announceAdditionalValidatorsToGossip returns the number of additional non-elected validators in the
+// validator conn set, which is lowered under load if config.AnnounceAdaptiveGossip is set.
+func (sb *Backend) announceAdditionalValidatorsToGossip() int64 {
+	if !sb.config.AnnounceAdaptiveGossip {
+		return sb.config.AnnounceAdditionalValidatorsToGossip
+	}
+	return sb.adaptiveGossip.value()
+}
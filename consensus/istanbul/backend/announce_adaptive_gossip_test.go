@@ -0,0 +1,74 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+func TestAdaptiveGossip(t *testing.T) {
+	g := newAdaptiveGossip(10)
+	overloaded := func() {
+		g.observeLatency(2 * adaptiveGossipLatencyThreshold)
+		g.observeLatency(adaptiveGossipLatencyThreshold)
+	}
+
+	overloaded()
+	if _, effective := g.adjust(); effective != 5 {
+		t.Errorf("effective = %d after a period under load, want 5", effective)
+	}
+	overloaded()
+	g.adjust()
+	overloaded()
+	if _, effective := g.adjust(); effective != 1 {
+		t.Errorf("effective = %d after three periods under load, want 1", effective)
+	}
+	overloaded()
+	if _, effective := g.adjust(); effective != 0 {
+		t.Errorf("effective = %d under sustained load, want 0", effective)
+	}
+
+	// Once the load subsides, the configured number is restored a quarter at a time
+	g.observeLatency(time.Millisecond)
+	if _, effective := g.adjust(); effective != 2 {
+		t.Errorf("effective = %d after a period without load, want 2", effective)
+	}
+	for i := 0; i < 4; i++ {
+		g.adjust()
+	}
+	if effective := g.value(); effective != 10 {
+		t.Errorf("effective = %d once restored, want the configured 10", effective)
+	}
+}
+
+func TestAnnounceAdditionalValidatorsToGossip(t *testing.T) {
+	config := *istanbul.DefaultConfig
+	sb := &Backend{config: &config, adaptiveGossip: newAdaptiveGossip(config.AnnounceAdditionalValidatorsToGossip)}
+	sb.adaptiveGossip.observeLatency(2 * adaptiveGossipLatencyThreshold)
+	sb.adaptiveGossip.adjust()
+
+	if have := sb.announceAdditionalValidatorsToGossip(); have != config.AnnounceAdditionalValidatorsToGossip {
+		t.Errorf("additional validators = %d without adaptive gossip, want the configured %d", have, config.AnnounceAdditionalValidatorsToGossip)
+	}
+	config.AnnounceAdaptiveGossip = true
+	if have := sb.announceAdditionalValidatorsToGossip(); have != config.AnnounceAdditionalValidatorsToGossip/2 {
+		t.Errorf("additional validators = %d with adaptive gossip under load, want %d", have, config.AnnounceAdditionalValidatorsToGossip/2)
+	}
+}
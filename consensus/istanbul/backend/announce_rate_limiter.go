@@ -0,0 +1,103 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+const (
+	// The window in which the announce messages of a peer are counted
+	announceRateLimitWindow = time.Minute
+
+	// Validator peers relay the announce messages of the whole validator set, so they
+	// may send this many times more announce messages than other peers
+	announceValidatorRateLimitFactor = 4
+)
+
+// announceRateLimiter counts the announce messages received from each peer within a sliding window.
+type announceRateLimiter struct {
+	window time.Duration
+
+	peerMsgTimestamps   map[enode.ID][]time.Time
+	peerMsgTimestampsMu sync.Mutex
+}
+
+func newAnnounceRateLimiter(window time.Duration) *announceRateLimiter {
+	return &announceRateLimiter{
+		window:            window,
+		peerMsgTimestamps: make(map[enode.ID][]time.Time),
+	}
+}
+
+// allow returns whether a message received from the given peer at the given time is within
+// the limit of messages per window, and if so counts it.
+func (rl *announceRateLimiter) allow(peerID enode.ID, limit uint64, now time.Time) bool {
+	rl.peerMsgTimestampsMu.Lock()
+	defer rl.peerMsgTimestampsMu.Unlock()
+
+	// Forget the messages that slid out of the window
+	timestamps := rl.peerMsgTimestamps[peerID]
+	i := 0
+	for i < len(timestamps) && now.Sub(timestamps[i]) >= rl.window {
+		i++
+	}
+	timestamps = timestamps[i:]
+
+	if uint64(len(timestamps)) >= limit {
+		rl.peerMsgTimestamps[peerID] = timestamps
+		return false
+	}
+	rl.peerMsgTimestamps[peerID] = append(timestamps, now)
+	return true
+}
+
+// removePeer forgets the messages received from the given peer.
+func (rl *announceRateLimiter) removePeer(peerID enode.ID) {
+	rl.peerMsgTimestampsMu.Lock()
+	defer rl.peerMsgTimestampsMu.Unlock()
+	delete(rl.peerMsgTimestamps, peerID)
+}
+
+// isAnnounceMsg returns whether the given message code is one of the gossiped announce messages.
+func isAnnounceMsg(msgCode uint64) bool {
+	return msgCode == istanbul.QueryEnodeMsg || msgCode == istanbul.VersionCertificatesMsg
+}
+
+// shouldHandleAnnounceMsg returns whether an announce message received from the given peer is within
+// the peer's rate limit. Proxy peers are not limited, since they relay the messages of all their peers.
+func (sb *Backend) shouldHandleAnnounceMsg(peer consensus.Peer) bool {
+	if sb.config.AnnounceMaxMessagesPerMinute == 0 || peer.PurposeIsSet(p2p.ProxyPurpose) {
+		return true
+	}
+	limit := sb.config.AnnounceMaxMessagesPerMinute
+	if peer.PurposeIsSet(p2p.ValidatorPurpose) {
+		limit *= announceValidatorRateLimitFactor
+	}
+	if sb.announceRateLimiter.allow(peer.Node().ID(), limit, time.Now()) {
+		return true
+	}
+	sb.announceMsgsRateLimitedMeter.Mark(1)
+	sb.logger.Warn("Dropping announce message, peer exceeded its rate limit", "peer", peer.Node().ID(), "limit", limit)
+	return false
+}
@@ -0,0 +1,198 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+const (
+	// announceRelayFailureThreshold is the number of consecutive enode certificates that couldn't be
+	// delivered directly to a validator after which they are delivered through the announce relay
+	announceRelayFailureThreshold = 3
+	// announceRelayPollPeriod is the time between two fetches of the messages relayed to this node
+	announceRelayPollPeriod = 1 * time.Minute
+	// announceRelayTimeout bounds every request to the announce relay
+	announceRelayTimeout = 10 * time.Second
+	// maxAnnounceRelayResponseSize bounds the size of the messages fetched from the announce relay at once
+	maxAnnounceRelayResponseSize = 1024 * 1024
+)
+
+// relayedAnnounceMsg is an announce message exchanged through the announce relay. The payload is the same
+// as the one sent to a peer, so it carries the same signatures and is validated in the same way.
+type relayedAnnounceMsg struct {
+	Code    uint64        `json:"code"`
+	Payload hexutil.Bytes `json:"payload"`
+}
+
+// announceRelay is a client of a rendezvous service that stores announce messages for validators that
+// can't be reached directly, e.g. because they are behind a NAT. Messages are posted to
+// <endpoint>/<destination address> and fetched, once, by a GET of <endpoint>/<own address>.
+type announceRelay struct {
+	endpoint string
+	client   *http.Client
+
+	failures   map[common.Address]int // The consecutive failed direct deliveries per validator
+	failuresMu sync.Mutex
+}
+
+func newAnnounceRelay(endpoint string) *announceRelay {
+	return &announceRelay{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   &http.Client{Timeout: announceRelayTimeout},
+		failures: make(map[common.Address]int),
+	}
+}
+
+// directDeliveryFailed records that a message couldn't be delivered directly to the validator, and returns
+// whether that has happened often enough in a row to deliver it through the relay instead.
+func (r *announceRelay) directDeliveryFailed(address common.Address) bool {
+	r.failuresMu.Lock()
+	defer r.failuresMu.Unlock()
+	r.failures[address]++
+	return r.failures[address] >= announceRelayFailureThreshold
+}
+
+// directDeliverySucceeded resets the failed direct deliveries to the validator.
+func (r *announceRelay) directDeliverySucceeded(address common.Address) {
+	r.failuresMu.Lock()
+	defer r.failuresMu.Unlock()
+	delete(r.failures, address)
+}
+
+func (r *announceRelay) url(address common.Address) string {
+	return r.endpoint + "/" + address.Hex()
+}
+
+// post stores messages on the relay for the given validator.
+func (r *announceRelay) post(dest common.Address, msgs []*relayedAnnounceMsg) error {
+	body, err := json.Marshal(msgs)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Post(r.url(dest), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("announce relay responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// fetch retrieves the messages stored on the relay for the given validator.
+func (r *announceRelay) fetch(address common.Address) ([]*relayedAnnounceMsg, error) {
+	resp, err := r.client.Get(r.url(address))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("announce relay responded with status %s", resp.Status)
+	}
+	var msgs []*relayedAnnounceMsg
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxAnnounceRelayResponseSize)).Decode(&msgs); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// usesAnnounceRelay returns whether announce messages are exchanged through the relay. Proxied validators
+// rely on their proxies, which are reachable, to deliver their announce messages.
+func (sb *Backend) usesAnnounceRelay() bool {
+	return sb.announceRelay != nil && !sb.IsProxy() && !sb.IsProxiedValidator()
+}
+
+// relayEnodeCertificate is called after trying to deliver an enode certificate directly to a validator.
+// Once the direct delivery failed announceRelayFailureThreshold times in a row, the enode certificate is
+// posted to the announce relay along with this node's version certificate.
+func (sb *Backend) relayEnodeCertificate(address common.Address, enodeCertPayload []byte) {
+	if !sb.usesAnnounceRelay() {
+		return
+	}
+	if len(sb.getPeersFromDestAddresses([]common.Address{address})) > 0 {
+		sb.announceRelay.directDeliverySucceeded(address)
+		return
+	}
+	if !sb.announceRelay.directDeliveryFailed(address) {
+		return
+	}
+
+	logger := sb.logger.New("func", "relayEnodeCertificate", "address", address)
+	var msgs []*relayedAnnounceMsg
+	if entry, err := sb.versionCertificateTable.Get(sb.Address()); err == nil {
+		payload, err := sb.encodeVersionCertificatesMsg([]*versionCertificate{newVersionCertificateFromEntry(entry)})
+		if err != nil {
+			logger.Warn("Error encoding version certificate msg", "err", err)
+			return
+		}
+		msgs = append(msgs, &relayedAnnounceMsg{Code: istanbul.VersionCertificatesMsg, Payload: payload})
+	}
+	msgs = append(msgs, &relayedAnnounceMsg{Code: istanbul.EnodeCertificateMsg, Payload: enodeCertPayload})
+
+	go func() {
+		if err := sb.announceRelay.post(address, msgs); err != nil {
+			logger.Warn("Error relaying enode certificate", "err", err)
+			return
+		}
+		logger.Debug("Relayed enode certificate to a validator that can't be reached directly")
+	}()
+}
+
+// pollAnnounceRelay fetches the announce messages relayed to this node, and handles them as if they were
+// received from a peer.
+func (sb *Backend) pollAnnounceRelay() {
+	logger := sb.logger.New("func", "pollAnnounceRelay")
+	msgs, err := sb.announceRelay.fetch(sb.Address())
+	if err != nil {
+		logger.Warn("Error fetching relayed announce messages", "err", err)
+		return
+	}
+	for _, msg := range msgs {
+		if err := sb.handleRelayedAnnounceMsg(msg); err != nil {
+			logger.Debug("Error handling relayed announce message", "code", msg.Code, "err", err)
+			continue
+		}
+		sb.announceRelayedMeter.Mark(1)
+	}
+}
+
+func (sb *Backend) handleRelayedAnnounceMsg(msg *relayedAnnounceMsg) error {
+	switch msg.Code {
+	case istanbul.VersionCertificatesMsg:
+		return sb.handleVersionCertificatesMsg(common.Address{}, nil, msg.Payload)
+	case istanbul.EnodeCertificateMsg:
+		return sb.handleEnodeCertificateMsg(nil, msg.Payload)
+	default:
+		return fmt.Errorf("unexpected relayed message code %d", msg.Code)
+	}
+}
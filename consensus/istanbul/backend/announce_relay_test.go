@@ -0,0 +1,108 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+func TestAnnounceRelayFailureThreshold(t *testing.T) {
+	relay := newAnnounceRelay("http://localhost")
+	address := common.HexToAddress("0x01")
+
+	for i := 1; i < announceRelayFailureThreshold; i++ {
+		if relay.directDeliveryFailed(address) {
+			t.Fatalf("relaying after %d failed direct deliveries", i)
+		}
+	}
+	if !relay.directDeliveryFailed(address) {
+		t.Errorf("not relaying after %d failed direct deliveries", announceRelayFailureThreshold)
+	}
+	// A successful direct delivery starts over
+	relay.directDeliverySucceeded(address)
+	if relay.directDeliveryFailed(address) {
+		t.Errorf("relaying after a successful direct delivery")
+	}
+}
+
+func TestAnnounceRelayPostAndFetch(t *testing.T) {
+	// A relay that keeps the posted messages until they are fetched
+	var mu sync.Mutex
+	stored := make(map[string][]*relayedAnnounceMsg)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		key := strings.ToLower(strings.TrimPrefix(r.URL.Path, "/announce/"))
+		switch r.Method {
+		case http.MethodPost:
+			var msgs []*relayedAnnounceMsg
+			if err := json.NewDecoder(r.Body).Decode(&msgs); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			stored[key] = append(stored[key], msgs...)
+		case http.MethodGet:
+			if len(stored[key]) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(stored[key])
+			delete(stored, key)
+		}
+	}))
+	defer server.Close()
+
+	relay := newAnnounceRelay(server.URL + "/announce/")
+	dest := common.HexToAddress("0x02")
+	msgs := []*relayedAnnounceMsg{
+		{Code: istanbul.VersionCertificatesMsg, Payload: []byte{1, 2}},
+		{Code: istanbul.EnodeCertificateMsg, Payload: []byte{3}},
+	}
+	if err := relay.post(dest, msgs); err != nil {
+		t.Fatalf("failed to post to the relay: %v", err)
+	}
+
+	fetched, err := relay.fetch(dest)
+	if err != nil {
+		t.Fatalf("failed to fetch from the relay: %v", err)
+	}
+	if len(fetched) != len(msgs) {
+		t.Fatalf("fetched %d messages, want %d", len(fetched), len(msgs))
+	}
+	for i, msg := range fetched {
+		if msg.Code != msgs[i].Code || !bytes.Equal(msg.Payload, msgs[i].Payload) {
+			t.Errorf("message %d = %+v, want %+v", i, msg, msgs[i])
+		}
+	}
+
+	// Nothing is left for the validator, nor relayed to another one
+	if fetched, err = relay.fetch(dest); err != nil || len(fetched) != 0 {
+		t.Errorf("fetched %v (err %v) again, want nothing", fetched, err)
+	}
+	if fetched, err = relay.fetch(common.HexToAddress("0x03")); err != nil || len(fetched) != 0 {
+		t.Errorf("fetched %v (err %v) for another validator, want nothing", fetched, err)
+	}
+}
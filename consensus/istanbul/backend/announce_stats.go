@@ -0,0 +1,96 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+// AnnounceStats summarizes the announce protocol activity of this node since it started.
+type AnnounceStats struct {
+	QueryEnodeMsgsSent           uint64 `json:"queryEnodeMsgsSent"`           // The number of query enode messages gossiped by this node
+	QueryEnodeMsgsReceived       uint64 `json:"queryEnodeMsgsReceived"`       // The number of distinct query enode messages received from peers
+	EnodeURLsResolved            uint64 `json:"enodeURLsResolved"`            // The number of enode URLs of other validators decrypted from query enode messages and answered
+	VersionCertificates          int    `json:"versionCertificates"`          // The number of version certificates stored in the version certificate table
+	Querying                     bool   `json:"querying"`                     // Whether this node periodically gossips query enode messages
+	QueryEnodeGossipPeriod       uint64 `json:"queryEnodeGossipPeriod"`       // The current period (in seconds) between gossiped query enode messages
+	AggressiveGossip             bool   `json:"aggressiveGossip"`             // Whether the query enode messages are gossiped at the high frequency used on enablement
+	AdditionalValidatorsToGossip int64  `json:"additionalValidatorsToGossip"` // The effective number of additional non-elected validators gossiped to, lowered under load with AnnounceAdaptiveGossip
+}
+
+// announceStats holds the counters and gossip state reported in AnnounceStats.
+type announceStats struct {
+	queryEnodeMsgsSent     uint64
+	queryEnodeMsgsReceived uint64
+	enodeURLsResolved      uint64
+	querying               bool
+	queryEnodeGossipPeriod time.Duration
+	aggressiveGossip       bool
+	mu                     sync.Mutex
+}
+
+func (s *announceStats) markQueryEnodeMsgSent() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queryEnodeMsgsSent++
+}
+
+func (s *announceStats) markQueryEnodeMsgReceived() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queryEnodeMsgsReceived++
+}
+
+func (s *announceStats) markEnodeURLResolved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enodeURLsResolved++
+}
+
+// setQueryEnodeGossip records the query enode gossip state of the announce thread.
+func (s *announceStats) setQueryEnodeGossip(querying bool, period time.Duration, aggressive bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.querying = querying
+	s.queryEnodeGossipPeriod = period
+	s.aggressiveGossip = aggressive
+}
+
+// GetAnnounceStats returns the statistics of the announce protocol since this node started.
+func (sb *Backend) GetAnnounceStats() (*AnnounceStats, error) {
+	versionCertificates, err := sb.versionCertificateTable.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	sb.announceStats.mu.Lock()
+	defer sb.announceStats.mu.Unlock()
+	stats := &AnnounceStats{
+		QueryEnodeMsgsSent:           sb.announceStats.queryEnodeMsgsSent,
+		QueryEnodeMsgsReceived:       sb.announceStats.queryEnodeMsgsReceived,
+		EnodeURLsResolved:            sb.announceStats.enodeURLsResolved,
+		VersionCertificates:          len(versionCertificates),
+		Querying:                     sb.announceStats.querying,
+		AggressiveGossip:             sb.announceStats.aggressiveGossip,
+		AdditionalValidatorsToGossip: sb.announceAdditionalValidatorsToGossip(),
+	}
+	if sb.announceStats.querying {
+		stats.QueryEnodeGossipPeriod = uint64(sb.announceStats.queryEnodeGossipPeriod / time.Second)
+	}
+	return stats, nil
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	vet "github.com/ethereum/go-ethereum/consensus/istanbul/backend/internal/enodes"
+	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -132,6 +134,20 @@ func TestAnnounceGossipQueryMsg(t *testing.T) {
 		t.Errorf("Incorrect val enode table entry for engine0.  Want: %v, Have: %v", expectedEntry, entry)
 	}
 
+	// Verify the announce stats of the sender and of a receiver
+	stats0, err := engine0.GetAnnounceStats()
+	if err != nil {
+		t.Errorf("Error in retrieving the announce stats of engine0.  Error: %v", err)
+	} else if stats0.QueryEnodeMsgsSent < 1 || stats0.VersionCertificates < 2 {
+		t.Errorf("Incorrect announce stats for engine0.  Have: %+v", stats0)
+	}
+	stats1, err := engine1.GetAnnounceStats()
+	if err != nil {
+		t.Errorf("Error in retrieving the announce stats of engine1.  Error: %v", err)
+	} else if stats1.QueryEnodeMsgsReceived != 1 || stats1.EnodeURLsResolved != 1 {
+		t.Errorf("Incorrect announce stats for engine1.  Have: %+v", stats1)
+	}
+
 	engine0.StopAnnouncing()
 	engine1.StopAnnouncing()
 	engine2.StopAnnouncing()
@@ -251,3 +267,297 @@ func TestSetAndShareUpdatedAnnounceVersion(t *testing.T) {
 
 	engine.StopAnnouncing()
 }
+
+func TestScaledQueryEnodeGossipPeriod(t *testing.T) {
+	config := *istanbul.DefaultConfig
+	config.AnnounceQueryEnodeGossipPeriod = 300
+	config.AnnounceMaxQueryEnodeGossipPeriod = 600
+
+	tests := []struct {
+		perValidator  uint64
+		numValidators int
+		want          time.Duration
+	}{
+		{0, 100, 300 * time.Second},
+		{2, 0, 300 * time.Second},
+		{2, 100, 500 * time.Second},
+		{2, 150, 600 * time.Second},
+		{2, 1000, 600 * time.Second},
+	}
+	for _, tt := range tests {
+		config.AnnounceGossipPeriodPerValidator = tt.perValidator
+		if have := scaledQueryEnodeGossipPeriod(&config, tt.numValidators); have != tt.want {
+			t.Errorf("scaledQueryEnodeGossipPeriod(perValidator=%d, numValidators=%d) = %v, want %v", tt.perValidator, tt.numValidators, have, tt.want)
+		}
+	}
+}
+
+func TestEnoughAggressiveQueryEnodePeers(t *testing.T) {
+	config := *istanbul.DefaultConfig
+
+	tests := []struct {
+		minPeers          uint64
+		numValidatorPeers int
+		want              bool
+	}{
+		{0, 0, true},
+		{3, 0, false},
+		{3, 2, false},
+		{3, 3, true},
+		{3, 10, true},
+	}
+	for _, tt := range tests {
+		config.AnnounceAggressiveMinPeers = tt.minPeers
+		if have := enoughAggressiveQueryEnodePeers(&config, tt.numValidatorPeers); have != tt.want {
+			t.Errorf("enoughAggressiveQueryEnodePeers(minPeers=%d, numValidatorPeers=%d) = %v, want %v", tt.minPeers, tt.numValidatorPeers, have, tt.want)
+		}
+	}
+}
+
+func TestHasAggressiveQueryEnodePeersProxied(t *testing.T) {
+	chain, engine := newBlockChain(1, true)
+	defer chain.Stop()
+
+	engine.config.AnnounceAggressiveMinPeers = 3
+	if engine.hasAggressiveQueryEnodePeers() {
+		t.Errorf("expected a validator without validator peers to wait for them")
+	}
+	// A proxied validator has no validator peers of its own
+	config := *engine.config
+	config.Proxied = true
+	proxied := &Backend{config: &config, broadcaster: engine.broadcaster}
+	if !proxied.hasAggressiveQueryEnodePeers() {
+		t.Errorf("expected a proxied validator not to wait for validator peers")
+	}
+}
+
+func TestGracefulStopAnnouncing(t *testing.T) {
+	chain, engine := newBlockChain(1, true)
+	defer chain.Stop()
+
+	// The pending announce tasks are handled, but a send that doesn't finish holds the drain until the timeout
+	engine.startGossipQueryEnodeTask()
+	engine.asyncSends.add()
+	if engine.drainAnnounce(50 * time.Millisecond) {
+		t.Errorf("drain finished with a send in flight")
+	}
+	if len(engine.generateAndGossipQueryEnodeCh) != 0 {
+		t.Errorf("drain left the query enode task pending")
+	}
+	engine.asyncSends.done()
+
+	engine.GracefulStopAnnouncing()
+	if engine.announceRunning {
+		t.Errorf("announcing still running after a graceful stop")
+	}
+	if err := engine.GracefulStopAnnouncing(); err != istanbul.ErrStoppedAnnounce {
+		t.Errorf("error mismatch: have %v, want %v", err, istanbul.ErrStoppedAnnounce)
+	}
+}
+
+func TestAsyncSendCounter(t *testing.T) {
+	var sends asyncSendCounter
+	select {
+	case <-sends.idleCh():
+	default:
+		t.Errorf("expected no pending sends")
+	}
+
+	sends.add()
+	idle := sends.idleCh()
+	// Sends may start while waiting for the pending ones
+	sends.add()
+	sends.done()
+	select {
+	case <-idle:
+		t.Errorf("idle with a send in flight")
+	default:
+	}
+	sends.done()
+	select {
+	case <-idle:
+	default:
+		t.Errorf("expected no pending sends after they finished")
+	}
+}
+
+func TestAnnounceRateLimiter(t *testing.T) {
+	rl := newAnnounceRateLimiter(time.Minute)
+	peerA := enode.ID{1}
+	peerB := enode.ID{2}
+	start := time.Unix(1000, 0)
+
+	for i := 0; i < 3; i++ {
+		if !rl.allow(peerA, 3, start.Add(time.Duration(i)*time.Second)) {
+			t.Errorf("message %d should be within the limit", i)
+		}
+	}
+	if rl.allow(peerA, 3, start.Add(10*time.Second)) {
+		t.Errorf("message beyond the limit should not be allowed")
+	}
+	// The limit is tracked per peer
+	if !rl.allow(peerB, 3, start.Add(10*time.Second)) {
+		t.Errorf("message from another peer should be allowed")
+	}
+	// Once the first message slides out of the window, there is room for one more
+	if !rl.allow(peerA, 3, start.Add(time.Minute)) {
+		t.Errorf("message should be allowed after the window slid")
+	}
+	if rl.allow(peerA, 3, start.Add(time.Minute)) {
+		t.Errorf("message beyond the limit should not be allowed after the window slid")
+	}
+	// Removing a peer forgets its messages
+	rl.removePeer(peerA)
+	if !rl.allow(peerA, 3, start.Add(time.Minute)) {
+		t.Errorf("message should be allowed after the peer was removed")
+	}
+}
+
+func TestQueryEnodeGossipSubset(t *testing.T) {
+	addresses := make([]common.Address, 10)
+	for i := range addresses {
+		addresses[i] = common.BytesToAddress([]byte{byte(10 - i)})
+	}
+	elected := map[common.Address]bool{addresses[0]: true, addresses[5]: true}
+	coveragePeriods := uint64(3)
+
+	covered := make(map[common.Address]int)
+	for periodIndex := uint64(0); periodIndex < coveragePeriods; periodIndex++ {
+		subset := queryEnodeGossipSubset(addresses, elected, coveragePeriods, periodIndex)
+		for address := range elected {
+			if !subset[address] {
+				t.Errorf("period %d: elected validator %v is not included", periodIndex, address.Hex())
+			}
+		}
+		for address := range subset {
+			if !elected[address] {
+				covered[address]++
+			}
+		}
+		// The subset only depends on the period within the coverage cycle
+		next := queryEnodeGossipSubset(addresses, elected, coveragePeriods, periodIndex+coveragePeriods)
+		if len(next) != len(subset) {
+			t.Errorf("period %d: subset differs from the same period in the next cycle", periodIndex)
+		}
+		for address := range subset {
+			if !next[address] {
+				t.Errorf("period %d: subset differs from the same period in the next cycle", periodIndex)
+			}
+		}
+	}
+	// Every non elected validator is queried exactly once per cycle
+	for _, address := range addresses {
+		if elected[address] {
+			continue
+		}
+		if covered[address] != 1 {
+			t.Errorf("validator %v included in %d periods of the cycle, want 1", address.Hex(), covered[address])
+		}
+	}
+
+	if subset := queryEnodeGossipSubset(addresses, elected, 1, 7); len(subset) != len(addresses) {
+		t.Errorf("a single coverage period should include all %d validators, got %d", len(addresses), len(subset))
+	}
+}
+
+func TestIsExpiredQueryEnode(t *testing.T) {
+	config := *istanbul.DefaultConfig
+	sb := &Backend{config: &config}
+	now := time.Unix(10000, 0)
+
+	tests := []struct {
+		ttl          uint64
+		msgTimestamp uint
+		want         bool
+	}{
+		{600, 10000, false},
+		{600, 9400, false},
+		{600, 9399, true},
+		{600, 10600, false},
+		{0, 0, false},
+	}
+	for _, tt := range tests {
+		config.AnnounceMessageTTL = tt.ttl
+		if have := sb.isExpiredQueryEnode(tt.msgTimestamp, now); have != tt.want {
+			t.Errorf("isExpiredQueryEnode(ttl=%d, msgTimestamp=%d) = %v, want %v", tt.ttl, tt.msgTimestamp, have, tt.want)
+		}
+	}
+}
+
+func TestIsAnnounceAllowed(t *testing.T) {
+	config := *istanbul.DefaultConfig
+	sb := &Backend{config: &config}
+	allowed := common.HexToAddress("0x01")
+	other := common.HexToAddress("0x02")
+
+	// Without an allowlist, every validator is answered
+	if !sb.isAnnounceAllowed(allowed) || !sb.isAnnounceAllowed(other) {
+		t.Errorf("validator not answered without an allowlist")
+	}
+
+	config.AnnounceAllowlist = []common.Address{allowed}
+	if !sb.isAnnounceAllowed(allowed) {
+		t.Errorf("allowlisted validator not answered")
+	}
+	if sb.isAnnounceAllowed(other) {
+		t.Errorf("validator outside the allowlist answered")
+	}
+}
+
+func TestIsVersionCertificateInValidityWindow(t *testing.T) {
+	config := *istanbul.DefaultConfig
+	sb := &Backend{config: &config}
+	now := time.Unix(100000, 0)
+
+	tests := []struct {
+		validity uint64
+		version  uint
+		want     bool
+	}{
+		{3600, 100000, true},
+		{3600, 96400, true},
+		{3600, 96399, false},
+		{3600, 100000 + versionCertificateAllowedSkew, true},
+		{3600, 100000 + versionCertificateAllowedSkew + 1, false},
+		{0, 1, true},
+	}
+	for _, tt := range tests {
+		config.VersionCertificateValidity = tt.validity
+		if have := sb.isVersionCertificateInValidityWindow(tt.version, now); have != tt.want {
+			t.Errorf("isVersionCertificateInValidityWindow(validity=%d, version=%d) = %v, want %v", tt.validity, tt.version, have, tt.want)
+		}
+	}
+}
+
+func TestIsVersionCertificateMonotonic(t *testing.T) {
+	sb := newBackend()
+	validator := common.HexToAddress("0x01")
+
+	for _, tt := range []struct {
+		version uint
+		want    bool
+	}{
+		{100, true},
+		{200, true},
+		{150, false}, // A downgrade
+		{200, true},  // The same certificate, e.g. regossiped
+		{300, true},  // A new certificate, e.g. after a restart
+	} {
+		if have := sb.isVersionCertificateMonotonic(validator, tt.version); have != tt.want {
+			t.Errorf("isVersionCertificateMonotonic(version=%d) = %v, want %v", tt.version, have, tt.want)
+		}
+	}
+
+	// Versions stored in the version certificate table are taken into account
+	key, _ := generatePrivateKey()
+	stored := &vet.VersionCertificateEntry{Address: common.HexToAddress("0x02"), PublicKey: &key.PublicKey, Version: 500}
+	if _, err := sb.versionCertificateTable.Upsert([]*vet.VersionCertificateEntry{stored}); err != nil {
+		t.Fatalf("failed to upsert version certificate: %v", err)
+	}
+	if sb.isVersionCertificateMonotonic(stored.Address, 400) {
+		t.Errorf("accepted a version lower than the stored one")
+	}
+	if !sb.isVersionCertificateMonotonic(stored.Address, 500) {
+		t.Errorf("rejected the stored version")
+	}
+}
@@ -17,9 +17,11 @@
 package backend
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
@@ -41,6 +43,61 @@ type API struct {
 	istanbul *Backend
 }
 
+// AdminAPI is the administrative RPC API of the Istanbul engine
+type AdminAPI struct {
+	istanbul *Backend
+}
+
+// TimingConfigArgs is the subset of the istanbul config that can be changed with SetIstanbulConfig.
+// Fields that are not set keep their current value.
+type TimingConfigArgs struct {
+	RequestTimeout *uint64 `json:"requestTimeout"`
+
+	// BlockPeriod is only accepted to reject it explicitly, see SetIstanbulConfig.
+	BlockPeriod *uint64 `json:"blockPeriod"`
+}
+
+// errBlockPeriodNotReloadable is returned if SetIstanbulConfig is asked to change the block period.
+var errBlockPeriodNotReloadable = errors.New("blockPeriod can't be changed on a running node, restart it with the new value")
+
+// SetIstanbulConfig changes the timing config of the running engine. The change takes effect
+// at the next round boundary, so that rounds already in flight are not affected.
+//
+// The block period can't be changed this way: unlike the request timeout, which only the core reads,
+// it is read from the shared config when preparing headers, when computing the time at which the next
+// block is proposed and when verifying the timestamps of headers, where it is a consensus rule.
+func (api *AdminAPI) SetIstanbulConfig(args TimingConfigArgs) (bool, error) {
+	if args.BlockPeriod != nil {
+		return false, errBlockPeriodNotReloadable
+	}
+	api.istanbul.coreMu.RLock()
+	defer api.istanbul.coreMu.RUnlock()
+	if !api.istanbul.coreStarted {
+		return false, istanbul.ErrStoppedEngine
+	}
+
+	config := *api.istanbul.config
+	config.RequestTimeout = api.istanbul.core.TimingConfig().RequestTimeout
+	if args.RequestTimeout != nil {
+		config.RequestTimeout = *args.RequestTimeout
+	}
+	if err := config.Validate(); err != nil {
+		return false, err
+	}
+
+	api.istanbul.logger.Info("Scheduling istanbul timing config change", "request_timeout", config.RequestTimeout)
+	api.istanbul.core.SetTimingConfig(core.TimingConfig{
+		RequestTimeout: config.RequestTimeout,
+	})
+	return true, nil
+}
+
+// MigrateValidatorEnodeDB moves the validator enode table to a new database at the given absolute path
+// while the node keeps running. The current database is kept if the new one can't be opened or written.
+func (api *AdminAPI) MigrateValidatorEnodeDB(path string) (*ValidatorEnodeDBMigration, error) {
+	return api.istanbul.MigrateValidatorEnodeDB(path)
+}
+
 // getHeaderByNumber retrieves the header requested block or current if unspecified.
 func (api *API) getParentHeaderByNumber(number *rpc.BlockNumber) (*types.Header, error) {
 	var parent uint64
@@ -93,6 +150,21 @@ func (api *API) GetValidators(number *rpc.BlockNumber) ([]common.Address, error)
 	return istanbul.MapValidatorsToAddresses(validators), nil
 }
 
+// GetValidatorUptime retrieves the share of the blocks within the lookback window up to a given block
+// that each elected validator signed, as recorded by the parent aggregated seals in the headers.
+func (api *API) GetValidatorUptime(number *rpc.BlockNumber) (map[common.Address]float64, error) {
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.istanbul.validatorParticipation(header)
+}
+
 // GetValidatorsBLSPublicKeys retrieves the list of validators BLS public keys that must sign a given block.
 func (api *API) GetValidatorsBLSPublicKeys(number *rpc.BlockNumber) ([]blscrypto.SerializedPublicKey, error) {
 	header, err := api.getParentHeaderByNumber(number)
@@ -121,11 +193,83 @@ func (api *API) GetProposer(sequence *rpc.BlockNumber, round *uint64) (common.Ad
 	if round == nil {
 		round = new(uint64)
 	}
-	proposer := validator.GetProposerSelector(api.istanbul.config.ProposerPolicy)(valSet, previousProposer, *round)
-	return proposer.Address(), nil
+	return validator.SelectProposer(valSet, previousProposer, *round, api.istanbul.config.ProposerPolicyAt(header.Number.Uint64()+1)), nil
+}
+
+// GetBannedProposers retrieves the validators banned from proposing the given block number (i.e. sequence),
+// for failing more than ProposerBanThreshold of the last ProposerBanWindow rounds before it.
+func (api *API) GetBannedProposers(sequence *rpc.BlockNumber) ([]common.Address, error) {
+	header, err := api.getParentHeaderByNumber(sequence)
+	if err != nil {
+		return nil, err
+	}
+	banned := api.istanbul.bannedProposers(header.Number.Uint64(), header.Hash())
+	if banned == nil {
+		banned = []common.Address{}
+	}
+	return banned, nil
+}
+
+// GetBlockProposer retrieves the validator that proposed a committed block, recovered from the proposer
+// seal in the block's header. The proposer must be elected in the validator set the block was committed
+// by, which is reconstructed from the snapshot of the block's epoch if it isn't cached.
+func (api *API) GetBlockProposer(number rpc.BlockNumber) (common.Address, error) {
+	header, err := api.getCommittedHeaderByNumber(number)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if header.Number.Sign() == 0 {
+		return common.Address{}, errors.New("the genesis block has no proposer")
+	}
+
+	proposer, err := api.istanbul.Author(header)
+	if err != nil {
+		return common.Address{}, err
+	}
+	snap, err := api.istanbul.snapshot(api.chain, header.Number.Uint64()-1, header.ParentHash, nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if index, _ := snap.ValSet.GetByAddress(proposer); index < 0 {
+		return common.Address{}, errUnauthorized
+	}
+	return proposer, nil
+}
+
+// GetCommitSigners retrieves the validators whose COMMIT signatures are aggregated in the seal of a committed
+// block, decoded against the validator set that committed it, with their count and the quorum of that set.
+func (api *API) GetCommitSigners(number rpc.BlockNumber) (*CommitSigners, error) {
+	header, err := api.getCommittedHeaderByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	return api.istanbul.commitSigners(header)
+}
+
+// getCommittedHeaderByNumber retrieves the header of a committed block, or of the head if latest or pending is
+// requested.
+func (api *API) getCommittedHeaderByNumber(number rpc.BlockNumber) (*types.Header, error) {
+	head := api.chain.CurrentHeader()
+	if head == nil {
+		return nil, errUnknownBlock
+	}
+	var header *types.Header
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		header = head
+	} else if uint64(number) > head.Number.Uint64() {
+		return nil, fmt.Errorf("block %d is beyond the chain head %d", number, head.Number.Uint64())
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return header, nil
 }
 
-// AddProxy peers with a remote node that acts as a proxy, even if slots are full
+// AddProxy peers with a remote node that acts as a proxy, even if slots are full.
+// If the proxy was already added with a different external url, e.g. because its public IP address changed,
+// the external url is updated and the new enode is announced to the other validators.
 func (api *API) AddProxy(url, externalUrl string) (bool, error) {
 	if !api.istanbul.config.Proxied {
 		api.istanbul.logger.Error("Add proxy node failed: this node is not configured to be proxied")
@@ -165,24 +309,331 @@ func (api *API) GetValEnodeTable() (map[string]*vet.ValEnodeEntryInfo, error) {
 	return api.istanbul.valEnodeTable.ValEnodeTableInfo()
 }
 
+// GetValidatorEnodeTable retrieves the validator enode table, keyed by validator address.
+// If onlyElected is true, only the entries of the currently elected validators are returned.
+func (api *API) GetValidatorEnodeTable(onlyElected *bool) (map[string]*vet.ValEnodeEntryInfo, error) {
+	valEnodeTableInfo, err := api.istanbul.valEnodeTable.ValEnodeTableInfo()
+	if err != nil {
+		return nil, err
+	}
+	if onlyElected == nil || !*onlyElected {
+		return valEnodeTableInfo, nil
+	}
+
+	header := api.chain.CurrentHeader()
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	electedValEnodeTableInfo := make(map[string]*vet.ValEnodeEntryInfo)
+	for _, val := range api.istanbul.GetValidators(header.Number, header.Hash()) {
+		if entryInfo, ok := valEnodeTableInfo[val.Address().Hex()]; ok {
+			electedValEnodeTableInfo[val.Address().Hex()] = entryInfo
+		}
+	}
+	return electedValEnodeTableInfo, nil
+}
+
+// ExportValidatorEnodes retrieves the known validator enodes, to be imported into another node with
+// ImportValidatorEnodes.
+func (api *API) ExportValidatorEnodes() ([]*ValidatorEnode, error) {
+	return api.istanbul.ExportValidatorEnodes()
+}
+
+// ImportValidatorEnodes seeds the validator enode table with the validator enodes exported by a trusted
+// node, so that a new node doesn't have to wait for announce to discover them.
+func (api *API) ImportValidatorEnodes(entries []*ValidatorEnode) (*ValidatorEnodesImport, error) {
+	return api.istanbul.ImportValidatorEnodes(entries)
+}
+
 func (api *API) GetVersionCertificateTableInfo() (map[string]*vet.VersionCertificateEntryInfo, error) {
 	return api.istanbul.versionCertificateTable.Info()
 }
 
+// GetAnnounceStats retrieves the statistics of the announce protocol since this node started
+func (api *API) GetAnnounceStats() (*AnnounceStats, error) {
+	return api.istanbul.GetAnnounceStats()
+}
+
+// GetObserverStats retrieves the statistics of the consensus messages received since this node started with
+// ObserverMode
+func (api *API) GetObserverStats() (*ObserverStats, error) {
+	return api.istanbul.GetObserverStats()
+}
+
 // GetCurrentRoundState retrieves the current IBFT RoundState
 func (api *API) GetCurrentRoundState() (*core.RoundStateSummary, error) {
+	// Hold the core lock so that the core can't be stopped while the summary is built
+	api.istanbul.coreMu.RLock()
+	defer api.istanbul.coreMu.RUnlock()
 	if !api.istanbul.coreStarted {
 		return nil, istanbul.ErrStoppedEngine
 	}
-	return api.istanbul.core.CurrentRoundState().Summary(), nil
+	summary := api.istanbul.core.CurrentRoundState().Summary()
+	summary.TimeoutRemaining = uint64(api.istanbul.core.RoundChangeTimeoutRemaining() / time.Millisecond)
+	summary.Timeout = uint64(api.istanbul.core.RoundChangeTimeout() / time.Millisecond)
+	return summary, nil
 }
 
-// GetCurrentRoundState retrieves the current IBFT RoundState
-func (api *API) ForceRoundChange() (bool, error) {
+// ProposerStatus is whether this validator proposes in the current round, and when it's expected to propose next.
+type ProposerStatus struct {
+	IsProposer       bool           `json:"isProposer"`
+	Proposer         common.Address `json:"proposer"`
+	Sequence         *big.Int       `json:"sequence"`
+	Round            *big.Int       `json:"round"`
+	TimeoutRemaining uint64         `json:"timeoutRemaining"` // Time (in milliseconds) until the current round times out
+	// The next sequence at which this validator is expected to propose, if every sequence is committed in
+	// round 0 and the validator set doesn't change. Nil if this validator isn't elected or if it's indeterminate.
+	NextProposerSequence *uint64 `json:"nextProposerSequence"`
+	// Set when the next proposer sequence can't be estimated: with the sticky policies, which depend on when
+	// rounds fail, with the WeightedRoundRobin policy, which draws each proposer from a seed of its own, or
+	// when this validator isn't expected to propose again before the end of the epoch.
+	Indeterminate bool `json:"indeterminate"`
+}
+
+// ProposerStatus retrieves whether this validator is the proposer of the current round, and an estimate of the
+// next sequence at which it will be the proposer under the current policy and validator set.
+func (api *API) ProposerStatus() (*ProposerStatus, error) {
+	api.istanbul.coreMu.RLock()
+	defer api.istanbul.coreMu.RUnlock()
+	if !api.istanbul.coreStarted {
+		return nil, istanbul.ErrStoppedEngine
+	}
+	roundState := api.istanbul.core.CurrentRoundState()
+	valSet := roundState.ValidatorSet()
+	proposer := roundState.Proposer().Address()
+	status := &ProposerStatus{
+		IsProposer:       proposer == api.istanbul.ValidatorAddress(),
+		Proposer:         proposer,
+		Sequence:         roundState.Sequence(),
+		Round:            roundState.Round(),
+		TimeoutRemaining: uint64(api.istanbul.core.RoundChangeTimeoutRemaining() / time.Millisecond),
+	}
+	if index, _ := valSet.GetByAddress(api.istanbul.ValidatorAddress()); index < 0 {
+		return status, nil
+	}
+	policy := api.istanbul.config.ProposerPolicyAt(status.Sequence.Uint64())
+	if policy == istanbul.Sticky || policy == istanbul.StickyWithFallback || policy == istanbul.WeightedRoundRobin {
+		status.Indeterminate = true
+		return status, nil
+	}
+	status.NextProposerSequence = nextProposerSequence(valSet, status.Sequence.Uint64(), proposer, api.istanbul.ValidatorAddress(), api.istanbul.config)
+	status.Indeterminate = status.NextProposerSequence == nil
+	return status, nil
+}
+
+// nextProposerSequence returns the first sequence after the given one at which address is the proposer in round 0,
+// assuming that each sequence is committed by its round 0 proposer, starting with the given proposer. The search
+// stops at the end of the epoch, after which the validator set may change, or at the proposer policy fork, and nil
+// is returned.
+func nextProposerSequence(valSet istanbul.ValidatorSet, sequence uint64, proposer, address common.Address, config *istanbul.Config) *uint64 {
+	policy := config.ProposerPolicyAt(sequence)
+	lastBlockOfEpoch := istanbul.GetEpochLastBlockNumber(istanbul.GetEpochNumber(sequence, config.Epoch), config.Epoch)
+	for next := sequence + 1; next <= lastBlockOfEpoch && next <= sequence+uint64(valSet.Size()); next++ {
+		if config.ProposerPolicyAt(next) != policy {
+			return nil
+		}
+		proposer = validator.SelectProposer(valSet, proposer, 0, policy)
+		if proposer == address {
+			return &next
+		}
+	}
+	return nil
+}
+
+// maxPredictedBlocks is the furthest ahead of the current head that istanbul_predictProposers predicts proposers.
+const maxPredictedBlocks = 10000
+
+// PredictedProposer is the validator expected to propose a block.
+type PredictedProposer struct {
+	Sequence uint64         `json:"sequence"`
+	Address  common.Address `json:"address"`
+}
+
+// ProposerPrediction is the expected proposer of each block of a range, if every block is committed in round 0.
+type ProposerPrediction struct {
+	Proposers []PredictedProposer `json:"proposers"`
+	// Set when the prediction depends on inputs that aren't known yet, so it's only a best effort: blocks after
+	// the current epoch, whose validator set and shuffle seed aren't known, a change of the proposer policy, the
+	// StickyWithFallback policy, whose demotions change from block to block, or proposer bans, which expire.
+	BestEffort bool `json:"bestEffort"`
+}
+
+// PredictProposers predicts the proposers of count blocks starting at fromBlock, which must be after the current
+// head, by running the proposer selection forward from the current head, assuming that there are no round changes
+// and that the validator set doesn't change. Proposer exclusions set on this node and the proposer bans at the
+// current head are taken into account.
+func (api *API) PredictProposers(fromBlock uint64, count uint64) (*ProposerPrediction, error) {
+	head := api.chain.CurrentHeader()
+	headNumber := head.Number.Uint64()
+	if fromBlock <= headNumber {
+		return nil, fmt.Errorf("block %d is not after the current head %d", fromBlock, headNumber)
+	}
+	if count == 0 {
+		return nil, errors.New("count must be greater than 0")
+	}
+	lastBlock := fromBlock + count - 1
+	if lastBlock < fromBlock || lastBlock-headNumber > maxPredictedBlocks {
+		return nil, fmt.Errorf("can't predict proposers more than %d blocks after the current head", maxPredictedBlocks)
+	}
+
+	sb := api.istanbul
+	proposer := common.ZeroAddress
+	if headNumber > 0 {
+		author, err := sb.Author(head)
+		if err != nil {
+			return nil, err
+		}
+		proposer = author
+	}
+
+	config := sb.config
+	headValSet := sb.orderValidators(headNumber, head.Hash())
+	if headValSet.Size() == 0 {
+		return nil, errors.New("no validators at the current head")
+	}
+	// The bans at the head are assumed to last, although they expire as the ban window moves on
+	banned := sb.bannedProposers(headNumber, head.Hash())
+	headValSet = sb.withProposerBans(headValSet, headNumber, head.Hash())
+	headPolicy := config.ProposerPolicyAt(headNumber + 1)
+	lastBlockOfEpoch := istanbul.GetEpochLastBlockNumber(istanbul.GetEpochNumber(headNumber+1, config.Epoch), config.Epoch)
+	// The shuffle seed may differ after the head, e.g. after an epoch block, so reshuffle with the seed of each block
+	reshuffled := make(map[uint64]istanbul.ValidatorSet)
+
+	prediction := &ProposerPrediction{Proposers: make([]PredictedProposer, 0, count)}
+	for sequence := headNumber + 1; sequence <= lastBlock; sequence++ {
+		policy := config.ProposerPolicyAt(sequence)
+		valSet := headValSet
+		if number := sequence - 1; number > headNumber && policy == istanbul.ShuffledRoundRobin {
+			seedBlock := sb.shuffleSeedBlockNumber(number)
+			if reshuffled[seedBlock] == nil {
+				reshuffled[seedBlock] = headValSet.Copy()
+				reshuffled[seedBlock].SetRandomness(sb.shuffleSeed(number, common.Hash{}))
+			}
+			valSet = reshuffled[seedBlock]
+		}
+		if number := sequence - 1; number > headNumber && policy == istanbul.WeightedRoundRobin {
+			valSet = headValSet.Copy()
+			valSet.SetRandomness(sb.weightedRoundRobinSeed(number, common.Hash{}))
+		}
+		proposer = validator.SelectProposer(sb.withProposerExclusions(valSet, sequence), proposer, 0, policy)
+
+		if sequence > lastBlockOfEpoch || policy != headPolicy || policy == istanbul.StickyWithFallback || len(banned) > 0 {
+			prediction.BestEffort = true
+		}
+		if sequence >= fromBlock {
+			prediction.Proposers = append(prediction.Proposers, PredictedProposer{Sequence: sequence, Address: proposer})
+		}
+	}
+	return prediction, nil
+}
+
+// DumpRoundStateHistory retrieves up to count of the most recent round states, recorded at each state
+// transition of the core, oldest first. At most RoundStateHistorySize round states are kept.
+func (api *API) DumpRoundStateHistory(count uint64) ([]*core.RoundStateSnapshot, error) {
+	if api.istanbul.config.RoundStateHistorySize == 0 {
+		return nil, errors.New("round state history is disabled")
+	}
+	if count > api.istanbul.config.RoundStateHistorySize {
+		count = api.istanbul.config.RoundStateHistorySize
+	}
+	return api.istanbul.core.RoundStateHistory(int(count)), nil
+}
+
+// GetDoubleSignEvidence retrieves the pairs of COMMIT messages, with valid committed seals, in which a
+// validator committed to two different blocks in the same round, as received since this node started.
+func (api *API) GetDoubleSignEvidence() []*core.DoubleSignEvidence {
+	return api.istanbul.core.DoubleSignEvidence()
+}
+
+// GetEquivocationEvidence retrieves the pairs of PREPREPARE messages in which the proposer of a round proposed
+// two different blocks, as received since this node started.
+func (api *API) GetEquivocationEvidence() []*core.EquivocationEvidence {
+	return api.istanbul.core.EquivocationEvidence()
+}
+
+// GetProposerFairness retrieves the number of blocks of an epoch that each of its validators proposed, against
+// the number each would have proposed if the blocks were shared equally. The current epoch is counted up to the
+// head, and is used if no epoch is given.
+func (api *API) GetProposerFairness(epoch *uint64) (*ProposerFairness, error) {
+	head := api.chain.CurrentHeader()
+	if head == nil {
+		return nil, errUnknownBlock
+	}
+	if epoch == nil {
+		current := istanbul.GetEpochNumber(head.Number.Uint64(), api.istanbul.config.Epoch)
+		epoch = &current
+	}
+	return api.istanbul.proposerFairness(*epoch, head)
+}
+
+// GetValidatorSetChanges retrieves the validators that joined and left the validator set at up to the given
+// number of most recent epoch transitions, oldest first, as seen since this node started.
+func (api *API) GetValidatorSetChanges(epochs uint64) []*ValidatorSetChange {
+	return api.istanbul.GetValidatorSetChanges(epochs)
+}
+
+// StateTransitions creates a subscription that streams the state transitions of the core:
+// entering a new sequence, changing rounds, and entering the prepared and committed states.
+// Events are dropped if the subscriber falls behind, rather than slowing down consensus.
+func (api *API) StateTransitions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan istanbul.StateTransitionEvent, 128)
+		sub := api.istanbul.SubscribeStateTransitionEvents(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, ev)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// ForceRoundChange forces the core to move to the given round, or to the next desired round if none
+// is given, and returns the round moved to. The target round must be after the current desired round
+// and at most a few rounds past it.
+func (api *API) ForceRoundChange(targetRound *uint64) (uint64, error) {
+	if !api.istanbul.coreStarted {
+		return 0, istanbul.ErrStoppedEngine
+	}
+	var target *big.Int
+	if targetRound != nil {
+		target = new(big.Int).SetUint64(*targetRound)
+	}
+	api.istanbul.logger.Warn("Round change forced through the API", "target_round", target)
+	round, err := api.istanbul.core.ForceRoundChange(target)
+	if err != nil {
+		api.istanbul.logger.Warn("Rejected forced round change", "target_round", target, "err", err)
+		return 0, err
+	}
+	api.istanbul.logger.Warn("Moving to forced round", "round", round)
+	return round.Uint64(), nil
+}
+
+// TraceSequence captures every consensus message received for the given sequence, with its sender, code,
+// round and payload, and logs them once the sequence is committed or abandoned. Tracing another sequence
+// abandons the current trace.
+func (api *API) TraceSequence(seq uint64) (bool, error) {
 	if !api.istanbul.coreStarted {
 		return false, istanbul.ErrStoppedEngine
 	}
-	api.istanbul.core.ForceRoundChange()
+	if err := api.istanbul.core.TraceSequence(new(big.Int).SetUint64(seq)); err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
@@ -219,6 +670,47 @@ func (api *API) GetProxiedValidators() ([]*proxy.ProxiedValidatorInfo, error) {
 	}
 }
 
+// GetProxyInfo retrieves the place of this node in the proxy topology. On a proxy, it returns the address
+// of the proxied validator it serves and the connected proxied validators. On a proxied validator, it
+// returns the configured proxies, which of them are peered, and the validators assigned to each.
+func (api *API) GetProxyInfo() (*proxy.TopologyInfo, error) {
+	info := &proxy.TopologyInfo{
+		Proxy:   api.istanbul.IsProxy(),
+		Proxied: api.istanbul.IsProxiedValidator(),
+	}
+	if info.Proxy {
+		address := api.istanbul.config.ProxiedValidatorAddress
+		info.ProxiedValidatorAddress = &address
+		proxiedValidators, err := api.istanbul.proxyEngine.GetProxiedValidatorsInfo()
+		if err != nil {
+			return nil, err
+		}
+		info.ProxiedValidators = proxiedValidators
+	}
+	if info.Proxied {
+		proxies, err := api.GetProxiesInfo()
+		if err != nil {
+			return nil, err
+		}
+		info.Proxies = proxies
+	}
+	return info, nil
+}
+
+// ExcludeProposer makes this node skip the given validator when selecting the proposers of the blocks up
+// to untilBlock, or lifts the exclusion if untilBlock is zero. This is a tool for coordinated operations,
+// e.g. to keep a validator from proposing while it is restarted. The exclusion always applies locally,
+// whether or not other validators set it: it only keeps the validator from proposing if the same
+// exclusion is set on a quorum of validators, and otherwise costs rounds until this node detects that
+// the network didn't honor it and lifts it. See Backend.ExcludeProposer.
+func (api *API) ExcludeProposer(address common.Address, untilBlock uint64) error {
+	if untilBlock != 0 && untilBlock <= api.chain.CurrentHeader().Number.Uint64() {
+		return errors.New("untilBlock has already been reached")
+	}
+	api.istanbul.ExcludeProposer(address, untilBlock)
+	return nil
+}
+
 // StartValidating starts the consensus engine
 func (api *API) StartValidating() error {
 	return api.istanbul.MakePrimary()
@@ -248,6 +740,13 @@ func (api *API) IsValidating() bool {
 	return api.istanbul.IsValidating()
 }
 
+// SelfTest signs a dummy COMMIT message and its committed seal with the configured keys, and reports whether they
+// verify against this node's address and the given BLS public key, with the address recovered from the message.
+// Without a BLS public key, the one registered for this validator in the current validator set is used.
+func (api *API) SelfTest(blsPublicKey *blscrypto.SerializedPublicKey) *SelfTest {
+	return api.istanbul.selfTest(blsPublicKey)
+}
+
 // GetCurrentRoundState retrieves the current replica state
 func (api *API) GetCurrentReplicaState() (*replica.ReplicaStateSummary, error) {
 	if api.istanbul.replicaState != nil {
@@ -0,0 +1,166 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestNextProposerSequence(t *testing.T) {
+	valSet, _ := newTestValidatorSet(4)
+	validators := valSet.List()
+	config := *istanbul.DefaultConfig
+	config.ProposerPolicy = istanbul.RoundRobin
+	config.Epoch = 100
+
+	// With round robin, the validator after the current proposer proposes the next sequence
+	next := nextProposerSequence(valSet, 10, validators[0].Address(), validators[1].Address(), &config)
+	if next == nil || *next != 11 {
+		t.Errorf("next proposer sequence = %v, want 11", next)
+	}
+	// The current proposer takes its next turn once every validator had one
+	next = nextProposerSequence(valSet, 10, validators[0].Address(), validators[0].Address(), &config)
+	if next == nil || *next != 14 {
+		t.Errorf("next proposer sequence = %v, want 14", next)
+	}
+	// Turns after the end of the epoch depend on the next validator set
+	next = nextProposerSequence(valSet, 98, validators[0].Address(), validators[3].Address(), &config)
+	if next != nil {
+		t.Errorf("next proposer sequence = %v in the next epoch, want nil", *next)
+	}
+	// Turns after the proposer policy fork are selected with another policy
+	config.LegacyProposerPolicy = istanbul.RoundRobin
+	config.ProposerPolicy = istanbul.ShuffledRoundRobin
+	config.ProposerPolicyForkBlock = 12
+	next = nextProposerSequence(valSet, 10, validators[0].Address(), validators[0].Address(), &config)
+	if next != nil {
+		t.Errorf("next proposer sequence = %v after the proposer policy fork, want nil", *next)
+	}
+}
+
+func TestProposerStatus(t *testing.T) {
+	chain, engine := newBlockChain(1, true)
+	defer chain.Stop()
+	api := &API{chain: chain, istanbul: engine}
+
+	status, err := api.ProposerStatus()
+	if err != nil {
+		t.Fatalf("failed to get proposer status: %v", err)
+	}
+	if !status.IsProposer || status.Proposer != engine.ValidatorAddress() {
+		t.Errorf("status = %+v, want this validator as the proposer", status)
+	}
+	if status.Indeterminate || status.NextProposerSequence == nil || *status.NextProposerSequence != status.Sequence.Uint64()+1 {
+		t.Errorf("next proposer sequence = %v, want %d", status.NextProposerSequence, status.Sequence.Uint64()+1)
+	}
+
+	engine.config.ProposerPolicy = istanbul.Sticky
+	defer func() { engine.config.ProposerPolicy = istanbul.DefaultConfig.ProposerPolicy }()
+	if status, err = api.ProposerStatus(); err != nil || !status.Indeterminate || status.NextProposerSequence != nil {
+		t.Errorf("status = %+v (err %v), want an indeterminate next proposer sequence with the sticky policy", status, err)
+	}
+}
+
+func TestPredictProposers(t *testing.T) {
+	chain, engine := newBlockChain(4, true)
+	defer chain.Stop()
+	api := &API{chain: chain, istanbul: engine}
+	engine.config.ProposerPolicy = istanbul.RoundRobin
+	defer func() { engine.config.ProposerPolicy = istanbul.DefaultConfig.ProposerPolicy }()
+
+	prediction, err := api.PredictProposers(1, 8)
+	if err != nil {
+		t.Fatalf("failed to predict proposers: %v", err)
+	}
+	if prediction.BestEffort || len(prediction.Proposers) != 8 {
+		t.Fatalf("prediction = %+v, want 8 exact proposers", prediction)
+	}
+	// With round robin, every validator proposes once before the first one proposes again
+	seen := make(map[common.Address]bool)
+	for i, proposer := range prediction.Proposers {
+		if proposer.Sequence != uint64(i+1) {
+			t.Errorf("sequence = %d, want %d", proposer.Sequence, i+1)
+		}
+		if i < 4 {
+			seen[proposer.Address] = true
+		} else if proposer.Address != prediction.Proposers[i-4].Address {
+			t.Errorf("proposer of %d = %v, want %v", proposer.Sequence, proposer.Address.Hex(), prediction.Proposers[i-4].Address.Hex())
+		}
+	}
+	if len(seen) != 4 {
+		t.Errorf("%d validators propose in a row of 4 blocks, want 4", len(seen))
+	}
+
+	// The proposers of the next epoch depend on its validator set
+	engine.config.Epoch = 10
+	defer func() { engine.config.Epoch = istanbul.DefaultConfig.Epoch }()
+	if prediction, err = api.PredictProposers(9, 4); err != nil || !prediction.BestEffort {
+		t.Errorf("prediction = %+v (err %v), want a best effort prediction across epochs", prediction, err)
+	}
+
+	if _, err := api.PredictProposers(0, 1); err == nil {
+		t.Errorf("expected an error for a block that isn't after the head")
+	}
+	if _, err := api.PredictProposers(1, 0); err == nil {
+		t.Errorf("expected an error for no blocks")
+	}
+	if _, err := api.PredictProposers(1, maxPredictedBlocks+1); err == nil {
+		t.Errorf("expected an error for too many blocks")
+	}
+}
+
+func TestGetBlockProposer(t *testing.T) {
+	genesisCfg, nodeKeys := getGenesisAndKeys(1, true)
+	chain, engine, _ := newBlockChainWithKeys(false, common.Address{}, false, genesisCfg, nodeKeys[0])
+	defer chain.Stop()
+	api := &API{chain: chain, istanbul: engine}
+
+	block, err := makeBlock(nodeKeys, chain, engine, chain.Genesis())
+	if err != nil {
+		t.Fatalf("failed to make block 1: %v", err)
+	}
+	if _, err := makeBlock(nodeKeys, chain, engine, block); err != nil {
+		t.Fatalf("failed to make block 2: %v", err)
+	}
+
+	for _, number := range []rpc.BlockNumber{1, 2, rpc.LatestBlockNumber} {
+		if proposer, err := api.GetBlockProposer(number); err != nil || proposer != engine.Address() {
+			t.Errorf("proposer of block %d = %v (err %v), want %v", number, proposer.Hex(), err, engine.Address().Hex())
+		}
+	}
+	if _, err := api.GetBlockProposer(0); err == nil {
+		t.Errorf("expected an error for the genesis block")
+	}
+	if _, err := api.GetBlockProposer(3); err == nil {
+		t.Errorf("expected an error for a block beyond the chain head")
+	}
+}
+
+func TestSetIstanbulConfigRejectsBlockPeriod(t *testing.T) {
+	chain, engine := newBlockChain(1, true)
+	defer chain.Stop()
+	api := &AdminAPI{istanbul: engine}
+
+	blockPeriod := uint64(1)
+	if ok, err := api.SetIstanbulConfig(TimingConfigArgs{BlockPeriod: &blockPeriod}); ok || err != errBlockPeriodNotReloadable {
+		t.Errorf("SetIstanbulConfig(blockPeriod) = %v, %v, want false, %v", ok, err, errBlockPeriodNotReloadable)
+	}
+}
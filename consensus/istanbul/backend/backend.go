@@ -46,7 +46,9 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
 	lru "github.com/hashicorp/golang-lru"
 )
 
@@ -59,6 +61,9 @@ var (
 	// errInvalidSigningFn is returned when the consensus signing function is invalid.
 	errInvalidSigningFn = errors.New("invalid signing function for istanbul messages")
 
+	// errSignerTimeout is returned when the signer doesn't sign within SignerTimeout.
+	errSignerTimeout = errors.New("signer timed out")
+
 	// errNoBlockHeader is returned when the requested block header could not be found.
 	errNoBlockHeader = errors.New("failed to retrieve block header")
 )
@@ -67,6 +72,9 @@ var (
 func New(config *istanbul.Config, db ethdb.Database) consensus.Istanbul {
 	// Allocate the snapshot caches and create the engine
 	logger := log.New()
+	if err := config.Validate(); err != nil {
+		logger.Crit("Invalid istanbul config", "err", err)
+	}
 	recentSnapshots, err := lru.NewARC(inmemorySnapshots)
 	if err != nil {
 		logger.Crit("Failed to create recent snapshots cache", "err", err)
@@ -79,6 +87,22 @@ func New(config *istanbul.Config, db ethdb.Database) consensus.Istanbul {
 	if err != nil {
 		logger.Crit("Failed to create known messages cache", "err", err)
 	}
+	recentDemotedProposers, err := lru.NewARC(inmemoryDemotedProposers)
+	if err != nil {
+		logger.Crit("Failed to create recent demoted proposers cache", "err", err)
+	}
+	recentBannedProposers, err := lru.NewARC(inmemoryBannedProposers)
+	if err != nil {
+		logger.Crit("Failed to create recent banned proposers cache", "err", err)
+	}
+	recentShuffleSeeds, err := lru.NewARC(inmemoryShuffleSeeds)
+	if err != nil {
+		logger.Crit("Failed to create recent shuffle seeds cache", "err", err)
+	}
+	aggregatedSealCache, err := newAggregatedSealCache(config.AggregatedSealCacheSize)
+	if err != nil {
+		logger.Crit("Failed to create aggregated seal cache", "err", err)
+	}
 	backend := &Backend{
 		config:                             config,
 		istanbulEventMux:                   new(event.TypeMux),
@@ -86,6 +110,10 @@ func New(config *istanbul.Config, db ethdb.Database) consensus.Istanbul {
 		db:                                 db,
 		commitCh:                           make(chan *types.Block, 1),
 		recentSnapshots:                    recentSnapshots,
+		recentDemotedProposers:             recentDemotedProposers,
+		recentBannedProposers:              recentBannedProposers,
+		recentShuffleSeeds:                 recentShuffleSeeds,
+		aggregatedSealCache:                aggregatedSealCache,
 		coreStarted:                        false,
 		announceRunning:                    false,
 		peerRecentMessages:                 peerRecentMessages,
@@ -93,8 +121,12 @@ func New(config *istanbul.Config, db ethdb.Database) consensus.Istanbul {
 		announceThreadWg:                   new(sync.WaitGroup),
 		generateAndGossipQueryEnodeCh:      make(chan struct{}, 1),
 		updateAnnounceVersionCh:            make(chan struct{}, 1),
+		announceDrainCh:                    make(chan chan struct{}),
+		announceNewEpochCh:                 make(chan struct{}, 1),
+		stateTransitionSubs:                make(map[chan<- istanbul.StateTransitionEvent]struct{}),
 		lastQueryEnodeGossiped:             make(map[common.Address]time.Time),
 		lastVersionCertificatesGossiped:    make(map[common.Address]time.Time),
+		highestVersionCertificateVersions:  make(map[common.Address]uint),
 		updatingCachedValidatorConnSetCond: sync.NewCond(&sync.Mutex{}),
 		finalizationTimer:                  metrics.NewRegisteredTimer("consensus/istanbul/backend/finalize", nil),
 		rewardDistributionTimer:            metrics.NewRegisteredTimer("consensus/istanbul/backend/rewards", nil),
@@ -110,6 +142,26 @@ func New(config *istanbul.Config, db ethdb.Database) consensus.Istanbul {
 		blocksDowntimeEventMeter:           metrics.NewRegisteredMeter("consensus/istanbul/blocks/downtimeevent", nil),
 		blocksFinalizedTransactionsGauge:   metrics.NewRegisteredGauge("consensus/istanbul/blocks/transactions", nil),
 		blocksFinalizedGasUsedGauge:        metrics.NewRegisteredGauge("consensus/istanbul/blocks/gasused", nil),
+		versionCertificatesPrunedMeter:     metrics.NewRegisteredMeter("consensus/istanbul/backend/versioncertificates/pruned", nil),
+		versionCertificatesOutsideMeter:    metrics.NewRegisteredMeter("consensus/istanbul/backend/versioncertificates/outsidevalidity", nil),
+		safetyViolationsMeter:              metrics.NewRegisteredMeter("consensus/istanbul/backend/safetyviolations", nil),
+		signerTimeoutsMeter:                metrics.NewRegisteredMeter("consensus/istanbul/backend/signertimeouts", nil),
+		proposalsTooFarInFutureMeter:       metrics.NewRegisteredMeter("consensus/istanbul/backend/proposals/toofarinfuture", nil),
+		stateTransitionsDroppedMeter:       metrics.NewRegisteredMeter("consensus/istanbul/backend/statetransitions/dropped", nil),
+		announceRateLimiter:                newAnnounceRateLimiter(announceRateLimitWindow),
+		announceMsgsRateLimitedMeter:       metrics.NewRegisteredMeter("consensus/istanbul/backend/announce/ratelimited", nil),
+		queryEnodeMsgsExpiredMeter:         metrics.NewRegisteredMeter("consensus/istanbul/backend/announce/queryenode/expired", nil),
+		messageVerifySlots:                 make(chan struct{}, messageVerifyWorkers(config)),
+		adaptiveGossip:                     newAdaptiveGossip(config.AnnounceAdditionalValidatorsToGossip),
+		announceRelayedMeter:               metrics.NewRegisteredMeter("consensus/istanbul/backend/announce/relayed", nil),
+		consensusObserver:                  newConsensusObserver(),
+	}
+	if config.AnnounceRelayEndpoint != "" {
+		backend.announceRelay = newAnnounceRelay(config.AnnounceRelayEndpoint)
+	}
+	if config.PrioritizeConsensusMsgs {
+		backend.msgQueues = newMsgPriorityQueues(msgQueueSize)
+		backend.msgQueues.start(messageVerifyWorkers(config))
 	}
 	backend.core = istanbulCore.New(backend, backend.config)
 
@@ -192,11 +244,27 @@ type Backend struct {
 	proposedBlockHash common.Hash
 	sealMu            sync.Mutex
 	coreStarted       bool
+	coreStopping      bool // Set while a graceful stop waits for the current sequence to be committed
 	coreMu            sync.RWMutex
 
 	// Snapshots for recent blocks to speed up reorgs
 	recentSnapshots *lru.ARCCache
 
+	// Proposers demoted by the StickyWithFallback policy for recent blocks
+	recentDemotedProposers *lru.ARCCache
+
+	// Proposers banned for failing too many of the recent rounds, by the hash of the block before the ban
+	recentBannedProposers *lru.ARCCache
+
+	// ShuffledRoundRobin seeds by epoch block number, kept when FreezeProposerOrderWithinEpoch is set
+	recentShuffleSeeds *lru.ARCCache
+
+	// Aggregated seals whose signature was verified, nil if AggregatedSealCacheSize is zero
+	aggregatedSealCache *aggregatedSealCache
+
+	// Validators that the operator excluded from proposing
+	proposerExclusions proposerExclusions
+
 	// event subscription for ChainHeadEvent event
 	broadcaster consensus.Broadcaster
 
@@ -214,6 +282,9 @@ type Backend struct {
 	versionCertificateTable           *enodes.VersionCertificateDB
 	lastVersionCertificatesGossiped   map[common.Address]time.Time
 	lastVersionCertificatesGossipedMu sync.RWMutex
+	// The highest version of the version certificates received per validator, enforced with StrictVersionCertificates
+	highestVersionCertificateVersions   map[common.Address]uint
+	highestVersionCertificateVersionsMu sync.Mutex
 
 	announceRunning               bool
 	announceMu                    sync.RWMutex
@@ -222,9 +293,15 @@ type Backend struct {
 	announceVersion               uint
 	announceVersionMu             sync.RWMutex
 	generateAndGossipQueryEnodeCh chan struct{}
+	queryEnodeGossipPeriodIndex   uint64 // The number of query enode messages generated, used to rotate the queried validators
 
 	updateAnnounceVersionCh chan struct{}
 
+	announceDrainCh chan chan struct{} // Used to ask the announce thread to handle its pending tasks before shutting down
+	asyncSends      asyncSendCounter   // Tracks the messages being sent to peers asynchronously
+
+	announceNewEpochCh chan struct{} // Used to notify the announce thread that a new epoch has started
+
 	// The enode certificate message map contains the most recently generated
 	// enode certificates for each external node ID (e.g. will have one entry per proxy
 	// for a proxied validator, or just one entry if it's a standalone validator).
@@ -239,6 +316,22 @@ type Backend struct {
 	delegateSignFeed  event.Feed
 	delegateSignScope event.SubscriptionScope
 
+	// Whether the MinValidatorsToStart threshold was met when last checked. Only accessed
+	// from the core's event loop, and used to log when consensus pauses or resumes.
+	minValidatorsToStartMet bool
+
+	// Channels subscribed to the state transitions of the core. Events are sent to them
+	// without blocking, so that a slow subscriber can't stall the core.
+	stateTransitionSubs   map[chan<- istanbul.StateTransitionEvent]struct{}
+	stateTransitionSubsMu sync.RWMutex
+	stateTransitionScope  event.SubscriptionScope
+
+	// The validator set changes of the most recent epoch transitions, oldest first
+	validatorSetChanges     []*ValidatorSetChange
+	validatorSetChangesMu   sync.RWMutex
+	validatorSetChangeFeed  event.Feed
+	validatorSetChangeScope event.SubscriptionScope
+
 	// Metric timer used to record block finalization times.
 	finalizationTimer metrics.Timer
 	// Metric timer used to record epoch reward distribution times.
@@ -273,6 +366,51 @@ type Backend struct {
 	// Gauge counting the gas used in the last block
 	blocksFinalizedGasUsedGauge metrics.Gauge
 
+	// Meter counting the expired entries removed from the version certificate table
+	versionCertificatesPrunedMeter metrics.Meter
+	// Meter counting the received version certificates dropped because they were outside their validity window
+	versionCertificatesOutsideMeter metrics.Meter
+
+	// Meter counting the received blocks with a valid aggregated seal that conflict with the local chain
+	safetyViolationsMeter metrics.Meter
+	// The first safety violation detected with HaltOnSafetyViolation, after which the node halts
+	safetyViolation   *SafetyViolation
+	safetyViolationMu sync.RWMutex
+
+	// Meter counting the state transition events dropped because a subscriber's channel was full
+	stateTransitionsDroppedMeter metrics.Meter
+
+	// Meter counting the signatures that the signer didn't return within SignerTimeout
+	signerTimeoutsMeter metrics.Meter
+	// Meter counting the proposals rejected because their timestamp was more than MaxFutureBlockTime ahead
+	proposalsTooFarInFutureMeter metrics.Meter
+
+	// Limits the announce messages handled per peer
+	announceRateLimiter *announceRateLimiter
+	// Meter counting the announce messages dropped because a peer exceeded its rate limit
+	announceMsgsRateLimitedMeter metrics.Meter
+	// Meter counting the query enode messages dropped because they were older than AnnounceMessageTTL
+	queryEnodeMsgsExpiredMeter metrics.Meter
+	// Counters and gossip state reported by GetAnnounceStats
+	announceStats announceStats
+	// The number of additional validators to gossip to, lowered under load if AnnounceAdaptiveGossip is set
+	adaptiveGossip *adaptiveGossip
+	// The relay that announce messages are exchanged through with unreachable validators, nil unless AnnounceRelayEndpoint is set
+	announceRelay *announceRelay
+	// Meter counting the announce messages received through the announce relay
+	announceRelayedMeter metrics.Meter
+	// The queues of received consensus and announce messages, nil unless PrioritizeConsensusMsgs is set
+	msgQueues *msgPriorityQueues
+
+	// The rounds and commit times of the blocks since the last summary logged every HealthLogInterval blocks
+	consensusHealth consensusHealth
+
+	// The consensus messages interpreted with ObserverMode
+	consensusObserver *consensusObserver
+
+	// Bounds the number of consensus messages whose signature is verified at once
+	messageVerifySlots chan struct{}
+
 	// Cache for the return values of the method RetrieveValidatorConnSet
 	cachedValidatorConnSet         map[common.Address]bool
 	cachedValidatorConnSetBlockNum uint64
@@ -379,6 +517,11 @@ func (sb *Backend) SelfNode() *enode.Node {
 // Close the backend
 func (sb *Backend) Close() error {
 	sb.delegateSignScope.Close()
+	sb.stateTransitionScope.Close()
+	sb.validatorSetChangeScope.Close()
+	if sb.msgQueues != nil {
+		sb.msgQueues.stop()
+	}
 	var errs []error
 	if err := sb.valEnodeTable.Close(); err != nil {
 		errs = append(errs, err)
@@ -471,6 +614,12 @@ func (sb *Backend) Commit(proposal istanbul.Proposal, aggregatedSeal types.Istan
 		Signature: aggregatedEpochValidatorSetSeal.Signature,
 	})
 
+	// A shadow validator leaves it to the live validators to produce the block, and gets it through sync
+	if sb.config.ShadowValidator {
+		sb.logger.Info("Shadow validator would have committed", "address", sb.Address(), "round", aggregatedSeal.Round.Uint64(), "hash", proposal.Hash(), "number", proposal.Number().Uint64())
+		return nil
+	}
+
 	sb.logger.Info("Committed", "address", sb.Address(), "round", aggregatedSeal.Round.Uint64(), "hash", proposal.Hash(), "number", proposal.Number().Uint64())
 	// - if the proposed and committed blocks are the same, send the proposed hash
 	//   to commit channel, which is being watched inside the engine.Seal() function.
@@ -509,6 +658,13 @@ func (sb *Backend) Verify(proposal istanbul.Proposal) (time.Duration, error) {
 		return 0, core.ErrBlacklistedHash
 	}
 
+	// Don't wait for a proposal whose proposer's clock is far ahead, so that the round changes instead
+	if ahead := int64(block.Time()) - now().Unix(); sb.config.MaxFutureBlockTime > 0 && ahead > int64(sb.config.MaxFutureBlockTime) {
+		sb.proposalsTooFarInFutureMeter.Mark(1)
+		sb.logger.Warn("Rejecting proposal too far in the future", "number", block.Number(), "hash", block.Hash(), "coinbase", block.Coinbase(), "ahead", ahead, "max_future_block_time", sb.config.MaxFutureBlockTime)
+		return 0, errProposalTooFarInFuture
+	}
+
 	// check block body
 	txnHash := types.DeriveSha(block.Transactions())
 	if txnHash != block.Header().TxHash {
@@ -626,22 +782,77 @@ func (sb *Backend) verifyValSetDiff(proposal istanbul.Proposal, block *types.Blo
 
 // Sign implements istanbul.Backend.Sign
 func (sb *Backend) Sign(data []byte) ([]byte, error) {
-	if sb.signFn == nil {
-		return nil, errInvalidSigningFn
+	return sb.signData(accounts.MimetypeIstanbul, data)
+}
+
+// signHeader returns the proposer seal of the given header.
+func (sb *Backend) signHeader(header *types.Header) ([]byte, error) {
+	data, err := rlp.EncodeToBytes(types.IstanbulFilteredHeader(header, false))
+	if err != nil {
+		return nil, err
 	}
+	return sb.signData(accounts.MimetypeIstanbulHeader, data)
+}
+
+// signData signs data of the given mimetype with the ECDSA signer.
+func (sb *Backend) signData(mimeType string, data []byte) ([]byte, error) {
+	// Copy the signer under the lock and call it unlocked, so that a hung signer doesn't block Authorize
 	sb.signFnMu.RLock()
-	defer sb.signFnMu.RUnlock()
-	return sb.signFn(accounts.Account{Address: sb.address}, accounts.MimetypeIstanbul, data)
+	signFn, address := sb.signFn, sb.address
+	sb.signFnMu.RUnlock()
+	if signFn == nil {
+		return nil, errInvalidSigningFn
+	}
+	var sig []byte
+	if err := sb.withSignerTimeout(func() (err error) {
+		sig, err = signFn(accounts.Account{Address: address}, mimeType, data)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return sig, nil
 }
 
 // Sign implements istanbul.Backend.SignBLS
 func (sb *Backend) SignBLS(data []byte, extra []byte, useComposite bool) (blscrypto.SerializedSignature, error) {
-	if sb.signBLSFn == nil {
+	sb.signFnMu.RLock()
+	signBLSFn, blsAddress := sb.signBLSFn, sb.blsAddress
+	sb.signFnMu.RUnlock()
+	if signBLSFn == nil {
 		return blscrypto.SerializedSignature{}, errInvalidSigningFn
 	}
-	sb.signFnMu.RLock()
-	defer sb.signFnMu.RUnlock()
-	return sb.signBLSFn(accounts.Account{Address: sb.blsAddress}, data, extra, useComposite)
+	var sig blscrypto.SerializedSignature
+	if err := sb.withSignerTimeout(func() (err error) {
+		sig, err = signBLSFn(accounts.Account{Address: blsAddress}, data, extra, useComposite)
+		return err
+	}); err != nil {
+		return blscrypto.SerializedSignature{}, err
+	}
+	return sig, nil
+}
+
+// withSignerTimeout runs sign, and gives up with errSignerTimeout if it doesn't return within SignerTimeout, so
+// that a slow external signer can't stall consensus beyond the round timeout. A signature that arrives late is
+// dropped, so sign must not hold any lock that it would keep holding after the timeout.
+func (sb *Backend) withSignerTimeout(sign func() error) error {
+	if sb.config.SignerTimeout == 0 {
+		return sign()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- sign()
+	}()
+
+	timeout := time.NewTimer(time.Duration(sb.config.SignerTimeout) * time.Millisecond)
+	defer timeout.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timeout.C:
+		sb.signerTimeoutsMeter.Mark(1)
+		sb.logger.Error("Signer didn't sign within the signer timeout, it can't keep up with consensus", "timeout", sb.config.SignerTimeout)
+		return errSignerTimeout
+	}
 }
 
 // CheckSignature implements istanbul.Backend.CheckSignature
@@ -681,12 +892,55 @@ func (sb *Backend) getValidators(number uint64, hash common.Hash) istanbul.Valid
 	return snap.ValSet
 }
 
-// validatorRandomnessAtBlockNumber calls into the EVM to get the randomness to use in proposer ordering at a given block.
-func (sb *Backend) validatorRandomnessAtBlockNumber(number uint64, hash common.Hash) (common.Hash, error) {
-	lastBlockInPreviousEpoch := number
-	if number > 0 {
-		lastBlockInPreviousEpoch = number - istanbul.GetNumberWithinEpoch(number, sb.config.Epoch)
+// shuffleSeedBlockNumber returns the number of the block whose seed shuffles the validator set at a
+// given block. This is the last block of the previous epoch. With FreezeProposerOrderWithinEpoch the
+// epoch is the one of the block after the given one, i.e. of the block whose proposer is selected, so
+// the set returned at an epoch block is shuffled with the same seed as the rest of the next epoch.
+func (sb *Backend) shuffleSeedBlockNumber(number uint64) uint64 {
+	if sb.config.FreezeProposerOrderWithinEpoch {
+		number++
 	}
+	if number == 0 {
+		return 0
+	}
+	return number - istanbul.GetNumberWithinEpoch(number, sb.config.Epoch)
+}
+
+// shuffleSeedAtBlockNumber returns the seed with which the ShuffledRoundRobin policy shuffles the validator set
+// at a given block, according to the configured seed source. The seed is taken at the block returned by
+// shuffleSeedBlockNumber.
+func (sb *Backend) shuffleSeedAtBlockNumber(number uint64, hash common.Hash) (common.Hash, error) {
+	lastBlockInPreviousEpoch := sb.shuffleSeedBlockNumber(number)
+	switch sb.config.ShuffleSeedSource {
+	case istanbul.EpochBlockHashSeed:
+		header := sb.chain.GetHeaderByNumber(lastBlockInPreviousEpoch)
+		if header == nil {
+			return common.Hash{}, errNoBlockHeader
+		}
+		return header.Hash(), nil
+	default:
+		return sb.validatorRandomnessAtBlockNumber(lastBlockInPreviousEpoch)
+	}
+}
+
+// frozenShuffleSeed returns the seed of the epoch of the block after the given one, as remembered from
+// the first time it was read, so that a failure to read it again doesn't reshuffle the validator set in
+// the middle of the epoch.
+func (sb *Backend) frozenShuffleSeed(number uint64, seed common.Hash, err error) (common.Hash, error) {
+	epochBlock := sb.shuffleSeedBlockNumber(number)
+	if err == nil {
+		sb.recentShuffleSeeds.Add(epochBlock, seed)
+		return seed, nil
+	}
+	if cached, ok := sb.recentShuffleSeeds.Get(epochBlock); ok {
+		sb.logger.Debug("Using the remembered shuffle seed of the epoch", "block_number", number, "epoch_block", epochBlock, "error", err)
+		return cached.(common.Hash), nil
+	}
+	return seed, err
+}
+
+// validatorRandomnessAtBlockNumber calls into the EVM to get the randomness beacon value at a given block.
+func (sb *Backend) validatorRandomnessAtBlockNumber(number uint64) (common.Hash, error) {
 	header := sb.chain.CurrentHeader()
 	if header == nil {
 		return common.Hash{}, errNoBlockHeader
@@ -695,30 +949,116 @@ func (sb *Backend) validatorRandomnessAtBlockNumber(number uint64, hash common.H
 	if err != nil {
 		return common.Hash{}, err
 	}
-	return random.BlockRandomness(header, state, lastBlockInPreviousEpoch)
+	return random.BlockRandomness(header, state, number)
 }
 
 func (sb *Backend) getOrderedValidators(number uint64, hash common.Hash) istanbul.ValidatorSet {
+	return sb.withProposerExclusions(sb.withProposerBans(sb.orderValidators(number, hash), number, hash), number+1)
+}
+
+// orderValidators returns the validator set at the given block, ordered by the proposer policy of the next
+// block, without the proposer exclusions.
+func (sb *Backend) orderValidators(number uint64, hash common.Hash) istanbul.ValidatorSet {
 	valSet := sb.getValidators(number, hash)
 	if valSet.Size() == 0 {
 		return valSet
 	}
 
-	if sb.config.ProposerPolicy == istanbul.ShuffledRoundRobin {
-		seed, err := sb.validatorRandomnessAtBlockNumber(number, hash)
-		if err != nil {
-			if err == comm_errors.ErrRegistryContractNotDeployed {
-				sb.logger.Debug("Failed to set randomness for proposer selection", "block_number", number, "hash", hash, "error", err)
-			} else {
-				sb.logger.Warn("Failed to set randomness for proposer selection", "block_number", number, "hash", hash, "error", err)
-			}
+	// The validator set at block n selects the proposer of block n+1
+	policy := sb.config.ProposerPolicyAt(number + 1)
+	if policy == istanbul.ShuffledRoundRobin {
+		valSet.SetRandomness(sb.shuffleSeed(number, hash))
+	}
+
+	if policy == istanbul.StickyWithFallback {
+		// The demotions differ from block to block, so don't modify the snapshot's validator set
+		valSet = valSet.Copy()
+		valSet.SetDemotedProposers(sb.stickyFallbackDemotedProposers(number, hash))
+	}
+
+	if policy == istanbul.WeightedRoundRobin {
+		// The seed differs from block to block, so don't modify the snapshot's validator set
+		valSet = valSet.Copy()
+		valSet.SetRandomness(sb.weightedRoundRobinSeed(number, hash))
+	}
+	return valSet
+}
+
+// weightedRoundRobinSeed returns the seed from which the WeightedRoundRobin policy draws the proposer of the
+// block after the given one. It mixes the shuffle seed of the epoch, which the proposer of the given block
+// can't choose, unlike its hash, with the number of the block whose proposer is drawn.
+func (sb *Backend) weightedRoundRobinSeed(number uint64, hash common.Hash) common.Hash {
+	return istanbul.RLPHash([]interface{}{sb.shuffleSeed(number, hash), number + 1})
+}
+
+// shuffleSeed returns the seed with which the ShuffledRoundRobin policy shuffles the validator set at the given
+// block. Failures to read the seed are logged and the zero seed is returned.
+func (sb *Backend) shuffleSeed(number uint64, hash common.Hash) common.Hash {
+	seed, err := sb.shuffleSeedAtBlockNumber(number, hash)
+	if sb.config.FreezeProposerOrderWithinEpoch {
+		seed, err = sb.frozenShuffleSeed(number, seed, err)
+	}
+	if err != nil {
+		if err == comm_errors.ErrRegistryContractNotDeployed {
+			sb.logger.Debug("Failed to set randomness for proposer selection", "block_number", number, "hash", hash, "error", err)
+		} else {
+			sb.logger.Warn("Failed to set randomness for proposer selection", "block_number", number, "hash", hash, "error", err)
 		}
-		valSet.SetRandomness(seed)
 	}
+	return seed
+}
 
+// withProposerExclusions returns the validator set with the validators excluded from proposing the given block
+// demoted, without modifying the given set.
+func (sb *Backend) withProposerExclusions(valSet istanbul.ValidatorSet, number uint64) istanbul.ValidatorSet {
+	if excluded := sb.proposerExclusions.excludedAt(number); len(excluded) > 0 {
+		valSet = valSet.Copy()
+		demoted := append(append([]common.Address{}, valSet.GetDemotedProposers()...), excluded...)
+		valSet.SetDemotedProposers(demoted)
+	}
 	return valSet
 }
 
+// stickyFallbackDemotedProposers returns the validators that the StickyWithFallback proposer policy
+// skips for the block after the given one, based on the last StickyFallbackCooldown blocks.
+func (sb *Backend) stickyFallbackDemotedProposers(number uint64, hash common.Hash) []common.Address {
+	if demoted, ok := sb.recentDemotedProposers.Get(hash); ok {
+		return demoted.([]common.Address)
+	}
+
+	turns := make([]validator.StickyFallbackTurn, 0, sb.config.StickyFallbackCooldown)
+	header := sb.chain.GetHeader(hash, number)
+	for header != nil && header.Number.Uint64() > 0 && uint64(len(turns)) < sb.config.StickyFallbackCooldown {
+		author, err := sb.Author(header)
+		if err != nil {
+			sb.logger.Warn("Failed to retrieve author for proposer demotion", "number", header.Number, "hash", header.Hash(), "err", err)
+			return nil
+		}
+		extra, err := types.ExtractIstanbulExtra(header)
+		if err != nil {
+			sb.logger.Warn("Failed to extract istanbul extra for proposer demotion", "number", header.Number, "hash", header.Hash(), "err", err)
+			return nil
+		}
+		var round uint64
+		if extra.AggregatedSeal.Round != nil {
+			round = extra.AggregatedSeal.Round.Uint64()
+		}
+		turns = append(turns, validator.StickyFallbackTurn{Author: author, Round: round})
+		header = sb.chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+
+	// The turns were collected from newest to oldest
+	for i, j := 0, len(turns)-1; i < j; i, j = i+1, j-1 {
+		turns[i], turns[j] = turns[j], turns[i]
+	}
+	demoted := validator.StickyFallbackDemotedProposers(turns, sb.config.StickyFallbackThreshold)
+	if len(demoted) > 0 {
+		sb.logger.Debug("Demoted proposers", "number", number+1, "demoted", common.ConvertToStringSlice(demoted))
+	}
+	sb.recentDemotedProposers.Add(hash, demoted)
+	return demoted
+}
+
 // GetCurrentHeadBlock retrieves the last block
 func (sb *Backend) GetCurrentHeadBlock() istanbul.Proposal {
 	return sb.currentBlock()
@@ -788,6 +1128,70 @@ func (sb *Backend) ValidatorAddress() common.Address {
 	return sb.Address()
 }
 
+// HasMinValidatorsToStart implements core.CoreBackend.HasMinValidatorsToStart
+func (sb *Backend) HasMinValidatorsToStart() bool {
+	if sb.config.MinValidatorsToStart == 0 {
+		return true
+	}
+
+	numValidators, err := sb.numConnectedVerifiedValidators()
+	if err != nil {
+		sb.logger.Warn("Error counting the connected validators", "err", err)
+		return sb.minValidatorsToStartMet
+	}
+
+	met := uint64(numValidators) >= sb.config.MinValidatorsToStart
+	if met && !sb.minValidatorsToStartMet {
+		sb.logger.Info("Enough validators connected, starting consensus", "connected_validators", numValidators, "min_validators", sb.config.MinValidatorsToStart)
+	} else if !met && sb.minValidatorsToStartMet {
+		sb.logger.Warn("Too few validators connected, pausing consensus", "connected_validators", numValidators, "min_validators", sb.config.MinValidatorsToStart)
+	}
+	sb.minValidatorsToStartMet = met
+	return met
+}
+
+// Synchronise implements core.CoreBackend.Synchronise
+func (sb *Backend) Synchronise() {
+	if sb.broadcaster != nil {
+		sb.broadcaster.Synchronise()
+	}
+}
+
+// numConnectedVerifiedValidators returns the number of validators in the current validator set
+// whose enode was verified through the announce protocol and that this node is connected to,
+// including this node itself. A proxied validator is connected to the other validators through
+// its proxies, so only the announce verification is taken into account for it.
+func (sb *Backend) numConnectedVerifiedValidators() (int, error) {
+	currentBlock := sb.currentBlock()
+	valSet := sb.getValidators(currentBlock.Number().Uint64(), currentBlock.Hash())
+
+	valEnodes, err := sb.valEnodeTable.GetValEnodes(istanbul.MapValidatorsToAddresses(valSet.List()))
+	if err != nil {
+		return 0, err
+	}
+
+	var peers map[enode.ID]consensus.Peer
+	if sb.broadcaster != nil {
+		peers = sb.broadcaster.FindPeers(nil, p2p.AnyPurpose)
+	}
+
+	numValidators := 0
+	for _, val := range valSet.List() {
+		if val.Address() == sb.ValidatorAddress() {
+			numValidators++
+			continue
+		}
+		valEnode, ok := valEnodes[val.Address()]
+		if !ok || valEnode.Node == nil {
+			continue
+		}
+		if _, connected := peers[valEnode.Node.ID()]; connected || sb.IsProxiedValidator() {
+			numValidators++
+		}
+	}
+	return numValidators, nil
+}
+
 // RetrieveValidatorConnSet returns the cached validator conn set if the cache
 // is younger than 20 blocks, younger than 1 minute, or if an epoch transition didn't occur since the last
 // cached entry. In the event of a cache miss, this may block for a
@@ -903,7 +1307,7 @@ func (sb *Backend) retrieveUncachedValidatorConnSet() (map[common.Address]bool,
 	if err != nil {
 		return nil, 0, time.Time{}, err
 	}
-	electNValidators, err := election.ElectNValidatorSigners(currentBlock.Header(), currentState, sb.config.AnnounceAdditionalValidatorsToGossip)
+	electNValidators, err := election.ElectNValidatorSigners(currentBlock.Header(), currentState, sb.announceAdditionalValidatorsToGossip())
 
 	// The validator contract may not be deployed yet.
 	// Even if it is deployed, it may not have any registered validators yet.
@@ -17,15 +17,19 @@
 package backend
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	lru "github.com/hashicorp/golang-lru"
 )
 
 func TestSign(t *testing.T) {
@@ -45,6 +49,43 @@ func TestSign(t *testing.T) {
 	}
 }
 
+func TestSignerTimeout(t *testing.T) {
+	b := newBackend()
+	b.config.SignerTimeout = 50
+	data := []byte("Here is a string....")
+	if _, err := b.Sign(data); err != nil {
+		t.Errorf("error mismatch for a fast signer: have %v, want nil", err)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	b.signFnMu.Lock()
+	signFn := b.signFn
+	b.signFn = func(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+		<-release
+		return signFn(account, mimeType, data)
+	}
+	b.signFnMu.Unlock()
+	if _, err := b.Sign(data); err != errSignerTimeout {
+		t.Errorf("error mismatch for a slow signer: have %v, want %v", err, errSignerTimeout)
+	}
+
+	// The hung signer must not keep Authorize from replacing it
+	authorized := make(chan struct{})
+	go func() {
+		b.Authorize(b.address, b.blsAddress, b.publicKey, b.decryptFn, signFn, b.signBLSFn)
+		close(authorized)
+	}()
+	select {
+	case <-authorized:
+	case <-time.After(time.Second):
+		t.Fatal("Authorize blocked on a hung signer")
+	}
+	if _, err := b.Sign(data); err != nil {
+		t.Errorf("error mismatch after replacing the slow signer: have %v, want nil", err)
+	}
+}
+
 func TestCheckSignature(t *testing.T) {
 	key, _ := generatePrivateKey()
 	data := []byte("Here is a string....")
@@ -185,3 +226,51 @@ func TestGetProposer(t *testing.T) {
 		t.Errorf("proposer mismatch: have %v, want %v, currentblock: %v", actual.Hex(), expected.Hex(), chain.CurrentBlock().Number())
 	}
 }
+
+func TestShuffleSeedBlockNumber(t *testing.T) {
+	config := *istanbul.DefaultConfig
+	config.Epoch = 10
+	sb := &Backend{config: &config}
+
+	// The validator set at block n selects the proposer of block n+1. Without freezing, the set at
+	// an epoch block is shuffled with the seed of the epoch that ends at that block.
+	for number, want := range map[uint64]uint64{0: 0, 1: 0, 9: 0, 10: 0, 11: 10, 19: 10, 20: 10} {
+		if have := sb.shuffleSeedBlockNumber(number); have != want {
+			t.Errorf("shuffleSeedBlockNumber(%d) = %d, want %d", number, have, want)
+		}
+	}
+
+	// When frozen, every set selecting a proposer of the same epoch is shuffled with the same seed.
+	config.FreezeProposerOrderWithinEpoch = true
+	for number, want := range map[uint64]uint64{0: 0, 1: 0, 9: 0, 10: 10, 11: 10, 19: 10, 20: 20} {
+		if have := sb.shuffleSeedBlockNumber(number); have != want {
+			t.Errorf("frozen shuffleSeedBlockNumber(%d) = %d, want %d", number, have, want)
+		}
+	}
+}
+
+func TestFrozenShuffleSeed(t *testing.T) {
+	config := *istanbul.DefaultConfig
+	config.Epoch = 10
+	config.FreezeProposerOrderWithinEpoch = true
+	recentShuffleSeeds, _ := lru.NewARC(inmemoryShuffleSeeds)
+	sb := &Backend{config: &config, logger: log.New(), recentShuffleSeeds: recentShuffleSeeds}
+
+	errSeed := errors.New("state not available")
+	if _, err := sb.frozenShuffleSeed(12, common.Hash{}, errSeed); err != errSeed {
+		t.Fatalf("expected the error without a remembered seed, got %v", err)
+	}
+
+	seed := common.HexToHash("0x01")
+	if have, err := sb.frozenShuffleSeed(10, seed, nil); err != nil || have != seed {
+		t.Fatalf("frozenShuffleSeed = %v, %v, want %v", have, err, seed)
+	}
+	// A failure to read the seed later in the epoch falls back to the remembered one
+	if have, err := sb.frozenShuffleSeed(15, common.Hash{}, errSeed); err != nil || have != seed {
+		t.Errorf("frozenShuffleSeed after failure = %v, %v, want %v", have, err, seed)
+	}
+	// The seed of another epoch isn't reused
+	if _, err := sb.frozenShuffleSeed(20, common.Hash{}, errSeed); err != errSeed {
+		t.Errorf("expected the error for the next epoch, got %v", err)
+	}
+}
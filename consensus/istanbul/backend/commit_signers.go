@@ -0,0 +1,87 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// CommitSigners is the validators whose COMMIT signatures are aggregated in the seal of a committed block.
+type CommitSigners struct {
+	Number  uint64           `json:"number"`
+	Round   uint64           `json:"round"`   // The round in which the block was committed
+	Signers []common.Address `json:"signers"` // In validator set order
+	Count   int              `json:"count"`
+	Weight  uint64           `json:"weight"` // The total weight of the signers
+	Quorum  uint64           `json:"quorum"` // The minimum quorum weight of the validator set that committed the block
+}
+
+// sealSigners returns the validators marked as signers in the bitmap of an aggregated seal, which must not
+// refer to validators outside the given validator set.
+func sealSigners(validators istanbul.ValidatorSet, seal types.IstanbulAggregatedSeal) ([]common.Address, error) {
+	if seal.Bitmap == nil || seal.Bitmap.Sign() < 0 || seal.Bitmap.BitLen() > validators.Size() {
+		return nil, istanbul.ErrInvalidAggregatedSeal
+	}
+	signers := []common.Address{}
+	for i := 0; i < validators.Size(); i++ {
+		if seal.Bitmap.Bit(i) == 1 {
+			signers = append(signers, validators.GetByIndex(uint64(i)).Address())
+		}
+	}
+	return signers, nil
+}
+
+// commitSigners returns the signers of the aggregated seal of the given header, decoded against the
+// validator set that committed it, i.e. the validator set after its parent. That set is reconstructed from
+// the snapshot of the parent's epoch if it isn't cached.
+func (sb *Backend) commitSigners(header *types.Header) (*CommitSigners, error) {
+	if header.Number.Sign() == 0 {
+		return nil, errors.New("the genesis block has no commit signers")
+	}
+	extra, err := types.ExtractIstanbulExtra(header)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := sb.snapshot(sb.chain, header.Number.Uint64()-1, header.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	signers, err := sealSigners(snap.ValSet, extra.AggregatedSeal)
+	if err != nil {
+		return nil, err
+	}
+	weight := uint64(0)
+	for _, signer := range signers {
+		weight += snap.ValSet.GetWeight(signer)
+	}
+	round := uint64(0)
+	if extra.AggregatedSeal.Round != nil {
+		round = extra.AggregatedSeal.Round.Uint64()
+	}
+	return &CommitSigners{
+		Number:  header.Number.Uint64(),
+		Round:   round,
+		Signers: signers,
+		Count:   len(signers),
+		Weight:  weight,
+		Quorum:  snap.ValSet.MinQuorumWeight(),
+	}, nil
+}
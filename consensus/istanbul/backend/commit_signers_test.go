@@ -0,0 +1,85 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestSealSigners(t *testing.T) {
+	valSet, _ := newTestValidatorSet(4)
+	validators := valSet.List()
+
+	signers, err := sealSigners(valSet, types.IstanbulAggregatedSeal{Bitmap: big.NewInt(0xb)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []common.Address{validators[0].Address(), validators[1].Address(), validators[3].Address()}
+	if !reflect.DeepEqual(signers, want) {
+		t.Errorf("signers mismatch: have %v, want %v", signers, want)
+	}
+
+	// The bitmap can't refer to validators outside the validator set
+	if _, err := sealSigners(valSet, types.IstanbulAggregatedSeal{Bitmap: big.NewInt(0x10)}); err != istanbul.ErrInvalidAggregatedSeal {
+		t.Errorf("error mismatch: have %v, want %v", err, istanbul.ErrInvalidAggregatedSeal)
+	}
+	if _, err := sealSigners(valSet, types.IstanbulAggregatedSeal{}); err != istanbul.ErrInvalidAggregatedSeal {
+		t.Errorf("error mismatch for a seal without bitmap: have %v, want %v", err, istanbul.ErrInvalidAggregatedSeal)
+	}
+}
+
+func TestGetCommitSigners(t *testing.T) {
+	genesisCfg, nodeKeys := getGenesisAndKeys(4, true)
+	chain, engine, _ := newBlockChainWithKeys(false, common.Address{}, false, genesisCfg, nodeKeys[0])
+	defer chain.Stop()
+	api := &API{chain: chain, istanbul: engine}
+
+	block, err := makeBlockAtRound(nodeKeys, chain, engine, chain.Genesis(), 0)
+	if err != nil {
+		t.Fatalf("failed to make block 1: %v", err)
+	}
+	if _, err := makeBlockAtRound(nodeKeys, chain, engine, block, 1); err != nil {
+		t.Fatalf("failed to make block 2: %v", err)
+	}
+
+	validators := istanbul.MapValidatorsToAddresses(engine.getValidators(0, chain.Genesis().Hash()).List())
+	for number, round := range map[rpc.BlockNumber]uint64{1: 0, 2: 1, rpc.LatestBlockNumber: 1} {
+		signers, err := api.GetCommitSigners(number)
+		if err != nil {
+			t.Fatalf("failed to get the commit signers of block %d: %v", number, err)
+		}
+		if !reflect.DeepEqual(signers.Signers, validators) || signers.Count != 4 || signers.Weight != 4 || signers.Quorum != 3 {
+			t.Errorf("commit signers of block %d = %v, %d weighing %d of %d, want %v, 4 weighing 4 of 3", number, signers.Signers, signers.Count, signers.Weight, signers.Quorum, validators)
+		}
+		if signers.Round != round {
+			t.Errorf("round of block %d = %d, want %d", number, signers.Round, round)
+		}
+	}
+	if _, err := api.GetCommitSigners(0); err == nil {
+		t.Errorf("expected an error for the genesis block")
+	}
+	if _, err := api.GetCommitSigners(3); err == nil {
+		t.Errorf("expected an error for a block beyond the chain head")
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// consensusHealth accumulates the rounds and commit times of the blocks since the last health summary.
+type consensusHealth struct {
+	blocks      uint64
+	totalRounds uint64
+
+	commits         uint64
+	totalCommitTime time.Duration
+	lastHead        uint64
+	lastHeadTime    time.Time
+
+	mu sync.Mutex
+}
+
+// observe records the round at which the new chain head was committed, and the time since the previous
+// chain head if that was its parent.
+func (h *consensusHealth) observe(number uint64, round uint64, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.blocks++
+	h.totalRounds += round
+	if !h.lastHeadTime.IsZero() && h.lastHead+1 == number {
+		h.commits++
+		h.totalCommitTime += now.Sub(h.lastHeadTime)
+	}
+	h.lastHead, h.lastHeadTime = number, now
+}
+
+// averages returns the average round and time to commit since the previous call, and starts over.
+func (h *consensusHealth) averages() (float64, time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var avgRound float64
+	var avgCommitTime time.Duration
+	if h.blocks > 0 {
+		avgRound = float64(h.totalRounds) / float64(h.blocks)
+	}
+	if h.commits > 0 {
+		avgCommitTime = h.totalCommitTime / time.Duration(h.commits)
+	}
+	h.blocks, h.totalRounds, h.commits, h.totalCommitTime = 0, 0, 0, 0
+	return avgRound, avgCommitTime
+}
+
+// reportConsensusHealth records the new chain head, and logs a one line summary of the consensus health
+// every HealthLogInterval blocks. The time to commit is measured between consecutive chain heads.
+func (sb *Backend) reportConsensusHealth(block *types.Block) {
+	if sb.config.HealthLogInterval == 0 {
+		return
+	}
+	number := block.NumberU64()
+	round := uint64(0)
+	if extra, err := types.ExtractIstanbulExtra(block.Header()); err == nil && extra.AggregatedSeal.Round != nil {
+		round = extra.AggregatedSeal.Round.Uint64()
+	}
+	sb.consensusHealth.observe(number, round, time.Now())
+	if number%sb.config.HealthLogInterval != 0 {
+		return
+	}
+
+	avgRound, avgCommitTime := sb.consensusHealth.averages()
+	validatorPeers := 0
+	if sb.broadcaster != nil {
+		validatorPeers = len(sb.broadcaster.FindPeers(nil, p2p.ValidatorPurpose))
+	}
+	valSetIndex, _ := sb.getValidators(number, block.Hash()).GetByAddress(sb.ValidatorAddress())
+	sb.logger.Info("Consensus health", "number", number, "interval", sb.config.HealthLogInterval, "avg_round", avgRound,
+		"avg_time_to_commit", common.PrettyDuration(avgCommitTime), "validator_peers", validatorPeers, "elected", valSetIndex >= 0)
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConsensusHealthAverages(t *testing.T) {
+	var health consensusHealth
+	start := time.Unix(1000, 0)
+
+	health.observe(10, 0, start)
+	health.observe(11, 2, start.Add(5*time.Second))
+	health.observe(12, 1, start.Add(8*time.Second))
+	avgRound, avgCommitTime := health.averages()
+	if avgRound != 1 {
+		t.Errorf("average round = %v, want 1", avgRound)
+	}
+	// The first head has no parent to measure its commit time from
+	if avgCommitTime != 4*time.Second {
+		t.Errorf("average time to commit = %v, want 4s", avgCommitTime)
+	}
+
+	// The next summary only covers the blocks after the previous one, and a gap in the chain heads,
+	// e.g. while syncing, isn't a commit time
+	health.observe(13, 0, start.Add(10*time.Second))
+	health.observe(20, 0, start.Add(11*time.Second))
+	if avgRound, avgCommitTime = health.averages(); avgRound != 0 || avgCommitTime != 2*time.Second {
+		t.Errorf("averages = %v, %v, want 0, 2s", avgRound, avgCommitTime)
+	}
+
+	if avgRound, avgCommitTime = health.averages(); avgRound != 0 || avgCommitTime != 0 {
+		t.Errorf("averages without blocks = %v, %v, want 0, 0", avgRound, avgCommitTime)
+	}
+}
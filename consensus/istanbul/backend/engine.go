@@ -41,10 +41,13 @@ import (
 )
 
 const (
-	inmemorySnapshots             = 128 // Number of recent vote snapshots to keep in memory
-	inmemoryPeers                 = 40
-	inmemoryMessages              = 1024
-	mobileAllowedClockSkew uint64 = 5
+	inmemorySnapshots               = 128 // Number of recent vote snapshots to keep in memory
+	inmemoryPeers                   = 40
+	inmemoryMessages                = 1024
+	inmemoryDemotedProposers        = 128 // Number of recent StickyWithFallback demotions to keep in memory
+	inmemoryBannedProposers         = 128 // Number of recent proposer bans to keep in memory
+	inmemoryShuffleSeeds            = 16  // Number of recent ShuffledRoundRobin seeds to keep in memory
+	mobileAllowedClockSkew   uint64 = 5
 )
 
 var (
@@ -67,6 +70,8 @@ var (
 	errInvalidCoinbase = errors.New("invalid coinbase")
 	// errInvalidTimestamp is returned if the timestamp of a block is lower than the previous block's timestamp + the minimum block period.
 	errInvalidTimestamp = errors.New("invalid timestamp")
+	// errProposalTooFarInFuture is returned if the timestamp of a proposal is more than MaxFutureBlockTime ahead of the local clock.
+	errProposalTooFarInFuture = errors.New("proposal timestamp too far in the future")
 	// errInvalidVotingChain is returned if an authorization list is attempted to
 	// be modified via out-of-range or non-contiguous headers.
 	errInvalidVotingChain = errors.New("invalid voting chain")
@@ -83,6 +88,10 @@ var (
 	errUnauthorizedAnnounceMessage = errors.New("unauthorized announce message")
 	// errNotAValidator is returned when the node is not configured as a validator
 	errNotAValidator = errors.New("Not configured as a validator")
+	// errStoppingValidating is returned when a block is sealed while the validator is gracefully stopping
+	errStoppingValidating = errors.New("validator is stopping")
+	// errSafetyViolation is returned when a block is verified, prepared or sealed after the node halted on a safety violation
+	errSafetyViolation = errors.New("halted on a safety violation")
 )
 
 var (
@@ -113,18 +122,25 @@ func (sb *Backend) verifyHeader(chain consensus.ChainReader, header *types.Heade
 	if header.Number == nil {
 		return errUnknownBlock
 	}
+	if sb.SafetyViolation() != nil {
+		return errSafetyViolation
+	}
 
 	// If the full chain isn't available (as on mobile devices), don't reject future blocks
 	// This is due to potential clock skew
-	allowedFutureBlockTime := uint64(now().Unix())
+	nowTime := uint64(now().Unix())
+	allowedFutureBlockTime := nowTime + sb.config.AllowedClockSkew
 	if !chain.Config().FullHeaderChainAvailable {
-		allowedFutureBlockTime = allowedFutureBlockTime + mobileAllowedClockSkew
+		allowedFutureBlockTime = nowTime + mobileAllowedClockSkew
 	}
 
 	// Don't waste time checking blocks from the future
 	if header.Time > allowedFutureBlockTime {
 		return consensus.ErrFutureBlock
 	}
+	if header.Time > nowTime && chain.Config().FullHeaderChainAvailable {
+		sb.logger.Info("Accepting block from the future within the allowed clock skew", "number", header.Number, "hash", header.Hash(), "ahead", header.Time-nowTime, "allowed_clock_skew", sb.config.AllowedClockSkew)
+	}
 
 	// Ensure that the extra data format is satisfied
 	if _, err := types.ExtractIstanbulExtra(header); err != nil {
@@ -278,6 +294,9 @@ func (sb *Backend) verifyAggregatedSeals(chain consensus.ChainReader, header *ty
 	if err != nil {
 		return err
 	}
+	if err := sb.checkSafetyViolation(chain, header); err != nil {
+		return err
+	}
 
 	// The genesis block is skipped since it has no parents.
 	// The first block is also skipped, since its parent
@@ -314,29 +333,26 @@ func (sb *Backend) verifyAggregatedSeals(chain consensus.ChainReader, header *ty
 
 func (sb *Backend) verifyAggregatedSeal(headerHash common.Hash, validators istanbul.ValidatorSet, aggregatedSeal types.IstanbulAggregatedSeal) error {
 	logger := sb.logger.New("func", "Backend.verifyAggregatedSeal()")
-	if len(aggregatedSeal.Signature) != types.IstanbulExtraBlsSignature {
-		return errInvalidAggregatedSeal
+	cacheKey := aggregatedSealCacheKey(headerHash, aggregatedSeal, validators)
+	if sb.aggregatedSealCache.isVerified(cacheKey) {
+		return nil
 	}
 
-	proposalSeal := istanbulCore.PrepareCommittedSeal(headerHash, aggregatedSeal.Round)
-	// Find which public keys signed from the provided validator set
-	publicKeys := []blscrypto.SerializedPublicKey{}
-	for i := 0; i < validators.Size(); i++ {
-		if aggregatedSeal.Bitmap.Bit(i) == 1 {
-			pubKey := validators.GetByIndex(uint64(i)).BLSPublicKey()
-			publicKeys = append(publicKeys, pubKey)
-		}
-	}
-	// The length of a valid seal should be greater than the minimum quorum size
-	if len(publicKeys) < validators.MinQuorumSize() {
-		logger.Error("Aggregated seal does not aggregate enough seals", "numSeals", len(publicKeys), "minimum quorum size", validators.MinQuorumSize())
-		return errInsufficientSeals
-	}
-	err := blscrypto.VerifyAggregatedSignature(publicKeys, proposalSeal, []byte{}, aggregatedSeal.Signature, false)
-	if err != nil {
+	// The combined weight of the signers of a valid seal should be at least the minimum quorum weight,
+	// which is the minimum quorum size if the validator set isn't weighted
+	quorum, err := istanbul.VerifyAggregatedSealOf(headerHash, aggregatedSeal, validators)
+	switch {
+	case err == istanbul.ErrInvalidAggregatedSignature:
 		logger.Error("Unable to verify aggregated signature", "err", err)
 		return errInvalidSignature
+	case err != nil:
+		logger.Error("Malformed aggregated seal", "err", err)
+		return errInvalidAggregatedSeal
+	case !quorum:
+		logger.Error("Aggregated seal does not aggregate enough seals", "bitmap", aggregatedSeal.Bitmap, "minimum quorum weight", validators.MinQuorumWeight())
+		return errInsufficientSeals
 	}
+	sb.aggregatedSealCache.markVerified(cacheKey)
 
 	return nil
 }
@@ -367,6 +383,10 @@ func (sb *Backend) VerifySeal(chain consensus.ChainReader, header *types.Header)
 // Prepare initializes the consensus fields of a block header according to the
 // rules of a particular engine. The changes are executed inline.
 func (sb *Backend) Prepare(chain consensus.ChainReader, header *types.Header) error {
+	if sb.SafetyViolation() != nil {
+		return errSafetyViolation
+	}
+
 	// unused fields, force to set to empty
 	header.Coinbase = sb.address
 
@@ -378,23 +398,39 @@ func (sb *Backend) Prepare(chain consensus.ChainReader, header *types.Header) er
 	}
 
 	// set header's timestamp
-	header.Time = parent.Time + sb.config.BlockPeriod
-	nowTime := uint64(now().Unix())
-	if header.Time < nowTime {
-		header.Time = nowTime
-	}
+	var delay time.Duration
+	header.Time, delay = sb.nextBlockTime(parent.Time, now())
 
 	if err := writeEmptyIstanbulExtra(header); err != nil {
 		return err
 	}
 
 	// wait for the timestamp of header, use this to adjust the block period
-	delay := time.Unix(int64(header.Time), 0).Sub(now())
 	time.Sleep(delay)
 
 	return sb.addParentSeal(chain, header)
 }
 
+// nextBlockTime returns the timestamp of a block built on a parent with the given timestamp, and how long to wait
+// before proposing it. Blocks are timestamped BlockPeriod after their parent, or at the current time if that's
+// in the past. When the parent is further behind the current time than BlockTimeCatchupRate, e.g. after a
+// stall, the timestamp instead moves at most BlockTimeCatchupRate closer to the current time, and the block is
+// proposed a BlockPeriod later rather than right away, so that blocks aren't produced back-to-back.
+func (sb *Backend) nextBlockTime(parentTime uint64, currentTime time.Time) (uint64, time.Duration) {
+	blockPeriod := sb.config.BlockPeriod
+	blockTime := parentTime + blockPeriod
+	nowTime := uint64(currentTime.Unix())
+	if blockTime >= nowTime {
+		return blockTime, time.Unix(int64(blockTime), 0).Sub(currentTime)
+	}
+	catchupRate := sb.config.BlockTimeCatchupRate
+	if catchupRate == 0 || nowTime-blockTime <= catchupRate {
+		return nowTime, 0
+	}
+	sb.logger.Debug("Chain is behind the local clock, catching up gradually", "parent_time", parentTime, "behind", nowTime-blockTime, "catchup_rate", catchupRate)
+	return blockTime + catchupRate, time.Duration(blockPeriod) * time.Second
+}
+
 // UpdateValSetDiff will update the validator set diff in the header, if the mined header is the last block of the epoch
 func (sb *Backend) UpdateValSetDiff(chain consensus.ChainReader, header *types.Header, state *state.StateDB) error {
 	// If this is the last block of the epoch, then get the validator set diff, to save into the header
@@ -499,12 +535,24 @@ func (sb *Backend) Seal(chain consensus.ChainReader, block *types.Block, results
 	header := block.Header()
 	number := header.Number.Uint64()
 
+	// Don't propose a block that this validator may not stay around to commit
+	sb.coreMu.RLock()
+	stopping := sb.coreStopping
+	sb.coreMu.RUnlock()
+	if stopping {
+		return errStoppingValidating
+	}
+	if sb.SafetyViolation() != nil {
+		return errSafetyViolation
+	}
+
 	// Bail out if we're unauthorized to sign a block
 	snap, err := sb.snapshot(chain, number-1, header.ParentHash, nil)
 	if err != nil {
 		return err
 	}
-	if _, v := snap.ValSet.GetByAddress(sb.address); v == nil {
+	// A shadow validator assembles blocks even when it is not elected, to log the proposals it would have made
+	if _, v := snap.ValSet.GetByAddress(sb.address); v == nil && !sb.config.ShadowValidator {
 		return errUnauthorized
 	}
 
@@ -559,7 +607,7 @@ func (sb *Backend) SealHash(header *types.Header) common.Hash {
 func (sb *Backend) updateBlock(parent *types.Header, block *types.Block) (*types.Block, error) {
 	header := block.Header()
 	// sign the hash
-	seal, err := sb.Sign(sigHash(header).Bytes())
+	seal, err := sb.signHeader(header)
 	if err != nil {
 		return nil, err
 	}
@@ -579,6 +627,10 @@ func (sb *Backend) APIs(chain consensus.ChainReader) []rpc.API {
 		Version:   "1.0",
 		Service:   &API{chain: chain, istanbul: sb},
 		Public:    true,
+	}, {
+		Namespace: "admin",
+		Version:   "1.0",
+		Service:   &AdminAPI{istanbul: sb},
 	}}
 }
 
@@ -599,6 +651,10 @@ func (sb *Backend) newChainHeadLoop(bc *ethCore.BlockChain) {
 	// Batched. For stats & announce
 	chainHeadCh := make(chan ethCore.ChainHeadEvent, 10)
 	chainHeadSub := bc.SubscribeChainHeadEvent(chainHeadCh)
+	if chainHeadSub == nil {
+		// The blockchain was already stopped
+		return
+	}
 	defer chainHeadSub.Unsubscribe()
 
 	for {
@@ -617,6 +673,10 @@ func (sb *Backend) updateReplicaStateLoop(bc *ethCore.BlockChain) {
 	// Unbatched event listener
 	chainEventCh := make(chan ethCore.ChainEvent, 10)
 	chainEventSub := bc.SubscribeChainEvent(chainEventCh)
+	if chainEventSub == nil {
+		// The blockchain was already stopped
+		return
+	}
 	defer chainEventSub.Unsubscribe()
 
 	for {
@@ -654,6 +714,12 @@ func (sb *Backend) SetBlockProcessors(hasBadBlock func(common.Hash) bool,
 func (sb *Backend) StartValidating() error {
 	sb.coreMu.Lock()
 	defer sb.coreMu.Unlock()
+	if sb.SafetyViolation() != nil {
+		return errSafetyViolation
+	}
+	if sb.config.ObserverMode {
+		return errObserverMode
+	}
 	if sb.coreStarted {
 		return istanbul.ErrStartedEngine
 	}
@@ -709,6 +775,55 @@ func (sb *Backend) StopValidating() error {
 	return nil
 }
 
+// GracefulStopValidating stops this validator from proposing blocks, but keeps it participating in the
+// current sequence until the sequence is committed or GracefulShutdownTimeout passes, and then stops
+// validating. This spares the other validators a round change when the validator is stopped on purpose.
+func (sb *Backend) GracefulStopValidating() error {
+	sb.coreMu.Lock()
+	if !sb.coreStarted {
+		sb.coreMu.Unlock()
+		return istanbul.ErrStoppedEngine
+	}
+	sb.coreStopping = true
+	sb.coreMu.Unlock()
+
+	defer func() {
+		sb.coreMu.Lock()
+		sb.coreStopping = false
+		sb.coreMu.Unlock()
+	}()
+
+	timeout := time.Duration(sb.config.GracefulShutdownTimeout) * time.Millisecond
+	if view := sb.core.CurrentView(); view != nil && timeout > 0 {
+		logger := sb.logger.New("func", "GracefulStopValidating", "seq", view.Sequence, "timeout", timeout)
+		logger.Info("Waiting for the current sequence to be committed before stopping validating")
+		if sb.waitForBlock(view.Sequence.Uint64(), timeout) {
+			logger.Info("Current sequence committed")
+		} else {
+			logger.Warn("Timed out waiting for the current sequence to be committed")
+		}
+	}
+	return sb.StopValidating()
+}
+
+// waitForBlock waits until the block with the given number is in the chain, and returns whether it is
+// before the timeout passes.
+func (sb *Backend) waitForBlock(number uint64, timeout time.Duration) bool {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+	for {
+		if sb.currentBlock().NumberU64() >= number {
+			return true
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return false
+		}
+	}
+}
+
 // StartAnnouncing implements consensus.Istanbul.StartAnnouncing
 func (sb *Backend) StartAnnouncing() error {
 	sb.announceMu.Lock()
@@ -747,6 +862,54 @@ func (sb *Backend) StopAnnouncing() error {
 	return sb.vph.stopThread()
 }
 
+// GracefulStopAnnouncing sends the announce messages still pending, waiting up to GracefulShutdownTimeout
+// for them to be sent to the peers, and then stops announcing. This spares the peers stale enode info
+// until this node announces again after a planned restart.
+func (sb *Backend) GracefulStopAnnouncing() error {
+	sb.announceMu.RLock()
+	running := sb.announceRunning
+	sb.announceMu.RUnlock()
+	if !running {
+		return istanbul.ErrStoppedAnnounce
+	}
+
+	if timeout := time.Duration(sb.config.GracefulShutdownTimeout) * time.Millisecond; timeout > 0 {
+		logger := sb.logger.New("func", "GracefulStopAnnouncing", "timeout", timeout)
+		if sb.drainAnnounce(timeout) {
+			logger.Debug("Sent the pending announce messages")
+		} else {
+			logger.Warn("Timed out sending the pending announce messages")
+		}
+	}
+	return sb.StopAnnouncing()
+}
+
+// drainAnnounce has the announce thread handle its pending tasks, and waits for the messages being sent to
+// the peers. It returns whether they were sent before the timeout passes.
+func (sb *Backend) drainAnnounce(timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	drained := make(chan struct{})
+	select {
+	case sb.announceDrainCh <- drained:
+	case <-deadline.C:
+		return false
+	}
+	select {
+	case <-drained:
+	case <-deadline.C:
+		return false
+	}
+
+	select {
+	case <-sb.asyncSends.idleCh():
+		return true
+	case <-deadline.C:
+		return false
+	}
+}
+
 // StartProxiedValidatorEngine implements consensus.Istanbul.StartProxiedValidatorEngine
 func (sb *Backend) StartProxiedValidatorEngine() error {
 	sb.proxiedValidatorEngineMu.Lock()
@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/core/types"
 	blscrypto "github.com/ethereum/go-ethereum/crypto/bls"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -152,6 +153,138 @@ func TestSealCommitted(t *testing.T) {
 	}
 }
 
+func TestGracefulStopValidating(t *testing.T) {
+	chain, engine := newBlockChain(1, true)
+	engine.config.GracefulShutdownTimeout = 10000
+
+	// Commit a block that the chain doesn't have yet, so that the core waits for it
+	block := makeBlockWithoutSeal(chain, engine, chain.Genesis())
+	results := make(chan *types.Block)
+	go engine.Seal(chain, block, results, nil)
+	committedBlock := <-results
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- engine.GracefulStopValidating()
+	}()
+	<-time.After(200 * time.Millisecond)
+
+	if err := engine.Seal(chain, makeBlockWithoutSeal(chain, engine, chain.Genesis()), results, nil); err != errStoppingValidating {
+		t.Errorf("error mismatch: have %v, want %v", err, errStoppingValidating)
+	}
+	if !engine.IsValidating() {
+		t.Fatalf("stopped validating before the current sequence was committed")
+	}
+
+	if _, err := chain.InsertChain(types.Blocks{committedBlock}); err != nil {
+		t.Fatalf("failed to insert the committed block: %v", err)
+	}
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("error mismatch: have %v, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("did not stop validating once the current sequence was committed")
+	}
+	if engine.IsValidating() {
+		t.Errorf("still validating after a graceful stop")
+	}
+}
+
+func TestVerifyAggregatedSealWithWeights(t *testing.T) {
+	numValidators := 4
+	genesisCfg, nodeKeys := getGenesisAndKeys(numValidators, true)
+	chain, engine, _ := newBlockChainWithKeys(false, common.Address{}, false, genesisCfg, nodeKeys[0])
+	block := makeBlockWithoutSeal(chain, engine, chain.Genesis())
+	validators := engine.getValidators(0, chain.Genesis().Hash()).Copy()
+
+	// The first validator outweighs the other three together
+	weights := make(map[common.Address]uint64)
+	for i, val := range validators.List() {
+		weights[val.Address()] = 1
+		if i == 0 {
+			weights[val.Address()] = 6
+		}
+	}
+
+	heavySeal := signBlock(nodeKeys[:1], block)
+	if err := engine.verifyAggregatedSeal(block.Hash(), validators, heavySeal); err != errInsufficientSeals {
+		t.Errorf("error mismatch for 1 of 4 unweighted signers: have %v, want %v", err, errInsufficientSeals)
+	}
+	validators.SetWeights(weights)
+	if err := engine.verifyAggregatedSeal(block.Hash(), validators, heavySeal); err != nil {
+		t.Errorf("error mismatch for a weighted majority: have %v, want nil", err)
+	}
+
+	// Three of the four validators are a minority by weight
+	for i, val := range validators.List() {
+		weights[val.Address()] = 1
+		if i == numValidators-1 {
+			weights[val.Address()] = 6
+		}
+	}
+	validators.SetWeights(weights)
+	lightSeal := signBlock(nodeKeys[:3], block)
+	if err := engine.verifyAggregatedSeal(block.Hash(), validators, lightSeal); err != errInsufficientSeals {
+		t.Errorf("error mismatch for a weighted minority: have %v, want %v", err, errInsufficientSeals)
+	}
+	validators.SetWeights(nil)
+	if err := engine.verifyAggregatedSeal(block.Hash(), validators, lightSeal); err != nil {
+		t.Errorf("error mismatch for 3 of 4 unweighted signers: have %v, want nil", err)
+	}
+}
+
+func TestStandaloneVerifyAggregatedSeal(t *testing.T) {
+	genesisCfg, nodeKeys := getGenesisAndKeys(4, true)
+	chain, engine, _ := newBlockChainWithKeys(false, common.Address{}, false, genesisCfg, nodeKeys[0])
+	defer chain.Stop()
+	block := makeBlockWithoutSeal(chain, engine, chain.Genesis())
+	validators := engine.getValidators(0, chain.Genesis().Hash())
+
+	sealedHeader := func(seal types.IstanbulAggregatedSeal) *types.Header {
+		header := block.Header()
+		if err := writeAggregatedSeal(header, seal, false); err != nil {
+			t.Fatalf("failed to write aggregated seal: %v", err)
+		}
+		return header
+	}
+
+	// The seal is verified the same way as by the engine
+	header := sealedHeader(signBlock(nodeKeys[:3], block))
+	if quorum, err := istanbul.VerifyAggregatedSeal(header, validators); err != nil || !quorum {
+		t.Errorf("VerifyAggregatedSeal = %v, %v for 3 of 4 signers, want quorum", quorum, err)
+	}
+	if err := engine.VerifySeal(chain, header); err != nil {
+		t.Errorf("engine rejected the seal: %v", err)
+	}
+
+	// A valid seal of too few signers doesn't reach quorum
+	if quorum, err := istanbul.VerifyAggregatedSeal(sealedHeader(signBlock(nodeKeys[:2], block)), validators); err != nil || quorum {
+		t.Errorf("VerifyAggregatedSeal = %v, %v for 2 of 4 signers, want no quorum", quorum, err)
+	}
+
+	// A bitmap claiming other signers than the ones of the signature
+	seal := signBlock(nodeKeys[:3], block)
+	seal.Bitmap = big.NewInt(0xe)
+	if _, err := istanbul.VerifyAggregatedSeal(sealedHeader(seal), validators); err != istanbul.ErrInvalidAggregatedSignature {
+		t.Errorf("error mismatch for a wrong bitmap: have %v, want %v", err, istanbul.ErrInvalidAggregatedSignature)
+	}
+	// A valid seal whose bitmap also refers to a validator outside the set is rejected by both
+	seal.Bitmap = big.NewInt(0x17)
+	header = sealedHeader(seal)
+	if _, err := istanbul.VerifyAggregatedSeal(header, validators); err != istanbul.ErrInvalidAggregatedSeal {
+		t.Errorf("error mismatch for a bitmap outside the set: have %v, want %v", err, istanbul.ErrInvalidAggregatedSeal)
+	}
+	if err := engine.verifyAggregatedSeal(header.Hash(), validators, seal); err != errInvalidAggregatedSeal {
+		t.Errorf("engine error mismatch for a bitmap outside the set: have %v, want %v", err, errInvalidAggregatedSeal)
+	}
+	// An unsealed header
+	if _, err := istanbul.VerifyAggregatedSeal(block.Header(), validators); err != istanbul.ErrInvalidAggregatedSeal {
+		t.Errorf("error mismatch for an unsealed header: have %v, want %v", err, istanbul.ErrInvalidAggregatedSeal)
+	}
+}
+
 func TestVerifyHeader(t *testing.T) {
 	chain, engine := newBlockChain(1, true)
 
@@ -372,6 +505,80 @@ func TestVerifyHeaderWithoutFullChain(t *testing.T) {
 	}
 }
 
+func TestVerifyHeaderWithClockSkew(t *testing.T) {
+	chain, engine := newBlockChain(1, true)
+	engine.config.AllowedClockSkew = 3
+
+	// allow future block within the allowed clock skew
+	block := makeBlockWithoutSeal(chain, engine, chain.Genesis())
+	header := block.Header()
+	header.Time = uint64(now().Unix() + 2)
+	err := engine.VerifyHeader(chain, header, false)
+	if err != errEmptyAggregatedSeal {
+		t.Errorf("error mismatch: have %v, want %v", err, errEmptyAggregatedSeal)
+	}
+
+	// reject future block beyond the allowed clock skew
+	block = makeBlockWithoutSeal(chain, engine, chain.Genesis())
+	header = block.Header()
+	header.Time = uint64(now().Unix() + 10)
+	err = engine.VerifyHeader(chain, header, false)
+	if err != consensus.ErrFutureBlock {
+		t.Errorf("error mismatch: have %v, want %v", err, consensus.ErrFutureBlock)
+	}
+}
+
+func TestVerifyProposalTooFarInFuture(t *testing.T) {
+	chain, engine := newBlockChain(1, true)
+	defer chain.Stop()
+	engine.proposalsTooFarInFutureMeter = metrics.NewMeterForced()
+
+	// A proposal whose proposer's clock is 20 seconds ahead
+	defer func(original func() time.Time) { now = original }(now)
+	now = func() time.Time { return time.Now().Add(20 * time.Second) }
+	block := makeBlockWithoutSeal(chain, engine, chain.Genesis())
+	now = time.Now
+
+	engine.config.MaxFutureBlockTime = 30
+	if _, err := engine.Verify(block); err == errProposalTooFarInFuture {
+		t.Errorf("rejected a proposal within MaxFutureBlockTime")
+	}
+	engine.config.MaxFutureBlockTime = 10
+	if _, err := engine.Verify(block); err != errProposalTooFarInFuture {
+		t.Errorf("error mismatch: have %v, want %v", err, errProposalTooFarInFuture)
+	}
+	if count := engine.proposalsTooFarInFutureMeter.Count(); count != 1 {
+		t.Errorf("proposals too far in the future = %d, want 1", count)
+	}
+}
+
+func TestNextBlockTime(t *testing.T) {
+	chain, engine := newBlockChain(1, true)
+	defer chain.Stop()
+	engine.config.BlockPeriod = 5
+	currentTime := time.Unix(1000, 0)
+
+	tests := []struct {
+		name        string
+		catchupRate uint64
+		parentTime  uint64
+		wantTime    uint64
+		wantDelay   time.Duration
+	}{
+		{"parent is recent", 0, 998, 1003, 3 * time.Second},
+		{"parent is behind without catch-up rate", 0, 900, 1000, 0},
+		{"parent is behind within the catch-up rate", 10, 990, 1000, 0},
+		{"parent is behind beyond the catch-up rate", 10, 900, 915, 5 * time.Second},
+	}
+	for _, test := range tests {
+		engine.config.BlockTimeCatchupRate = test.catchupRate
+		blockTime, delay := engine.nextBlockTime(test.parentTime, currentTime)
+		if blockTime != test.wantTime || delay != test.wantDelay {
+			t.Errorf("%s: got time %d and delay %v, want time %d and delay %v", test.name, blockTime, delay, test.wantTime, test.wantDelay)
+		}
+	}
+}
+
 func TestPrepareExtra(t *testing.T) {
 	oldValidators := make([]istanbul.ValidatorData, 2)
 	oldValidators[0] = istanbul.ValidatorData{
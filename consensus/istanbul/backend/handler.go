@@ -55,6 +55,10 @@ func (sb *Backend) HandleMsg(addr common.Address, msg p2p.Msg, peer consensus.Pe
 		return true, errDecodeFailed
 	}
 
+	if isAnnounceMsg(msg.Code) && !sb.shouldHandleAnnounceMsg(peer) {
+		return true, nil
+	}
+
 	if sb.IsProxy() {
 		switch msg.Code {
 		// TODO(Joshua): Decide to pull out specific proxy handlers
@@ -79,10 +83,10 @@ func (sb *Backend) HandleMsg(addr common.Address, msg p2p.Msg, peer consensus.Pe
 			})
 			return true, nil
 		case istanbul.QueryEnodeMsg:
-			go sb.handleQueryEnodeMsg(addr, peer, data)
+			sb.dispatchMsg(msg.Code, func() { sb.handleQueryEnodeMsg(addr, peer, data) })
 			return true, nil
 		case istanbul.VersionCertificatesMsg:
-			go sb.handleVersionCertificatesMsg(addr, peer, data)
+			sb.dispatchMsg(msg.Code, func() { sb.handleVersionCertificatesMsg(addr, peer, data) })
 			return true, nil
 		case istanbul.ValidatorHandshakeMsg:
 			logger.Warn("Received unexpected Istanbul validator handshake message")
@@ -95,9 +99,7 @@ func (sb *Backend) HandleMsg(addr common.Address, msg p2p.Msg, peer consensus.Pe
 		// Handle messages as primary validator
 		switch msg.Code {
 		case istanbul.ConsensusMsg:
-			go sb.istanbulEventMux.Post(istanbul.MessageEvent{
-				Payload: data,
-			})
+			sb.dispatchMsg(msg.Code, func() { sb.postConsensusMsg(data) })
 			return true, nil
 		case istanbul.DelegateSignMsg:
 			if sb.shouldHandleDelegateSign(peer) {
@@ -114,10 +116,10 @@ func (sb *Backend) HandleMsg(addr common.Address, msg p2p.Msg, peer consensus.Pe
 			go sb.handleEnodeCertificateMsg(peer, data)
 			return true, nil
 		case istanbul.QueryEnodeMsg:
-			go sb.handleQueryEnodeMsg(addr, peer, data)
+			sb.dispatchMsg(msg.Code, func() { sb.handleQueryEnodeMsg(addr, peer, data) })
 			return true, nil
 		case istanbul.VersionCertificatesMsg:
-			go sb.handleVersionCertificatesMsg(addr, peer, data)
+			sb.dispatchMsg(msg.Code, func() { sb.handleVersionCertificatesMsg(addr, peer, data) })
 			return true, nil
 		case istanbul.ValidatorHandshakeMsg:
 			logger.Warn("Received unexpected Istanbul validator handshake message")
@@ -130,7 +132,10 @@ func (sb *Backend) HandleMsg(addr common.Address, msg p2p.Msg, peer consensus.Pe
 		// Handle messages as replica validator
 		switch msg.Code {
 		case istanbul.ConsensusMsg:
-			// Ignore consensus messages
+			// Ignore consensus messages, unless only observing them
+			if sb.config.ObserverMode {
+				sb.dispatchMsg(msg.Code, func() { sb.observeConsensusMsg(data) })
+			}
 			return true, nil
 		case istanbul.DelegateSignMsg:
 			if sb.shouldHandleDelegateSign(peer) {
@@ -147,10 +152,10 @@ func (sb *Backend) HandleMsg(addr common.Address, msg p2p.Msg, peer consensus.Pe
 			go sb.handleEnodeCertificateMsg(peer, data)
 			return true, nil
 		case istanbul.QueryEnodeMsg:
-			go sb.handleQueryEnodeMsg(addr, peer, data)
+			sb.dispatchMsg(msg.Code, func() { sb.handleQueryEnodeMsg(addr, peer, data) })
 			return true, nil
 		case istanbul.VersionCertificatesMsg:
-			go sb.handleVersionCertificatesMsg(addr, peer, data)
+			sb.dispatchMsg(msg.Code, func() { sb.handleVersionCertificatesMsg(addr, peer, data) })
 			return true, nil
 		case istanbul.ValidatorHandshakeMsg:
 			logger.Warn("Received unexpected Istanbul validator handshake message")
@@ -187,6 +192,35 @@ func (sb *Backend) SubscribeNewDelegateSignEvent(ch chan<- istanbul.MessageWithP
 	return sb.delegateSignScope.Track(sb.delegateSignFeed.Subscribe(ch))
 }
 
+// SubscribeStateTransitionEvents subscribes a channel to the state transitions of the core.
+// Events are dropped, rather than blocking the core, whenever the channel is full.
+func (sb *Backend) SubscribeStateTransitionEvents(ch chan<- istanbul.StateTransitionEvent) event.Subscription {
+	sb.stateTransitionSubsMu.Lock()
+	sb.stateTransitionSubs[ch] = struct{}{}
+	sb.stateTransitionSubsMu.Unlock()
+
+	return sb.stateTransitionScope.Track(event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		sb.stateTransitionSubsMu.Lock()
+		delete(sb.stateTransitionSubs, ch)
+		sb.stateTransitionSubsMu.Unlock()
+		return nil
+	}))
+}
+
+// NotifyStateTransition implements core.CoreBackend.NotifyStateTransition
+func (sb *Backend) NotifyStateTransition(ev istanbul.StateTransitionEvent) {
+	sb.stateTransitionSubsMu.RLock()
+	defer sb.stateTransitionSubsMu.RUnlock()
+	for ch := range sb.stateTransitionSubs {
+		select {
+		case ch <- ev:
+		default:
+			sb.stateTransitionsDroppedMeter.Mark(1)
+		}
+	}
+}
+
 // SetBroadcaster implements consensus.Handler.SetBroadcaster
 func (sb *Backend) SetBroadcaster(broadcaster consensus.Broadcaster) {
 	sb.broadcaster = broadcaster
@@ -280,8 +314,7 @@ func (sb *Backend) UpdateMetricsForParentOfBlock(child *types.Block) {
 		// to re-propose an existing block, thus not placing it's own signature on it.
 		gpAuthor := sb.AuthorForBlock(number - 2)
 		for i := int64(0); i < missedRounds; i++ {
-			proposer := validator.GetProposerSelector(sb.config.ProposerPolicy)(gpValSet, gpAuthor, uint64(i))
-			if sb.Address() == proposer.Address() {
+			if sb.Address() == validator.SelectProposer(gpValSet, gpAuthor, uint64(i), sb.config.ProposerPolicyAt(number-1)) {
 				sb.blocksMissedRoundsAsProposerMeter.Mark(1)
 				break
 			}
@@ -323,9 +356,24 @@ func (sb *Backend) newChainHead(newBlock *types.Block) {
 	// Update metrics for whether we were elected and signed the parent of this block.
 	sb.UpdateMetricsForParentOfBlock(newBlock)
 
+	// Lift the proposer exclusions that the network didn't honor
+	sb.checkProposerExclusion(newBlock)
+
+	// Report the participation of the validators once per lookback window.
+	if sb.config.LookbackWindow > 0 && newBlock.Number().Uint64()%sb.config.LookbackWindow == 0 {
+		sb.reportValidatorParticipation(newBlock.Header())
+	}
+
+	// Log a summary of the consensus health every HealthLogInterval blocks.
+	sb.reportConsensusHealth(newBlock)
+
 	// If this is the last block of the epoch:
 	// * Print an easy to find log message giving our address and whether we're elected in next epoch.
 	// * If this is a node maintaining validator connections (e.g. a proxy or a standalone validator), refresh the validator enode table.
+	// * Notify the announce thread of a new epoch.
+	// * Log and record the validators that joined and left the validator set.
+	// * Log how many blocks of the epoch each validator proposed.
+	// * Remove expired version certificates of validators that are in neither the previous nor the next validator set.
 	// * If this is a proxied validator, notify the proxied validator engine of a new epoch.
 	if istanbul.IsLastBlockOfEpoch(newBlock.Number().Uint64(), sb.config.Epoch) {
 
@@ -344,6 +392,17 @@ func (sb *Backend) newChainHead(newBlock *types.Block) {
 			}
 		}
 
+		// The announce thread may need to adjust its query enode gossip period to the new validator set size.
+		// sb.announceNewEpochCh has a buffer of 1, so don't block if there is already an unread notification.
+		select {
+		case sb.announceNewEpochCh <- struct{}{}:
+		default:
+		}
+
+		sb.recordValidatorSetChange(newBlock, valSet)
+
+		go sb.reportProposerFairness(newBlock.Header())
+
 		if sb.IsProxiedValidator() {
 			if err := sb.proxiedValidatorEngine.NewEpoch(); err != nil {
 				sb.logger.Warn("Error while notifying proxied validator engine of new epoch", "err", err)
@@ -380,6 +439,8 @@ func (sb *Backend) RegisterPeer(peer consensus.Peer, isProxiedPeer bool) error {
 }
 
 func (sb *Backend) UnregisterPeer(peer consensus.Peer, isProxiedPeer bool) {
+	sb.announceRateLimiter.removePeer(peer.Node().ID())
+
 	if sb.IsProxy() && isProxiedPeer {
 		sb.proxyEngine.UnregisterProxiedValidatorPeer(peer)
 	} else if sb.IsProxiedValidator() {
@@ -450,6 +511,25 @@ func (sb *Backend) Handshake(peer consensus.Peer) (bool, error) {
 	}
 }
 
+// preferProxyPeers returns whether validators connecting directly to this node are treated as regular peers,
+// which count against the peer limits, because it's a proxied validator configured to rely on its proxies and at
+// least one of them is connected. Without a connected proxy, direct validator connections are kept as a fallback.
+func (sb *Backend) preferProxyPeers() bool {
+	if !sb.IsProxiedValidator() || !sb.config.PreferProxyPeers {
+		return false
+	}
+	proxies, _, err := sb.proxiedValidatorEngine.GetProxiesAndValAssignments()
+	if err != nil {
+		return false
+	}
+	for _, proxy := range proxies {
+		if proxy.IsPeered() {
+			return true
+		}
+	}
+	return false
+}
+
 // readValidatorHandshakeMessage reads a validator handshake message.
 // Returns if the peer is a validator or if an error occurred.
 func (sb *Backend) readValidatorHandshakeMessage(peer consensus.Peer) (bool, error) {
@@ -495,6 +575,11 @@ func (sb *Backend) readValidatorHandshakeMessage(peer consensus.Peer) (bool, err
 		return false, errors.New("Incorrect node in enodeCertificate")
 	}
 
+	if sb.preferProxyPeers() {
+		logger.Debug("Treating a directly connected validator as a regular peer, the proxies relay its messages", "msg.Address", msg.Address)
+		return false, nil
+	}
+
 	// Check if the peer is within the validator conn set.
 	validatorConnSet := sb.retrieveCachedValidatorConnSet()
 	// If no set has ever been cached, update it and try again. This is an expensive
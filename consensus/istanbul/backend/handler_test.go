@@ -22,6 +22,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -220,9 +221,52 @@ func TestReadValidatorHandshakeMessage(t *testing.T) {
 	if !isValidator {
 		t.Errorf("Expected isValidator to be true with valid message")
 	}
+
+	// Only proxied validators treat directly connected validators as regular peers
+	backend.config.PreferProxyPeers = true
+	defer func() { backend.config.PreferProxyPeers = false }()
+	peer.Messages <- makeMsg(istanbul.ValidatorHandshakeMsg, validMsgPayload)
+	isValidator, err = backend.readValidatorHandshakeMessage(peer)
+	if err != nil {
+		t.Errorf("Error from readValidatorHandshakeMessage with valid message %v", err)
+	}
+	if !isValidator {
+		t.Errorf("Expected isValidator to be true with valid message on a validator that isn't proxied")
+	}
 }
 
 func makeMsg(msgcode uint64, data interface{}) p2p.Msg {
 	size, r, _ := rlp.EncodeToReader(data)
 	return p2p.Msg{Code: msgcode, Size: uint32(size), Payload: r}
 }
+
+func TestStateTransitionSubscription(t *testing.T) {
+	sb := &Backend{
+		stateTransitionSubs:          make(map[chan<- istanbul.StateTransitionEvent]struct{}),
+		stateTransitionsDroppedMeter: metrics.NewMeterForced(),
+	}
+	defer sb.stateTransitionsDroppedMeter.Stop()
+
+	events := make(chan istanbul.StateTransitionEvent, 1)
+	sub := sb.SubscribeStateTransitionEvents(events)
+
+	// A full channel must not block the notifying core, the excess events are dropped
+	sb.NotifyStateTransition(istanbul.StateTransitionEvent{Transition: istanbul.NewSequenceTransition})
+	sb.NotifyStateTransition(istanbul.StateTransitionEvent{Transition: istanbul.PreparedTransition})
+	sb.NotifyStateTransition(istanbul.StateTransitionEvent{Transition: istanbul.CommittedTransition})
+	if ev := <-events; ev.Transition != istanbul.NewSequenceTransition {
+		t.Errorf("unexpected event: have %v, want %v", ev.Transition, istanbul.NewSequenceTransition)
+	}
+	if dropped := sb.stateTransitionsDroppedMeter.Count(); dropped != 2 {
+		t.Errorf("unexpected number of dropped events: have %d, want 2", dropped)
+	}
+
+	// No events are sent after unsubscribing
+	sub.Unsubscribe()
+	sb.NotifyStateTransition(istanbul.StateTransitionEvent{Transition: istanbul.RoundChangeTransition})
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected event after unsubscribing: %v", ev.Transition)
+	default:
+	}
+}
@@ -17,6 +17,7 @@
 package enodes
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -67,17 +68,23 @@ func addressEntryFromGenericEntry(entry db.GenericEntry) (*istanbul.AddressEntry
 // by address or enode
 type ValidatorEnodeDB struct {
 	gdb     *db.GenericDB
+	path    string
 	lock    sync.RWMutex
 	handler ValidatorEnodeHandler
 	logger  log.Logger
 }
 
+// openValEnodeGenericDB opens the generic database of a validator enode database at the given path.
+func openValEnodeGenericDB(path string, logger log.Logger) (*db.GenericDB, error) {
+	return db.New(int64(valEnodeDBVersion), path, logger, &opt.WriteOptions{NoWriteMerge: true})
+}
+
 // OpenValidatorEnodeDB opens a validator enode database for storing and retrieving infos about validator
 // enodes. If no path is given an in-memory, temporary database is constructed.
 func OpenValidatorEnodeDB(path string, handler ValidatorEnodeHandler) (*ValidatorEnodeDB, error) {
 	logger := log.New("db", "ValidatorEnodeDB")
 
-	gdb, err := db.New(int64(valEnodeDBVersion), path, logger, &opt.WriteOptions{NoWriteMerge: true})
+	gdb, err := openValEnodeGenericDB(path, logger)
 	if err != nil {
 		logger.Error("Error creating db", "err", err)
 		return nil, err
@@ -85,6 +92,7 @@ func OpenValidatorEnodeDB(path string, handler ValidatorEnodeHandler) (*Validato
 
 	return &ValidatorEnodeDB{
 		gdb:     gdb,
+		path:    path,
 		handler: handler,
 		logger:  logger,
 	}, nil
@@ -95,6 +103,74 @@ func (vet *ValidatorEnodeDB) Close() error {
 	return vet.gdb.Close()
 }
 
+// Path returns the location of the database, which is empty for an in-memory database.
+func (vet *ValidatorEnodeDB) Path() string {
+	vet.lock.RLock()
+	defer vet.lock.RUnlock()
+	return vet.path
+}
+
+// Migrate moves the database to a new, empty, database at the given path. The entries that can be
+// decoded are copied to the new database, which then replaces the current one, and the current one is
+// closed. Its files are left in place. Reads and writes wait for the migration, so they always see a
+// consistent table. If the new database can't be opened or written, the current one is kept.
+// It returns the number of entries copied and skipped.
+func (vet *ValidatorEnodeDB) Migrate(path string) (int, int, error) {
+	vet.lock.Lock()
+	defer vet.lock.Unlock()
+	logger := vet.logger.New("func", "Migrate", "from", vet.path, "to", path)
+
+	if path == "" {
+		return 0, 0, errors.New("the path of the new database is empty")
+	}
+	if path == vet.path {
+		return 0, 0, errors.New("the database is already at this path")
+	}
+	newGdb, err := openValEnodeGenericDB(path, logger)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Only migrate into an empty database, so that no stale entry is picked up
+	notEmpty := errors.New("the database at the new path is not empty")
+	if err := newGdb.Iterate([]byte(dbAddressPrefix), func(_, _ []byte) error { return notEmpty }); err != nil {
+		newGdb.Close()
+		return 0, 0, err
+	}
+
+	batch := new(leveldb.Batch)
+	copied, skipped := 0, 0
+	err = vet.gdb.Iterate([]byte(dbAddressPrefix), func(key []byte, value []byte) error {
+		var entry istanbul.AddressEntry
+		if err := rlp.DecodeBytes(value, &entry); err != nil {
+			logger.Warn("Skipping an undecodable entry", "address", common.BytesToAddress(key), "err", err)
+			skipped++
+			return nil
+		}
+		batch.Put(addressKey(common.BytesToAddress(key)), value)
+		if entry.Node != nil {
+			batch.Put(nodeIDKey(entry.Node.ID()), key)
+		}
+		copied++
+		return nil
+	})
+	if err == nil {
+		err = newGdb.Write(batch)
+	}
+	if err != nil {
+		newGdb.Close()
+		return 0, 0, err
+	}
+
+	oldGdb := vet.gdb
+	vet.gdb, vet.path = newGdb, path
+	if err := oldGdb.Close(); err != nil {
+		logger.Warn("Error closing the previous database", "err", err)
+	}
+	logger.Info("Migrated the validator enode database", "copied", copied, "skipped", skipped)
+	return copied, skipped, nil
+}
+
 func (vet *ValidatorEnodeDB) String() string {
 	vet.lock.RLock()
 	defer vet.lock.RUnlock()
@@ -534,7 +610,8 @@ type ValEnodeEntryInfo struct {
 	Version                      uint   `json:"version"`
 	HighestKnownVersion          uint   `json:"highestKnownVersion"`
 	NumQueryAttemptsForHKVersion uint   `json:"numQueryAttemptsForHKVersion"`
-	LastQueryTimestamp           string `json:"lastQueryTimestamp"` // Unix timestamp
+	LastQueryTimestamp           string `json:"lastQueryTimestamp"`   // Unix timestamp
+	LastUpdatedTimestamp         string `json:"lastUpdatedTimestamp"` // Time the validator created the announce that produced Enode
 }
 
 // ValEnodeTableInfo gives basic information for each entry of the table
@@ -562,6 +639,10 @@ func (vet *ValidatorEnodeDB) ValEnodeTableInfo() (map[string]*ValEnodeEntryInfo,
 			if valEnodeEntry.LastQueryTimestamp != nil {
 				entryInfo.LastQueryTimestamp = valEnodeEntry.LastQueryTimestamp.String()
 			}
+			// Announce versions are the unix timestamp at which the validator created the announce
+			if valEnodeEntry.Version > 0 {
+				entryInfo.LastUpdatedTimestamp = time.Unix(int64(valEnodeEntry.Version), 0).String()
+			}
 
 			valEnodeTableInfo[address.Hex()] = entryInfo
 		}
@@ -1,7 +1,11 @@
 package enodes
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
@@ -153,3 +157,99 @@ func TestTableToString(t *testing.T) {
 		t.Errorf("String() error: got: %s", vet.String())
 	}
 }
+
+func TestValEnodeTableInfo(t *testing.T) {
+	vet, err := OpenValidatorEnodeDB("", &mockListener{})
+	if err != nil {
+		t.Fatal("Failed to open DB")
+	}
+
+	version := uint(time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC).Unix())
+	vet.UpsertVersionAndEnode([]*istanbul.AddressEntry{{Address: addressA, Node: nodeA, Version: version}})
+
+	info, err := vet.ValEnodeTableInfo()
+	if err != nil {
+		t.Fatalf("ValEnodeTableInfo() error: %v", err)
+	}
+	entryInfo, ok := info[addressA.Hex()]
+	if !ok {
+		t.Fatalf("Missing entry for %s", addressA.Hex())
+	}
+	if entryInfo.Enode != enodeURLA || entryInfo.Version != version {
+		t.Errorf("Unexpected entry info: %v", entryInfo)
+	}
+	if want := time.Unix(int64(version), 0).String(); entryInfo.LastUpdatedTimestamp != want {
+		t.Errorf("LastUpdatedTimestamp: got %s, want %s", entryInfo.LastUpdatedTimestamp, want)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "valenodedb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	vet, err := OpenValidatorEnodeDB("", &mockListener{})
+	if err != nil {
+		t.Fatal("Failed to open DB")
+	}
+	defer vet.Close()
+	entries := []*istanbul.AddressEntry{{Address: addressA, Node: nodeA, Version: 1}, {Address: addressB, Node: nodeB, Version: 2}}
+	if err := vet.UpsertVersionAndEnode(entries); err != nil {
+		t.Fatal("Failed to upsert")
+	}
+
+	// A path that can't be opened keeps the current database
+	notADir := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(notADir, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := vet.Migrate(notADir); err == nil {
+		t.Errorf("expected an error migrating to a file")
+	}
+	if node, err := vet.GetNodeFromAddress(addressA); err != nil || node.String() != enodeURLA {
+		t.Errorf("entry after a failed migration = %v, %v, want %s", node, err, enodeURLA)
+	}
+
+	path := filepath.Join(dir, "new")
+	copied, skipped, err := vet.Migrate(path)
+	if err != nil || copied != 2 || skipped != 0 {
+		t.Fatalf("Migrate = %d, %d, %v, want 2 entries copied", copied, skipped, err)
+	}
+	if vet.Path() != path {
+		t.Errorf("path = %q, want %q", vet.Path(), path)
+	}
+	if addr, err := vet.GetAddressFromNodeID(nodeB.ID()); err != nil || addr != addressB {
+		t.Errorf("address of node B after the migration = %v, %v, want %v", addr, err, addressB)
+	}
+	if version, err := vet.GetVersionFromAddress(addressB); err != nil || version != 2 {
+		t.Errorf("version of B after the migration = %d, %v, want 2", version, err)
+	}
+
+	// The migrated database is used from then on
+	if err := vet.RemoveEntry(addressA); err != nil {
+		t.Fatalf("Failed to remove entry: %v", err)
+	}
+	vet.Close()
+	reopened, err := OpenValidatorEnodeDB(path, &mockListener{})
+	if err != nil {
+		t.Fatalf("Failed to reopen the migrated DB: %v", err)
+	}
+	defer reopened.Close()
+	if _, err := reopened.GetNodeFromAddress(addressA); err != leveldb.ErrNotFound {
+		t.Errorf("error mismatch for the removed entry: have %v, want %v", err, leveldb.ErrNotFound)
+	}
+	if node, err := reopened.GetNodeFromAddress(addressB); err != nil || node.String() != enodeURLB {
+		t.Errorf("entry of B in the migrated DB = %v, %v, want %s", node, err, enodeURLB)
+	}
+
+	// A database with entries isn't migrated into
+	other, _ := OpenValidatorEnodeDB("", &mockListener{})
+	defer other.Close()
+	other.UpsertVersionAndEnode(entries[:1])
+	reopened.Close()
+	if _, _, err := other.Migrate(path); err == nil {
+		t.Errorf("expected an error migrating into a database that isn't empty")
+	}
+}
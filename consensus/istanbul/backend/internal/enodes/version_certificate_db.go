@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
@@ -234,20 +235,26 @@ func (svdb *VersionCertificateDB) Remove(address common.Address) error {
 	return svdb.gdb.Write(batch)
 }
 
-// Prune will remove entries for all addresses not present in addressesToKeep
-func (svdb *VersionCertificateDB) Prune(addressesToKeep map[common.Address]bool) error {
+// Prune will remove entries for all addresses not present in addressesToKeep, unless
+// their version (the unix timestamp at which the certificate was created) is at or after
+// expiry. A zero expiry removes every entry not in addressesToKeep. It returns the number
+// of removed entries.
+func (svdb *VersionCertificateDB) Prune(addressesToKeep map[common.Address]bool, expiry time.Time) (int, error) {
 	batch := new(leveldb.Batch)
 	err := svdb.iterate(func(address common.Address, entry *VersionCertificateEntry) error {
-		if !addressesToKeep[address] {
-			svdb.logger.Trace("Deleting entry", "address", address)
+		if !addressesToKeep[address] && (expiry.IsZero() || int64(entry.Version) < expiry.Unix()) {
+			svdb.logger.Trace("Deleting entry", "address", address, "version", entry.Version)
 			batch.Delete(addressKey(address))
 		}
 		return nil
 	})
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return svdb.gdb.Write(batch)
+	if err := svdb.gdb.Write(batch); err != nil {
+		return 0, err
+	}
+	return batch.Len(), nil
 }
 
 // iterate will call `onEntry` for each entry in the db
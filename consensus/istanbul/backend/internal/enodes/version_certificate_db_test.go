@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -146,7 +147,7 @@ func TestVersionCertificateDBPrune(t *testing.T) {
 	addressesToKeep := make(map[common.Address]bool)
 	addressesToKeep[addressB] = true
 
-	table.Prune(addressesToKeep)
+	table.Prune(addressesToKeep, time.Time{})
 
 	_, err = table.Get(addressB)
 	if err != nil {
@@ -159,6 +160,43 @@ func TestVersionCertificateDBPrune(t *testing.T) {
 
 }
 
+func TestVersionCertificateDBPruneExpired(t *testing.T) {
+	table, err := OpenVersionCertificateDB("")
+	if err != nil {
+		t.Fatal("Failed to open DB")
+	}
+
+	expiry := time.Now().Add(-time.Hour)
+	addressC := common.HexToAddress("0xCCCCCC46d924CCCCCCc806721496599FC3FCCCCC")
+	batch := []*VersionCertificateEntry{
+		// Expired and not kept
+		{Address: addressA, PublicKey: nodeA.Pubkey(), Version: uint(expiry.Unix() - 1), Signature: []byte("foo")},
+		// Expired but kept
+		{Address: addressB, PublicKey: nodeB.Pubkey(), Version: uint(expiry.Unix() - 1), Signature: []byte("bar")},
+		// Not kept but not expired
+		{Address: addressC, PublicKey: nodeA.Pubkey(), Version: uint(expiry.Unix()), Signature: []byte("baz")},
+	}
+	if _, err := table.Upsert(batch); err != nil {
+		t.Fatal("Failed to upsert entry")
+	}
+
+	pruned, err := table.Prune(map[common.Address]bool{addressB: true}, expiry)
+	if err != nil {
+		t.Fatalf("Prune() error: %v", err)
+	}
+	if pruned != 1 {
+		t.Errorf("Prune() pruned %d entries, want 1", pruned)
+	}
+	if _, err := table.Get(addressA); err == nil {
+		t.Errorf("It should have NOT found %s after prune", addressA.Hex())
+	}
+	for _, address := range []common.Address{addressB, addressC} {
+		if _, err := table.Get(address); err != nil {
+			t.Errorf("It should have found %s after prune", address.Hex())
+		}
+	}
+}
+
 func TestVersionCertificateEntryRLP(t *testing.T) {
 	original := &VersionCertificateEntry{
 		Address:   addressA,
@@ -17,6 +17,8 @@
 package backend
 
 import (
+	"sync"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
@@ -50,7 +52,10 @@ func (sb *Backend) Multicast(destAddresses []common.Address, payload []byte, eth
 
 	var err error
 
-	if sb.IsProxiedValidator() {
+	if sb.config.ShadowValidator && ethMsgCode == istanbul.ConsensusMsg {
+		// A shadow validator only delivers its consensus messages to itself
+		logger.Trace("Not sending consensus message to peers as a shadow validator")
+	} else if sb.IsProxiedValidator() {
 		err = sb.proxiedValidatorEngine.SendForwardMsgToAllProxies(destAddresses, ethMsgCode, payload)
 		if err != nil {
 			logger.Warn("Error in sending forward message to the proxies", "err", err)
@@ -114,7 +119,9 @@ func (sb *Backend) asyncMulticast(destPeers map[enode.ID]consensus.Peer, payload
 
 	for _, peer := range destPeers {
 		peer := peer // Create new instance of peer for the goroutine
+		sb.asyncSends.add()
 		go func() {
+			defer sb.asyncSends.done()
 			logger.Trace("Sending istanbul message(s) to peer", "peer", peer, "node", peer.Node())
 			if err := peer.Send(ethMsgCode, payload); err != nil {
 				logger.Warn("Error in sending message", "peer", peer, "ethMsgCode", ethMsgCode, "err", err)
@@ -123,6 +130,45 @@ func (sb *Backend) asyncMulticast(destPeers map[enode.ID]consensus.Peer, payload
 	}
 }
 
+// asyncSendCounter counts the messages being sent to peers asynchronously. Unlike a sync.WaitGroup, sends
+// may start while someone waits for the pending ones to finish.
+type asyncSendCounter struct {
+	mu      sync.Mutex
+	pending int
+	idle    chan struct{} // Closed once no send is pending, nil while nobody waits for it
+}
+
+func (c *asyncSendCounter) add() {
+	c.mu.Lock()
+	c.pending++
+	c.mu.Unlock()
+}
+
+func (c *asyncSendCounter) done() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending--
+	if c.pending == 0 && c.idle != nil {
+		close(c.idle)
+		c.idle = nil
+	}
+}
+
+// idleCh returns a channel that is closed once no send is pending.
+func (c *asyncSendCounter) idleCh() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending == 0 {
+		idle := make(chan struct{})
+		close(idle)
+		return idle
+	}
+	if c.idle == nil {
+		c.idle = make(chan struct{})
+	}
+	return c.idle
+}
+
 // Unicast asynchronously sends a message to a single peer.
 func (sb *Backend) Unicast(peer consensus.Peer, payload []byte, ethMsgCode uint64) {
 	peerMap := map[enode.ID]consensus.Peer{peer.Node().ID(): peer}
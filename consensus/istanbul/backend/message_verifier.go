@@ -0,0 +1,67 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+// messageVerifyWorkers returns the number of consensus messages whose signature is verified in parallel.
+func messageVerifyWorkers(config *istanbul.Config) int {
+	if config.MessageVerifyWorkers == 0 {
+		return runtime.NumCPU()
+	}
+	return int(config.MessageVerifyWorkers)
+}
+
+// recoverMessageSigner decodes a consensus message and returns the address that signed it, which
+// must be the address the message claims to be from.
+func recoverMessageSigner(payload []byte) (common.Address, error) {
+	msg := new(istanbul.Message)
+	if err := msg.FromPayload(payload, istanbul.GetSignatureAddress); err != nil {
+		return common.Address{}, err
+	}
+	return msg.Address, nil
+}
+
+// postConsensusMsg verifies the signature of a received consensus message and posts it to the core.
+// The signature recovery runs here, on up to MessageVerifyWorkers messages at once, so that the
+// single-threaded core only has to check that the signer is in the validator set.
+func (sb *Backend) postConsensusMsg(payload []byte) {
+	sb.messageVerifySlots <- struct{}{}
+	signer, err := recoverMessageSigner(payload)
+	<-sb.messageVerifySlots
+	if err != nil {
+		sb.logger.Debug("Dropping consensus message with an invalid signature", "err", err)
+		return
+	}
+	sb.checkExcludedProposal(signer, payload)
+
+	// Posting blocks until the core receives the message, so its duration reflects the load of the core
+	start := time.Now()
+	sb.istanbulEventMux.Post(istanbul.MessageEvent{
+		Payload: payload,
+		Signer:  &signer,
+	})
+	if sb.config.AnnounceAdaptiveGossip {
+		sb.adaptiveGossip.observeLatency(time.Since(start))
+	}
+}
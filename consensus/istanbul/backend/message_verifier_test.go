@@ -0,0 +1,91 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+func newSignedTestMessage(t *testing.T, claimedAddress *common.Address) ([]byte, common.Address) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	msg := &istanbul.Message{Code: istanbul.MsgPrepare, Msg: []byte{0x01}, Address: crypto.PubkeyToAddress(key.PublicKey)}
+	if claimedAddress != nil {
+		msg.Address = *claimedAddress
+	}
+	data, err := msg.PayloadNoSig()
+	if err != nil {
+		t.Fatalf("failed to encode message: %v", err)
+	}
+	if msg.Signature, err = crypto.Sign(crypto.Keccak256(data), key); err != nil {
+		t.Fatalf("failed to sign message: %v", err)
+	}
+	payload, err := msg.Payload()
+	if err != nil {
+		t.Fatalf("failed to encode message: %v", err)
+	}
+	return payload, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func TestPostConsensusMsg(t *testing.T) {
+	config := *istanbul.DefaultConfig
+	config.MessageVerifyWorkers = 2
+	sb := &Backend{
+		config:             &config,
+		istanbulEventMux:   new(event.TypeMux),
+		logger:             log.New(),
+		messageVerifySlots: make(chan struct{}, messageVerifyWorkers(&config)),
+	}
+	sub := sb.istanbulEventMux.Subscribe(istanbul.MessageEvent{})
+	defer sub.Unsubscribe()
+
+	// A message signed by another address than the one it claims to be from is dropped
+	other := common.HexToAddress("0x01")
+	forged, _ := newSignedTestMessage(t, &other)
+	go sb.postConsensusMsg(forged)
+
+	payload, signer := newSignedTestMessage(t, nil)
+	go sb.postConsensusMsg(payload)
+
+	select {
+	case ev := <-sub.Chan():
+		msgEvent := ev.Data.(istanbul.MessageEvent)
+		if msgEvent.Signer == nil || *msgEvent.Signer != signer {
+			t.Errorf("signer = %v, want %v", msgEvent.Signer, signer.Hex())
+		}
+		if string(msgEvent.Payload) != string(payload) {
+			t.Errorf("posted the payload of another message")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("message not posted")
+	}
+
+	select {
+	case <-sub.Chan():
+		t.Errorf("message with an invalid signature posted")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// msgQueueSize is the number of received messages of each class that wait to be handled with
+// PrioritizeConsensusMsgs.
+const msgQueueSize = 1024
+
+// msgPriorityQueues queues the handlers of received consensus and announce messages separately, and
+// runs them on a fixed number of workers. A worker takes a queued consensus message whenever there is
+// one, so that consensus messages aren't delayed behind announce gossip on a busy node.
+type msgPriorityQueues struct {
+	consensus chan func()
+	announce  chan func()
+	quit      chan struct{}
+
+	// Gauges of the number of queued messages of each class
+	consensusDepthGauge metrics.Gauge
+	announceDepthGauge  metrics.Gauge
+	// Meters counting the messages of each class dropped because their queue was full
+	consensusDroppedMeter metrics.Meter
+	announceDroppedMeter  metrics.Meter
+}
+
+func newMsgPriorityQueues(size int) *msgPriorityQueues {
+	return &msgPriorityQueues{
+		consensus:             make(chan func(), size),
+		announce:              make(chan func(), size),
+		quit:                  make(chan struct{}),
+		consensusDepthGauge:   metrics.NewRegisteredGauge("consensus/istanbul/backend/msgqueue/consensus/depth", nil),
+		announceDepthGauge:    metrics.NewRegisteredGauge("consensus/istanbul/backend/msgqueue/announce/depth", nil),
+		consensusDroppedMeter: metrics.NewRegisteredMeter("consensus/istanbul/backend/msgqueue/consensus/dropped", nil),
+		announceDroppedMeter:  metrics.NewRegisteredMeter("consensus/istanbul/backend/msgqueue/announce/dropped", nil),
+	}
+}
+
+// start starts the given number of workers, which run until stop is called.
+func (q *msgPriorityQueues) start(workers int) {
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+}
+
+// stop stops the workers. The queued messages are dropped.
+func (q *msgPriorityQueues) stop() {
+	close(q.quit)
+}
+
+// push queues the handler of a received message with the given code. It is called from the peer's read
+// loop, so it never blocks: a message is dropped when its queue is full. Announce messages are gossiped
+// again, and validators that miss consensus messages catch up through round changes.
+func (q *msgPriorityQueues) push(code uint64, handler func()) {
+	queue, droppedMeter := q.consensus, q.consensusDroppedMeter
+	if isAnnounceMsg(code) {
+		queue, droppedMeter = q.announce, q.announceDroppedMeter
+	}
+	select {
+	case queue <- handler:
+	default:
+		droppedMeter.Mark(1)
+	}
+	q.updateDepths()
+}
+
+func (q *msgPriorityQueues) updateDepths() {
+	q.consensusDepthGauge.Update(int64(len(q.consensus)))
+	q.announceDepthGauge.Update(int64(len(q.announce)))
+}
+
+func (q *msgPriorityQueues) work() {
+	for {
+		var handler func()
+		// Take a queued consensus message first, and only wait for either class if there is none
+		select {
+		case handler = <-q.consensus:
+		case <-q.quit:
+			return
+		default:
+			select {
+			case handler = <-q.consensus:
+			case handler = <-q.announce:
+			case <-q.quit:
+				return
+			}
+		}
+		q.updateDepths()
+		handler()
+	}
+}
+
+// dispatchMsg runs the handler of a received consensus or announce message in its own goroutine, or
+// queues it by priority with PrioritizeConsensusMsgs.
+func (sb *Backend) dispatchMsg(code uint64, handler func()) {
+	if sb.msgQueues == nil {
+		go handler()
+		return
+	}
+	sb.msgQueues.push(code, handler)
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+func TestMsgPriorityQueues(t *testing.T) {
+	q := newMsgPriorityQueues(3)
+	defer q.stop()
+
+	handled := make(chan string, 10)
+	handler := func(name string) func() { return func() { handled <- name } }
+	q.push(istanbul.QueryEnodeMsg, handler("announce1"))
+	q.push(istanbul.ConsensusMsg, handler("consensus1"))
+	q.push(istanbul.VersionCertificatesMsg, handler("announce2"))
+	q.push(istanbul.ConsensusMsg, handler("consensus2"))
+	q.push(istanbul.QueryEnodeMsg, handler("announce3"))
+	q.push(istanbul.ConsensusMsg, handler("consensus3"))
+	// Both queues are full
+	q.push(istanbul.QueryEnodeMsg, handler("announce4"))
+	q.push(istanbul.ConsensusMsg, handler("consensus4"))
+
+	q.start(1)
+	var have []string
+	for len(have) < 6 {
+		select {
+		case name := <-handled:
+			have = append(have, name)
+		case <-time.After(time.Second):
+			t.Fatalf("handled %v, want 6 messages", have)
+		}
+	}
+	if want := []string{"consensus1", "consensus2", "consensus3", "announce1", "announce2", "announce3"}; !reflect.DeepEqual(have, want) {
+		t.Errorf("handled %v, want %v", have, want)
+	}
+	select {
+	case name := <-handled:
+		t.Errorf("handled %s, which should have been dropped", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+var (
+	// errObserverMode is returned when starting to validate with ObserverMode
+	errObserverMode = errors.New("can't validate in observer mode")
+	// errNotObserving is returned when retrieving the observer stats without ObserverMode
+	errNotObserving = errors.New("observer mode is not enabled")
+	// errInvalidObservedMsg is returned when an observed consensus message has an unknown code or no view
+	errInvalidObservedMsg = errors.New("invalid consensus message")
+)
+
+// ObserverStats summarizes the consensus messages received since this node started with ObserverMode.
+type ObserverStats struct {
+	Sequence        uint64                    `json:"sequence"`        // The highest sequence of a received consensus message
+	Round           uint64                    `json:"round"`           // The highest round of a received consensus message of that sequence
+	Sequences       uint64                    `json:"sequences"`       // The number of sequences followed, i.e. in which consensus messages were received
+	RoundChanges    uint64                    `json:"roundChanges"`    // The number of followed sequences that went past round 0
+	PreprepareMsgs  uint64                    `json:"preprepareMsgs"`  // The number of PREPREPARE messages received
+	PrepareMsgs     uint64                    `json:"prepareMsgs"`     // The number of PREPARE messages received
+	CommitMsgs      uint64                    `json:"commitMsgs"`      // The number of COMMIT messages received
+	RoundChangeMsgs uint64                    `json:"roundChangeMsgs"` // The number of ROUND CHANGE messages received
+	InvalidMsgs     uint64                    `json:"invalidMsgs"`     // The number of consensus messages dropped because they couldn't be decoded or had an invalid signature
+	Participation   map[common.Address]uint64 `json:"participation"`   // The number of followed sequences in which each validator sent a COMMIT message
+}
+
+// consensusObserver holds the counters reported in ObserverStats.
+type consensusObserver struct {
+	sequence     uint64
+	round        uint64
+	roundChanged bool // Whether the current sequence went past round 0
+	sequences    uint64
+	roundChanges uint64
+	msgs         [istanbul.MsgRoundChange + 1]uint64
+	invalidMsgs  uint64
+
+	// The last sequence in which the COMMIT message of each validator was counted
+	lastCommits   map[common.Address]uint64
+	participation map[common.Address]uint64
+
+	mu sync.Mutex
+}
+
+func newConsensusObserver() *consensusObserver {
+	return &consensusObserver{
+		lastCommits:   make(map[common.Address]uint64),
+		participation: make(map[common.Address]uint64),
+	}
+}
+
+// observe records a consensus message with a valid signature. A message of a later sequence than the
+// current one starts following that sequence. Messages of earlier sequences are counted, but don't
+// change the followed sequence or its round.
+func (o *consensusObserver) observe(code uint64, sequence, round uint64, sender common.Address) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.msgs[code]++
+	if sequence > o.sequence {
+		o.sequence, o.round, o.roundChanged = sequence, 0, false
+		o.sequences++
+	}
+	if sequence == o.sequence {
+		if round > o.round {
+			o.round = round
+		}
+		if round > 0 && !o.roundChanged {
+			o.roundChanged = true
+			o.roundChanges++
+		}
+	}
+	if code == istanbul.MsgCommit && o.lastCommits[sender] < sequence {
+		o.lastCommits[sender] = sequence
+		o.participation[sender]++
+	}
+}
+
+func (o *consensusObserver) markInvalid() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.invalidMsgs++
+}
+
+func (o *consensusObserver) stats() *ObserverStats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	stats := &ObserverStats{
+		Sequence:        o.sequence,
+		Round:           o.round,
+		Sequences:       o.sequences,
+		RoundChanges:    o.roundChanges,
+		PreprepareMsgs:  o.msgs[istanbul.MsgPreprepare],
+		PrepareMsgs:     o.msgs[istanbul.MsgPrepare],
+		CommitMsgs:      o.msgs[istanbul.MsgCommit],
+		RoundChangeMsgs: o.msgs[istanbul.MsgRoundChange],
+		InvalidMsgs:     o.invalidMsgs,
+		Participation:   make(map[common.Address]uint64, len(o.participation)),
+	}
+	for address, sequences := range o.participation {
+		stats.Participation[address] = sequences
+	}
+	return stats
+}
+
+// observedMsgView decodes the view of a consensus message.
+func observedMsgView(msg *istanbul.Message) (*istanbul.View, error) {
+	var view *istanbul.View
+	switch msg.Code {
+	case istanbul.MsgPreprepare:
+		var preprepare *istanbul.Preprepare
+		if err := msg.Decode(&preprepare); err != nil {
+			return nil, err
+		}
+		view = preprepare.View
+	case istanbul.MsgPrepare:
+		var subject *istanbul.Subject
+		if err := msg.Decode(&subject); err != nil {
+			return nil, err
+		}
+		view = subject.View
+	case istanbul.MsgCommit:
+		var committedSubject *istanbul.CommittedSubject
+		if err := msg.Decode(&committedSubject); err != nil {
+			return nil, err
+		}
+		if committedSubject.Subject != nil {
+			view = committedSubject.Subject.View
+		}
+	case istanbul.MsgRoundChange:
+		var roundChange *istanbul.RoundChange
+		if err := msg.Decode(&roundChange); err != nil {
+			return nil, err
+		}
+		view = roundChange.View
+	}
+	if view == nil || view.Sequence == nil || view.Round == nil {
+		return nil, errInvalidObservedMsg
+	}
+	return view, nil
+}
+
+// observeConsensusMsg records a consensus message received with ObserverMode. It is only interpreted,
+// never handled by the core or forwarded.
+func (sb *Backend) observeConsensusMsg(payload []byte) {
+	msg := new(istanbul.Message)
+	if err := msg.FromPayload(payload, istanbul.GetSignatureAddress); err != nil {
+		sb.logger.Trace("Dropping observed consensus message with an invalid signature", "err", err)
+		sb.consensusObserver.markInvalid()
+		return
+	}
+	view, err := observedMsgView(msg)
+	if err != nil {
+		sb.logger.Trace("Dropping observed consensus message that can't be decoded", "code", msg.Code, "from", msg.Address, "err", err)
+		sb.consensusObserver.markInvalid()
+		return
+	}
+	sb.consensusObserver.observe(msg.Code, view.Sequence.Uint64(), view.Round.Uint64(), msg.Address)
+}
+
+// GetObserverStats returns the statistics of the consensus messages received since this node started
+// with ObserverMode.
+func (sb *Backend) GetObserverStats() (*ObserverStats, error) {
+	if !sb.config.ObserverMode {
+		return nil, errNotObserving
+	}
+	return sb.consensusObserver.stats(), nil
+}
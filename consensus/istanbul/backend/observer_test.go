@@ -0,0 +1,104 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func newObservedTestMessage(t *testing.T, key *ecdsa.PrivateKey, code uint64, sequence, round int64) []byte {
+	view := &istanbul.View{Sequence: big.NewInt(sequence), Round: big.NewInt(round)}
+	var content interface{}
+	switch code {
+	case istanbul.MsgPrepare:
+		content = &istanbul.Subject{View: view}
+	case istanbul.MsgCommit:
+		content = &istanbul.CommittedSubject{Subject: &istanbul.Subject{View: view}}
+	case istanbul.MsgRoundChange:
+		content = &istanbul.RoundChange{View: view, PreparedCertificate: istanbul.EmptyPreparedCertificate()}
+	}
+	encoded, err := rlp.EncodeToBytes(content)
+	if err != nil {
+		t.Fatalf("failed to encode message content: %v", err)
+	}
+	msg := &istanbul.Message{Code: code, Msg: encoded, Address: crypto.PubkeyToAddress(key.PublicKey)}
+	if err := msg.Sign(func(data []byte) ([]byte, error) { return crypto.Sign(crypto.Keccak256(data), key) }); err != nil {
+		t.Fatalf("failed to sign message: %v", err)
+	}
+	payload, err := msg.Payload()
+	if err != nil {
+		t.Fatalf("failed to encode message: %v", err)
+	}
+	return payload
+}
+
+func TestObserveConsensusMsgs(t *testing.T) {
+	config := *istanbul.DefaultConfig
+	config.ObserverMode = true
+	sb := &Backend{config: &config, logger: log.New(), consensusObserver: newConsensusObserver()}
+	keyA, _ := crypto.GenerateKey()
+	keyB, _ := crypto.GenerateKey()
+	a, b := crypto.PubkeyToAddress(keyA.PublicKey), crypto.PubkeyToAddress(keyB.PublicKey)
+
+	for _, payload := range [][]byte{
+		newObservedTestMessage(t, keyA, istanbul.MsgPrepare, 5, 0),
+		newObservedTestMessage(t, keyA, istanbul.MsgCommit, 5, 0),
+		newObservedTestMessage(t, keyB, istanbul.MsgCommit, 5, 0),
+		// A validator's COMMIT messages only count once per sequence
+		newObservedTestMessage(t, keyA, istanbul.MsgCommit, 5, 0),
+		newObservedTestMessage(t, keyB, istanbul.MsgRoundChange, 6, 1),
+		newObservedTestMessage(t, keyB, istanbul.MsgCommit, 6, 1),
+		// A late message of an earlier sequence doesn't change the followed one
+		newObservedTestMessage(t, keyA, istanbul.MsgCommit, 4, 2),
+		[]byte{0x01, 0x02},
+	} {
+		sb.observeConsensusMsg(payload)
+	}
+
+	stats, err := sb.GetObserverStats()
+	if err != nil {
+		t.Fatalf("failed to get observer stats: %v", err)
+	}
+	want := &ObserverStats{
+		Sequence:        6,
+		Round:           1,
+		Sequences:       2,
+		RoundChanges:    1,
+		PrepareMsgs:     1,
+		CommitMsgs:      5,
+		RoundChangeMsgs: 1,
+		InvalidMsgs:     1,
+		Participation:   map[common.Address]uint64{a: 1, b: 2},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("observer stats = %+v, want %+v", stats, want)
+	}
+
+	config.ObserverMode = false
+	if _, err := sb.GetObserverStats(); err != errNotObserving {
+		t.Errorf("error without observer mode = %v, want %v", err, errNotObserving)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// signedBlock records which of the validators elected for a block signed it.
+type signedBlock struct {
+	validators []common.Address // The validators elected for the block, in validator set order
+	bitmap     *big.Int         // The bitmap of the signers of the block, indexed by validator set order
+}
+
+// participationRatios returns, for each validator elected for any of the given blocks, the share of the
+// blocks it was elected for that it signed.
+func participationRatios(blocks []signedBlock) map[common.Address]float64 {
+	elected := make(map[common.Address]uint64)
+	signed := make(map[common.Address]uint64)
+	for _, block := range blocks {
+		for i, address := range block.validators {
+			elected[address]++
+			if block.bitmap != nil && block.bitmap.Bit(i) == 1 {
+				signed[address]++
+			}
+		}
+	}
+	ratios := make(map[common.Address]float64, len(elected))
+	for address, count := range elected {
+		ratios[address] = float64(signed[address]) / float64(count)
+	}
+	return ratios
+}
+
+// validatorParticipation returns the participation ratio of each validator over the last LookbackWindow
+// blocks whose signers are recorded in the chain up to the given header. The signers of a block are taken
+// from the parent aggregated seal of its child, since that is the canonical record the uptime score uses.
+func (sb *Backend) validatorParticipation(header *types.Header) (map[common.Address]float64, error) {
+	blocks := make([]signedBlock, 0, sb.config.LookbackWindow)
+	for uint64(len(blocks)) < sb.config.LookbackWindow && header.Number.Uint64() > 1 {
+		number := header.Number.Uint64()
+		parent := sb.chain.GetHeader(header.ParentHash, number-1)
+		if parent == nil {
+			return nil, errUnknownBlock
+		}
+		extra, err := types.ExtractIstanbulExtra(header)
+		if err != nil {
+			return nil, err
+		}
+		valSet := sb.getValidators(number-2, parent.ParentHash)
+		validators := make([]common.Address, 0, valSet.Size())
+		for _, val := range valSet.List() {
+			validators = append(validators, val.Address())
+		}
+		blocks = append(blocks, signedBlock{validators: validators, bitmap: extra.ParentAggregatedSeal.Bitmap})
+		header = parent
+	}
+	return participationRatios(blocks), nil
+}
+
+// reportValidatorParticipation logs and updates the participation metric of each validator over the
+// lookback window ending at the given header, warning about the validators that didn't sign any block.
+func (sb *Backend) reportValidatorParticipation(header *types.Header) {
+	ratios, err := sb.validatorParticipation(header)
+	if err != nil {
+		sb.logger.Warn("Failed to compute the validator participation", "number", header.Number, "err", err)
+		return
+	}
+	silent := 0
+	for address, ratio := range ratios {
+		metrics.GetOrRegisterGaugeFloat64("consensus/istanbul/backend/participation/"+strings.ToLower(address.Hex()), nil).Update(ratio)
+		if ratio == 0 {
+			silent++
+			sb.logger.Warn("Elected validator didn't sign any block in the lookback window", "address", address, "number", header.Number, "lookback_window", sb.config.LookbackWindow)
+		}
+	}
+	sb.logger.Info("Validator participation", "number", header.Number, "lookback_window", sb.config.LookbackWindow, "validators", len(ratios), "silent", silent)
+}
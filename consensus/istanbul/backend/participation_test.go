@@ -0,0 +1,52 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParticipationRatios(t *testing.T) {
+	a := common.HexToAddress("0x1")
+	b := common.HexToAddress("0x2")
+	c := common.HexToAddress("0x3")
+	d := common.HexToAddress("0x4")
+
+	blocks := []signedBlock{
+		{validators: []common.Address{a, b, c}, bitmap: big.NewInt(0x3)}, // a and b signed
+		{validators: []common.Address{a, b, c}, bitmap: big.NewInt(0x1)}, // a signed
+		{validators: []common.Address{a, b, c}, bitmap: big.NewInt(0x5)}, // a and c signed
+		{validators: []common.Address{d, a, b}, bitmap: big.NewInt(0x2)}, // a signed, in another validator set order
+	}
+	want := map[common.Address]float64{
+		a: 1,
+		b: 0.25,
+		c: 1.0 / 3,
+		d: 0,
+	}
+	if have := participationRatios(blocks); !reflect.DeepEqual(have, want) {
+		t.Errorf("ratios mismatch: have %v, want %v", have, want)
+	}
+
+	if have := participationRatios(nil); len(have) != 0 {
+		t.Errorf("expected no ratios without blocks, have %v", have)
+	}
+}
@@ -145,20 +145,26 @@ func (sb *Backend) updateValidatorScores(header *types.Header, state *state.Stat
 		logger.Error(err.Error())
 		return nil, err
 	}
+	graceCredits, err := sb.newValidatorGraceCredits(epoch, valSet)
+	if err != nil {
+		logger.Error("Failed to compute the uptime grace of new validators", "err", err)
+		return nil, err
+	}
 
 	for i, entry := range accumulated.Entries {
 		if i >= len(valSet) {
 			break
 		}
-		val_logger := logger.New("scoreTally", entry.ScoreTally, "denominator", denominator, "index", i, "address", valSet[i].Address())
+		scoreTally := entry.ScoreTally + graceCredits[i]
+		val_logger := logger.New("scoreTally", scoreTally, "graceCredits", graceCredits[i], "denominator", denominator, "index", i, "address", valSet[i].Address())
 
-		if entry.ScoreTally > denominator {
+		if scoreTally > denominator {
 			val_logger.Error("ScoreTally exceeds max possible")
 			uptimes = append(uptimes, params.Fixidity1)
 			continue
 		}
 
-		numerator := big.NewInt(0).Mul(big.NewInt(int64(entry.ScoreTally)), params.Fixidity1)
+		numerator := big.NewInt(0).Mul(big.NewInt(int64(scoreTally)), params.Fixidity1)
 		uptimes = append(uptimes, big.NewInt(0).Div(numerator, big.NewInt(int64(denominator))))
 	}
 
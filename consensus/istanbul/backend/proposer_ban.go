@@ -0,0 +1,95 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// bannedProposers returns the validators that are skipped as proposers of the block after the given one,
+// because they failed more than ProposerBanThreshold of the last ProposerBanWindow rounds. No proposer is
+// banned before ProposerBanForkBlock.
+//
+// The rounds are read from the chain: every block contributes the round it was committed in, and the
+// earlier rounds of its sequence, which ended with a round change. The proposer of a failed round is the
+// one selected by the ordering without bans, so that the ban only depends on the headers and is the same
+// on every validator.
+func (sb *Backend) bannedProposers(number uint64, hash common.Hash) []common.Address {
+	if sb.config.ProposerBanWindow == 0 || number+1 < sb.config.ProposerBanForkBlock {
+		return nil
+	}
+	if banned, ok := sb.recentBannedProposers.Get(hash); ok {
+		return banned.([]common.Address)
+	}
+
+	window := sb.config.ProposerBanWindow
+	rounds := make([]validator.ProposerBanRound, 0, window)
+	header := sb.chain.GetHeader(hash, number)
+	for header != nil && header.Number.Uint64() > 0 && uint64(len(rounds)) < window {
+		author, err := sb.Author(header)
+		if err != nil {
+			sb.logger.Warn("Failed to retrieve author for proposer ban", "number", header.Number, "hash", header.Hash(), "err", err)
+			return nil
+		}
+		extra, err := types.ExtractIstanbulExtra(header)
+		if err != nil {
+			sb.logger.Warn("Failed to extract istanbul extra for proposer ban", "number", header.Number, "hash", header.Hash(), "err", err)
+			return nil
+		}
+		parent := sb.chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		if parent == nil {
+			break
+		}
+		rounds = append(rounds, validator.ProposerBanRound{Proposer: author})
+
+		if extra.AggregatedSeal.Round != nil && extra.AggregatedSeal.Round.Sign() > 0 {
+			valSet := sb.orderValidators(parent.Number.Uint64(), parent.Hash())
+			lastProposer, _ := sb.Author(parent)
+			policy := sb.config.ProposerPolicyAt(header.Number.Uint64())
+			for round := extra.AggregatedSeal.Round.Uint64(); round > 0 && uint64(len(rounds)) < window; round-- {
+				proposer := validator.SelectProposer(valSet, lastProposer, round-1, policy)
+				rounds = append(rounds, validator.ProposerBanRound{Proposer: proposer, Failed: true})
+			}
+		}
+		header = parent
+	}
+
+	// The rounds were collected from newest to oldest
+	for i, j := 0, len(rounds)-1; i < j; i, j = i+1, j-1 {
+		rounds[i], rounds[j] = rounds[j], rounds[i]
+	}
+	banned := validator.BannedProposers(rounds, sb.config.ProposerBanThreshold)
+	if len(banned) > 0 {
+		sb.logger.Debug("Banned proposers", "number", number+1, "banned", common.ConvertToStringSlice(banned))
+	}
+	sb.recentBannedProposers.Add(hash, banned)
+	return banned
+}
+
+// withProposerBans returns the validator set with the validators banned from proposing the block after the
+// given one demoted, without modifying the given set.
+func (sb *Backend) withProposerBans(valSet istanbul.ValidatorSet, number uint64, hash common.Hash) istanbul.ValidatorSet {
+	if banned := sb.bannedProposers(number, hash); len(banned) > 0 {
+		valSet = valSet.Copy()
+		demoted := append(append([]common.Address{}, valSet.GetDemotedProposers()...), banned...)
+		valSet.SetDemotedProposers(demoted)
+	}
+	return valSet
+}
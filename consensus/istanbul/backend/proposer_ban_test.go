@@ -0,0 +1,83 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestBannedProposers(t *testing.T) {
+	genesisCfg, nodeKeys := getGenesisAndKeys(1, true)
+	chain, engine, _ := newBlockChainWithKeys(false, common.Address{}, false, genesisCfg, nodeKeys[0])
+	defer chain.Stop()
+
+	// Block 1 is committed in round 2, after the validator failed to propose it in rounds 0 and 1
+	block1, err := makeBlockAtRound(nodeKeys, chain, engine, chain.Genesis(), 2)
+	if err != nil {
+		t.Fatalf("failed to make block 1: %v", err)
+	}
+	block2, err := makeBlock(nodeKeys, chain, engine, block1)
+	if err != nil {
+		t.Fatalf("failed to make block 2: %v", err)
+	}
+
+	self := []common.Address{engine.Address()}
+	cases := []struct {
+		window, threshold uint64
+		want              []common.Address
+	}{
+		{0, 1, nil},
+		// The last four rounds are the rounds 0 to 2 of block 1 and the round 0 of block 2
+		{4, 1, self},
+		{4, 2, nil},
+		// The failed rounds of block 1 are out of the window
+		{2, 1, nil},
+	}
+	for i, tc := range cases {
+		engine.config.ProposerBanWindow, engine.config.ProposerBanThreshold = tc.window, tc.threshold
+		engine.recentBannedProposers.Purge()
+		if have := engine.bannedProposers(block2.NumberU64(), block2.Hash()); !reflect.DeepEqual(have, tc.want) {
+			t.Errorf("case %d: banned mismatch: have %v, want %v", i, have, tc.want)
+		}
+	}
+
+	// Proposers are only banned from the fork block on
+	engine.config.ProposerBanWindow, engine.config.ProposerBanThreshold = 4, 1
+	for fork, want := range map[uint64][]common.Address{block2.NumberU64() + 1: self, block2.NumberU64() + 2: nil} {
+		engine.config.ProposerBanForkBlock = fork
+		engine.recentBannedProposers.Purge()
+		if have := engine.bannedProposers(block2.NumberU64(), block2.Hash()); !reflect.DeepEqual(have, want) {
+			t.Errorf("fork block %d: banned mismatch: have %v, want %v", fork, have, want)
+		}
+	}
+	engine.config.ProposerBanForkBlock = 0
+
+	engine.config.ProposerBanWindow, engine.config.ProposerBanThreshold = 3, 1
+	engine.recentBannedProposers.Purge()
+	api := &API{chain: chain, istanbul: engine}
+	latest, pending := rpc.LatestBlockNumber, rpc.PendingBlockNumber
+	if banned, err := api.GetBannedProposers(&latest); err != nil || !reflect.DeepEqual(banned, self) {
+		t.Errorf("banned proposers of the head = %v (err %v), want %v", banned, err, self)
+	}
+	if banned, err := api.GetBannedProposers(&pending); err != nil || banned == nil || len(banned) != 0 {
+		t.Errorf("banned proposers after the head = %v (err %v), want none", banned, err)
+	}
+}
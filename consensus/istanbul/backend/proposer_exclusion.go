@@ -0,0 +1,149 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// proposerExclusions holds the validators that the operator excluded from proposing, each until a block.
+type proposerExclusions struct {
+	until      map[common.Address]uint64 // The last block for which each excluded validator is skipped
+	conflicted map[common.Address]bool   // The excluded validators that were seen proposing anyway
+	mu         sync.RWMutex
+}
+
+// set excludes the given validator from proposing the blocks up to untilBlock, or lifts its exclusion if
+// untilBlock is zero.
+func (e *proposerExclusions) set(address common.Address, untilBlock uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.conflicted, address)
+	if untilBlock == 0 {
+		delete(e.until, address)
+		return
+	}
+	if e.until == nil {
+		e.until = make(map[common.Address]uint64)
+	}
+	e.until[address] = untilBlock
+}
+
+// excludedAt returns the validators excluded from proposing the given block.
+func (e *proposerExclusions) excludedAt(number uint64) []common.Address {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var excluded []common.Address
+	for address, until := range e.until {
+		if number <= until {
+			excluded = append(excluded, address)
+		}
+	}
+	return excluded
+}
+
+// isExcludedAt returns whether the given validator is excluded from proposing the given block.
+func (e *proposerExclusions) isExcludedAt(address common.Address, number uint64) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	until, ok := e.until[address]
+	return ok && number <= until
+}
+
+// isExcluded returns whether the given validator is excluded from proposing any block.
+func (e *proposerExclusions) isExcluded(address common.Address) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	_, ok := e.until[address]
+	return ok
+}
+
+// markConflict records that the given validator proposed the given block, and returns whether it was
+// excluded from proposing it and this is the first such proposal since the exclusion was set.
+func (e *proposerExclusions) markConflict(address common.Address, number uint64) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if until, ok := e.until[address]; !ok || number > until || e.conflicted[address] {
+		return false
+	}
+	if e.conflicted == nil {
+		e.conflicted = make(map[common.Address]bool)
+	}
+	e.conflicted[address] = true
+	return true
+}
+
+// ExcludeProposer makes this node skip the given validator when selecting the proposers of the blocks up
+// to untilBlock, e.g. while the validator is restarted during a maintenance window. A zero untilBlock
+// lifts the exclusion.
+//
+// This is a tool for coordinated operations. The exclusion always applies locally and at once, without
+// any check that other validators agree with it: it changes which validator this node expects to propose,
+// so a validator without the exclusion rejects the proposals of the replacement proposer and the other
+// way around, and each disagreement costs a round. It only keeps the excluded validator from proposing if
+// it is set identically on enough validators to form a quorum. As soon as the excluded validator proposes
+// one of the excluded blocks anyway, this node warns that the exclusion may be ineffective, and if the
+// excluded validator gets one of those blocks committed, the exclusion is lifted so that this node agrees
+// with the network again.
+func (sb *Backend) ExcludeProposer(address common.Address, untilBlock uint64) {
+	sb.proposerExclusions.set(address, untilBlock)
+	if untilBlock == 0 {
+		sb.logger.Info("Lifted proposer exclusion", "address", address)
+		return
+	}
+	sb.logger.Warn("Excluding validator from proposing, this is only effective if set on a quorum of validators", "address", address, "until_block", untilBlock)
+}
+
+// checkProposerExclusion lifts the exclusion of the proposer of the given block if it was supposed to be
+// excluded, since the network didn't honor the exclusion.
+func (sb *Backend) checkProposerExclusion(block *types.Block) {
+	number := block.NumberU64()
+	if len(sb.proposerExclusions.excludedAt(number)) == 0 {
+		return
+	}
+	author, err := sb.Author(block.Header())
+	if err != nil || !sb.proposerExclusions.isExcludedAt(author, number) {
+		return
+	}
+	sb.logger.Error("Proposer exclusion is ineffective, it isn't set on enough validators. Lifting it", "address", author, "number", number)
+	sb.proposerExclusions.set(author, 0)
+}
+
+// checkExcludedProposal warns the first time that an excluded validator sends a proposal for a block it
+// is excluded from proposing, which shows that at least some validators don't have the exclusion.
+func (sb *Backend) checkExcludedProposal(signer common.Address, payload []byte) {
+	if !sb.proposerExclusions.isExcluded(signer) {
+		return
+	}
+	msg := new(istanbul.Message)
+	if err := rlp.DecodeBytes(payload, msg); err != nil || msg.Code != istanbul.MsgPreprepare {
+		return
+	}
+	var preprepare *istanbul.Preprepare
+	if err := msg.Decode(&preprepare); err != nil || preprepare.View == nil || preprepare.View.Sequence == nil {
+		return
+	}
+	number := preprepare.View.Sequence.Uint64()
+	if sb.proposerExclusions.markConflict(signer, number) {
+		sb.logger.Warn("Proposer exclusion may be ineffective, the excluded validator is proposing. It must be set on a quorum of validators", "address", signer, "number", number, "round", preprepare.View.Round)
+	}
+}
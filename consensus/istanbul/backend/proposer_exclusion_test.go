@@ -0,0 +1,110 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
+)
+
+func TestProposerExclusions(t *testing.T) {
+	var exclusions proposerExclusions
+	addr := common.HexToAddress("0x01")
+
+	if excluded := exclusions.excludedAt(1); len(excluded) != 0 {
+		t.Errorf("excluded validators without exclusions: %v", excluded)
+	}
+
+	exclusions.set(addr, 10)
+	if excluded := exclusions.excludedAt(10); !reflect.DeepEqual(excluded, []common.Address{addr}) {
+		t.Errorf("excludedAt(10) = %v, want %v", excluded, addr)
+	}
+	if !exclusions.isExcludedAt(addr, 10) {
+		t.Errorf("validator not excluded at its last excluded block")
+	}
+	if exclusions.isExcludedAt(addr, 11) || len(exclusions.excludedAt(11)) != 0 {
+		t.Errorf("validator excluded after its last excluded block")
+	}
+
+	// Only the first proposal of an excluded block conflicts, until the exclusion is set again
+	if exclusions.markConflict(addr, 11) {
+		t.Errorf("proposal after the last excluded block reported as a conflict")
+	}
+	if !exclusions.markConflict(addr, 10) {
+		t.Errorf("proposal of an excluded block not reported as a conflict")
+	}
+	if exclusions.markConflict(addr, 9) {
+		t.Errorf("second proposal of an excluded block reported as a conflict")
+	}
+	exclusions.set(addr, 20)
+	if !exclusions.markConflict(addr, 9) {
+		t.Errorf("proposal of an excluded block not reported as a conflict after the exclusion was set again")
+	}
+
+	exclusions.set(addr, 0)
+	if exclusions.isExcludedAt(addr, 5) || exclusions.isExcluded(addr) {
+		t.Errorf("validator still excluded after the exclusion was lifted")
+	}
+}
+
+func TestProposerExclusionSkipsProposer(t *testing.T) {
+	chain, engine := newBlockChain(4, true)
+	defer chain.Stop()
+	genesis := chain.Genesis()
+	policy := engine.config.ProposerPolicyAt(1)
+
+	proposer := validator.SelectProposer(engine.getOrderedValidators(0, genesis.Hash()), common.Address{}, 0, policy)
+	engine.ExcludeProposer(proposer, 1)
+	valSet := engine.getOrderedValidators(0, genesis.Hash())
+	replacement := validator.SelectProposer(valSet, common.Address{}, 0, policy)
+	if replacement == proposer || replacement == (common.Address{}) {
+		t.Errorf("proposer of block 1 = %v, want another validator than the excluded %v", replacement, proposer)
+	}
+	if _, val := valSet.GetByAddress(proposer); val == nil {
+		t.Errorf("excluded validator removed from the validator set")
+	}
+
+	// The exclusion doesn't apply after its last block
+	if next := validator.SelectProposer(engine.withProposerExclusions(engine.orderValidators(0, genesis.Hash()), 2), common.Address{}, 0, policy); next != proposer {
+		t.Errorf("proposer after the exclusion = %v, want %v", next, proposer)
+	}
+}
+
+func TestProposerExclusionLiftedOnCommit(t *testing.T) {
+	chain, engine := newBlockChain(4, true)
+	defer chain.Stop()
+	genesis := chain.Genesis()
+	block, err := engine.updateBlock(genesis.Header(), makeBlockWithoutSeal(chain, engine, genesis))
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	other := common.HexToAddress("0x01")
+	engine.ExcludeProposer(engine.Address(), 5)
+	engine.ExcludeProposer(other, 5)
+
+	// The excluded validator got its block committed, so the network doesn't honor its exclusion
+	engine.checkProposerExclusion(block)
+	if engine.proposerExclusions.isExcluded(engine.Address()) {
+		t.Errorf("exclusion of the proposer of a committed block not lifted")
+	}
+	if !engine.proposerExclusions.isExcludedAt(other, 5) {
+		t.Errorf("exclusion of another validator lifted")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// proposerFairnessWarnRatio is the share of its expected proposals below which a validator is warned about
+// in the proposer fairness report logged at the end of each epoch.
+const proposerFairnessWarnRatio = 0.5
+
+// ValidatorProposals is the number of blocks of an epoch that a validator proposed.
+type ValidatorProposals struct {
+	Address  common.Address `json:"address"`
+	Proposed uint64         `json:"proposed"` // The number of committed blocks of the epoch authored by the validator
+	Expected float64        `json:"expected"` // The number of blocks the validator would have proposed if they were shared equally
+}
+
+// ProposerFairness compares the number of blocks each validator of an epoch proposed with the number it
+// would have proposed if the blocks were shared equally, which the round robin proposer policies aim for.
+// Round changes skew the shares towards the validators after the proposers that fail.
+type ProposerFairness struct {
+	Epoch      uint64                `json:"epoch"`
+	FirstBlock uint64                `json:"firstBlock"`
+	LastBlock  uint64                `json:"lastBlock"`  // The last block counted, which is the head for the current epoch
+	Validators []*ValidatorProposals `json:"validators"` // In validator set order
+}
+
+// proposerShares counts the blocks authored by each of the given validators. Authors that aren't in the
+// validators are ignored.
+func proposerShares(validators []common.Address, authors []common.Address) []*ValidatorProposals {
+	shares := make([]*ValidatorProposals, len(validators))
+	byAddress := make(map[common.Address]*ValidatorProposals, len(validators))
+	expected := 0.0
+	if len(validators) > 0 {
+		expected = float64(len(authors)) / float64(len(validators))
+	}
+	for i, address := range validators {
+		shares[i] = &ValidatorProposals{Address: address, Expected: expected}
+		byAddress[address] = shares[i]
+	}
+	for _, author := range authors {
+		if share, ok := byAddress[author]; ok {
+			share.Proposed++
+		}
+	}
+	return shares
+}
+
+// proposerFairness returns the proposer fairness of the given epoch, counting its blocks up to the given
+// header, which must be in the epoch or after it.
+func (sb *Backend) proposerFairness(epoch uint64, head *types.Header) (*ProposerFairness, error) {
+	firstBlock, err := istanbul.GetEpochFirstBlockNumber(epoch, sb.config.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	if head.Number.Uint64() < firstBlock {
+		return nil, fmt.Errorf("epoch %d starts after the chain head %d", epoch, head.Number.Uint64())
+	}
+
+	// Walk back from the last block of the epoch that is in the chain
+	header := head
+	lastBlock := istanbul.GetEpochLastBlockNumber(epoch, sb.config.Epoch)
+	if header.Number.Uint64() > lastBlock {
+		header = sb.chain.GetHeaderByNumber(lastBlock)
+	} else {
+		lastBlock = header.Number.Uint64()
+	}
+	authors := make([]common.Address, 0, lastBlock-firstBlock+1)
+	for header != nil && header.Number.Uint64() >= firstBlock {
+		author, err := sb.Author(header)
+		if err != nil {
+			return nil, err
+		}
+		authors = append(authors, author)
+		if header.Number.Uint64() == firstBlock {
+			break
+		}
+		header = sb.chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+
+	// The validator set of an epoch is the one after the last block of the previous epoch
+	parent := sb.chain.GetHeader(header.ParentHash, firstBlock-1)
+	if parent == nil {
+		return nil, errUnknownBlock
+	}
+	valSet := sb.getValidators(parent.Number.Uint64(), parent.Hash())
+	validators := make([]common.Address, 0, valSet.Size())
+	for _, val := range valSet.List() {
+		validators = append(validators, val.Address())
+	}
+	if len(validators) == 0 {
+		return nil, errors.New("no validators in the epoch")
+	}
+
+	return &ProposerFairness{
+		Epoch:      epoch,
+		FirstBlock: firstBlock,
+		LastBlock:  lastBlock,
+		Validators: proposerShares(validators, authors),
+	}, nil
+}
+
+// reportProposerFairness logs the proposer fairness of the epoch ending with the given header, warning about
+// the validators that proposed less than proposerFairnessWarnRatio of their expected share.
+func (sb *Backend) reportProposerFairness(lastBlockOfEpoch *types.Header) {
+	epoch := istanbul.GetEpochNumber(lastBlockOfEpoch.Number.Uint64(), sb.config.Epoch)
+	fairness, err := sb.proposerFairness(epoch, lastBlockOfEpoch)
+	if err != nil {
+		sb.logger.Warn("Failed to compute the proposer fairness", "epoch", epoch, "err", err)
+		return
+	}
+	underproposing := 0
+	for _, share := range fairness.Validators {
+		if float64(share.Proposed) < proposerFairnessWarnRatio*share.Expected {
+			underproposing++
+			sb.logger.Warn("Validator proposed much less than its share of the epoch's blocks", "address", share.Address, "epoch", epoch,
+				"proposed", share.Proposed, "expected", share.Expected)
+		}
+	}
+	sb.logger.Info("Proposer fairness", "epoch", epoch, "blocks", fairness.LastBlock-fairness.FirstBlock+1, "validators", len(fairness.Validators),
+		"underproposing", underproposing)
+}
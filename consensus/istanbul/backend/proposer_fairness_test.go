@@ -0,0 +1,73 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestProposerShares(t *testing.T) {
+	a, b, c, outsider := common.HexToAddress("0xa"), common.HexToAddress("0xb"), common.HexToAddress("0xc"), common.HexToAddress("0xd")
+	have := proposerShares([]common.Address{a, b, c}, []common.Address{b, a, b, outsider, b, a})
+	want := []*ValidatorProposals{
+		{Address: a, Proposed: 2, Expected: 2},
+		{Address: b, Proposed: 3, Expected: 2},
+		{Address: c, Proposed: 0, Expected: 2},
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("shares mismatch: have %v, want %v", have, want)
+	}
+}
+
+func TestGetProposerFairness(t *testing.T) {
+	genesisCfg, nodeKeys := getGenesisAndKeys(1, true)
+	chain, engine, _ := newBlockChainWithKeys(false, common.Address{}, false, genesisCfg, nodeKeys[0])
+	defer chain.Stop()
+
+	block1, err := makeBlock(nodeKeys, chain, engine, chain.Genesis())
+	if err != nil {
+		t.Fatalf("failed to make block 1: %v", err)
+	}
+	if _, err := makeBlock(nodeKeys, chain, engine, block1); err != nil {
+		t.Fatalf("failed to make block 2: %v", err)
+	}
+
+	// The current epoch is counted up to the head
+	api := &API{chain: chain, istanbul: engine}
+	fairness, err := api.GetProposerFairness(nil)
+	if err != nil {
+		t.Fatalf("failed to get the proposer fairness: %v", err)
+	}
+	want := &ProposerFairness{
+		Epoch:      1,
+		FirstBlock: 1,
+		LastBlock:  2,
+		Validators: []*ValidatorProposals{{Address: engine.Address(), Proposed: 2, Expected: 2}},
+	}
+	if !reflect.DeepEqual(fairness, want) {
+		t.Errorf("fairness mismatch: have %+v, want %+v", fairness, want)
+	}
+
+	for _, epoch := range []uint64{0, 2} {
+		if _, err := api.GetProposerFairness(&epoch); err == nil {
+			t.Errorf("proposer fairness of epoch %d: expected an error", epoch)
+		}
+	}
+}
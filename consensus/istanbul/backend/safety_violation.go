@@ -0,0 +1,81 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SafetyViolation is a block with a valid aggregated seal that conflicts with a block of the local chain.
+type SafetyViolation struct {
+	Number          uint64      `json:"number"`
+	LocalHash       common.Hash `json:"localHash"`
+	ConflictingHash common.Hash `json:"conflictingHash"`
+	DetectedAt      time.Time   `json:"detectedAt"`
+}
+
+// checkSafetyViolation is called with a header whose aggregated seal was verified, and reports a safety violation
+// if the local chain has another block at its height: two blocks committed by a quorum at the same height mean that
+// the consensus failed. With HaltOnSafetyViolation, the header is rejected and the node halts.
+func (sb *Backend) checkSafetyViolation(chain consensus.ChainReader, header *types.Header) error {
+	number := header.Number.Uint64()
+	local := chain.GetHeaderByNumber(number)
+	if local == nil || local.Hash() == header.Hash() {
+		return nil
+	}
+	sb.safetyViolationsMeter.Mark(1)
+	sb.logger.Error("Received a block with a valid aggregated seal that conflicts with the local chain", "number", number, "local_hash", local.Hash(), "conflicting_hash", header.Hash())
+	if !sb.config.HaltOnSafetyViolation {
+		return nil
+	}
+	sb.haltOnSafetyViolation(&SafetyViolation{Number: number, LocalHash: local.Hash(), ConflictingHash: header.Hash(), DetectedAt: time.Now()})
+	return errSafetyViolation
+}
+
+// haltOnSafetyViolation records the first safety violation, after which no block is produced or accepted until
+// the node is restarted, and stops validating.
+func (sb *Backend) haltOnSafetyViolation(violation *SafetyViolation) {
+	sb.safetyViolationMu.Lock()
+	first := sb.safetyViolation == nil
+	if first {
+		sb.safetyViolation = violation
+	}
+	sb.safetyViolationMu.Unlock()
+	if !first {
+		return
+	}
+
+	sb.logger.Error("Halting on a safety violation, no block will be produced or accepted until the node is restarted", "number", violation.Number, "local_hash", violation.LocalHash, "conflicting_hash", violation.ConflictingHash)
+	// Headers are verified from the block import path, so don't wait for the core to stop here
+	go func() {
+		if err := sb.StopValidating(); err != nil && err != istanbul.ErrStoppedEngine {
+			sb.logger.Error("Failed to stop validating after a safety violation", "err", err)
+		}
+	}()
+}
+
+// SafetyViolation returns the safety violation this node halted on, or nil if it didn't.
+func (sb *Backend) SafetyViolation() *SafetyViolation {
+	sb.safetyViolationMu.RLock()
+	defer sb.safetyViolationMu.RUnlock()
+	return sb.safetyViolation
+}
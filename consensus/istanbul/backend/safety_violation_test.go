@@ -0,0 +1,85 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// localChain is a chain whose canonical header at the height of local is local.
+type localChain struct {
+	consensus.ChainReader
+	local *types.Header
+}
+
+func (c *localChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number == c.local.Number.Uint64() {
+		return c.local
+	}
+	return c.ChainReader.GetHeaderByNumber(number)
+}
+
+func TestSafetyViolation(t *testing.T) {
+	genesisCfg, nodeKeys := getGenesisAndKeys(4, true)
+	chain, engine, _ := newBlockChainWithKeys(false, common.Address{}, false, genesisCfg, nodeKeys[0])
+	defer chain.Stop()
+
+	block := makeBlockWithoutSeal(chain, engine, chain.Genesis())
+	header := block.Header()
+	if err := writeAggregatedSeal(header, signBlock(nodeKeys, block), false); err != nil {
+		t.Fatalf("failed to write aggregated seal: %v", err)
+	}
+	local := types.CopyHeader(header)
+	local.Time++
+
+	// The same block as the local one isn't a violation
+	if err := engine.verifyAggregatedSeals(&localChain{chain, header}, header, nil); err != nil {
+		t.Errorf("error mismatch for the local block: have %v, want nil", err)
+	}
+
+	// Without HaltOnSafetyViolation, a conflicting block is only reported
+	if err := engine.verifyAggregatedSeals(&localChain{chain, local}, header, nil); err != nil {
+		t.Errorf("error mismatch for a conflicting block without halting: have %v, want nil", err)
+	}
+	if violation := engine.SafetyViolation(); violation != nil {
+		t.Errorf("halted on %+v without HaltOnSafetyViolation", violation)
+	}
+
+	engine.config.HaltOnSafetyViolation = true
+	if err := engine.verifyAggregatedSeals(&localChain{chain, local}, header, nil); err != errSafetyViolation {
+		t.Errorf("error mismatch for a conflicting block: have %v, want %v", err, errSafetyViolation)
+	}
+	violation := engine.SafetyViolation()
+	if violation == nil || violation.Number != 1 || violation.LocalHash != local.Hash() || violation.ConflictingHash != header.Hash() {
+		t.Fatalf("violation = %+v, want block 1 %v conflicting with %v", violation, header.Hash().Hex(), local.Hash().Hex())
+	}
+
+	// Once halted, no block is accepted or produced
+	if err := engine.VerifyHeader(chain, header, false); err != errSafetyViolation {
+		t.Errorf("error mismatch for verifying a header: have %v, want %v", err, errSafetyViolation)
+	}
+	if err := engine.Prepare(chain, makeHeader(chain.Genesis(), engine.config)); err != errSafetyViolation {
+		t.Errorf("error mismatch for preparing a header: have %v, want %v", err, errSafetyViolation)
+	}
+	if err := engine.StartValidating(); err != errSafetyViolation {
+		t.Errorf("error mismatch for starting to validate: have %v, want %v", err, errSafetyViolation)
+	}
+}
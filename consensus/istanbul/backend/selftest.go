@@ -0,0 +1,121 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/crypto"
+	blscrypto "github.com/ethereum/go-ethereum/crypto/bls"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// selfTestDigest is the digest of the dummy proposal committed by the self test.
+var selfTestDigest = common.BytesToHash(crypto.Keccak256([]byte("istanbul self test")))
+
+// SelfTest is the outcome of signing a dummy COMMIT message and its committed seal with the configured keys.
+type SelfTest struct {
+	Address      common.Address                 `json:"address"`                // The validator address recovered from the signed message
+	BLSPublicKey *blscrypto.SerializedPublicKey `json:"blsPublicKey,omitempty"` // The BLS public key the seal was verified against
+	Success      bool                           `json:"success"`
+	Error        string                         `json:"error,omitempty"` // The first check that failed
+}
+
+// selfTest signs a dummy COMMIT message with the configured ECDSA and BLS keys, and checks that the message
+// signature recovers this node's address and public key, and that the committed seal verifies against the
+// given BLS public key, alone and aggregated. Without a BLS public key, the one registered for this validator
+// in the current validator set is used, which requires it to be elected.
+func (sb *Backend) selfTest(blsPublicKey *blscrypto.SerializedPublicKey) *SelfTest {
+	result := &SelfTest{}
+	if err := sb.runSelfTest(result, blsPublicKey); err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Success = true
+	}
+	return result
+}
+
+func (sb *Backend) runSelfTest(result *SelfTest, blsPublicKey *blscrypto.SerializedPublicKey) error {
+	sealData := istanbul.PrepareCommittedSeal(selfTestDigest, common.Big0)
+	committedSeal, err := sb.SignBLS(sealData, []byte{}, false)
+	if err != nil {
+		return fmt.Errorf("failed to sign the committed seal: %v", err)
+	}
+	subject, err := rlp.EncodeToBytes(&istanbul.CommittedSubject{
+		Subject:       &istanbul.Subject{View: &istanbul.View{Sequence: common.Big0, Round: common.Big0}, Digest: selfTestDigest},
+		CommittedSeal: committedSeal[:],
+	})
+	if err != nil {
+		return err
+	}
+	msg := &istanbul.Message{Code: istanbul.MsgCommit, Msg: subject, Address: sb.Address()}
+	if err := msg.Sign(sb.Sign); err != nil {
+		return fmt.Errorf("failed to sign the consensus message: %v", err)
+	}
+	payload, err := msg.Payload()
+	if err != nil {
+		return err
+	}
+
+	// Decode the message and recover its signer as its receivers would
+	decoded := new(istanbul.Message)
+	if err := decoded.FromPayload(payload, nil); err != nil {
+		return err
+	}
+	data, err := decoded.PayloadNoSig()
+	if err != nil {
+		return err
+	}
+	if result.Address, err = istanbul.GetSignatureAddress(data, decoded.Signature); err != nil {
+		return fmt.Errorf("failed to recover the consensus message signer: %v", err)
+	}
+	if result.Address != sb.Address() {
+		return fmt.Errorf("the consensus message signature recovers %s instead of %s", result.Address.Hex(), sb.Address().Hex())
+	}
+	if sb.publicKey == nil || crypto.PubkeyToAddress(*sb.publicKey) != result.Address {
+		return fmt.Errorf("the configured public key doesn't belong to %s", result.Address.Hex())
+	}
+
+	if blsPublicKey == nil {
+		block := sb.currentBlock()
+		_, val := sb.getValidators(block.Number().Uint64(), block.Hash()).GetByAddress(sb.ValidatorAddress())
+		if val == nil {
+			return fmt.Errorf("%s isn't elected, the BLS public key to verify the seal against must be given", sb.ValidatorAddress().Hex())
+		}
+		key := val.BLSPublicKey()
+		blsPublicKey = &key
+	}
+	result.BLSPublicKey = blsPublicKey
+
+	var commit istanbul.CommittedSubject
+	if err := decoded.Decode(&commit); err != nil {
+		return err
+	}
+	if err := blscrypto.VerifySignature(*blsPublicKey, sealData, []byte{}, commit.CommittedSeal, false); err != nil {
+		return fmt.Errorf("failed to verify the committed seal: %v", err)
+	}
+	aggregatedSeal, err := blscrypto.AggregateSignatures([][]byte{commit.CommittedSeal})
+	if err != nil {
+		return fmt.Errorf("failed to aggregate the committed seal: %v", err)
+	}
+	if err := blscrypto.VerifyAggregatedSignature([]blscrypto.SerializedPublicKey{*blsPublicKey}, sealData, []byte{}, aggregatedSeal, false); err != nil {
+		return fmt.Errorf("failed to verify the aggregated seal: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	blscrypto "github.com/ethereum/go-ethereum/crypto/bls"
+)
+
+func TestSelfTest(t *testing.T) {
+	genesisCfg, nodeKeys := getGenesisAndKeys(2, true)
+	chain, engine, _ := newBlockChainWithKeys(false, common.Address{}, false, genesisCfg, nodeKeys[0])
+	defer chain.Stop()
+
+	// An elected validator is checked against the BLS public key in the validator set
+	if result := engine.selfTest(nil); !result.Success || result.Address != engine.Address() || result.BLSPublicKey == nil {
+		t.Errorf("self test of an elected validator = %+v, want a success for %v", result, engine.Address().Hex())
+	}
+
+	otherBLSPrivateKey, _ := blscrypto.ECDSAToBLS(nodeKeys[1])
+	otherBLSPublicKey, _ := blscrypto.PrivateToPublic(otherBLSPrivateKey)
+	if result := engine.selfTest(&otherBLSPublicKey); result.Success || result.Error == "" {
+		t.Errorf("self test against another BLS public key = %+v, want a failure", result)
+	}
+
+	// A validator that isn't elected yet must give its BLS public key
+	key, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	engine.Authorize(address, address, &key.PublicKey, DecryptFn(key), SignFn(key), SignBLSFn(key))
+	if result := engine.selfTest(nil); result.Success || result.Address != address {
+		t.Errorf("self test of a validator that isn't elected = %+v, want a failure for %v", result, address.Hex())
+	}
+	blsPrivateKey, _ := blscrypto.ECDSAToBLS(key)
+	blsPublicKey, _ := blscrypto.PrivateToPublic(blsPrivateKey)
+	if result := engine.selfTest(&blsPublicKey); !result.Success || result.Address != address {
+		t.Errorf("self test with the BLS public key = %+v, want a success for %v", result, address.Hex())
+	}
+
+	// The configured public key must be the one of the signing key
+	engine.Authorize(address, address, &nodeKeys[1].PublicKey, DecryptFn(key), SignFn(key), SignBLSFn(key))
+	if result := engine.selfTest(&blsPublicKey); result.Success {
+		t.Errorf("self test with a mismatched public key = %+v, want a failure", result)
+	}
+}
@@ -156,6 +156,19 @@ func (s *Snapshot) validators() []istanbul.ValidatorData {
 	return validator.MapValidatorsToData(s.ValSet.List())
 }
 
+// weights returns the weights of the validators in the order of validators, or nil if the validator set isn't weighted
+func (s *Snapshot) weights() []uint64 {
+	if !s.ValSet.IsWeighted() {
+		return nil
+	}
+	validators := s.ValSet.List()
+	weights := make([]uint64, len(validators))
+	for i, val := range validators {
+		weights[i] = s.ValSet.GetWeight(val.Address())
+	}
+	return weights
+}
+
 type snapshotJSON struct {
 	Epoch  uint64      `json:"epoch"`
 	Number uint64      `json:"number"`
@@ -163,6 +176,7 @@ type snapshotJSON struct {
 
 	// for validator set
 	Validators []istanbul.ValidatorData `json:"validators"`
+	Weights    []uint64                 `json:"weights,omitempty"`
 }
 
 func (s *Snapshot) toJSONStruct() *snapshotJSON {
@@ -172,6 +186,7 @@ func (s *Snapshot) toJSONStruct() *snapshotJSON {
 		Number:     s.Number,
 		Hash:       s.Hash,
 		Validators: validators,
+		Weights:    s.weights(),
 	}
 }
 
@@ -186,6 +201,11 @@ func (s *Snapshot) UnmarshalJSON(b []byte) error {
 	s.Number = j.Number
 	s.Hash = j.Hash
 	s.ValSet = validator.NewSet(j.Validators)
+	weights, err := validator.MapDataToWeights(j.Validators, j.Weights)
+	if err != nil {
+		return err
+	}
+	s.ValSet.SetWeights(weights)
 	return nil
 }
 
@@ -383,3 +383,31 @@ func TestSaveAndLoad(t *testing.T) {
 		t.Errorf("validator set mismatch: have %v, want %v", snap1.ValSet, snap.ValSet)
 	}
 }
+
+func TestSaveAndLoadWeighted(t *testing.T) {
+	addresses := []common.Address{common.BytesToAddress([]byte("1234567894")), common.BytesToAddress([]byte("1234567895"))}
+	snap := &Snapshot{
+		Epoch:  5,
+		Number: 10,
+		Hash:   common.HexToHash("1234567890"),
+		ValSet: validator.NewSet([]istanbul.ValidatorData{{Address: addresses[0]}, {Address: addresses[1]}}),
+	}
+	snap.ValSet.SetWeights(map[common.Address]uint64{addresses[0]: 1, addresses[1]: 5})
+	db := rawdb.NewMemoryDatabase()
+	if err := snap.store(db); err != nil {
+		t.Fatalf("store snapshot failed: %v", err)
+	}
+
+	snap1, err := loadSnapshot(snap.Epoch, db, snap.Hash)
+	if err != nil {
+		t.Fatalf("load snapshot failed: %v", err)
+	}
+	if !snap1.ValSet.IsWeighted() {
+		t.Fatalf("loaded validator set isn't weighted")
+	}
+	for _, address := range addresses {
+		if have, want := snap1.ValSet.GetWeight(address), snap.ValSet.GetWeight(address); have != want {
+			t.Errorf("weight mismatch for %v: have %d, want %d", address, have, want)
+		}
+	}
+}
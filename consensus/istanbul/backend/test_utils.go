@@ -12,7 +12,6 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/consensustest"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/consensus/istanbul/backend/backendtest"
-	istanbulCore "github.com/ethereum/go-ethereum/consensus/istanbul/core"
 	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
 	"github.com/ethereum/go-ethereum/contract_comm"
 	"github.com/ethereum/go-ethereum/core"
@@ -180,6 +179,25 @@ func makeBlock(keys []*ecdsa.PrivateKey, chain *core.BlockChain, engine *Backend
 	return block, nil
 }
 
+// makeBlockAtRound makes a block committed in the given consensus round and inserts it into the chain. It
+// doesn't go through the sealing procedure, in which the engine would commit the block itself in round 0.
+func makeBlockAtRound(keys []*ecdsa.PrivateKey, chain *core.BlockChain, engine *Backend, parent *types.Block, round uint64) (*types.Block, error) {
+	block := makeBlockWithoutSeal(chain, engine, parent)
+	block, err := engine.updateBlock(parent.Header(), block)
+	if err != nil {
+		return nil, err
+	}
+	header := block.Header()
+	if err := writeAggregatedSeal(header, signBlockAtRound(keys, block, round), false); err != nil {
+		return nil, err
+	}
+	block = block.WithSeal(header)
+	if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
 func makeBlockWithoutSeal(chain *core.BlockChain, engine *Backend, parent *types.Block) *types.Block {
 	header := makeHeader(parent, engine.config)
 	engine.Prepare(chain, header)
@@ -266,7 +284,7 @@ func SignFn(key *ecdsa.PrivateKey) istanbul.SignerFn {
 	}
 
 	return func(_ accounts.Account, mimeType string, data []byte) ([]byte, error) {
-		return crypto.Sign(crypto.Keccak256(data), key)
+		return crypto.Sign(accounts.DataHash(mimeType, data), key)
 	}
 }
 
@@ -304,13 +322,18 @@ func SignBLSFn(key *ecdsa.PrivateKey) istanbul.BLSSignerFn {
 // this will return an aggregate sig by the BLS keys corresponding to the `keys` array over the
 // block's hash, on consensus round 0, without a composite hasher
 func signBlock(keys []*ecdsa.PrivateKey, block *types.Block) types.IstanbulAggregatedSeal {
+	return signBlockAtRound(keys, block, 0)
+}
+
+// signBlockAtRound returns the aggregate sig like signBlock, on the given consensus round.
+func signBlockAtRound(keys []*ecdsa.PrivateKey, block *types.Block, consensusRound uint64) types.IstanbulAggregatedSeal {
 	extraData := []byte{}
 	useComposite := false
-	round := big.NewInt(0)
+	round := new(big.Int).SetUint64(consensusRound)
 	headerHash := block.Header().Hash()
 	signatures := make([][]byte, len(keys))
 
-	msg := istanbulCore.PrepareCommittedSeal(headerHash, round)
+	msg := istanbul.PrepareCommittedSeal(headerHash, round)
 
 	for i, key := range keys {
 		signFn := SignBLSFn(key)
@@ -0,0 +1,113 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// newValidatorGraceCredits returns, for each validator of the epoch, the number of blocks of the uptime tally
+// window that are credited to it on top of its accumulated score tally, because they are within the first
+// NewValidatorGraceBlocks blocks after it entered the validator set. Epochs that end before
+// NewValidatorGraceForkBlock get no credits.
+//
+// Validators only enter the set at the first block of an epoch, and NewValidatorGraceBlocks is smaller than
+// the epoch, so only the validators added by the last block of the previous epoch can be within their grace
+// window. Each block of the grace window they weren't credited for, i.e. that is not preceded by one of their
+// signatures within the lookback window, is credited, so that it doesn't lower their uptime.
+func (sb *Backend) newValidatorGraceCredits(epoch uint64, valSet []istanbul.Validator) ([]uint64, error) {
+	credits := make([]uint64, len(valSet))
+	grace := sb.config.NewValidatorGraceBlocksAt(istanbul.GetEpochLastBlockNumber(epoch, sb.EpochSize()))
+	if grace == 0 {
+		return credits, nil
+	}
+
+	window := sb.LookbackWindow()
+	epochFirstBlock, err := istanbul.GetEpochFirstBlockNumber(epoch, sb.EpochSize())
+	if err != nil {
+		return nil, err
+	}
+	tallyFirstBlock := istanbul.GetValScoreTallyFirstBlockNumber(epoch, sb.EpochSize(), window)
+	graceLastBlock := epochFirstBlock + grace - 1
+	if tallyLastBlock := istanbul.GetValScoreTallyLastBlockNumber(epoch, sb.EpochSize()); graceLastBlock > tallyLastBlock {
+		graceLastBlock = tallyLastBlock
+	}
+	if graceLastBlock < tallyFirstBlock {
+		return credits, nil
+	}
+
+	previousEpochLastHeader := sb.chain.GetHeaderByNumber(epochFirstBlock - 1)
+	if previousEpochLastHeader == nil {
+		return nil, errUnknownBlock
+	}
+	extra, err := types.ExtractIstanbulExtra(previousEpochLastHeader)
+	if err != nil {
+		return nil, err
+	}
+	added := make(map[common.Address]bool, len(extra.AddedValidators))
+	for _, address := range extra.AddedValidators {
+		added[address] = true
+	}
+
+	// The parent aggregated seal of each block records the signers of its parent. The seal of the epoch's
+	// first block is for the previous epoch's validator set, so it isn't part of the uptime.
+	bitmaps := make(map[uint64]*big.Int, graceLastBlock-epochFirstBlock)
+	for number := epochFirstBlock + 1; number <= graceLastBlock; number++ {
+		header := sb.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, fmt.Errorf("missing header %d to credit the uptime grace of new validators", number)
+		}
+		extra, err := types.ExtractIstanbulExtra(header)
+		if err != nil {
+			return nil, err
+		}
+		bitmaps[number] = extra.ParentAggregatedSeal.Bitmap
+	}
+
+	for i, val := range valSet {
+		if added[val.Address()] {
+			credits[i] = uptimeGraceCredits(bitmaps, i, epochFirstBlock+1, tallyFirstBlock, graceLastBlock, window)
+		}
+	}
+	return credits, nil
+}
+
+// uptimeGraceCredits returns the number of blocks of the uptime tally window, from tallyFirstBlock to
+// graceLastBlock, that the validator at the given index wasn't credited for, given the parent seal bitmaps of
+// the blocks from firstBlock to graceLastBlock. It follows how the accumulated uptime is tallied: a block is
+// credited if the validator signed one of the window blocks preceding it.
+func uptimeGraceCredits(bitmaps map[uint64]*big.Int, index int, firstBlock, tallyFirstBlock, graceLastBlock, window uint64) uint64 {
+	credits := uint64(0)
+	lastSignedBlock := uint64(0)
+	for number := firstBlock; number <= graceLastBlock; number++ {
+		if bitmap := bitmaps[number]; bitmap != nil && bitmap.Bit(index) == 1 {
+			lastSignedBlock = number - 1
+		}
+		if number < tallyFirstBlock {
+			continue
+		}
+		if lastSignedBlock < number-window {
+			credits++
+		}
+	}
+	return credits
+}
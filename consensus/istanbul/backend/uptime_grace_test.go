@@ -0,0 +1,67 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestUptimeGraceCredits(t *testing.T) {
+	// Validator 0 only signed block 4, validator 1 signed every block and validator 2 none, bit i of a bitmap is validator i
+	bitmaps := map[uint64]*big.Int{
+		2: big.NewInt(2),
+		3: big.NewInt(2),
+		4: big.NewInt(2),
+		5: big.NewInt(3),
+		6: big.NewInt(2),
+	}
+	// With a lookback window of 2 blocks, blocks 3 to 6 of the epoch starting at block 1 are tallied
+	for index, want := range []uint64{2, 0, 4} {
+		if have := uptimeGraceCredits(bitmaps, index, 2, 3, 6, 2); have != want {
+			t.Errorf("uptimeGraceCredits of validator %d = %d, want %d", index, have, want)
+		}
+	}
+	// Blocks after the grace window aren't credited
+	if have := uptimeGraceCredits(bitmaps, 2, 2, 3, 4, 2); have != 2 {
+		t.Errorf("uptimeGraceCredits with a shorter grace window = %d, want 2", have)
+	}
+}
+
+func TestNewValidatorGraceCredits(t *testing.T) {
+	chain, engine := newBlockChain(1, true)
+	defer chain.Stop()
+	valSet := engine.GetValidators(big.NewInt(0), chain.Genesis().Hash())
+
+	// The grace window of the first epoch ends before its tally window starts
+	engine.config.NewValidatorGraceBlocks = engine.config.LookbackWindow
+	credits, err := engine.newValidatorGraceCredits(1, valSet)
+	if err != nil || len(credits) != len(valSet) || credits[0] != 0 {
+		t.Errorf("newValidatorGraceCredits = %v, %v, want no credits", credits, err)
+	}
+	// Without the blocks of the grace window, the credits can't be computed
+	engine.config.NewValidatorGraceBlocks = engine.config.LookbackWindow + 2
+	if _, err := engine.newValidatorGraceCredits(1, valSet); err == nil {
+		t.Errorf("expected an error without the headers of the grace window")
+	}
+	// Before the fork block, there is no grace window to credit
+	engine.config.NewValidatorGraceForkBlock = engine.EpochSize() + 1
+	credits, err = engine.newValidatorGraceCredits(1, valSet)
+	if err != nil || len(credits) != len(valSet) || credits[0] != 0 {
+		t.Errorf("newValidatorGraceCredits before the fork = %v, %v, want no credits", credits, err)
+	}
+}
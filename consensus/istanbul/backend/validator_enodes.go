@@ -0,0 +1,159 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// ValidatorEnode is an entry of the validator enode table as exported to and imported from another node.
+type ValidatorEnode struct {
+	Address common.Address `json:"address"`
+	Enode   string         `json:"enode"`
+	Version uint           `json:"version"` // The announce version the enode was learned with
+}
+
+// ValidatorEnodesImport reports the outcome of importing validator enodes.
+type ValidatorEnodesImport struct {
+	Imported []common.Address  `json:"imported"` // The entries of validators in the validator connection set
+	Inactive []common.Address  `json:"inactive"` // The entries of other validators, which are stored but not peered with, and pruned with the table unless the validators join the connection set
+	Rejected map[string]string `json:"rejected"` // The reason each rejected entry was rejected, keyed by address
+}
+
+// ExportValidatorEnodes returns the entries of the validator enode table that have a known enode.
+func (sb *Backend) ExportValidatorEnodes() ([]*ValidatorEnode, error) {
+	valEnodeEntries, err := sb.valEnodeTable.GetValEnodes(nil)
+	if err != nil {
+		return nil, err
+	}
+	exported := make([]*ValidatorEnode, 0, len(valEnodeEntries))
+	for address, entry := range valEnodeEntries {
+		if entry.Node == nil || entry.Version == 0 {
+			continue
+		}
+		exported = append(exported, &ValidatorEnode{Address: address, Enode: entry.Node.URLv4(), Version: entry.Version})
+	}
+	return exported, nil
+}
+
+// ImportValidatorEnodes seeds the validator enode table with entries exported by another node. The
+// entries are checked against this node's announce data like the entries learned through announce:
+// an entry is rejected if its version is in the future, or older than the version of the entry already
+// in the table or of the validator's version certificate, since its enode is then known to be outdated.
+// An entry is also rejected if it has the same version as the entry in the table but another enode.
+func (sb *Backend) ImportValidatorEnodes(entries []*ValidatorEnode) (*ValidatorEnodesImport, error) {
+	validatorConnSet, err := sb.RetrieveValidatorConnSet()
+	if err != nil {
+		return nil, err
+	}
+	existingEntries, err := sb.valEnodeTable.GetValEnodes(nil)
+	if err != nil {
+		return nil, err
+	}
+	certifiedVersions := make(map[common.Address]uint)
+	versionCertificates, err := sb.versionCertificateTable.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, versionCertificate := range versionCertificates {
+		certifiedVersions[versionCertificate.Address] = versionCertificate.Version
+	}
+
+	result := &ValidatorEnodesImport{Rejected: make(map[string]string)}
+	now := getTimestamp()
+	var accepted []*istanbul.AddressEntry
+	for _, entry := range entries {
+		node, err := parseImportedValidatorEnode(entry, sb.ValidatorAddress(), now, existingEntries[entry.Address], certifiedVersions)
+		if err != nil {
+			result.Rejected[entry.Address.Hex()] = err.Error()
+			continue
+		}
+		accepted = append(accepted, &istanbul.AddressEntry{Address: entry.Address, Node: node, Version: entry.Version})
+		if validatorConnSet[entry.Address] {
+			result.Imported = append(result.Imported, entry.Address)
+		} else {
+			result.Inactive = append(result.Inactive, entry.Address)
+		}
+	}
+
+	// Peers are only added for the validators in the validator connection set
+	if err := sb.valEnodeTable.UpsertVersionAndEnode(accepted); err != nil {
+		return nil, err
+	}
+	sb.logger.Info("Imported validator enodes", "imported", len(result.Imported), "inactive", len(result.Inactive), "rejected", len(result.Rejected))
+	return result, nil
+}
+
+// parseImportedValidatorEnode returns the enode of an imported validator enode entry, or why the entry
+// must not be stored.
+func parseImportedValidatorEnode(entry *ValidatorEnode, ownAddress common.Address, now uint, existing *istanbul.AddressEntry, certifiedVersions map[common.Address]uint) (*enode.Node, error) {
+	if entry.Address == ownAddress {
+		return nil, errors.New("entry is for this node's own address")
+	}
+	node, err := enode.ParseV4(entry.Enode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid enode: %v", err)
+	}
+	if entry.Version == 0 {
+		return nil, errors.New("missing version")
+	}
+	// Announce versions are the unix timestamp at which the validator created the announce
+	if entry.Version > now {
+		return nil, fmt.Errorf("version %d is in the future", entry.Version)
+	}
+	if existing != nil {
+		if entry.Version < existing.Version {
+			return nil, fmt.Errorf("version %d is older than the known version %d", entry.Version, existing.Version)
+		}
+		if entry.Version == existing.Version && existing.Node != nil && existing.Node.String() != node.String() {
+			return nil, fmt.Errorf("enode conflicts with the known enode of version %d", entry.Version)
+		}
+	}
+	if certifiedVersion, ok := certifiedVersions[entry.Address]; ok && entry.Version < certifiedVersion {
+		return nil, fmt.Errorf("version %d is older than the certified version %d", entry.Version, certifiedVersion)
+	}
+	return node, nil
+}
+
+// ValidatorEnodeDBMigration reports the outcome of moving the validator enode table to a new database.
+type ValidatorEnodeDBMigration struct {
+	Path    string `json:"path"`
+	Copied  int    `json:"copied"`
+	Skipped int    `json:"skipped"` // The entries that couldn't be decoded, which are left out
+}
+
+// MigrateValidatorEnodeDB moves the validator enode table to a new database at the given absolute path,
+// e.g. on another disk, without stopping the node. The announce protocol keeps using the table, and only
+// waits while the entries are copied. If the new database can't be opened or written, the current one is
+// kept. The node opens the database at ValidatorEnodeDBPath on startup, so that setting must be updated
+// for the new database to be used after a restart.
+func (sb *Backend) MigrateValidatorEnodeDB(path string) (*ValidatorEnodeDBMigration, error) {
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("the path of the new validator enode database must be absolute: %q", path)
+	}
+	copied, skipped, err := sb.valEnodeTable.Migrate(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ValidatorEnodeDBMigration{Path: path, Copied: copied, Skipped: skipped}, nil
+}
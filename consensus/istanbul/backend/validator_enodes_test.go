@@ -0,0 +1,96 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"net"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func newTestEnodeURL(t *testing.T) string {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return enode.NewV4(&key.PublicKey, net.IPv4(127, 0, 0, 1), 30303, 30303).URLv4()
+}
+
+func TestImportValidatorEnodes(t *testing.T) {
+	chain, engine := newBlockChain(3, true)
+	defer chain.Stop()
+
+	validatorConnSet, err := engine.RetrieveValidatorConnSet()
+	if err != nil {
+		t.Fatalf("failed to retrieve validator connection set: %v", err)
+	}
+	var validator common.Address
+	for address := range validatorConnSet {
+		if address != engine.ValidatorAddress() {
+			validator = address
+			break
+		}
+	}
+	nonValidator := common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+	now := getTimestamp()
+
+	result, err := engine.ImportValidatorEnodes([]*ValidatorEnode{
+		{Address: validator, Enode: newTestEnodeURL(t), Version: now - 10},
+		{Address: nonValidator, Enode: newTestEnodeURL(t), Version: now - 10},
+		{Address: engine.ValidatorAddress(), Enode: newTestEnodeURL(t), Version: now - 10},
+		{Address: common.HexToAddress("0x02"), Enode: "enode://invalid", Version: now - 10},
+		{Address: common.HexToAddress("0x03"), Enode: newTestEnodeURL(t), Version: now + 3600},
+	})
+	if err != nil {
+		t.Fatalf("failed to import validator enodes: %v", err)
+	}
+	if len(result.Imported) != 1 || result.Imported[0] != validator {
+		t.Errorf("imported = %v, want [%v]", result.Imported, validator.Hex())
+	}
+	if len(result.Inactive) != 1 || result.Inactive[0] != nonValidator {
+		t.Errorf("inactive = %v, want [%v]", result.Inactive, nonValidator.Hex())
+	}
+	for _, address := range []common.Address{engine.ValidatorAddress(), common.HexToAddress("0x02"), common.HexToAddress("0x03")} {
+		if _, ok := result.Rejected[address.Hex()]; !ok {
+			t.Errorf("entry for %v not rejected", address.Hex())
+		}
+	}
+
+	// An older entry than the stored one is outdated
+	result, err = engine.ImportValidatorEnodes([]*ValidatorEnode{{Address: validator, Enode: newTestEnodeURL(t), Version: now - 20}})
+	if err != nil {
+		t.Fatalf("failed to import validator enodes: %v", err)
+	}
+	if _, ok := result.Rejected[validator.Hex()]; !ok {
+		t.Errorf("outdated entry for %v not rejected", validator.Hex())
+	}
+
+	exported, err := engine.ExportValidatorEnodes()
+	if err != nil {
+		t.Fatalf("failed to export validator enodes: %v", err)
+	}
+	exportedAddresses := make(map[common.Address]bool)
+	for _, entry := range exported {
+		exportedAddresses[entry.Address] = true
+	}
+	if len(exported) != 2 || !exportedAddresses[validator] || !exportedAddresses[nonValidator] {
+		t.Errorf("exported %d entries, want the entries of %v and %v", len(exported), validator.Hex(), nonValidator.Hex())
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// maxValidatorSetChanges is the number of epoch transitions whose validator set changes are kept.
+const maxValidatorSetChanges = 16
+
+// ValidatorSetChange lists the validators that joined and left the validator set when Epoch started.
+type ValidatorSetChange struct {
+	Epoch   uint64           `json:"epoch"`
+	Block   uint64           `json:"block"` // The last block of the previous epoch
+	Added   []common.Address `json:"added"`
+	Removed []common.Address `json:"removed"`
+	Size    int              `json:"size"` // The size of the new validator set
+}
+
+// diffValidatorSets returns the validators of next that aren't in prev, and those of prev that aren't in next.
+func diffValidatorSets(prev, next []istanbul.Validator) (added, removed []common.Address) {
+	prevAddresses := make(map[common.Address]bool, len(prev))
+	for _, val := range prev {
+		prevAddresses[val.Address()] = true
+	}
+	nextAddresses := make(map[common.Address]bool, len(next))
+	for _, val := range next {
+		nextAddresses[val.Address()] = true
+		if !prevAddresses[val.Address()] {
+			added = append(added, val.Address())
+		}
+	}
+	for _, val := range prev {
+		if !nextAddresses[val.Address()] {
+			removed = append(removed, val.Address())
+		}
+	}
+	return added, removed
+}
+
+// recordValidatorSetChange logs and keeps the changes from the validator set of the epoch ending with
+// lastBlockOfEpoch to nextValSet, and sends them to the subscribers.
+func (sb *Backend) recordValidatorSetChange(lastBlockOfEpoch *types.Block, nextValSet istanbul.ValidatorSet) {
+	number := lastBlockOfEpoch.NumberU64()
+	var prevVals []istanbul.Validator
+	if number > 0 {
+		prevVals = sb.getValidators(number-1, lastBlockOfEpoch.ParentHash()).List()
+	}
+	added, removed := diffValidatorSets(prevVals, nextValSet.List())
+	change := &ValidatorSetChange{
+		Epoch:   istanbul.GetEpochNumber(number+1, sb.config.Epoch),
+		Block:   number,
+		Added:   added,
+		Removed: removed,
+		Size:    nextValSet.Size(),
+	}
+	sb.logger.Info("Validator set changed at epoch boundary", "epoch", change.Epoch, "number", number, "added", added, "removed", removed, "size", change.Size)
+
+	sb.validatorSetChangesMu.Lock()
+	// A reorg can make the same block the chain head again, in which case its change replaces the recorded one
+	replaced := false
+	for i, recorded := range sb.validatorSetChanges {
+		if recorded.Block == number {
+			sb.validatorSetChanges[i] = change
+			replaced = true
+		}
+	}
+	if !replaced {
+		sb.validatorSetChanges = append(sb.validatorSetChanges, change)
+		if len(sb.validatorSetChanges) > maxValidatorSetChanges {
+			sb.validatorSetChanges = sb.validatorSetChanges[1:]
+		}
+	}
+	sb.validatorSetChangesMu.Unlock()
+
+	sb.validatorSetChangeFeed.Send(change)
+}
+
+// GetValidatorSetChanges returns the validator set changes of up to the given number of most recent epoch
+// transitions, oldest first.
+func (sb *Backend) GetValidatorSetChanges(epochs uint64) []*ValidatorSetChange {
+	sb.validatorSetChangesMu.RLock()
+	defer sb.validatorSetChangesMu.RUnlock()
+	if epochs > uint64(len(sb.validatorSetChanges)) {
+		epochs = uint64(len(sb.validatorSetChanges))
+	}
+	return append([]*ValidatorSetChange(nil), sb.validatorSetChanges[uint64(len(sb.validatorSetChanges))-epochs:]...)
+}
+
+// SubscribeValidatorSetChanges subscribes a channel to the validator set changes at each epoch transition.
+func (sb *Backend) SubscribeValidatorSetChanges(ch chan<- *ValidatorSetChange) event.Subscription {
+	return sb.validatorSetChangeScope.Track(sb.validatorSetChangeFeed.Subscribe(ch))
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
+	blscrypto "github.com/ethereum/go-ethereum/crypto/bls"
+)
+
+func TestDiffValidatorSets(t *testing.T) {
+	newValidators := func(addresses ...string) []istanbul.Validator {
+		var vals []istanbul.Validator
+		for _, address := range addresses {
+			vals = append(vals, validator.New(common.HexToAddress(address), blscrypto.SerializedPublicKey{}))
+		}
+		return vals
+	}
+
+	added, removed := diffValidatorSets(newValidators("0x01", "0x02", "0x03"), newValidators("0x02", "0x03", "0x04", "0x05"))
+	if len(added) != 2 || added[0] != common.HexToAddress("0x04") || added[1] != common.HexToAddress("0x05") {
+		t.Errorf("added = %v, want [0x04 0x05]", added)
+	}
+	if len(removed) != 1 || removed[0] != common.HexToAddress("0x01") {
+		t.Errorf("removed = %v, want [0x01]", removed)
+	}
+
+	added, removed = diffValidatorSets(newValidators("0x01", "0x02"), newValidators("0x02", "0x01"))
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("reordered validator set reported as changed: added %v, removed %v", added, removed)
+	}
+}
+
+func TestRecordValidatorSetChange(t *testing.T) {
+	chain, engine := newBlockChain(4, true)
+	defer chain.Stop()
+
+	ch := make(chan *ValidatorSetChange, 1)
+	sub := engine.SubscribeValidatorSetChanges(ch)
+	defer sub.Unsubscribe()
+
+	genesis := chain.Genesis()
+	valSet := engine.getValidators(0, genesis.Hash())
+	engine.recordValidatorSetChange(genesis, valSet)
+
+	change := <-ch
+	if change.Block != 0 || change.Epoch != 1 || change.Size != 4 || len(change.Added) != 4 || len(change.Removed) != 0 {
+		t.Errorf("change = %+v, want the 4 validators added at epoch 1", change)
+	}
+	if changes := engine.GetValidatorSetChanges(10); len(changes) != 1 || changes[0] != change {
+		t.Errorf("got %d validator set changes, want 1", len(changes))
+	}
+
+	// Recording the same block again replaces its change
+	engine.recordValidatorSetChange(genesis, valSet)
+	<-ch
+	if changes := engine.GetValidatorSetChanges(10); len(changes) != 1 {
+		t.Errorf("got %d validator set changes after recording the same block, want 1", len(changes))
+	}
+	if changes := engine.GetValidatorSetChanges(0); len(changes) != 0 {
+		t.Errorf("got %d validator set changes for 0 epochs, want 0", len(changes))
+	}
+}
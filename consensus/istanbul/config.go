@@ -17,7 +17,16 @@
 package istanbul
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 )
 
@@ -27,61 +36,407 @@ const (
 	RoundRobin ProposerPolicy = iota
 	Sticky
 	ShuffledRoundRobin
+	StickyWithFallback
+	WeightedRoundRobin
+)
+
+// proposerPolicyNames is the registry of the known proposer policies, guarded by proposerPolicyNamesMu
+// since custom policies may be registered with RegisterProposerPolicy.
+var (
+	proposerPolicyNames = map[ProposerPolicy]string{
+		RoundRobin:         "RoundRobin",
+		Sticky:             "Sticky",
+		ShuffledRoundRobin: "ShuffledRoundRobin",
+		StickyWithFallback: "StickyWithFallback",
+		WeightedRoundRobin: "WeightedRoundRobin",
+	}
+	proposerPolicyNamesMu sync.RWMutex
+)
+
+// proposerPolicyName returns the registered name of the proposer policy, and whether it is registered.
+func proposerPolicyName(pp ProposerPolicy) (string, bool) {
+	proposerPolicyNamesMu.RLock()
+	defer proposerPolicyNamesMu.RUnlock()
+	name, ok := proposerPolicyNames[pp]
+	return name, ok
+}
+
+// String returns the name of the proposer policy.
+func (pp ProposerPolicy) String() string {
+	if name, ok := proposerPolicyName(pp); ok {
+		return name
+	}
+	return fmt.Sprintf("ProposerPolicy(%d)", uint64(pp))
+}
+
+// MarshalText implements encoding.TextMarshaler, so that the policy is written by name.
+func (pp ProposerPolicy) MarshalText() ([]byte, error) {
+	if _, ok := proposerPolicyName(pp); !ok {
+		return nil, fmt.Errorf("unknown proposer policy %d", uint64(pp))
+	}
+	return []byte(pp.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Both the policy name and,
+// for backwards compatibility with existing config files, its numeric value are accepted.
+func (pp *ProposerPolicy) UnmarshalText(text []byte) error {
+	proposerPolicyNamesMu.RLock()
+	for policy, name := range proposerPolicyNames {
+		if string(text) == name {
+			proposerPolicyNamesMu.RUnlock()
+			*pp = policy
+			return nil
+		}
+	}
+	proposerPolicyNamesMu.RUnlock()
+	if n, err := strconv.ParseUint(string(text), 10, 64); err == nil {
+		if _, ok := proposerPolicyName(ProposerPolicy(n)); ok {
+			*pp = ProposerPolicy(n)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown proposer policy %q, valid options are %s", text, validProposerPolicyNames())
+}
+
+func validProposerPolicyNames() string {
+	proposerPolicyNamesMu.RLock()
+	defer proposerPolicyNamesMu.RUnlock()
+	policies := make([]ProposerPolicy, 0, len(proposerPolicyNames))
+	for policy := range proposerPolicyNames {
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i] < policies[j] })
+	names := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		names = append(names, proposerPolicyNames[policy])
+	}
+	return strings.Join(names, ", ")
+}
+
+// RegisterProposerPolicy registers the name of a custom proposer policy, so that it can be configured
+// by name and passes Validate. It is called by validator.RegisterProposerSelector, which also registers
+// the selector of the policy.
+func RegisterProposerPolicy(policy ProposerPolicy, name string) error {
+	proposerPolicyNamesMu.Lock()
+	defer proposerPolicyNamesMu.Unlock()
+	if existing, ok := proposerPolicyNames[policy]; ok {
+		return fmt.Errorf("proposer policy %d is already registered as %s", uint64(policy), existing)
+	}
+	for existing, existingName := range proposerPolicyNames {
+		if name == existingName {
+			return fmt.Errorf("proposer policy name %s is already registered for policy %d", name, uint64(existing))
+		}
+	}
+	proposerPolicyNames[policy] = name
+	return nil
+}
+
+// ShuffleSeedSource is the source of the seed with which the ShuffledRoundRobin policy shuffles the
+// validator set. The seed is taken at the last block of the previous epoch, so the order is fixed for
+// a whole epoch. The source is set in the genesis, since validators using different sources would
+// disagree on the proposers.
+//
+// RandomnessBeaconSeed reads the randomness that the Random contract stored for that block, i.e. the
+// value revealed in the block's Randomness field, while EpochBlockHashSeed uses the hash of the block's
+// header.
+type ShuffleSeedSource uint64
+
+const (
+	// RandomnessBeaconSeed seeds the shuffle with the randomness beacon value of the Random contract
+	RandomnessBeaconSeed ShuffleSeedSource = iota
+	// EpochBlockHashSeed seeds the shuffle with the hash of the block
+	EpochBlockHashSeed
+)
+
+var shuffleSeedSourceNames = map[ShuffleSeedSource]string{
+	RandomnessBeaconSeed: "RandomnessBeacon",
+	EpochBlockHashSeed:   "EpochBlockHash",
+}
+
+// String returns the name of the shuffle seed source.
+func (s ShuffleSeedSource) String() string {
+	if name, ok := shuffleSeedSourceNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("ShuffleSeedSource(%d)", uint64(s))
+}
+
+// EquivocationPolicy is how a validator responds to an equivocation of the proposer, i.e. to two
+// conflicting proposals of the proposer of the same round. The evidence is recorded with either policy.
+type EquivocationPolicy uint64
+
+const (
+	// KeepFirstProposal ignores the conflicting proposal and continues with the first one received
+	KeepFirstProposal EquivocationPolicy = iota
+	// RoundChangeOnEquivocation moves to the next round right away, away from the equivocating proposer
+	RoundChangeOnEquivocation
 )
 
+var equivocationPolicyNames = map[EquivocationPolicy]string{
+	KeepFirstProposal:         "KeepFirstProposal",
+	RoundChangeOnEquivocation: "RoundChangeOnEquivocation",
+}
+
+// String returns the name of the equivocation policy.
+func (p EquivocationPolicy) String() string {
+	if name, ok := equivocationPolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("EquivocationPolicy(%d)", uint64(p))
+}
+
 type Config struct {
-	RequestTimeout              uint64         `toml:",omitempty"` // The timeout for each Istanbul round in milliseconds.
-	TimeoutBackoffFactor        uint64         `toml:",omitempty"` // Timeout at subsequent rounds is: RequestTimeout + 2**round * TimeoutBackoffFactor (in milliseconds)
-	MinResendRoundChangeTimeout uint64         `toml:",omitempty"` // Minimum interval with which to resend RoundChange messages for same round
-	MaxResendRoundChangeTimeout uint64         `toml:",omitempty"` // Maximum interval with which to resend RoundChange messages for same round
-	BlockPeriod                 uint64         `toml:",omitempty"` // Default minimum difference between two consecutive block's timestamps in second
-	ProposerPolicy              ProposerPolicy `toml:",omitempty"` // The policy for proposer selection
-	Epoch                       uint64         `toml:",omitempty"` // The number of blocks after which to checkpoint and reset the pending votes
-	LookbackWindow              uint64         `toml:",omitempty"` // The window of blocks in which a validator is forgived from voting
-	ReplicaStateDBPath          string         `toml:",omitempty"` // The location for the validator replica state DB
-	ValidatorEnodeDBPath        string         `toml:",omitempty"` // The location for the validator enodes DB
-	VersionCertificateDBPath    string         `toml:",omitempty"` // The location for the signed announce version DB
-	RoundStateDBPath            string         `toml:",omitempty"` // The location for the round states DB
-	Validator                   bool           `toml:",omitempty"` // Specified if this node is configured to validate  (specifically if --mine command line is set)
-	Replica                     bool           `toml:",omitempty"` // Specified if this node is configured to be a replica
+	RequestTimeout                 uint64             `toml:",omitempty"` // The timeout for each Istanbul round in milliseconds.
+	TimeoutBackoffFactor           uint64             `toml:",omitempty"` // Timeout at subsequent rounds is: RequestTimeout + 2**round * TimeoutBackoffFactor (in milliseconds)
+	MaxRequestTimeout              uint64             `toml:",omitempty"` // Maximum timeout at subsequent rounds in milliseconds. Ignored if zero or smaller than RequestTimeout
+	ProposalTimeout                uint64             `toml:",omitempty"` // Time (in milliseconds) within which the proposer must send its proposal, after which validators move to the next round without waiting for the round timeout. Must be smaller than RequestTimeout. Zero disables it
+	MinResendRoundChangeTimeout    uint64             `toml:",omitempty"` // Minimum interval with which to resend RoundChange messages for same round
+	MaxResendRoundChangeTimeout    uint64             `toml:",omitempty"` // Maximum interval with which to resend RoundChange messages for same round
+	RoundChangeResendJitter        uint64             `toml:",omitempty"` // Maximum percentage by which each RoundChange resend interval is randomly shortened, so that validators don't resend in lockstep
+	RoundChangeDeterministicOffset bool               `toml:",omitempty"` // Specifies if each RoundChange resend interval is shortened by an offset derived from this node's address instead of a random one, within RoundChangeResendJitter
+	BlockPeriod                    uint64             `toml:",omitempty"` // Default minimum difference between two consecutive block's timestamps in second
+	AllowedClockSkew               uint64             `toml:",omitempty"` // Time (in seconds) that a proposal's timestamp may be ahead of the local clock and still be accepted right away. Zero waits for the timestamp of every future proposal
+	MaxFutureBlockTime             uint64             `toml:",omitempty"` // Time (in seconds) that a proposal's timestamp may be ahead of the local clock at most. Proposals further ahead are rejected instead of waited for, which leads to a round change. Must not be smaller than AllowedClockSkew. Zero disables the bound
+	BlockTimeCatchupRate           uint64             `toml:",omitempty"` // Maximum time (in seconds) by which a proposed block's timestamp may exceed its parent's plus BlockPeriod when the chain is behind the local clock. Blocks are then proposed BlockPeriod apart until their timestamps catch up. Zero moves the timestamp to the local clock right away
+	ProposerPolicy                 ProposerPolicy     `toml:",omitempty"` // The policy for proposer selection
+	LegacyProposerPolicy           ProposerPolicy     `toml:",omitempty"` // The policy for proposer selection before ProposerPolicyForkBlock
+	ProposerPolicyForkBlock        uint64             `toml:",omitempty"` // The first block whose proposer is selected with ProposerPolicy instead of LegacyProposerPolicy. Zero uses ProposerPolicy from genesis
+	ShuffleSeedSource              ShuffleSeedSource  `toml:",omitempty"` // The source of the seed with which the ShuffledRoundRobin policy shuffles the validator set
+	FreezeProposerOrderWithinEpoch bool               `toml:",omitempty"` // Whether the ShuffledRoundRobin policy uses the order seeded at the epoch block for every block of the epoch, including the first one. Set from the genesis
+	StickyFallbackThreshold        uint64             `toml:",omitempty"` // The number of consecutive failed turns after which the StickyWithFallback policy demotes a proposer
+	StickyFallbackCooldown         uint64             `toml:",omitempty"` // The number of blocks that the StickyWithFallback policy looks back for failed turns of a proposer
+	ProposerBanWindow              uint64             `toml:",omitempty"` // The number of most recent rounds, as recorded in the chain, in which the failed rounds of each proposer are counted. Proposers that failed more than ProposerBanThreshold of them are skipped. Set from the genesis, as it changes proposer selection. Zero disables the ban
+	ProposerBanThreshold           uint64             `toml:",omitempty"` // The number of failed rounds within ProposerBanWindow above which a proposer is skipped. Must be smaller than ProposerBanWindow
+	ProposerBanForkBlock           uint64             `toml:",omitempty"` // The first block whose proposer is selected with the proposer ban. Zero applies it from genesis
+	SelfProposalFailureThreshold   uint64             `toml:",omitempty"` // The number of consecutive turns in which this node's proposal wasn't committed after which it pauses proposing. Zero disables the pause
+	SelfProposalCooldown           uint64             `toml:",omitempty"` // The number of blocks for which this node pauses proposing after repeated failed proposals
+	Epoch                          uint64             `toml:",omitempty"` // The number of blocks after which to checkpoint and reset the pending votes
+	LookbackWindow                 uint64             `toml:",omitempty"` // The window of blocks in which a validator is forgived from voting
+	NewValidatorGraceBlocks        uint64             `toml:",omitempty"` // The number of blocks after a validator enters the validator set during which missing votes don't lower its uptime. Must be smaller than Epoch. Zero disables the grace period
+	NewValidatorGraceForkBlock     uint64             `toml:",omitempty"` // The first block at which the uptime of the epoch it ends is scored with NewValidatorGraceBlocks. Zero applies it from genesis
+	MinValidatorsToStart           uint64             `toml:",omitempty"` // The number of connected, announce-verified validators (including this one) needed to propose or change rounds. Zero disables the check
+	ConsensusCatchupThreshold      uint64             `toml:",omitempty"` // The number of sequences more than F validators must be ahead of this node for it to stop changing rounds and sync the missing blocks right away. Zero disables the catch-up
+	AggregatedSealCacheSize        uint64             `toml:",omitempty"` // The number of verified aggregated seals to remember, so that verifying the same seal again is skipped. Zero disables the cache
+	MessageVerifyWorkers           uint64             `toml:",omitempty"` // The number of received consensus messages whose signature is verified in parallel before they are handled. Zero uses the number of CPUs
+	PrioritizeConsensusMsgs        bool               `toml:",omitempty"` // Specifies if received consensus and announce messages are queued separately and handled by MessageVerifyWorkers workers, which only handle an announce message while no consensus message is queued
+	MaxFutureMessages              uint64             `toml:",omitempty"` // The maximum number of future consensus messages buffered until this node reaches their sequence or round. When full, the messages furthest in the future are dropped
+	MaxFutureMessagesPerSender     uint64             `toml:",omitempty"` // The maximum number of future consensus messages buffered from one validator. When reached, its message furthest in the future is dropped. Must not be greater than MaxFutureMessages
+	SignerTimeout                  uint64             `toml:",omitempty"` // Maximum time (in milliseconds) to wait for the signer, e.g. an external signer, to sign a consensus message or seal. Must be smaller than RequestTimeout. Zero waits indefinitely
+	ReplicaStateDBPath             string             `toml:",omitempty"` // The location for the validator replica state DB
+	ValidatorEnodeDBPath           string             `toml:",omitempty"` // The location for the validator enodes DB
+	VersionCertificateDBPath       string             `toml:",omitempty"` // The location for the signed announce version DB
+	RoundStateDBPath               string             `toml:",omitempty"` // The location for the round states DB
+	RoundStateHistorySize          uint64             `toml:",omitempty"` // The number of round states, one per state transition, kept for istanbul_dumpRoundStateHistory. Zero disables the history
+	RoundStateRetention            uint64             `toml:",omitempty"` // The number of committed sequences whose round states are kept in the round states DB, older ones are periodically pruned and compacted. Zero keeps every round state
+	VersionCertificateTTL          uint64             `toml:",omitempty"` // Time (in seconds) during which version certificates of validators outside the validator connection set are kept. Zero removes them at the next pruning
+	VersionCertificateValidity     uint64             `toml:",omitempty"` // Time (in seconds) after its issuance during which a received version certificate is accepted. Announcing validators reissue theirs every 5 minutes. Zero disables the check
+	StrictVersionCertificates      bool               `toml:",omitempty"` // Specified if received version certificates whose version is lower than the highest one seen for their validator are dropped, so that a validator's announce data can't be downgraded by replaying an older certificate
+	Validator                      bool               `toml:",omitempty"` // Specified if this node is configured to validate  (specifically if --mine command line is set)
+	Replica                        bool               `toml:",omitempty"` // Specified if this node is configured to be a replica
+	ShadowValidator                bool               `toml:",omitempty"` // Specified if this node runs consensus without sending its consensus messages, proposals or committed blocks
+	SingleValidatorMode            bool               `toml:",omitempty"` // Specified if this node, when it is the only validator, commits its proposals right away without running the full consensus. Meant for local development, ignored with more than one validator
+	GracefulShutdownTimeout        uint64             `toml:",omitempty"` // Maximum time (in milliseconds) to wait on shutdown for the current sequence to be committed before stopping validating, and then for the pending announce messages to be sent. Zero stops right away
+	HaltOnSafetyViolation          bool               `toml:",omitempty"` // Specified if this node stops validating and refuses to produce or accept blocks once it receives a block with a valid aggregated seal that conflicts with its chain, until it is restarted
+	HealthLogInterval              uint64             `toml:",omitempty"` // The number of blocks between two one line summaries of the consensus health in the log. Zero disables the summary
+	ObserverMode                   bool               `toml:",omitempty"` // Specified if this non-validating node interprets the consensus messages it receives to track rounds, commits and participation, without ever signing or sending consensus messages
+	EquivocationPolicy             EquivocationPolicy `toml:",omitempty"` // How this node responds to two conflicting proposals of the proposer of the same round
+	AggregateRoundChange           bool               `toml:",omitempty"` // Specified if this node sends its first ROUND CHANGE message for a round only to the proposer of that round, which justifies its proposal with the aggregated round change certificate. Later ROUND CHANGE messages are broadcast
 
 	// Proxy Configs
 	Proxy                   bool           `toml:",omitempty"` // Specifies if this node is a proxy
 	ProxiedValidatorAddress common.Address `toml:",omitempty"` // The address of the proxied validator
 
 	// Proxied Validator Configs
-	Proxied      bool           `toml:",omitempty"` // Specifies if this node is proxied
-	ProxyConfigs []*ProxyConfig `toml:",omitempty"` // The set of proxy configs for this proxied validator at startup
+	Proxied                  bool           `toml:",omitempty"` // Specifies if this node is proxied
+	ProxyConfigs             []*ProxyConfig `toml:",omitempty"` // The set of proxy configs for this proxied validator at startup
+	ProxyHealthCheckInterval uint64         `toml:",omitempty"` // Time duration (in seconds) between health checks of the connections to the proxies
+	PreferProxyPeers         bool           `toml:",omitempty"` // Specifies if validators connecting directly to this proxied validator are treated as regular peers while a proxy is connected, instead of taking extra peer slots
 
 	// Announce Configs
-	AnnounceQueryEnodeGossipPeriod                 uint64 `toml:",omitempty"` // Time duration (in seconds) between gossiped query enode messages
-	AnnounceAggressiveQueryEnodeGossipOnEnablement bool   `toml:",omitempty"` // Specifies if this node should aggressively query enodes on announce enablement
-	AnnounceAdditionalValidatorsToGossip           int64  `toml:",omitempty"` // Specifies the number of additional non-elected validators to gossip an announce
+	AnnounceQueryEnodeGossipPeriod                 uint64           `toml:",omitempty"` // Time duration (in seconds) between gossiped query enode messages
+	AnnounceAggressiveQueryEnodeGossipOnEnablement bool             `toml:",omitempty"` // Specifies if this node should aggressively query enodes on announce enablement
+	AnnounceAggressiveMinPeers                     uint64           `toml:",omitempty"` // Minimum number of connected validator peers before the aggressive query enode gossip starts, it is deferred until then. Zero doesn't wait, and proxied validators don't wait either
+	AnnounceAdditionalValidatorsToGossip           int64            `toml:",omitempty"` // Specifies the number of additional non-elected validators to gossip an announce
+	AnnounceAdaptiveGossip                         bool             `toml:",omitempty"` // Specifies if the number of additional validators to gossip an announce is lowered while consensus messages take long to be handled, and restored once they don't
+	AnnounceGossipPeriodPerValidator               uint64           `toml:",omitempty"` // Time duration (in seconds) added to the query enode gossip period per elected validator. Zero disables the scaling
+	AnnounceMaxQueryEnodeGossipPeriod              uint64           `toml:",omitempty"` // Maximum time duration (in seconds) between gossiped query enode messages when the period is scaled
+	AnnounceMaxMessagesPerMinute                   uint64           `toml:",omitempty"` // Maximum number of announce messages handled per minute from a non-validator peer. Validator peers get a higher limit. Zero disables the limit
+	AnnounceGossipCoveragePeriods                  uint64           `toml:",omitempty"` // Number of query enode gossip periods over which the non-elected validators are queried in rotating slices. The elected validators are queried every period. Zero or one queries every validator every period
+	AnnounceMessageTTL                             uint64           `toml:",omitempty"` // Maximum age (in seconds) of a received query enode message, older messages are dropped instead of being handled and regossiped. Zero disables the limit
+	AnnounceAllowlist                              []common.Address `toml:",omitempty"` // The validators whose query enode messages this node answers. Empty answers every validator in the validator connection set
+	AnnounceRelayEndpoint                          string           `toml:",omitempty"` // The HTTP(S) URL of a relay through which enode certificates are exchanged with validators that repeatedly can't be reached directly, e.g. behind a NAT. Empty disables the relay
 }
 
 var DefaultConfig = &Config{
 	RequestTimeout:                 3000,
 	TimeoutBackoffFactor:           1000,
+	MaxRequestTimeout:              60 * 1000,
 	MinResendRoundChangeTimeout:    15 * 1000,
 	MaxResendRoundChangeTimeout:    2 * 60 * 1000,
+	RoundChangeResendJitter:        20,
 	BlockPeriod:                    5,
 	ProposerPolicy:                 ShuffledRoundRobin,
+	ShuffleSeedSource:              RandomnessBeaconSeed,
+	EquivocationPolicy:             KeepFirstProposal,
+	StickyFallbackThreshold:        3,
+	StickyFallbackCooldown:         100,
+	ProposerBanWindow:              0,
+	ProposerBanThreshold:           3,
+	SelfProposalFailureThreshold:   0,
+	SelfProposalCooldown:           100,
 	Epoch:                          30000,
 	LookbackWindow:                 12,
+	ConsensusCatchupThreshold:      10,
+	AggregatedSealCacheSize:        1024,
 	ReplicaStateDBPath:             "replicastate",
 	ValidatorEnodeDBPath:           "validatorenodes",
 	VersionCertificateDBPath:       "versioncertificates",
 	RoundStateDBPath:               "roundstates",
+	RoundStateHistorySize:          64,
+	RoundStateRetention:            100,
+	VersionCertificateTTL:          7 * 24 * 60 * 60, // 1 week
+	VersionCertificateValidity:     60 * 60,          // 1 hour
+	MaxFutureMessages:              10 * 1000,
+	MaxFutureMessagesPerSender:     1000,
 	Validator:                      false,
 	Replica:                        false,
+	GracefulShutdownTimeout:        5000,
 	Proxy:                          false,
 	Proxied:                        false,
+	ProxyHealthCheckInterval:       10,
 	AnnounceQueryEnodeGossipPeriod: 300, // 5 minutes
 	AnnounceAggressiveQueryEnodeGossipOnEnablement: true,
 	AnnounceAdditionalValidatorsToGossip:           10,
+	AnnounceGossipPeriodPerValidator:               0,
+	AnnounceMaxQueryEnodeGossipPeriod:              3600, // 1 hour
+	AnnounceMaxMessagesPerMinute:                   300,
+	AnnounceGossipCoveragePeriods:                  0,
+	AnnounceMessageTTL:                             600, // 10 minutes
 }
 
 type ProxyConfig struct {
 	InternalNode *enode.Node `toml:",omitempty"` // The internal facing node of the proxy that this proxied validator will peer with
 	ExternalNode *enode.Node `toml:",omitempty"` // The external facing node of the proxy that the proxied validator will broadcast via the announce message
 }
+
+// Validate checks that the timing and policy settings are consistent with each other.
+// An error naming the offending field is returned for settings the engine cannot run with,
+// while combinations that are merely suspicious are only logged.
+func (c *Config) Validate() error {
+	if c.RequestTimeout == 0 {
+		return errors.New("invalid istanbul config: RequestTimeout must be greater than 0")
+	}
+	if c.BlockPeriod == 0 {
+		return errors.New("invalid istanbul config: BlockPeriod must be greater than 0")
+	}
+	if c.Epoch == 0 {
+		return errors.New("invalid istanbul config: Epoch must be greater than 0")
+	}
+	if c.AllowedClockSkew >= c.BlockPeriod {
+		return fmt.Errorf("invalid istanbul config: AllowedClockSkew (%d) must be smaller than BlockPeriod (%d)", c.AllowedClockSkew, c.BlockPeriod)
+	}
+	if c.MaxFutureBlockTime > 0 && c.MaxFutureBlockTime < c.AllowedClockSkew {
+		return fmt.Errorf("invalid istanbul config: MaxFutureBlockTime (%d) must not be smaller than AllowedClockSkew (%d)", c.MaxFutureBlockTime, c.AllowedClockSkew)
+	}
+	if c.ProposalTimeout > 0 && c.ProposalTimeout >= c.RequestTimeout {
+		return fmt.Errorf("invalid istanbul config: ProposalTimeout (%d) must be smaller than RequestTimeout (%d)", c.ProposalTimeout, c.RequestTimeout)
+	}
+	if c.MinResendRoundChangeTimeout > c.MaxResendRoundChangeTimeout {
+		return fmt.Errorf("invalid istanbul config: MinResendRoundChangeTimeout (%d) must not be greater than MaxResendRoundChangeTimeout (%d)", c.MinResendRoundChangeTimeout, c.MaxResendRoundChangeTimeout)
+	}
+	if c.SignerTimeout > 0 && c.SignerTimeout >= c.RequestTimeout {
+		return fmt.Errorf("invalid istanbul config: SignerTimeout (%d) must be smaller than RequestTimeout (%d)", c.SignerTimeout, c.RequestTimeout)
+	}
+	if c.RoundChangeResendJitter > 100 {
+		return fmt.Errorf("invalid istanbul config: RoundChangeResendJitter (%d) must not be greater than 100", c.RoundChangeResendJitter)
+	}
+	if c.MaxFutureMessages == 0 || c.MaxFutureMessagesPerSender == 0 {
+		return errors.New("invalid istanbul config: MaxFutureMessages and MaxFutureMessagesPerSender must be greater than 0")
+	}
+	if c.MaxFutureMessagesPerSender > c.MaxFutureMessages {
+		return fmt.Errorf("invalid istanbul config: MaxFutureMessagesPerSender (%d) must not be greater than MaxFutureMessages (%d)", c.MaxFutureMessagesPerSender, c.MaxFutureMessages)
+	}
+	if c.Proxied && c.ProxyHealthCheckInterval == 0 {
+		return errors.New("invalid istanbul config: ProxyHealthCheckInterval must be greater than 0")
+	}
+	// Announcing validators reissue their version certificate every 5 minutes
+	if c.VersionCertificateValidity > 0 && c.VersionCertificateValidity <= 5*60 {
+		return fmt.Errorf("invalid istanbul config: VersionCertificateValidity (%d) must be longer than 300 seconds, the period at which validators reissue their version certificate", c.VersionCertificateValidity)
+	}
+	if c.AnnounceGossipPeriodPerValidator > 0 && c.AnnounceMaxQueryEnodeGossipPeriod < c.AnnounceQueryEnodeGossipPeriod {
+		return fmt.Errorf("invalid istanbul config: AnnounceMaxQueryEnodeGossipPeriod (%d) must not be smaller than AnnounceQueryEnodeGossipPeriod (%d)", c.AnnounceMaxQueryEnodeGossipPeriod, c.AnnounceQueryEnodeGossipPeriod)
+	}
+	if c.AnnounceRelayEndpoint != "" {
+		if u, err := url.Parse(c.AnnounceRelayEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid istanbul config: AnnounceRelayEndpoint %q must be an http or https URL", c.AnnounceRelayEndpoint)
+		}
+	}
+	if _, ok := proposerPolicyName(c.ProposerPolicy); !ok {
+		return fmt.Errorf("invalid istanbul config: unknown ProposerPolicy %d, valid options are %s", uint64(c.ProposerPolicy), validProposerPolicyNames())
+	}
+
+	if _, ok := proposerPolicyName(c.LegacyProposerPolicy); !ok && c.ProposerPolicyForkBlock > 0 {
+		return fmt.Errorf("invalid istanbul config: unknown LegacyProposerPolicy %d, valid options are %s", uint64(c.LegacyProposerPolicy), validProposerPolicyNames())
+	}
+
+	if _, ok := equivocationPolicyNames[c.EquivocationPolicy]; !ok {
+		return fmt.Errorf("invalid istanbul config: unknown EquivocationPolicy %d", uint64(c.EquivocationPolicy))
+	}
+
+	if _, ok := shuffleSeedSourceNames[c.ShuffleSeedSource]; !ok {
+		return fmt.Errorf("invalid istanbul config: unknown ShuffleSeedSource %d", uint64(c.ShuffleSeedSource))
+	}
+
+	if c.usesProposerPolicy(StickyWithFallback) && (c.StickyFallbackThreshold == 0 || c.StickyFallbackCooldown == 0) {
+		return errors.New("invalid istanbul config: StickyFallbackThreshold and StickyFallbackCooldown must be greater than 0 with the StickyWithFallback proposer policy")
+	}
+
+	if c.ProposerBanWindow > 0 && c.ProposerBanThreshold >= c.ProposerBanWindow {
+		return fmt.Errorf("invalid istanbul config: ProposerBanThreshold (%d) must be smaller than ProposerBanWindow (%d)", c.ProposerBanThreshold, c.ProposerBanWindow)
+	}
+
+	if c.ObserverMode && (c.Validator || c.Replica) {
+		return errors.New("invalid istanbul config: ObserverMode can't be set on a validator or replica")
+	}
+
+	if c.SelfProposalFailureThreshold > 0 && c.SelfProposalCooldown == 0 {
+		return errors.New("invalid istanbul config: SelfProposalCooldown must be greater than 0 when SelfProposalFailureThreshold is set")
+	}
+
+	if c.NewValidatorGraceBlocks >= c.Epoch {
+		return fmt.Errorf("invalid istanbul config: NewValidatorGraceBlocks (%d) must be smaller than Epoch (%d)", c.NewValidatorGraceBlocks, c.Epoch)
+	}
+
+	if c.RoundChangeDeterministicOffset && c.RoundChangeResendJitter == 0 {
+		log.Warn("Istanbul RoundChangeDeterministicOffset has no effect without RoundChangeResendJitter")
+	}
+
+	if c.LookbackWindow >= c.Epoch {
+		log.Warn("Istanbul LookbackWindow is not smaller than Epoch, uptime will not be tracked within an epoch", "lookbackWindow", c.LookbackWindow, "epoch", c.Epoch)
+	}
+	return nil
+}
+
+// ProposerPolicyAt returns the policy that selects the proposer of the block with the given number.
+// Every validator must use the same ProposerPolicyForkBlock, or they will disagree on the proposers
+// from the first block at which their policies differ.
+func (c *Config) ProposerPolicyAt(number uint64) ProposerPolicy {
+	if number < c.ProposerPolicyForkBlock {
+		return c.LegacyProposerPolicy
+	}
+	return c.ProposerPolicy
+}
+
+// usesProposerPolicy returns whether the given policy selects the proposer of any block.
+func (c *Config) usesProposerPolicy(policy ProposerPolicy) bool {
+	return c.ProposerPolicy == policy || (c.ProposerPolicyForkBlock > 0 && c.LegacyProposerPolicy == policy)
+}
+
+// NewValidatorGraceBlocksAt returns the uptime grace period of new validators that applies when the validator
+// scores are updated at the block with the given number, which is zero before NewValidatorGraceForkBlock.
+func (c *Config) NewValidatorGraceBlocksAt(number uint64) uint64 {
+	if number < c.NewValidatorGraceForkBlock {
+		return 0
+	}
+	return c.NewValidatorGraceBlocks
+}
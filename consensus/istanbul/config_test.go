@@ -0,0 +1,185 @@
+// Copyright 2017 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import "testing"
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr bool
+	}{
+		{"default", func(c *Config) {}, false},
+		{"zero request timeout", func(c *Config) { c.RequestTimeout = 0 }, true},
+		{"zero block period", func(c *Config) { c.BlockPeriod = 0 }, true},
+		{"zero epoch", func(c *Config) { c.Epoch = 0 }, true},
+		{"clock skew of a block period", func(c *Config) { c.AllowedClockSkew = c.BlockPeriod }, true},
+		{"clock skew below the block period", func(c *Config) { c.AllowedClockSkew = c.BlockPeriod - 1 }, false},
+		{"max future block time below the clock skew", func(c *Config) { c.AllowedClockSkew = 2; c.MaxFutureBlockTime = 1 }, true},
+		{"max future block time equal to the clock skew", func(c *Config) { c.AllowedClockSkew = 2; c.MaxFutureBlockTime = 2 }, false},
+		{"max future block time disabled with a clock skew", func(c *Config) { c.AllowedClockSkew = 2; c.MaxFutureBlockTime = 0 }, false},
+		{"proposal timeout of a request timeout", func(c *Config) { c.ProposalTimeout = c.RequestTimeout }, true},
+		{"proposal timeout below the request timeout", func(c *Config) { c.ProposalTimeout = c.RequestTimeout - 1 }, false},
+		{"version certificate validity of the reissue period", func(c *Config) { c.VersionCertificateValidity = 300 }, true},
+		{"disabled version certificate validity", func(c *Config) { c.VersionCertificateValidity = 0 }, false},
+		{"announce relay endpoint", func(c *Config) { c.AnnounceRelayEndpoint = "https://relay.example.org/announce" }, false},
+		{"announce relay endpoint without scheme", func(c *Config) { c.AnnounceRelayEndpoint = "relay.example.org" }, true},
+		{"zero max future messages", func(c *Config) { c.MaxFutureMessages = 0 }, true},
+		{"max future messages per sender above the total", func(c *Config) { c.MaxFutureMessagesPerSender = c.MaxFutureMessages + 1 }, true},
+		{"max future messages per sender equal to the total", func(c *Config) { c.MaxFutureMessagesPerSender = c.MaxFutureMessages }, false},
+		{"min resend above max", func(c *Config) { c.MinResendRoundChangeTimeout = c.MaxResendRoundChangeTimeout + 1 }, true},
+		{"min resend equal to max", func(c *Config) { c.MinResendRoundChangeTimeout = c.MaxResendRoundChangeTimeout }, false},
+		{"signer timeout of a request timeout", func(c *Config) { c.SignerTimeout = c.RequestTimeout }, true},
+		{"signer timeout below the request timeout", func(c *Config) { c.SignerTimeout = c.RequestTimeout - 1 }, false},
+		{"resend jitter above 100 percent", func(c *Config) { c.RoundChangeResendJitter = 101 }, true},
+		{"resend jitter of 100 percent", func(c *Config) { c.RoundChangeResendJitter = 100 }, false},
+		{"unknown proposer policy", func(c *Config) { c.ProposerPolicy = ProposerPolicy(42) }, true},
+		{"unknown equivocation policy", func(c *Config) { c.EquivocationPolicy = EquivocationPolicy(42) }, true},
+		{"round change on equivocation", func(c *Config) { c.EquivocationPolicy = RoundChangeOnEquivocation }, false},
+		{"unknown legacy proposer policy", func(c *Config) {
+			c.LegacyProposerPolicy = ProposerPolicy(42)
+			c.ProposerPolicyForkBlock = 100
+		}, true},
+		{"unknown legacy proposer policy without fork", func(c *Config) { c.LegacyProposerPolicy = ProposerPolicy(42) }, false},
+		{"self proposal failure threshold without cooldown", func(c *Config) {
+			c.SelfProposalFailureThreshold = 3
+			c.SelfProposalCooldown = 0
+		}, true},
+		{"self proposal failure threshold with cooldown", func(c *Config) { c.SelfProposalFailureThreshold = 3 }, false},
+		{"sticky with fallback without threshold", func(c *Config) {
+			c.ProposerPolicy = StickyWithFallback
+			c.StickyFallbackThreshold = 0
+		}, true},
+		{"sticky with fallback without cooldown", func(c *Config) {
+			c.ProposerPolicy = StickyWithFallback
+			c.StickyFallbackCooldown = 0
+		}, true},
+		{"legacy sticky with fallback without threshold", func(c *Config) {
+			c.LegacyProposerPolicy = StickyWithFallback
+			c.ProposerPolicyForkBlock = 100
+			c.StickyFallbackThreshold = 0
+		}, true},
+		{"observer validator", func(c *Config) { c.ObserverMode = true; c.Validator = true }, true},
+		{"observer replica", func(c *Config) { c.ObserverMode = true; c.Replica = true }, true},
+		{"observer", func(c *Config) { c.ObserverMode = true }, false},
+		{"proposer ban threshold of the window", func(c *Config) { c.ProposerBanWindow = 10; c.ProposerBanThreshold = 10 }, true},
+		{"proposer ban threshold below the window", func(c *Config) { c.ProposerBanWindow = 10; c.ProposerBanThreshold = 9 }, false},
+		{"new validator grace of an epoch", func(c *Config) { c.NewValidatorGraceBlocks = c.Epoch }, true},
+		{"new validator grace below the epoch", func(c *Config) { c.NewValidatorGraceBlocks = c.Epoch - 1 }, false},
+		{"lookback window not smaller than epoch", func(c *Config) { c.LookbackWindow = c.Epoch }, false},
+		{"proxied with zero health check interval", func(c *Config) { c.Proxied = true; c.ProxyHealthCheckInterval = 0 }, true},
+		{"not proxied with zero health check interval", func(c *Config) { c.ProxyHealthCheckInterval = 0 }, false},
+		{"scaled gossip period with max below base", func(c *Config) {
+			c.AnnounceGossipPeriodPerValidator = 1
+			c.AnnounceMaxQueryEnodeGossipPeriod = c.AnnounceQueryEnodeGossipPeriod - 1
+		}, true},
+		{"unscaled gossip period with max below base", func(c *Config) { c.AnnounceMaxQueryEnodeGossipPeriod = 0 }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := *DefaultConfig
+			tt.modify(&config)
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProposerPolicyAt(t *testing.T) {
+	config := *DefaultConfig
+	config.LegacyProposerPolicy = RoundRobin
+	config.ProposerPolicy = ShuffledRoundRobin
+	for number, want := range map[uint64]ProposerPolicy{0: ShuffledRoundRobin, 1: ShuffledRoundRobin} {
+		if have := config.ProposerPolicyAt(number); have != want {
+			t.Errorf("ProposerPolicyAt(%d) without fork = %v, want %v", number, have, want)
+		}
+	}
+
+	config.ProposerPolicyForkBlock = 10
+	for number, want := range map[uint64]ProposerPolicy{0: RoundRobin, 9: RoundRobin, 10: ShuffledRoundRobin, 11: ShuffledRoundRobin} {
+		if have := config.ProposerPolicyAt(number); have != want {
+			t.Errorf("ProposerPolicyAt(%d) = %v, want %v", number, have, want)
+		}
+	}
+}
+
+func TestNewValidatorGraceBlocksAt(t *testing.T) {
+	config := *DefaultConfig
+	config.NewValidatorGraceBlocks = 5
+	if have := config.NewValidatorGraceBlocksAt(0); have != 5 {
+		t.Errorf("NewValidatorGraceBlocksAt(0) without fork = %d, want 5", have)
+	}
+
+	config.NewValidatorGraceForkBlock = 10
+	for number, want := range map[uint64]uint64{0: 0, 9: 0, 10: 5, 11: 5} {
+		if have := config.NewValidatorGraceBlocksAt(number); have != want {
+			t.Errorf("NewValidatorGraceBlocksAt(%d) = %d, want %d", number, have, want)
+		}
+	}
+}
+
+func TestProposerPolicyText(t *testing.T) {
+	for _, policy := range []ProposerPolicy{RoundRobin, Sticky, ShuffledRoundRobin, StickyWithFallback, WeightedRoundRobin} {
+		text, err := policy.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText(%d): %v", policy, err)
+		}
+		var decoded ProposerPolicy
+		if err := decoded.UnmarshalText(text); err != nil {
+			t.Fatalf("UnmarshalText(%s): %v", text, err)
+		}
+		if decoded != policy {
+			t.Errorf("round trip of %s: have %d, want %d", text, decoded, policy)
+		}
+	}
+
+	tests := []struct {
+		input   string
+		want    ProposerPolicy
+		wantErr bool
+	}{
+		{"RoundRobin", RoundRobin, false},
+		{"Sticky", Sticky, false},
+		{"ShuffledRoundRobin", ShuffledRoundRobin, false},
+		{"0", RoundRobin, false},
+		{"1", Sticky, false},
+		{"2", ShuffledRoundRobin, false},
+		{"StickyWithFallback", StickyWithFallback, false},
+		{"3", StickyWithFallback, false},
+		{"WeightedRoundRobin", WeightedRoundRobin, false},
+		{"4", WeightedRoundRobin, false},
+		{"5", 0, true},
+		{"roundrobin", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		var policy ProposerPolicy
+		err := policy.UnmarshalText([]byte(tt.input))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("UnmarshalText(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		} else if !tt.wantErr && policy != tt.want {
+			t.Errorf("UnmarshalText(%q) = %d, want %d", tt.input, policy, tt.want)
+		}
+	}
+
+	if _, err := ProposerPolicy(42).MarshalText(); err == nil {
+		t.Errorf("MarshalText of unknown policy should fail")
+	}
+}
@@ -18,6 +18,7 @@ package core
 
 import (
 	"fmt"
+	"math"
 	"math/big"
 	"sort"
 	"sync"
@@ -26,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/prque"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 )
 
 var (
@@ -38,10 +40,7 @@ var (
 	}
 
 	// Do not accept messages for views more than this many sequences in the future.
-	acceptMaxFutureSequence             = big.NewInt(10)
-	acceptMaxFutureMsgsFromOneValidator = 1000
-	acceptMaxFutureMessages             = 10 * 1000
-	acceptMaxFutureMessagesPruneBatch   = 100
+	acceptMaxFutureSequence = big.NewInt(10)
 )
 
 // checkMessage checks the message state
@@ -111,6 +110,10 @@ type msgBacklogImpl struct {
 	msgCountBySrc map[common.Address]int
 	msgCount      int
 
+	maxMsgs          int // The maximum number of messages in the backlog
+	maxMsgsPerSender int // The maximum number of messages from one sender in the backlog
+	droppedMeter     metrics.Meter
+
 	currentView  *istanbul.View
 	currentState State
 
@@ -120,7 +123,7 @@ type msgBacklogImpl struct {
 	logger       log.Logger
 }
 
-func newMsgBacklog(msgProcessor func(*istanbul.Message), checkMessage func(msgCode uint64, msgView *istanbul.View) error) MsgBacklog {
+func newMsgBacklog(msgProcessor func(*istanbul.Message), checkMessage func(msgCode uint64, msgView *istanbul.View) error, maxMsgs uint64, maxMsgsPerSender uint64) MsgBacklog {
 	initialView := &istanbul.View{
 		Round:    big.NewInt(0),
 		Sequence: big.NewInt(1),
@@ -131,6 +134,10 @@ func newMsgBacklog(msgProcessor func(*istanbul.Message), checkMessage func(msgCo
 		msgCountBySrc: make(map[common.Address]int),
 		msgCount:      0,
 
+		maxMsgs:          int(maxMsgs),
+		maxMsgsPerSender: int(maxMsgsPerSender),
+		droppedMeter:     metrics.NewRegisteredMeter("consensus/istanbul/core/backlog/dropped", nil),
+
 		currentView:  initialView,
 		currentState: StateAcceptRequest,
 
@@ -164,45 +171,118 @@ func (c *msgBacklogImpl) store(msg *istanbul.Message) {
 		return
 	}
 
-	// Check and inc per-validator future message limit
-	if c.msgCountBySrc[msg.Address] > acceptMaxFutureMsgsFromOneValidator {
+	seq := view.Sequence.Uint64()
+	priority := toPriority(msg.Code, view)
+
+	// When the sender has too many messages in the backlog, replace its lowest priority one, unless the
+	// message has an even lower priority
+	if c.msgCountBySrc[msg.Address] >= c.maxMsgsPerSender && !c.removeLowestPriorityMsgFrom(msg.Address, seq, priority) {
 		logger.Debug("Dropping message", "reason", "exceeds per-address cap")
+		c.droppedMeter.Mark(1)
 		return
 	}
 
+	// When the backlog is full, make room by pruning the sequences further in the future than the message
+	if c.msgCount >= c.maxMsgs {
+		c.removeMessagesOverflow(seq)
+		if c.msgCount >= c.maxMsgs {
+			logger.Debug("Dropping message", "reason", "backlog is full")
+			c.droppedMeter.Mark(1)
+			return
+		}
+	}
+
 	logger.Trace("Store future message", "m", msg, "m_seq", view.Sequence, "m_round", view.Round)
 	c.msgCountBySrc[msg.Address]++
 	c.msgCount++
 
 	// Add message to per-seq list
-	backlogForSeq := c.backlogBySeq[view.Sequence.Uint64()]
+	backlogForSeq := c.backlogBySeq[seq]
 	if backlogForSeq == nil {
 		backlogForSeq = prque.New(nil)
-		c.backlogBySeq[view.Sequence.Uint64()] = backlogForSeq
+		c.backlogBySeq[seq] = backlogForSeq
 	}
 
-	backlogForSeq.Push(msg, toPriority(msg.Code, view))
+	backlogForSeq.Push(msg, priority)
+}
 
-	// After insert, remove messages if we have more than "acceptMaxFutureMessages"
-	c.removeMessagesOverflow()
+// removeMessagesOverflow removes the messages of the sequences after the given one, future-most first, until
+// the backlog has room for a batch of 1% of its maximum number of messages.
+func (c *msgBacklogImpl) removeMessagesOverflow(seq uint64) {
+	pruneBatch := c.maxMsgs / 100
+	if pruneBatch == 0 {
+		pruneBatch = 1
+	}
+	backlogSeqs := c.getSortedBacklogSeqs()
+	for i := len(backlogSeqs) - 1; i >= 0; i-- {
+		if backlogSeqs[i] <= seq || c.msgCount <= c.maxMsgs-pruneBatch {
+			break
+		}
+		count := c.msgCount
+		c.clearBacklogForSeq(backlogSeqs[i])
+		c.droppedMeter.Mark(int64(count - c.msgCount))
+	}
 }
 
-// removeMessagesOverflow will remove messages if necessary to maintain the number of messages <= acceptMaxFutureMessages
-// For that, it will remove messages that further on the future
-func (c *msgBacklogImpl) removeMessagesOverflow() {
-	// Keep backlog below total max size by pruning future-most sequence first
-	// (we always leave one sequence's entire messages and rely on per-validator limits)
-	if c.msgCount > acceptMaxFutureMessages {
-		backlogSeqs := c.getSortedBacklogSeqs()
-		for i := len(backlogSeqs) - 1; i > 0; i-- {
-			seq := backlogSeqs[i]
-			if seq <= c.currentView.Sequence.Uint64() ||
-				c.msgCount < (acceptMaxFutureMessages-acceptMaxFutureMessagesPruneBatch) {
-				break
-			}
-			c.clearBacklogForSeq(seq)
+// removeLowestPriorityMsgFrom removes the lowest priority message of the sender that has a lower priority
+// than a message of the given sequence and priority, i.e. is for a later sequence, or for the same sequence
+// and a lower priority. It returns whether a message was removed.
+func (c *msgBacklogImpl) removeLowestPriorityMsgFrom(sender common.Address, seq uint64, priority int64) bool {
+	backlogSeqs := c.getSortedBacklogSeqs()
+	for i := len(backlogSeqs) - 1; i >= 0 && backlogSeqs[i] >= seq; i-- {
+		below := int64(math.MaxInt64)
+		if backlogSeqs[i] == seq {
+			below = priority
 		}
+		if c.removeLowestPriorityMsgFromSeq(backlogSeqs[i], sender, below) {
+			c.droppedMeter.Mark(1)
+			return true
+		}
+	}
+	return false
+}
+
+// removeLowestPriorityMsgFromSeq removes the lowest priority message of the sender for the given sequence
+// whose priority is lower than below. It returns whether a message was removed.
+func (c *msgBacklogImpl) removeLowestPriorityMsgFromSeq(seq uint64, sender common.Address, below int64) bool {
+	backlogForSeq := c.backlogBySeq[seq]
+	if backlogForSeq == nil {
+		return false
+	}
+
+	// Messages are popped from the highest priority to the lowest
+	msgs := make([]*istanbul.Message, 0, backlogForSeq.Size())
+	priorities := make([]int64, 0, backlogForSeq.Size())
+	for !backlogForSeq.Empty() {
+		m, priority := backlogForSeq.Pop()
+		msgs = append(msgs, m.(*istanbul.Message))
+		priorities = append(priorities, priority)
+	}
+	removed := -1
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Address == sender && priorities[i] < below {
+			removed = i
+			break
+		}
+	}
+	for i := range msgs {
+		if i != removed {
+			backlogForSeq.Push(msgs[i], priorities[i])
+		}
+	}
+	if removed < 0 {
+		return false
+	}
+
+	c.msgCountBySrc[sender]--
+	if c.msgCountBySrc[sender] == 0 {
+		delete(c.msgCountBySrc, sender)
+	}
+	c.msgCount--
+	if backlogForSeq.Size() == 0 {
+		delete(c.backlogBySeq, seq)
 	}
+	return true
 }
 
 // Return slice of sequences present in backlog sorted in ascending order
@@ -223,12 +303,12 @@ func (c *msgBacklogImpl) getSortedBacklogSeqs() []uint64 {
 // clearBacklogForSeq will remove all entries in the backlog
 // for the given seq
 func (c *msgBacklogImpl) clearBacklogForSeq(seq uint64) {
-	c.processBacklogForSeq(seq, func(_ *istanbul.Message) bool { return true })
+	c.processBacklogForSeq(seq, func(_ *istanbul.Message) bool { return false })
 }
 
 // processBacklogForSeq will call process() with each entry of the backlog
-// for the given seq, until process return "false".
-// The entry on which process() returned false will remain in the backlog
+// for the given seq, until process returns "true".
+// The entry on which process() returned true will remain in the backlog
 func (c *msgBacklogImpl) processBacklogForSeq(seq uint64, process func(*istanbul.Message) bool) {
 	backlogForSeq := c.backlogBySeq[seq]
 	if backlogForSeq == nil {
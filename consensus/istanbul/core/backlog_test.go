@@ -29,6 +29,7 @@ import (
 	blscrypto "github.com/ethereum/go-ethereum/crypto/bls"
 	"github.com/ethereum/go-ethereum/event"
 	elog "github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 )
 
 func TestCheckMessage(t *testing.T) {
@@ -216,6 +217,8 @@ func TestStoreBacklog(t *testing.T) {
 	backlog := newMsgBacklog(
 		func(msg *istanbul.Message) {},
 		func(msgCode uint64, msgView *istanbul.View) error { return nil },
+		istanbul.DefaultConfig.MaxFutureMessages,
+		istanbul.DefaultConfig.MaxFutureMessagesPerSender,
 	).(*msgBacklogImpl)
 	defer backlog.clearBacklogForSeq(12)
 
@@ -318,12 +321,69 @@ func TestStoreBacklog(t *testing.T) {
 	}
 }
 
+func TestStoreBacklogLimits(t *testing.T) {
+	backlog := newMsgBacklog(
+		func(msg *istanbul.Message) {},
+		func(msgCode uint64, msgView *istanbul.View) error { return nil },
+		4, 2,
+	).(*msgBacklogImpl)
+	backlog.droppedMeter = metrics.NewMeterForced()
+
+	store := func(from common.Address, seq int64) {
+		payload, _ := Encode(&istanbul.Subject{
+			View:   &istanbul.View{Round: big.NewInt(0), Sequence: big.NewInt(seq)},
+			Digest: common.BytesToHash([]byte("1234567890")),
+		})
+		backlog.store(&istanbul.Message{Code: istanbul.MsgPrepare, Msg: payload, Address: from})
+	}
+	checkBacklog := func(wantSeqs []uint64, wantDropped int64) {
+		t.Helper()
+		if seqs := backlog.getSortedBacklogSeqs(); !reflect.DeepEqual(seqs, wantSeqs) {
+			t.Errorf("backlog sequences = %v, want %v", seqs, wantSeqs)
+		}
+		if dropped := backlog.droppedMeter.Count(); dropped != wantDropped {
+			t.Errorf("dropped messages = %d, want %d", dropped, wantDropped)
+		}
+	}
+	a, b, c := common.Address{1}, common.Address{2}, common.Address{3}
+
+	store(a, 5)
+	store(a, 6)
+	// A sender at its limit has its message furthest in the future replaced by a nearer one
+	store(a, 3)
+	checkBacklog([]uint64{3, 5}, 1)
+	// but not by one even further in the future
+	store(a, 9)
+	checkBacklog([]uint64{3, 5}, 2)
+
+	store(b, 8)
+	store(b, 2)
+	// A full backlog prunes the sequences after the message, future-most first
+	store(c, 4)
+	checkBacklog([]uint64{2, 3, 4, 5}, 3)
+	// and drops the message if there are none
+	store(c, 10)
+	checkBacklog([]uint64{2, 3, 4, 5}, 4)
+	if backlog.msgCount != 4 || backlog.msgCountBySrc[a] != 2 || backlog.msgCountBySrc[b] != 1 || backlog.msgCountBySrc[c] != 1 {
+		t.Errorf("message counts = %d, %v, want 4 and 2 from a, 1 from b and c", backlog.msgCount, backlog.msgCountBySrc)
+	}
+
+	// Reaching a sequence removes the messages of the earlier ones and processes its own
+	backlog.updateState(&istanbul.View{Round: big.NewInt(0), Sequence: big.NewInt(4)}, StateAcceptRequest)
+	checkBacklog([]uint64{5}, 4)
+	if backlog.msgCount != 1 {
+		t.Errorf("message count = %d, want 1", backlog.msgCount)
+	}
+}
+
 func TestProcessFutureBacklog(t *testing.T) {
 	testLogger.SetHandler(elog.StdoutHandler)
 
 	backlog := newMsgBacklog(
 		func(msg *istanbul.Message) {},
 		func(msgCode uint64, msgView *istanbul.View) error { return nil },
+		istanbul.DefaultConfig.MaxFutureMessages,
+		istanbul.DefaultConfig.MaxFutureMessagesPerSender,
 	).(*msgBacklogImpl)
 	defer backlog.clearBacklogForSeq(12)
 
@@ -458,6 +518,8 @@ func testProcessBacklog(t *testing.T, msg *istanbul.Message) {
 	backlog := newMsgBacklog(
 		registerCall,
 		func(msgCode uint64, msgView *istanbul.View) error { return nil },
+		istanbul.DefaultConfig.MaxFutureMessages,
+		istanbul.DefaultConfig.MaxFutureMessagesPerSender,
 	).(*msgBacklogImpl)
 	defer backlog.clearBacklogForSeq(12)
 
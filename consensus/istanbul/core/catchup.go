@@ -0,0 +1,96 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+// catchupSyncInterval is the minimum time between two chain synchronisations requested while catching up.
+const catchupSyncInterval = 5 * time.Second
+
+// catchupTracker remembers the highest sequence each validator sent a consensus message for, to detect
+// when this node fell behind the rest of the network. It is only used from the core's event loop.
+type catchupTracker struct {
+	sequences       map[common.Address]uint64
+	lastSyncRequest time.Time
+}
+
+func newCatchupTracker() *catchupTracker {
+	return &catchupTracker{sequences: make(map[common.Address]uint64)}
+}
+
+// observe records that the given validator sent a message for the given sequence.
+func (t *catchupTracker) observe(validator common.Address, sequence uint64) {
+	if sequence > t.sequences[validator] {
+		t.sequences[validator] = sequence
+	}
+}
+
+// networkSequence returns the highest sequence that at least count validators of the given set sent
+// messages for, or zero if fewer validators were heard from.
+func (t *catchupTracker) networkSequence(valSet istanbul.ValidatorSet, count int) uint64 {
+	var sequences []uint64
+	for validator, sequence := range t.sequences {
+		if _, val := valSet.GetByAddress(validator); val != nil {
+			sequences = append(sequences, sequence)
+		}
+	}
+	if count <= 0 || len(sequences) < count {
+		return 0
+	}
+	sort.Slice(sequences, func(i, j int) bool { return sequences[i] > sequences[j] })
+	return sequences[count-1]
+}
+
+// isCatchingUp returns whether more than F validators are over ConsensusCatchupThreshold sequences ahead of
+// this node, in which case it can't help to commit its current sequence and should sync the missing blocks
+// instead. Requiring F+1 validators makes sure at least one honest validator is ahead.
+func (c *core) isCatchingUp() bool {
+	if c.config.ConsensusCatchupThreshold == 0 {
+		return false
+	}
+	valSet := c.current.ValidatorSet()
+	networkSequence := c.catchup.networkSequence(valSet, valSet.F()+1)
+	return networkSequence > c.current.Sequence().Uint64()+c.config.ConsensusCatchupThreshold
+}
+
+// observeFutureMessage records the sequence of a future message, and asks the backend to synchronise the
+// chain if this node fell too far behind. Once the missing blocks are inserted, the core moves to the
+// sequence after the new head like after any committed block.
+func (c *core) observeFutureMessage(msg *istanbul.Message) {
+	if c.config.ConsensusCatchupThreshold == 0 {
+		return
+	}
+	view, err := extractMessageView(msg)
+	if err != nil {
+		return
+	}
+	c.catchup.observe(msg.Address, view.Sequence.Uint64())
+
+	if !c.isCatchingUp() || time.Since(c.catchup.lastSyncRequest) < catchupSyncInterval {
+		return
+	}
+	c.catchup.lastSyncRequest = time.Now()
+	valSet := c.current.ValidatorSet()
+	c.newLogger("func", "observeFutureMessage").Info("Fell behind the network, synchronising the chain", "network_seq", c.catchup.networkSequence(valSet, valSet.F()+1))
+	c.backend.Synchronise()
+}
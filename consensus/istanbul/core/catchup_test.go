@@ -0,0 +1,86 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+func TestCatchupTracker(t *testing.T) {
+	valSet := newTestValidatorSet(4)
+	tracker := newCatchupTracker()
+
+	tracker.observe(valSet.GetByIndex(0).Address(), 20)
+	if seq := tracker.networkSequence(valSet, 2); seq != 0 {
+		t.Errorf("network sequence = %d with one validator heard from, want 0", seq)
+	}
+
+	tracker.observe(valSet.GetByIndex(1).Address(), 15)
+	tracker.observe(valSet.GetByIndex(1).Address(), 12)
+	tracker.observe(common.HexToAddress("0x01"), 30)
+	if seq := tracker.networkSequence(valSet, 2); seq != 15 {
+		t.Errorf("network sequence = %d, want 15", seq)
+	}
+	if seq := tracker.networkSequence(valSet, 1); seq != 20 {
+		t.Errorf("network sequence = %d, want 20", seq)
+	}
+}
+
+func TestCatchupWhenBehind(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 1)
+	for _, backend := range sys.backends {
+		backend.engine.(*core).current = newTestRoundState(newView(1, 0), backend.peers)
+	}
+	v0 := sys.backends[0]
+	c := v0.engine.(*core)
+	c.config.ConsensusCatchupThreshold = 10
+
+	futureMsg := func(from int, seq uint64) *istanbul.Message {
+		m, _ := Encode(&istanbul.Subject{View: newView(seq, 0), Digest: common.HexToHash("0x01")})
+		return &istanbul.Message{Code: istanbul.MsgPrepare, Msg: m, Address: sys.backends[from].Address()}
+	}
+
+	// A single validator claiming to be ahead isn't enough, it may be faulty
+	c.observeFutureMessage(futureMsg(1, 20))
+	if c.isCatchingUp() || v0.synchroniseCalls != 0 {
+		t.Errorf("catching up after hearing from a single validator ahead")
+	}
+
+	c.observeFutureMessage(futureMsg(2, 20))
+	if !c.isCatchingUp() {
+		t.Fatalf("not catching up with F+1 validators ahead")
+	}
+	if v0.synchroniseCalls != 1 {
+		t.Errorf("synchronised %d times, want 1", v0.synchroniseCalls)
+	}
+	c.observeFutureMessage(futureMsg(3, 20))
+	if v0.synchroniseCalls != 1 {
+		t.Errorf("synchronised %d times within the sync interval, want 1", v0.synchroniseCalls)
+	}
+
+	// No round change is sent for the stale sequence
+	if err := c.handleTimeoutAndMoveToNextRound(newView(1, 0)); err != nil {
+		t.Fatalf("failed to handle the timeout: %v", err)
+	}
+	if c.current.DesiredRound().Sign() != 0 {
+		t.Errorf("moved to round %v while catching up", c.current.DesiredRound())
+	}
+	c.stopAllTimers()
+}
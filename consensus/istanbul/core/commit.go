@@ -48,8 +48,7 @@ func (c *core) generateEpochValidatorSetData(blockNumber uint64, newValSet istan
 	for _, v := range newValSet.List() {
 		blsPubKeys = append(blsPubKeys, v.BLSPublicKey())
 	}
-	maxNonSigners := uint32(newValSet.Size() - newValSet.MinQuorumSize())
-	epochData, err := blscrypto.EncodeEpochSnarkData(blsPubKeys, maxNonSigners, uint16(istanbul.GetEpochNumber(blockNumber, c.config.Epoch)))
+	epochData, err := blscrypto.EncodeEpochSnarkData(blsPubKeys, uint32(maxNonSigners(newValSet)), uint16(istanbul.GetEpochNumber(blockNumber, c.config.Epoch)))
 	if err != nil {
 		return nil, err
 	}
@@ -60,29 +59,36 @@ func (c *core) generateEpochValidatorSetData(blockNumber uint64, newValSet istan
 func (c *core) broadcastCommit(sub *istanbul.Subject) {
 	logger := c.newLogger("func", "broadcastCommit")
 
-	committedSeal, err := c.generateCommittedSeal(sub)
+	istMsg, err := c.newCommitMessage(sub)
 	if err != nil {
-		logger.Error("Failed to commit seal", "err", err)
+		logger.Error("Failed to create commit message", "err", err)
 		return
 	}
+	c.broadcast(istMsg)
+}
+
+// newCommitMessage returns the unsigned COMMIT message of this node for the given subject, carrying its
+// committed seal and, on the last block of an epoch, its epoch validator set seal.
+func (c *core) newCommitMessage(sub *istanbul.Subject) (*istanbul.Message, error) {
+	committedSeal, err := c.generateCommittedSeal(sub)
+	if err != nil {
+		return nil, err
+	}
 
 	currentBlockNumber := c.current.Proposal().Number().Uint64()
 	newValSet, err := c.backend.NextBlockValidators(c.current.Proposal())
 	if err != nil {
-		logger.Error("Failed to get next block's validators", "err", err)
-		return
+		return nil, err
 	}
 	epochValidatorSetData, err := c.generateEpochValidatorSetData(currentBlockNumber, newValSet)
 	if err != nil && err != errNotLastBlockInEpoch {
-		logger.Error("Failed to create epoch validator set data", "err", err)
-		return
+		return nil, err
 	}
 	var epochValidatorSetSeal blscrypto.SerializedSignature
 	if err == nil {
 		epochValidatorSetSeal, err = c.backend.SignBLS(epochValidatorSetData[:], []byte{}, true)
 		if err != nil {
-			logger.Error("Failed to sign epoch validator set seal", "err", err)
-			return
+			return nil, err
 		}
 	}
 
@@ -93,14 +99,13 @@ func (c *core) broadcastCommit(sub *istanbul.Subject) {
 	}
 	encodedCommittedSubject, err := Encode(committedSub)
 	if err != nil {
-		logger.Error("Failed to encode committedSubject", committedSub)
+		return nil, err
 	}
 
-	istMsg := istanbul.Message{
+	return &istanbul.Message{
 		Code: istanbul.MsgCommit,
 		Msg:  encodedCommittedSubject,
-	}
-	c.broadcast(&istMsg)
+	}, nil
 }
 
 func (c *core) handleCommit(msg *istanbul.Message) error {
@@ -147,6 +152,7 @@ func (c *core) handleCheckedCommitForPreviousSequence(msg *istanbul.Message, com
 	if err := c.verifyCommittedSeal(commit, validator); err != nil {
 		return errInvalidCommittedSeal
 	}
+	c.checkDoubleSign(msg, commit)
 	if headBlock.Number().Uint64() > 0 {
 		if err := c.verifyEpochValidatorSetSeal(commit, headBlock.Number().Uint64(), c.current.ValidatorSet(), validator); err != nil {
 			return errInvalidEpochValidatorSetSeal
@@ -177,6 +183,7 @@ func (c *core) handleCheckedCommitForCurrentSequence(msg *istanbul.Message, comm
 	if err := c.verifyCommittedSeal(commit, validator); err != nil {
 		return errInvalidCommittedSeal
 	}
+	c.checkDoubleSign(msg, commit)
 
 	newValSet, err := c.backend.NextBlockValidators(c.current.Proposal())
 	if err != nil {
@@ -198,7 +205,7 @@ func (c *core) handleCheckedCommitForCurrentSequence(msg *istanbul.Message, comm
 		return err
 	}
 	numberOfCommits := c.current.Commits().Size()
-	minQuorumSize := c.current.ValidatorSet().MinQuorumSize()
+	valSet := c.current.ValidatorSet()
 	logger.Trace("Accepted commit for current sequence", "Number of commits", numberOfCommits)
 
 	// Commit the proposal once we have enough COMMIT messages and we are not in the Committed state.
@@ -206,7 +213,7 @@ func (c *core) handleCheckedCommitForCurrentSequence(msg *istanbul.Message, comm
 	// If we already have a proposal, we may have chance to speed up the consensus process
 	// by committing the proposal without PREPARE messages.
 	// TODO(joshua): Remove state comparisons (or change the cmp function)
-	if numberOfCommits >= minQuorumSize && c.current.State().Cmp(StateCommitted) < 0 {
+	if hasQuorum(valSet, c.current.Commits().Addresses()) && c.current.State().Cmp(StateCommitted) < 0 {
 		logger.Trace("Got a quorum of commits", "tag", "stateTransition", "commits", c.current.Commits)
 		err := c.commit()
 		if err != nil {
@@ -214,12 +221,13 @@ func (c *core) handleCheckedCommitForCurrentSequence(msg *istanbul.Message, comm
 			return err
 		}
 
-	} else if c.current.GetPrepareOrCommitSize() >= minQuorumSize && c.current.State().Cmp(StatePrepared) < 0 {
-		err := c.current.TransitionToPrepared(minQuorumSize)
+	} else if hasQuorum(valSet, append(c.current.Prepares().Addresses(), c.current.Commits().Addresses()...)) && c.current.State().Cmp(StatePrepared) < 0 {
+		err := c.current.TransitionToPrepared(valSet.MinQuorumWeight())
 		if err != nil {
 			logger.Error("Failed to create and set preprared certificate", "err", err)
 			return err
 		}
+		c.notifyStateTransition(istanbul.PreparedTransition)
 		// Process Backlog Messages
 		c.backlog.updateState(c.current.View(), c.current.State())
 
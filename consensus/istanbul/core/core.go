@@ -17,10 +17,11 @@
 package core
 
 import (
-	"bytes"
+	"encoding/binary"
 	"fmt"
 	"math"
 	"math/big"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	blscrypto "github.com/ethereum/go-ethereum/crypto/bls"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
@@ -95,13 +97,28 @@ type CoreBackend interface {
 
 	IsPrimaryForSeq(seq *big.Int) bool
 	UpdateReplicaState(seq *big.Int)
+
+	// HasMinValidatorsToStart returns whether enough validators are connected for this node
+	// to propose and change rounds
+	HasMinValidatorsToStart() bool
+
+	// Synchronise starts downloading the blocks this node is missing from its peers
+	Synchronise()
+
+	// NotifyStateTransition informs the backend of a consensus state transition.
+	// It must not block, as it is called from the core's event loop.
+	NotifyStateTransition(ev istanbul.StateTransitionEvent)
 }
 
 type core struct {
-	config         *istanbul.Config
-	address        common.Address
-	logger         log.Logger
-	selectProposer istanbul.ProposerSelector
+	config  *istanbul.Config
+	address common.Address
+	logger  log.Logger
+
+	// Proposers selected for proposerCacheValSet, which is the validator set the
+	// proposer selection was last called with
+	proposerCache       map[proposerCacheKey]common.Address
+	proposerCacheValSet istanbul.ValidatorSet
 
 	backend           CoreBackend
 	events            *event.TypeMuxSubscription
@@ -111,6 +128,19 @@ type core struct {
 	futurePreprepareTimer         *time.Timer
 	resendRoundChangeMessageTimer *time.Timer
 	roundChangeTimer              *time.Timer
+	proposalTimer                 *time.Timer
+
+	// roundChangeTimerDeadline and roundChangeTimeout are read by the RPC API, so they are guarded separately from the timer itself
+	roundChangeTimerDeadline   time.Time
+	roundChangeTimeout         time.Duration // The effective timeout of the running round change timer, after the backoff and cap
+	roundChangeTimerDeadlineMu sync.RWMutex
+
+	// timingConfig is the core's own copy of the timing fields of the istanbul config, so that they can be
+	// changed through the API without touching the shared config. pendingTimingConfig is the update
+	// requested through the API, applied at the next round boundary.
+	timingConfig        TimingConfig
+	pendingTimingConfig *TimingConfig
+	timingConfigMu      sync.RWMutex
 
 	validateFn func([]byte, []byte) (common.Address, error)
 
@@ -129,20 +159,61 @@ type core struct {
 
 	// the timer to record consensus duration (from accepting a preprepare to final committed stage)
 	consensusTimer metrics.Timer
+
+	// the last sequence for which it was logged that the round change timeout is capped by MaxRequestTimeout
+	timeoutCapLoggedSequence uint64
+
+	// whether it was logged that SingleValidatorMode is ignored because of a larger validator set
+	singleValidatorModeIgnored bool
+
+	// the time at which the current sequence was started
+	sequenceTimestamp time.Time
+	// the timer to record the time to commit a block (from starting a sequence to moving on to the next one)
+	timeToCommitTimer metrics.Timer
+	// the histogram of the number of round changes it took to agree on each sequence
+	roundChangesHistogram metrics.Histogram
+	// the meter of round change timer expirations
+	timeoutMeter metrics.Meter
+	// the meter of received messages signed by an address outside the validator set
+	unknownSenderMeter metrics.Meter
+	// the gauge of the effective timeout (in milliseconds) of the last started round change timer
+	roundChangeTimeoutGauge metrics.Gauge
+	// the meters of the causes of round changes
+	roundChangeCauseMeters map[roundChangeCause]metrics.Meter
+	// the view of the last proposal that failed verification
+	rejectedProposalView *istanbul.View
+	// the messages received from the network that were handled, to drop their duplicates
+	handledMessages *handledMessages
+	// the round states recorded at the recent state transitions, for istanbul_dumpRoundStateHistory
+	roundStateHistory *roundStateHistory
+	// the COMMITs of the recent sequences, to detect validators that commit to two blocks in a round
+	doubleSignDetector *doubleSignDetector
+	// detects the proposers that propose two different blocks in a round
+	equivocationDetector *equivocationDetector
+	// the consensus messages of the sequence traced with istanbul_traceSequence
+	sequenceTracer sequenceTracer
+	// the highest sequences sent by the validators, to detect when this node fell behind the network
+	catchup *catchupTracker
+
+	// pauses proposing after repeated failed proposals of this node
+	selfProposalBreaker selfProposalBreaker
 }
 
 // New creates an Istanbul consensus core
 func New(backend CoreBackend, config *istanbul.Config) Engine {
-	rsdb, err := newRoundStateDB(config.RoundStateDBPath, nil)
+	rsdb, err := newRoundStateDB(config.RoundStateDBPath, &RoundStateDBOptions{
+		withGarbageCollector: config.RoundStateRetention > 0,
+		sequencesToSave:      config.RoundStateRetention,
+	})
 	if err != nil {
 		log.Crit("Failed to open RoundStateDB", "err", err)
 	}
 
 	c := &core{
 		config:             config,
+		timingConfig:       TimingConfig{RequestTimeout: config.RequestTimeout},
 		address:            backend.Address(),
 		logger:             log.New(),
-		selectProposer:     validator.GetProposerSelector(config.ProposerPolicy),
 		handlerWg:          new(sync.WaitGroup),
 		backend:            backend,
 		pendingRequests:    prque.New(nil),
@@ -150,13 +221,26 @@ func New(backend CoreBackend, config *istanbul.Config) Engine {
 		consensusTimestamp: time.Time{},
 		rsdb:               rsdb,
 		consensusTimer:     metrics.NewRegisteredTimer("consensus/istanbul/core/consensus", nil),
+
+		timeToCommitTimer:       metrics.NewRegisteredTimer("consensus/istanbul/core/timetocommit", nil),
+		roundChangesHistogram:   metrics.NewRegisteredHistogram("consensus/istanbul/core/roundchanges", nil, metrics.NewExpDecaySample(1028, 0.015)),
+		timeoutMeter:            metrics.NewRegisteredMeter("consensus/istanbul/core/timeouts", nil),
+		unknownSenderMeter:      metrics.NewRegisteredMeter("consensus/istanbul/core/msgs/unknownsender", nil),
+		roundChangeTimeoutGauge: metrics.NewRegisteredGauge("consensus/istanbul/core/roundchangetimeout", nil),
+
+		roundChangeCauseMeters: newRoundChangeCauseMeters(),
+		handledMessages:        newHandledMessages(),
+		roundStateHistory:      newRoundStateHistory(config.RoundStateHistorySize),
+		doubleSignDetector:     newDoubleSignDetector(),
+		equivocationDetector:   newEquivocationDetector(),
+		catchup:                newCatchupTracker(),
 	}
 	msgBacklog := newMsgBacklog(
 		func(msg *istanbul.Message) {
 			c.sendEvent(backlogEvent{
 				msg: msg,
 			})
-		}, c.checkMessage)
+		}, c.checkMessage, config.MaxFutureMessages, config.MaxFutureMessagesPerSender)
 	c.backlog = msgBacklog
 	c.validateFn = c.checkValidatorSignature
 	c.logger = istanbul.NewIstLogger(
@@ -193,19 +277,71 @@ func (c *core) ParentCommits() MessageSet {
 	return c.current.ParentCommits()
 }
 
-func (c *core) ForceRoundChange() {
-	// timeout current DesiredView
+func (c *core) RoundChangeTimeoutRemaining() time.Duration {
+	c.roundChangeTimerDeadlineMu.RLock()
+	defer c.roundChangeTimerDeadlineMu.RUnlock()
+	if c.roundChangeTimerDeadline.IsZero() {
+		return 0
+	}
+	if remaining := time.Until(c.roundChangeTimerDeadline); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+func (c *core) RoundChangeTimeout() time.Duration {
+	c.roundChangeTimerDeadlineMu.RLock()
+	defer c.roundChangeTimerDeadlineMu.RUnlock()
+	return c.roundChangeTimeout
+}
+
+// SetTimingConfig schedules the given timing config to be applied at the next round boundary.
+func (c *core) SetTimingConfig(timingConfig TimingConfig) {
+	c.timingConfigMu.Lock()
+	defer c.timingConfigMu.Unlock()
+	c.pendingTimingConfig = &timingConfig
+}
+
+// TimingConfig returns the timing config currently in effect.
+func (c *core) TimingConfig() TimingConfig {
+	c.timingConfigMu.RLock()
+	defer c.timingConfigMu.RUnlock()
+	return c.timingConfig
+}
+
+func (c *core) applyPendingTimingConfig() {
+	c.timingConfigMu.Lock()
+	defer c.timingConfigMu.Unlock()
+	if c.pendingTimingConfig == nil {
+		return
+	}
+	logger := c.newLogger("func", "applyPendingTimingConfig")
+	logger.Info("Applying new timing config", "old_request_timeout", c.timingConfig.RequestTimeout, "new_request_timeout", c.pendingTimingConfig.RequestTimeout)
+	c.timingConfig = *c.pendingTimingConfig
+	c.pendingTimingConfig = nil
+}
+
+// maxForcedRoundChangeSkip is the largest number of rounds a forced round change may move past the current desired round
+const maxForcedRoundChangeSkip = 5
+
+func (c *core) ForceRoundChange(targetRound *big.Int) (*big.Int, error) {
 	view := &istanbul.View{Sequence: c.current.Sequence(), Round: c.current.DesiredRound()}
-	c.sendEvent(timeoutAndMoveToNextRoundEvent{view})
+	if targetRound == nil {
+		targetRound = new(big.Int).Add(view.Round, common.Big1)
+	}
+	if targetRound.Cmp(view.Round) <= 0 {
+		return nil, errForcedRoundChangeBackward
+	}
+	if new(big.Int).Sub(targetRound, view.Round).Cmp(big.NewInt(maxForcedRoundChangeSkip)) > 0 {
+		return nil, errForcedRoundChangeTooFar
+	}
+	c.sendEvent(forceRoundChangeEvent{view: view, targetRound: targetRound})
+	return targetRound, nil
 }
 
 // PrepareCommittedSeal returns a committed seal for the given hash and round number.
 func PrepareCommittedSeal(hash common.Hash, round *big.Int) []byte {
-	var buf bytes.Buffer
-	buf.Write(hash.Bytes())
-	buf.Write(round.Bytes())
-	buf.Write([]byte{byte(istanbul.MsgCommit)})
-	return buf.Bytes()
+	return istanbul.PrepareCommittedSeal(hash, round)
 }
 
 // GetAggregatedSeal aggregates all the given seals for a given message set to a bls aggregated
@@ -350,6 +486,7 @@ func (c *core) commit() error {
 	if err != nil {
 		return err
 	}
+	c.notifyStateTransition(istanbul.CommittedTransition)
 
 	// Process Backlog Messages
 	c.backlog.updateState(c.current.View(), c.current.State())
@@ -360,20 +497,20 @@ func (c *core) commit() error {
 		if err != nil {
 			nextRound := new(big.Int).Add(c.current.Round(), common.Big1)
 			logger.Warn("Error on commit, waiting for desired round", "reason", "getAggregatedSeal", "err", err, "desired_round", nextRound)
-			c.waitForDesiredRound(nextRound)
+			c.waitForDesiredRound(nextRound, causeBadProposal)
 			return nil
 		}
 		aggregatedEpochValidatorSetSeal, err := GetAggregatedEpochValidatorSetSeal(proposal.Number().Uint64(), c.config.Epoch, c.current.Commits())
 		if err != nil {
 			nextRound := new(big.Int).Add(c.current.Round(), common.Big1)
 			c.logger.Warn("Error on commit, waiting for desired round", "reason", "GetAggregatedEpochValidatorSetSeal", "err", err, "desired_round", nextRound)
-			c.waitForDesiredRound(nextRound)
+			c.waitForDesiredRound(nextRound, causeBadProposal)
 			return nil
 		}
 		if err := c.backend.Commit(proposal, aggregatedSeal, aggregatedEpochValidatorSetSeal); err != nil {
 			nextRound := new(big.Int).Add(c.current.Round(), common.Big1)
 			logger.Warn("Error on commit, waiting for desired round", "reason", "backend.Commit", "err", err, "desired_round", nextRound)
-			c.waitForDesiredRound(nextRound)
+			c.waitForDesiredRound(nextRound, causeBadProposal)
 			return nil
 		}
 	}
@@ -418,7 +555,7 @@ func GetAggregatedEpochValidatorSetSeal(blockNumber, epoch uint64, seals Message
 func (c *core) getPreprepareWithRoundChangeCertificate(round *big.Int) (*istanbul.Request, istanbul.RoundChangeCertificate, error) {
 	logger := c.newLogger("func", "getPreprepareWithRoundChangeCertificate", "for_round", round)
 
-	roundChangeCertificate, err := c.roundChangeSet.getCertificate(round, c.current.ValidatorSet().MinQuorumSize())
+	roundChangeCertificate, err := c.roundChangeSet.getCertificate(round, c.current.ValidatorSet().MinQuorumWeight())
 	if err != nil {
 		return &istanbul.Request{}, istanbul.RoundChangeCertificate{}, err
 	}
@@ -469,6 +606,10 @@ func (c *core) startNewRound(round *big.Int) error {
 			c.consensusTimer.UpdateSince(c.consensusTimestamp)
 			c.consensusTimestamp = time.Time{}
 		}
+		if !c.sequenceTimestamp.IsZero() {
+			c.timeToCommitTimer.UpdateSince(c.sequenceTimestamp)
+			c.roundChangesHistogram.Update(c.current.Round().Int64())
+		}
 		logger.Trace("Catch up to the latest block.")
 	} else if headBlock.Number().Cmp(big.NewInt(c.current.Sequence().Int64()-1)) == 0 {
 		// Working on the block immediately after the last committed block.
@@ -510,6 +651,7 @@ func (c *core) startNewRound(round *big.Int) error {
 			Round:    new(big.Int),
 		}
 		valSet = c.backend.Validators(headBlock)
+		c.logValidatorSetChange(c.current.ValidatorSet(), valSet, newView.Sequence)
 		c.roundChangeSet = newRoundChangeSet(valSet)
 	}
 
@@ -526,19 +668,43 @@ func (c *core) startNewRound(round *big.Int) error {
 		}
 	}
 
+	// Account for the outcome of the round being left, in case this node proposed in it
+	ownCommitted := !roundChange && headAuthor == c.address
+	if c.selfProposalBreaker.roundEnded(c.current.View(), ownCommitted, c.config.SelfProposalFailureThreshold, c.config.SelfProposalCooldown) {
+		logger.Error("Pausing proposing after repeated failed proposals", "failed_turns", c.config.SelfProposalFailureThreshold, "cooldown", c.config.SelfProposalCooldown, "paused_until_seq", c.selfProposalBreaker.pausedUntil)
+	}
+
 	// Calculate new proposer
-	nextProposer := c.selectProposer(valSet, headAuthor, newView.Round.Uint64())
+	nextProposer := c.selectProposer(valSet, headAuthor, newView.Sequence.Uint64(), newView.Round.Uint64())
 	err := c.resetRoundState(newView, valSet, nextProposer, roundChange)
 
 	if err != nil {
 		return err
 	}
 
+	// Keep the certificate that justified the new round, so that it can be restored on restart
+	if roundChange {
+		if err := c.rsdb.UpdateRoundChangeCertificate(newView, roundChangeCertificate); err != nil {
+			logger.Error("Failed to store round change certificate", "err", err, "new_round", round)
+		}
+	}
+
+	// Only apply timing changes between rounds, so that in-flight rounds keep their timing
+	c.applyPendingTimingConfig()
+
+	if !roundChange {
+		c.sequenceTimestamp = time.Now()
+		c.finishSequenceTrace(newView.Sequence)
+		c.notifyStateTransition(istanbul.NewSequenceTransition)
+	} else {
+		c.notifyStateTransition(istanbul.RoundChangeTransition)
+	}
+
 	// Process backlog
 	c.processPendingRequests()
 	c.backlog.updateState(c.current.View(), c.current.State())
 
-	if roundChange && c.isProposer() && request != nil {
+	if roundChange && (c.isProposer() || c.config.ShadowValidator) && request != nil {
 		c.sendPreprepare(request, roundChangeCertificate)
 	}
 	c.resetRoundChangeTimer()
@@ -550,7 +716,7 @@ func (c *core) startNewRound(round *big.Int) error {
 }
 
 // All actions that occur when transitioning to waiting for round change state.
-func (c *core) waitForDesiredRound(r *big.Int) error {
+func (c *core) waitForDesiredRound(r *big.Int, cause roundChangeCause) error {
 	logger := c.newLogger("func", "waitForDesiredRound", "new_desired_round", r)
 
 	// Don't wait for an older round
@@ -558,12 +724,13 @@ func (c *core) waitForDesiredRound(r *big.Int) error {
 		logger.Trace("New desired round not greater than current desired round")
 		return nil
 	}
+	c.markRoundChange(r, cause)
 
 	logger.Debug("Round Change: Waiting for desired round")
 
 	// Perform all of the updates
 	_, headAuthor := c.backend.GetCurrentHeadBlockAndAuthor()
-	nextProposer := c.selectProposer(c.current.ValidatorSet(), headAuthor, r.Uint64())
+	nextProposer := c.selectProposer(c.current.ValidatorSet(), headAuthor, c.current.Sequence().Uint64(), r.Uint64())
 	err := c.current.TransitionToWaitingForNewRound(r, nextProposer)
 	if err != nil {
 		return err
@@ -575,7 +742,11 @@ func (c *core) waitForDesiredRound(r *big.Int) error {
 	c.backlog.updateState(c.current.View(), c.current.State())
 
 	// Send round change
-	c.sendRoundChange()
+	if c.aggregatesRoundChange() {
+		c.sendRoundChangeToProposer()
+	} else {
+		c.sendRoundChange()
+	}
 	return nil
 }
 
@@ -597,25 +768,28 @@ func (c *core) createRoundState() (RoundState, error) {
 		return nil, err
 	}
 
-	if err == leveldb.ErrNotFound || lastStoredView.Sequence.Cmp(nextSequence) < 0 {
-		if err == leveldb.ErrNotFound {
-			logger.Info("Creating new RoundState", "reason", "No storedView found")
-		} else {
-			logger.Info("Creating new RoundState", "reason", "old view", "stored_view", lastStoredView, "requested_seq", nextSequence)
-		}
-		valSet := c.backend.Validators(headBlock)
-		proposer := c.selectProposer(valSet, headAuthor, 0)
-		roundState = newRoundState(&istanbul.View{Sequence: nextSequence, Round: common.Big0}, valSet, proposer)
+	if err == leveldb.ErrNotFound {
+		logger.Info("Creating new RoundState", "reason", "No storedView found")
+	} else if lastStoredView.Sequence.Cmp(nextSequence) < 0 {
+		logger.Info("Creating new RoundState", "reason", "old view", "stored_view", lastStoredView, "requested_seq", nextSequence)
 	} else {
 		logger.Info("Retrieving stored RoundState", "stored_view", lastStoredView, "requested_seq", nextSequence)
 		roundState, err = c.rsdb.GetRoundStateFor(lastStoredView)
 
 		if err != nil {
-			logger.Error("Failed to fetch lastStoredRoundState", "err", err)
-			return nil, err
+			// Start afresh rather than fail to start when the stored RoundState can't be decoded,
+			// e.g. because it was stored with an incompatible version.
+			logger.Warn("Creating new RoundState", "reason", "failed to fetch lastStoredRoundState", "stored_view", lastStoredView, "err", err)
+			roundState = nil
 		}
 	}
 
+	if roundState == nil {
+		valSet := c.backend.Validators(headBlock)
+		proposer := c.selectProposer(valSet, headAuthor, nextSequence.Uint64(), 0)
+		roundState = newRoundState(&istanbul.View{Sequence: nextSequence, Round: common.Big0}, valSet, proposer)
+	}
+
 	return withSavingDecorator(c.rsdb, roundState), nil
 }
 
@@ -649,6 +823,54 @@ func (c *core) resetRoundState(view *istanbul.View, validatorSet istanbul.Valida
 
 }
 
+// notifyStateTransition informs the backend that the core made the given transition
+// into its current view and state.
+func (c *core) notifyStateTransition(transition istanbul.StateTransition) {
+	var proposer common.Address
+	if c.current.Proposer() != nil {
+		proposer = c.current.Proposer().Address()
+	}
+	now := time.Now()
+	c.backend.NotifyStateTransition(istanbul.StateTransitionEvent{
+		Transition: transition,
+		Sequence:   new(big.Int).Set(c.current.Sequence()),
+		Round:      new(big.Int).Set(c.current.Round()),
+		Proposer:   proposer,
+		Timestamp:  now,
+	})
+	c.roundStateHistory.record(transition, now, c.current)
+}
+
+// RoundStateHistory returns up to count of the most recent round states recorded at state transitions,
+// oldest first.
+func (c *core) RoundStateHistory(count int) []*RoundStateSnapshot {
+	return c.roundStateHistory.last(count)
+}
+
+type proposerCacheKey struct {
+	lastProposer common.Address
+	round        uint64
+	policy       istanbul.ProposerPolicy
+}
+
+// selectProposer returns the proposer of the given round of the given sequence in the given validator set.
+// Selections are memoized for as long as they are made for the same validator set.
+func (c *core) selectProposer(valSet istanbul.ValidatorSet, lastProposer common.Address, sequence, round uint64) istanbul.Validator {
+	if valSet != c.proposerCacheValSet {
+		c.proposerCache = make(map[proposerCacheKey]common.Address)
+		c.proposerCacheValSet = valSet
+	}
+	policy := c.config.ProposerPolicyAt(sequence)
+	key := proposerCacheKey{lastProposer: lastProposer, round: round, policy: policy}
+	proposer, ok := c.proposerCache[key]
+	if !ok {
+		proposer = validator.SelectProposer(valSet, lastProposer, round, policy)
+		c.proposerCache[key] = proposer
+	}
+	_, val := valSet.GetByAddress(proposer)
+	return val
+}
+
 func (c *core) isProposer() bool {
 	if c.current == nil {
 		return false
@@ -668,6 +890,28 @@ func (c *core) stopRoundChangeTimer() {
 		c.roundChangeTimer.Stop()
 		c.roundChangeTimer = nil
 	}
+	c.setRoundChangeTimeout(0)
+}
+
+func (c *core) stopProposalTimer() {
+	if c.proposalTimer != nil {
+		c.proposalTimer.Stop()
+		c.proposalTimer = nil
+	}
+}
+
+// setRoundChangeTimeout records the timeout of the round change timer that was just started, or
+// zero if it was stopped.
+func (c *core) setRoundChangeTimeout(timeout time.Duration) {
+	c.roundChangeTimerDeadlineMu.Lock()
+	defer c.roundChangeTimerDeadlineMu.Unlock()
+	c.roundChangeTimeout = timeout
+	if timeout == 0 {
+		c.roundChangeTimerDeadline = time.Time{}
+		return
+	}
+	c.roundChangeTimerDeadline = time.Now().Add(timeout)
+	c.roundChangeTimeoutGauge.Update(int64(timeout / time.Millisecond))
 }
 
 func (c *core) stopResendRoundChangeTimer() {
@@ -680,23 +924,49 @@ func (c *core) stopResendRoundChangeTimer() {
 func (c *core) stopAllTimers() {
 	c.stopFuturePreprepareTimer()
 	c.stopRoundChangeTimer()
+	c.stopProposalTimer()
 	c.stopResendRoundChangeTimer()
 }
 
 func (c *core) getRoundChangeTimeout() time.Duration {
-	baseTimeout := time.Duration(c.config.RequestTimeout) * time.Millisecond
+	baseTimeout := time.Duration(c.TimingConfig().RequestTimeout) * time.Millisecond
 	round := c.current.DesiredRound().Uint64()
 	if round == 0 {
 		// timeout for first round takes into account expected block period
 		return baseTimeout + time.Duration(c.config.BlockPeriod)*time.Second
 	} else {
-		// timeout for subsequent rounds adds an exponential backoff.
-		return baseTimeout + time.Duration(math.Pow(2, float64(round)))*time.Duration(c.config.TimeoutBackoffFactor)*time.Millisecond
+		// timeout for subsequent rounds adds an exponential backoff, capped by MaxRequestTimeout.
+		// The backoff is computed as a float since it overflows a time.Duration for large rounds.
+		backoff := math.Pow(2, float64(round)) * float64(c.config.TimeoutBackoffFactor) * float64(time.Millisecond)
+		maxTimeout := time.Duration(c.config.MaxRequestTimeout) * time.Millisecond
+		if c.config.MaxRequestTimeout == 0 || maxTimeout < baseTimeout || float64(baseTimeout)+backoff <= float64(maxTimeout) {
+			return baseTimeout + time.Duration(backoff)
+		}
+		if seq := c.current.Sequence().Uint64(); seq != c.timeoutCapLoggedSequence {
+			c.timeoutCapLoggedSequence = seq
+			c.logger.Info("Round change timeout capped by MaxRequestTimeout", "round", round, "maxRequestTimeout", maxTimeout)
+		}
+		return maxTimeout
 	}
 }
 
+// getProposalTimeout returns the time within which the proposer of the current round must send its
+// proposal, or zero if the round only ends at the round change timeout. ProposalTimeout is ignored
+// unless it is smaller than RequestTimeout, which may have been lowered since the config was validated.
+func (c *core) getProposalTimeout() time.Duration {
+	if c.config.ProposalTimeout == 0 || c.config.ProposalTimeout >= c.TimingConfig().RequestTimeout {
+		return 0
+	}
+	timeout := time.Duration(c.config.ProposalTimeout) * time.Millisecond
+	if c.current.DesiredRound().Sign() == 0 {
+		// like the round change timeout, the proposal timeout for the first round takes into account expected block period
+		timeout += time.Duration(c.config.BlockPeriod) * time.Second
+	}
+	return timeout
+}
+
 // Reset then set the timer that causes a timeoutAndMoveToNextRoundEvent to be processed.
-// This may also reset the timer for the next resendRoundChangeEvent.
+// This may also reset the timers for the next proposalTimeoutEvent and resendRoundChangeEvent.
 func (c *core) resetRoundChangeTimer() {
 	// Stop all timers here since all 'resends' happen within the interval of a round's timeout.
 	// (Races are handled anyway by checking the seq and desired round haven't changed between
@@ -705,10 +975,19 @@ func (c *core) resetRoundChangeTimer() {
 
 	view := &istanbul.View{Sequence: c.current.Sequence(), Round: c.current.DesiredRound()}
 	timeout := c.getRoundChangeTimeout()
+	c.setRoundChangeTimeout(timeout)
 	c.roundChangeTimer = time.AfterFunc(timeout, func() {
 		c.sendEvent(timeoutAndMoveToNextRoundEvent{view})
 	})
 
+	if c.current.State() == StateAcceptRequest {
+		if proposalTimeout := c.getProposalTimeout(); proposalTimeout > 0 {
+			c.proposalTimer = time.AfterFunc(proposalTimeout, func() {
+				c.sendEvent(proposalTimeoutEvent{view})
+			})
+		}
+	}
+
 	if c.current.DesiredRound().Cmp(common.Big1) > 0 {
 		logger := c.newLogger("func", "resetRoundChangeTimer")
 		logger.Info("Reset timer to do round change", "timeout", timeout)
@@ -731,6 +1010,11 @@ func (c *core) resetResendRoundChangeTimer() {
 		if resendTimeout > maxResendTimeout {
 			resendTimeout = maxResendTimeout
 		}
+		fraction := rand.Float64()
+		if c.config.RoundChangeDeterministicOffset {
+			fraction = addressResendFraction(c.address)
+		}
+		resendTimeout = jitterResendTimeout(resendTimeout, minResendTimeout, c.config.RoundChangeResendJitter, fraction)
 		view := &istanbul.View{Sequence: c.current.Sequence(), Round: c.current.DesiredRound()}
 		c.resendRoundChangeMessageTimer = time.AfterFunc(resendTimeout, func() {
 			c.sendEvent(resendRoundChangeEvent{view})
@@ -741,6 +1025,24 @@ func (c *core) resetResendRoundChangeTimer() {
 	}
 }
 
+// jitterResendTimeout shortens the resend timeout by up to jitterPercent percent, picking how much by the given
+// fraction in [0, 1), but not below the minimum resend timeout. This spreads out the resends of the validators
+// that round change together.
+func jitterResendTimeout(resendTimeout, minResendTimeout time.Duration, jitterPercent uint64, fraction float64) time.Duration {
+	jitter := time.Duration(float64(resendTimeout) * float64(jitterPercent) / 100 * fraction)
+	if resendTimeout-jitter < minResendTimeout {
+		return minResendTimeout
+	}
+	return resendTimeout - jitter
+}
+
+// addressResendFraction returns a fraction in [0, 1) derived from the hash of the given address, which gives each
+// validator a stable offset in the RoundChange resend schedule instead of a random one.
+func addressResendFraction(address common.Address) float64 {
+	hash := crypto.Keccak256(address.Bytes())
+	return float64(binary.BigEndian.Uint64(hash[:8])>>11) / (1 << 53)
+}
+
 // Rebroadcast RoundChange message for desired round if still in StateWaitingForNewRound.
 // Do not advance desired round. Then clear/reset timer so we may rebroadcast again.
 func (c *core) resendRoundChangeMessage() {
@@ -754,6 +1056,17 @@ func (c *core) checkValidatorSignature(data []byte, sig []byte) (common.Address,
 	return istanbul.CheckValidatorSignature(c.current.ValidatorSet(), data, sig)
 }
 
+// checkRecoveredSigner returns a validateFn for a message whose signer was already recovered from its
+// signature, which only checks that the signer is in the current validator set.
+func (c *core) checkRecoveredSigner(signer common.Address) func([]byte, []byte) (common.Address, error) {
+	return func(data []byte, sig []byte) (common.Address, error) {
+		if _, val := c.current.ValidatorSet().GetByAddress(signer); val != nil {
+			return val.Address(), nil
+		}
+		return common.Address{}, fmt.Errorf("%w %s", istanbul.ErrUnauthorizedAddress, signer.Hex())
+	}
+}
+
 func (c *core) verifyProposal(proposal istanbul.Proposal) (time.Duration, error) {
 	logger := c.newLogger("func", "verifyProposal", "proposal", proposal.Hash())
 	if verificationStatus, isCached := c.current.GetProposalVerificationStatus(proposal.Hash()); isCached {
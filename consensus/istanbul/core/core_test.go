@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -84,6 +85,237 @@ func TestNewRequest(t *testing.T) {
 	}
 }
 
+func TestStateTransitionNotifications(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 1)
+
+	close := sys.Run(true)
+	defer close()
+
+	sys.backends[0].NewRequest(makeBlock(1))
+
+	<-time.After(1 * time.Second)
+
+	expected := []struct {
+		transition istanbul.StateTransition
+		sequence   int64
+	}{
+		{istanbul.NewSequenceTransition, 1},
+		{istanbul.CommittedTransition, 1},
+		{istanbul.NewSequenceTransition, 2},
+	}
+	for i, backend := range sys.backends {
+		j := 0
+		for _, ev := range backend.stateTransitions {
+			if ev.Round.Sign() != 0 {
+				t.Errorf("backend %d: unexpected round in %v event: have %v, want 0", i, ev.Transition, ev.Round)
+			}
+			if ev.Proposer != sys.backends[0].Address() {
+				t.Errorf("backend %d: unexpected proposer in %v event: have %v, want %v", i, ev.Transition, ev.Proposer.Hex(), sys.backends[0].Address().Hex())
+			}
+			if j < len(expected) && ev.Transition == expected[j].transition && ev.Sequence.Int64() == expected[j].sequence {
+				j++
+			}
+		}
+		if j != len(expected) {
+			t.Errorf("backend %d: missing %v event for sequence %d", i, expected[j].transition, expected[j].sequence)
+		}
+	}
+}
+
+func TestMinValidatorsToStart(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 1)
+	// Let the round 0 timeout expire without waiting for the block period
+	sys.backends[0].engine.(*core).config.BlockPeriod = 0
+	for _, b := range sys.backends {
+		b.tooFewValidators = true
+	}
+
+	close := sys.Run(true)
+	defer close()
+
+	for _, b := range sys.backends {
+		b.NewRequest(makeBlock(1))
+	}
+
+	// While paused, nothing is proposed and the round change timeouts don't move to the next round
+	<-time.After(1 * time.Second)
+	for i, b := range sys.backends {
+		if len(b.committedMsgs) != 0 {
+			t.Errorf("backend %d: committed %d blocks while paused", i, len(b.committedMsgs))
+		}
+		if round := b.engine.CurrentView().Round; round.Sign() != 0 {
+			t.Errorf("backend %d: moved to round %v while paused", i, round)
+		}
+	}
+
+	// Once enough validators are connected, consensus starts normally
+	for _, b := range sys.backends {
+		b.tooFewValidators = false
+	}
+	<-time.After(2 * time.Second)
+	for i, b := range sys.backends {
+		if len(b.committedMsgs) == 0 {
+			t.Errorf("backend %d: did not commit a block after resuming", i)
+		}
+	}
+}
+
+func TestSelectProposerCache(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 1)
+	c := sys.backends[0].engine.(*core)
+	valSet := sys.backends[0].peers
+	lastProposer := valSet.GetByIndex(1).Address()
+
+	proposer := c.selectProposer(valSet, lastProposer, 1, 1)
+	if want := valSet.GetByIndex(3); proposer != want {
+		t.Errorf("proposer mismatch: have %v, want %v", proposer, want)
+	}
+	if len(c.proposerCache) != 1 {
+		t.Errorf("expected the selection to be memoized, have %d cache entries", len(c.proposerCache))
+	}
+	if cached := c.selectProposer(valSet, lastProposer, 1, 1); cached != proposer {
+		t.Errorf("cached proposer mismatch: have %v, want %v", cached, proposer)
+	}
+
+	// Selecting from another validator set resets the cache
+	otherValSet := valSet.Copy()
+	proposer = c.selectProposer(otherValSet, lastProposer, 1, 2)
+	if want := otherValSet.GetByIndex(0); proposer != want {
+		t.Errorf("proposer mismatch: have %v, want %v", proposer, want)
+	}
+	if len(c.proposerCache) != 1 || c.proposerCacheValSet != otherValSet {
+		t.Errorf("expected the cache to be reset for the new validator set")
+	}
+}
+
+func TestSelectProposerAtPolicyFork(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 2)
+	valSet := sys.backends[0].peers.Copy()
+	valSet.SetRandomness(common.HexToHash("0xabcdef"))
+	forkBlock := uint64(5)
+
+	// A node that knows about the fork switches from the legacy policy at the fork block
+	upgraded := sys.backends[0].engine.(*core)
+	upgradedConfig := *upgraded.config
+	upgradedConfig.LegacyProposerPolicy = istanbul.RoundRobin
+	upgradedConfig.ProposerPolicy = istanbul.ShuffledRoundRobin
+	upgradedConfig.ProposerPolicyForkBlock = forkBlock
+	upgraded.config = &upgradedConfig
+
+	// A node of the previous version only knows one policy at a time, and is restarted with the new
+	// policy when the fork block is reached
+	legacy := sys.backends[1].engine.(*core)
+	legacyConfig := *legacy.config
+	legacyConfig.ProposerPolicy = istanbul.RoundRobin
+	newConfig := legacyConfig
+	newConfig.ProposerPolicy = istanbul.ShuffledRoundRobin
+
+	lastProposer := valSet.GetByIndex(0).Address()
+	for sequence := uint64(1); sequence < 2*forkBlock; sequence++ {
+		legacy.config = &legacyConfig
+		if sequence >= forkBlock {
+			legacy.config = &newConfig
+		}
+		for round := uint64(0); round < 3; round++ {
+			want := legacy.selectProposer(valSet, lastProposer, sequence, round)
+			if have := upgraded.selectProposer(valSet, lastProposer, sequence, round); have != want {
+				t.Errorf("proposer mismatch at sequence %d round %d: have %v, want %v", sequence, round, have.Address(), want.Address())
+			}
+		}
+		lastProposer = upgraded.selectProposer(valSet, lastProposer, sequence, 0).Address()
+	}
+
+	// The policies select different proposers at the fork, so a node that didn't switch would disagree
+	legacy.config = &legacyConfig
+	if upgraded.selectProposer(valSet, lastProposer, forkBlock, 0) == legacy.selectProposer(valSet, lastProposer, forkBlock, 0) &&
+		upgraded.selectProposer(valSet, lastProposer, forkBlock, 1) == legacy.selectProposer(valSet, lastProposer, forkBlock, 1) {
+		t.Errorf("expected the proposer policies to select different proposers at the fork")
+	}
+}
+
+func TestShadowValidatorDoesNotPropose(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 1)
+	proposer := sys.backends[0].engine.(*core)
+	shadowConfig := *proposer.config
+	shadowConfig.ShadowValidator = true
+	proposer.config = &shadowConfig
+
+	close := sys.Run(true)
+	defer close()
+
+	sys.backends[0].NewRequest(makeBlock(1))
+
+	<-time.After(1 * time.Second)
+
+	for i, backend := range sys.backends {
+		if len(backend.committedMsgs) != 0 {
+			t.Errorf("backend %d: committed %d blocks without a proposal", i, len(backend.committedMsgs))
+		}
+		if state := backend.engine.(*core).current.State(); state != StateAcceptRequest {
+			t.Errorf("backend %d: unexpected state %v", i, state)
+		}
+	}
+}
+
+func TestForceRoundChange(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 1)
+	c := sys.backends[0].engine.(*core)
+
+	close := sys.Run(true)
+	defer close()
+
+	expectDesiredRound := func(round int64) {
+		t.Helper()
+		<-time.After(100 * time.Millisecond)
+		if desiredRound := c.current.DesiredRound(); desiredRound.Cmp(big.NewInt(round)) != 0 {
+			t.Errorf("Expected desired round %d, got %v", round, desiredRound)
+		}
+	}
+
+	round, err := c.ForceRoundChange(big.NewInt(3))
+	if err != nil || round.Cmp(big.NewInt(3)) != 0 {
+		t.Fatalf("Expected to force a round change to round 3, got %v, %v", round, err)
+	}
+	expectDesiredRound(3)
+
+	if _, err := c.ForceRoundChange(big.NewInt(3)); err != errForcedRoundChangeBackward {
+		t.Errorf("Expected %v when forcing the current desired round, got %v", errForcedRoundChangeBackward, err)
+	}
+	if _, err := c.ForceRoundChange(big.NewInt(3 + maxForcedRoundChangeSkip + 1)); err != errForcedRoundChangeTooFar {
+		t.Errorf("Expected %v when skipping too many rounds, got %v", errForcedRoundChangeTooFar, err)
+	}
+	expectDesiredRound(3)
+
+	round, err = c.ForceRoundChange(nil)
+	if err != nil || round.Cmp(big.NewInt(4)) != 0 {
+		t.Fatalf("Expected to force a round change to round 4, got %v, %v", round, err)
+	}
+	expectDesiredRound(4)
+}
+
+func TestCreateRoundStateDiscardsIncompatibleRoundState(t *testing.T) {
+	sys := NewTestSystemWithBackend(1, 0)
+	c := sys.backends[0].engine.(*core)
+
+	// Store a RoundState for the next sequence that can't be decoded
+	storedView := &istanbul.View{Sequence: big.NewInt(1), Round: big.NewInt(2)}
+	db := c.rsdb.(*roundStateDBImpl).db
+	if err := db.Put([]byte(lastViewKey), view2Key(storedView), nil); err != nil {
+		t.Fatalf("Failed to store the last view: %v", err)
+	}
+	if err := db.Put(view2Key(storedView), []byte{0xc1, 0x02}, nil); err != nil {
+		t.Fatalf("Failed to store the round state: %v", err)
+	}
+
+	rs, err := c.createRoundState()
+	if err != nil {
+		t.Fatalf("Failed to create a RoundState: %v", err)
+	}
+	if rs.Sequence().Cmp(big.NewInt(1)) != 0 || rs.Round().Sign() != 0 {
+		t.Errorf("Expected a new RoundState for sequence 1 and round 0, got %v", rs.View())
+	}
+}
+
 func TestVerifyProposal(t *testing.T) {
 	// Check that it should not be in the cache
 	sys := NewTestSystemWithBackend(1, 0)
@@ -171,3 +403,122 @@ func TestVerifyProposal(t *testing.T) {
 			})
 	}
 }
+
+func TestRoundChangeTimeoutRemaining(t *testing.T) {
+	sys := NewTestSystemWithBackend(1, 0)
+
+	close := sys.Run(true)
+	defer close()
+
+	c := sys.backends[0].engine.(*core)
+
+	remaining := c.RoundChangeTimeoutRemaining()
+	if remaining <= 0 || remaining > c.getRoundChangeTimeout() {
+		t.Errorf("Unexpected remaining timeout. Want: (0, %v], Actual: %v", c.getRoundChangeTimeout(), remaining)
+	}
+	if timeout := c.RoundChangeTimeout(); timeout != c.getRoundChangeTimeout() {
+		t.Errorf("Unexpected effective timeout. Want: %v, Actual: %v", c.getRoundChangeTimeout(), timeout)
+	}
+
+	c.stopAllTimers()
+	if remaining := c.RoundChangeTimeoutRemaining(); remaining != 0 {
+		t.Errorf("Unexpected remaining timeout after stopping timers. Want: 0, Actual: %v", remaining)
+	}
+	if timeout := c.RoundChangeTimeout(); timeout != 0 {
+		t.Errorf("Unexpected effective timeout after stopping timers. Want: 0, Actual: %v", timeout)
+	}
+}
+
+func TestSetTimingConfig(t *testing.T) {
+	sys := NewTestSystemWithBackend(1, 0)
+	c := sys.backends[0].engine.(*core)
+	oldRequestTimeout := c.config.RequestTimeout
+
+	c.SetTimingConfig(TimingConfig{RequestTimeout: oldRequestTimeout + 1000})
+	if c.TimingConfig().RequestTimeout != oldRequestTimeout {
+		t.Fatalf("Timing config applied before the round boundary")
+	}
+
+	c.applyPendingTimingConfig()
+	if timeout := c.TimingConfig().RequestTimeout; timeout != oldRequestTimeout+1000 {
+		t.Errorf("Unexpected request timeout. Want: %v, Actual: %v", oldRequestTimeout+1000, timeout)
+	}
+	if c.config.RequestTimeout != oldRequestTimeout {
+		t.Errorf("Shared config changed by the timing config. Want: %v, Actual: %v", oldRequestTimeout, c.config.RequestTimeout)
+	}
+	if c.pendingTimingConfig != nil {
+		t.Errorf("Pending timing config should be cleared once applied")
+	}
+}
+
+func TestJitterResendTimeout(t *testing.T) {
+	testCases := []struct {
+		resendTimeout time.Duration
+		jitterPercent uint64
+		fraction      float64
+		want          time.Duration
+	}{
+		{resendTimeout: 100 * time.Second, jitterPercent: 0, fraction: 0.5, want: 100 * time.Second},
+		{resendTimeout: 100 * time.Second, jitterPercent: 20, fraction: 0, want: 100 * time.Second},
+		{resendTimeout: 100 * time.Second, jitterPercent: 20, fraction: 0.5, want: 90 * time.Second},
+		{resendTimeout: 100 * time.Second, jitterPercent: 100, fraction: 0.5, want: 50 * time.Second},
+		// Never shorter than the minimum resend timeout
+		{resendTimeout: 100 * time.Second, jitterPercent: 100, fraction: 0.9, want: 15 * time.Second},
+	}
+
+	for _, testCase := range testCases {
+		have := jitterResendTimeout(testCase.resendTimeout, 15*time.Second, testCase.jitterPercent, testCase.fraction)
+		if have != testCase.want {
+			t.Errorf("jitterResendTimeout(%v, %d%%, %v) = %v, want %v", testCase.resendTimeout, testCase.jitterPercent, testCase.fraction, have, testCase.want)
+		}
+	}
+}
+
+func TestAddressResendFraction(t *testing.T) {
+	seen := make(map[float64]bool)
+	for i := 0; i < 10; i++ {
+		address := common.BigToAddress(big.NewInt(int64(i)))
+		fraction := addressResendFraction(address)
+		if fraction < 0 || fraction >= 1 {
+			t.Errorf("addressResendFraction(%v) = %v, want a fraction in [0, 1)", address.Hex(), fraction)
+		}
+		if again := addressResendFraction(address); again != fraction {
+			t.Errorf("addressResendFraction(%v) = %v, then %v, want a stable fraction", address.Hex(), fraction, again)
+		}
+		seen[fraction] = true
+	}
+	if len(seen) != 10 {
+		t.Errorf("addressResendFraction gave %d distinct fractions for 10 addresses, want 10", len(seen))
+	}
+}
+
+func TestRoundChangeTimeoutCap(t *testing.T) {
+	sys := NewTestSystemWithBackend(1, 0)
+	c := sys.backends[0].engine.(*core)
+	c.config.MaxRequestTimeout = 10000
+
+	// RequestTimeout is 300ms and TimeoutBackoffFactor is 100ms
+	tests := []struct {
+		round uint64
+		want  time.Duration
+	}{
+		{1, 500 * time.Millisecond},
+		{6, 6700 * time.Millisecond},
+		{7, 10 * time.Second},
+		{100, 10 * time.Second},
+		{2000, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		c.current = newTestRoundState(newView(1, tt.round), sys.backends[0].peers)
+		if have := c.getRoundChangeTimeout(); have != tt.want {
+			t.Errorf("round %d: unexpected timeout. Want: %v, Actual: %v", tt.round, tt.want, have)
+		}
+	}
+
+	// Without a cap the backoff keeps growing
+	c.config.MaxRequestTimeout = 0
+	c.current = newTestRoundState(newView(1, 10), sys.backends[0].peers)
+	if have, want := c.getRoundChangeTimeout(), 102700*time.Millisecond; have != want {
+		t.Errorf("unexpected uncapped timeout. Want: %v, Actual: %v", want, have)
+	}
+}
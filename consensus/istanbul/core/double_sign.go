@@ -0,0 +1,149 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxDoubleSignEvidence is the number of double signs whose evidence is kept, the oldest is dropped first.
+const maxDoubleSignEvidence = 100
+
+// SignedCommit is a COMMIT message as received from a validator.
+type SignedCommit struct {
+	Round         uint64        `json:"round"`
+	Digest        common.Hash   `json:"digest"`
+	CommittedSeal hexutil.Bytes `json:"committedSeal"`
+	Message       hexutil.Bytes `json:"message"` // The COMMIT message as signed by the validator
+}
+
+// DoubleSignEvidence is a pair of COMMIT messages with valid committed seals that a validator sent for
+// different blocks in the same round of a sequence.
+type DoubleSignEvidence struct {
+	Validator  common.Address `json:"validator"`
+	Sequence   uint64         `json:"sequence"`
+	First      *SignedCommit  `json:"first"`
+	Second     *SignedCommit  `json:"second"`
+	DetectedAt time.Time      `json:"detectedAt"`
+}
+
+// doubleSignDetector remembers the first COMMIT of each validator in each round of the recent sequences, and
+// keeps the evidence of the validators that committed to another block in a round they already committed in.
+// COMMITs for different blocks in different rounds are not a double sign: an honest validator that prepared a
+// block may commit to another one in a later round, if the round change certificate of that round didn't
+// include its prepared certificate.
+type doubleSignDetector struct {
+	commits  map[uint64]map[doubleSignKey]*SignedCommit // The first COMMIT of each validator in each round, by sequence
+	evidence []*DoubleSignEvidence
+	mu       sync.RWMutex
+
+	doubleSignsMeter metrics.Meter
+}
+
+type doubleSignKey struct {
+	validator common.Address
+	round     uint64
+}
+
+func newDoubleSignDetector() *doubleSignDetector {
+	return &doubleSignDetector{
+		commits:          make(map[uint64]map[doubleSignKey]*SignedCommit),
+		doubleSignsMeter: metrics.NewRegisteredMeter("consensus/istanbul/core/doublesigns", nil),
+	}
+}
+
+// record remembers a COMMIT whose committed seal was verified, and returns the evidence of a double sign
+// if the validator committed to another block in the same round before. Only the COMMITs of the given
+// sequence and the one before it are remembered, since older ones aren't handled anymore.
+func (d *doubleSignDetector) record(validator common.Address, sequence uint64, commit *SignedCommit, currentSequence uint64) *DoubleSignEvidence {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for seq := range d.commits {
+		if seq+1 < currentSequence {
+			delete(d.commits, seq)
+		}
+	}
+
+	commits, ok := d.commits[sequence]
+	if !ok {
+		commits = make(map[doubleSignKey]*SignedCommit)
+		d.commits[sequence] = commits
+	}
+	key := doubleSignKey{validator: validator, round: commit.Round}
+	first, ok := commits[key]
+	if !ok {
+		commits[key] = commit
+		return nil
+	}
+	if first.Digest == commit.Digest {
+		return nil
+	}
+	for _, evidence := range d.evidence {
+		if evidence.Validator == validator && evidence.Sequence == sequence && evidence.First.Round == commit.Round {
+			// Already reported
+			return nil
+		}
+	}
+
+	evidence := &DoubleSignEvidence{Validator: validator, Sequence: sequence, First: first, Second: commit, DetectedAt: time.Now()}
+	d.evidence = append(d.evidence, evidence)
+	if len(d.evidence) > maxDoubleSignEvidence {
+		d.evidence = d.evidence[1:]
+	}
+	d.doubleSignsMeter.Mark(1)
+	return evidence
+}
+
+// list returns the evidence of the detected double signs, oldest first.
+func (d *doubleSignDetector) list() []*DoubleSignEvidence {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]*DoubleSignEvidence(nil), d.evidence...)
+}
+
+// checkDoubleSign records a COMMIT message whose committed seal was verified, and logs the evidence if
+// its sender committed to another block in the same round before.
+func (c *core) checkDoubleSign(msg *istanbul.Message, commit *istanbul.CommittedSubject) {
+	payload, err := msg.Payload()
+	if err != nil {
+		return
+	}
+	signedCommit := &SignedCommit{
+		Round:         commit.Subject.View.Round.Uint64(),
+		Digest:        commit.Subject.Digest,
+		CommittedSeal: commit.CommittedSeal,
+		Message:       payload,
+	}
+	evidence := c.doubleSignDetector.record(msg.Address, commit.Subject.View.Sequence.Uint64(), signedCommit, c.current.Sequence().Uint64())
+	if evidence != nil {
+		c.newLogger("func", "checkDoubleSign").Error("Validator committed to two different blocks in the same round", "validator", evidence.Validator, "sequence", evidence.Sequence, "round", evidence.First.Round,
+			"first_digest", evidence.First.Digest, "first_seal", evidence.First.CommittedSeal, "second_digest", evidence.Second.Digest, "second_seal", evidence.Second.CommittedSeal)
+	}
+}
+
+// DoubleSignEvidence returns the evidence of the validators detected committing to two different blocks
+// in the same round, oldest first.
+func (c *core) DoubleSignEvidence() []*DoubleSignEvidence {
+	return c.doubleSignDetector.list()
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDoubleSignDetector(t *testing.T) {
+	d := newDoubleSignDetector()
+	validator := common.HexToAddress("0x01")
+	other := common.HexToAddress("0x02")
+	blockA := common.HexToHash("0xa")
+	blockB := common.HexToHash("0xb")
+
+	if evidence := d.record(validator, 1, &SignedCommit{Round: 0, Digest: blockA}, 1); evidence != nil {
+		t.Errorf("first commit reported as a double sign")
+	}
+	if evidence := d.record(validator, 1, &SignedCommit{Round: 0, Digest: blockA}, 1); evidence != nil {
+		t.Errorf("repeated commit reported as a double sign")
+	}
+	if evidence := d.record(other, 1, &SignedCommit{Round: 0, Digest: blockB}, 1); evidence != nil {
+		t.Errorf("commit of another validator reported as a double sign")
+	}
+	// An honest validator may commit to another block in a later round
+	if evidence := d.record(validator, 1, &SignedCommit{Round: 1, Digest: blockB}, 1); evidence != nil {
+		t.Errorf("commit in another round reported as a double sign")
+	}
+
+	evidence := d.record(validator, 1, &SignedCommit{Round: 0, Digest: blockB}, 1)
+	if evidence == nil {
+		t.Fatalf("double sign not detected")
+	}
+	if evidence.Validator != validator || evidence.Sequence != 1 || evidence.First.Digest != blockA || evidence.Second.Digest != blockB {
+		t.Errorf("evidence = %+v, want the commits of %v to %v and %v at sequence 1", evidence, validator.Hex(), blockA.Hex(), blockB.Hex())
+	}
+	// A double sign is reported once
+	if evidence := d.record(validator, 1, &SignedCommit{Round: 0, Digest: common.HexToHash("0xc")}, 1); evidence != nil {
+		t.Errorf("double sign reported twice")
+	}
+	if list := d.list(); len(list) != 1 {
+		t.Errorf("got %d double sign evidences, want 1", len(list))
+	}
+
+	// The commits of old sequences are forgotten, the evidence is kept
+	d.record(other, 3, &SignedCommit{Round: 0, Digest: blockA}, 3)
+	if _, ok := d.commits[1]; ok {
+		t.Errorf("commits of sequence 1 kept at sequence 3")
+	}
+	if list := d.list(); len(list) != 1 {
+		t.Errorf("got %d double sign evidences, want 1", len(list))
+	}
+}
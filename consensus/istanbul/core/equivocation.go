@@ -0,0 +1,139 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxEquivocationEvidence is the number of equivocations whose evidence is kept, the oldest is dropped first.
+const maxEquivocationEvidence = 100
+
+// SignedPreprepare is a PREPREPARE message as received from a proposer.
+type SignedPreprepare struct {
+	Digest  common.Hash   `json:"digest"`
+	Message hexutil.Bytes `json:"message"` // The PREPREPARE message as signed by the proposer
+}
+
+// EquivocationEvidence is a pair of PREPREPARE messages in which the proposer of a round proposed two
+// different blocks.
+type EquivocationEvidence struct {
+	Proposer   common.Address    `json:"proposer"`
+	Sequence   uint64            `json:"sequence"`
+	Round      uint64            `json:"round"`
+	First      *SignedPreprepare `json:"first"`
+	Second     *SignedPreprepare `json:"second"`
+	DetectedAt time.Time         `json:"detectedAt"`
+}
+
+// equivocationDetector remembers the first PREPREPARE received from the proposer of the latest view, and
+// keeps the evidence of the proposers that sent a PREPREPARE for another block in that view.
+type equivocationDetector struct {
+	view     *istanbul.View
+	first    *SignedPreprepare
+	reported bool
+	evidence []*EquivocationEvidence
+	mu       sync.RWMutex
+
+	equivocationsMeter metrics.Meter
+}
+
+func newEquivocationDetector() *equivocationDetector {
+	return &equivocationDetector{
+		equivocationsMeter: metrics.NewRegisteredMeter("consensus/istanbul/core/equivocations", nil),
+	}
+}
+
+// record remembers a PREPREPARE sent by the proposer of its view, and returns the evidence of an
+// equivocation if the proposer sent a PREPREPARE for another block in the same view before. Only the
+// latest view is remembered, PREPREPAREs of earlier views are ignored.
+func (d *equivocationDetector) record(proposer common.Address, view *istanbul.View, preprepare *SignedPreprepare) *EquivocationEvidence {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.view == nil || view.Cmp(d.view) > 0 {
+		d.view = &istanbul.View{Sequence: new(big.Int).Set(view.Sequence), Round: new(big.Int).Set(view.Round)}
+		d.first, d.reported = preprepare, false
+		return nil
+	}
+	if view.Cmp(d.view) < 0 || d.first.Digest == preprepare.Digest || d.reported {
+		return nil
+	}
+
+	d.reported = true
+	evidence := &EquivocationEvidence{
+		Proposer:   proposer,
+		Sequence:   view.Sequence.Uint64(),
+		Round:      view.Round.Uint64(),
+		First:      d.first,
+		Second:     preprepare,
+		DetectedAt: time.Now(),
+	}
+	d.evidence = append(d.evidence, evidence)
+	if len(d.evidence) > maxEquivocationEvidence {
+		d.evidence = d.evidence[1:]
+	}
+	d.equivocationsMeter.Mark(1)
+	return evidence
+}
+
+// list returns the evidence of the detected equivocations, oldest first.
+func (d *equivocationDetector) list() []*EquivocationEvidence {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]*EquivocationEvidence(nil), d.evidence...)
+}
+
+// checkEquivocation records a PREPREPARE message from the proposer of its view, and returns whether the
+// proposer already proposed another block in that view. The conflicting PREPREPARE is never handled: with
+// the KeepFirstProposal policy this node continues with the first proposal, with RoundChangeOnEquivocation
+// it moves to the next round if the equivocation is in the current round.
+func (c *core) checkEquivocation(msg *istanbul.Message, preprepare *istanbul.Preprepare) bool {
+	payload, err := msg.Payload()
+	if err != nil {
+		return false
+	}
+	signedPreprepare := &SignedPreprepare{Digest: preprepare.Proposal.Hash(), Message: payload}
+	evidence := c.equivocationDetector.record(msg.Address, preprepare.View, signedPreprepare)
+	if evidence == nil {
+		return false
+	}
+
+	logger := c.newLogger("func", "checkEquivocation")
+	logger.Error("Proposer sent two different proposals in the same round", "proposer", evidence.Proposer, "sequence", evidence.Sequence, "round", evidence.Round,
+		"first_digest", evidence.First.Digest, "second_digest", evidence.Second.Digest, "policy", c.config.EquivocationPolicy)
+	if c.config.EquivocationPolicy == istanbul.RoundChangeOnEquivocation && c.current.Round().Cmp(preprepare.View.Round) == 0 {
+		nextRound := new(big.Int).Add(preprepare.View.Round, common.Big1)
+		if err := c.waitForDesiredRound(nextRound, causeEquivocation); err != nil {
+			logger.Warn("Failed to move to the next round after an equivocation", "err", err)
+		}
+	}
+	return true
+}
+
+// EquivocationEvidence returns the evidence of the proposers detected proposing two blocks in a round,
+// oldest first.
+func (c *core) EquivocationEvidence() []*EquivocationEvidence {
+	return c.equivocationDetector.list()
+}
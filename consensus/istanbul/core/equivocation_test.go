@@ -0,0 +1,114 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestEquivocationDetector(t *testing.T) {
+	d := newEquivocationDetector()
+	proposer := common.HexToAddress("0x01")
+	blockA := &SignedPreprepare{Digest: common.HexToHash("0xa")}
+	blockB := &SignedPreprepare{Digest: common.HexToHash("0xb")}
+
+	if evidence := d.record(proposer, newView(1, 0), blockA); evidence != nil {
+		t.Errorf("first preprepare reported as an equivocation")
+	}
+	if evidence := d.record(proposer, newView(1, 0), blockA); evidence != nil {
+		t.Errorf("repeated preprepare reported as an equivocation")
+	}
+
+	evidence := d.record(proposer, newView(1, 0), blockB)
+	if evidence == nil {
+		t.Fatalf("equivocation not detected")
+	}
+	if evidence.Proposer != proposer || evidence.Sequence != 1 || evidence.Round != 0 || evidence.First != blockA || evidence.Second != blockB {
+		t.Errorf("evidence = %+v, want the preprepares of %v for %v and %v in view 1/0", evidence, proposer.Hex(), blockA.Digest.Hex(), blockB.Digest.Hex())
+	}
+	// An equivocation is reported once per view
+	if evidence := d.record(proposer, newView(1, 0), &SignedPreprepare{Digest: common.HexToHash("0xc")}); evidence != nil {
+		t.Errorf("equivocation reported twice")
+	}
+
+	// A proposer may propose another block in a later round, and preprepares of earlier views are ignored
+	if evidence := d.record(proposer, newView(1, 1), blockB); evidence != nil {
+		t.Errorf("preprepare of a later round reported as an equivocation")
+	}
+	if evidence := d.record(proposer, newView(1, 0), blockA); evidence != nil {
+		t.Errorf("preprepare of an earlier round reported as an equivocation")
+	}
+	if list := d.list(); len(list) != 1 {
+		t.Errorf("got %d equivocation evidences, want 1", len(list))
+	}
+}
+
+func TestHandleEquivocatingPreprepare(t *testing.T) {
+	for _, tc := range []struct {
+		policy       istanbul.EquivocationPolicy
+		wantState    State
+		desiredRound int64
+	}{
+		{istanbul.KeepFirstProposal, StatePreprepared, 0},
+		{istanbul.RoundChangeOnEquivocation, StateWaitingForNewRound, 1},
+	} {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			sys := NewTestSystemWithBackend(4, 1)
+			for _, backend := range sys.backends {
+				backend.engine.(*core).Start()
+			}
+			sys.Run(false)
+			defer sys.Stop(true)
+
+			proposer := sys.backends[0]
+			c := sys.backends[1].engine.(*core)
+			c.config.EquivocationPolicy = tc.policy
+
+			handle := func(proposal istanbul.Proposal) error {
+				m, _ := Encode(&istanbul.Preprepare{View: c.current.View(), Proposal: proposal})
+				return c.handlePreprepare(&istanbul.Message{Code: istanbul.MsgPreprepare, Msg: m, Address: proposer.Address()})
+			}
+			first := makeBlock(1)
+			if err := handle(first); err != nil {
+				t.Fatalf("failed to handle the first preprepare: %v", err)
+			}
+			conflicting := types.NewBlock(&types.Header{Number: big.NewInt(1), GasUsed: 1}, nil, nil, nil)
+			if err := handle(conflicting); err != errEquivocatingProposal {
+				t.Errorf("error = %v, want %v", err, errEquivocatingProposal)
+			}
+
+			if state := c.current.State(); state != tc.wantState {
+				t.Errorf("state = %v, want %v", state, tc.wantState)
+			}
+			if desiredRound := c.current.DesiredRound().Int64(); desiredRound != tc.desiredRound {
+				t.Errorf("desired round = %d, want %d", desiredRound, tc.desiredRound)
+			}
+			if tc.policy == istanbul.KeepFirstProposal && c.current.Proposal().Hash() != first.Hash() {
+				t.Errorf("continued with proposal %v, want the first one %v", c.current.Proposal().Hash().Hex(), first.Hash().Hex())
+			}
+			evidence := c.EquivocationEvidence()
+			if len(evidence) != 1 || evidence[0].First.Digest != first.Hash() || evidence[0].Second.Digest != conflicting.Hash() {
+				t.Errorf("evidence = %+v, want the first and conflicting proposals", evidence)
+			}
+		})
+	}
+}
@@ -75,6 +75,9 @@ var (
 	errInvalidEpochValidatorSetSeal = errors.New("invalid epoch validator set seal in COMMIT message")
 	// errNotLastBlockInEpoch is returned when the block number was not the last block in the epoch
 	errNotLastBlockInEpoch = errors.New("not last block in epoch")
+	// errEquivocatingProposal is returned when the proposer of a round sent a PREPREPARE for another block
+	// than the one of the first PREPREPARE it sent in that round.
+	errEquivocatingProposal = errors.New("conflicting PREPREPARE from the proposer")
 	// errMissingRoundChangeCertificate is returned when ROUND CHANGE certificate is missing from a PREPREPARE for round > 0.
 	errMissingRoundChangeCertificate = errors.New("missing ROUND CHANGE certificate in PREPREPARE")
 	// errFailedCreateRoundChangeCertificate is returned when there aren't enough ROUND CHANGE messages to create a ROUND CHANGE certificate.
@@ -86,4 +89,16 @@ var (
 	errInvalidValidatorAddress = errors.New("failed to find an existing validator by address")
 	// Invalid round state
 	errInvalidState = errors.New("invalid round state")
+	// errIncompatibleRoundStateVersion is returned when a stored round state was encoded with
+	// another version of the encoding.
+	errIncompatibleRoundStateVersion = errors.New("incompatible round state version")
+	// errForcedRoundChangeBackward is returned when a forced round change targets a round that is
+	// not after the current desired round.
+	errForcedRoundChangeBackward = errors.New("forced round change must move past the current desired round")
+	// errForcedRoundChangeTooFar is returned when a forced round change targets a round more than
+	// maxForcedRoundChangeSkip rounds past the current desired round.
+	errForcedRoundChangeTooFar = errors.New("forced round change skips too many rounds")
+	// errTraceSequenceInPast is returned when a sequence trace is requested for a sequence that the core
+	// already moved past.
+	errTraceSequenceInPast = errors.New("cannot trace a sequence before the current sequence")
 )
@@ -17,6 +17,8 @@
 package core
 
 import (
+	"math/big"
+
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 )
 
@@ -30,3 +32,10 @@ type resendRoundChangeEvent struct {
 type timeoutAndMoveToNextRoundEvent struct {
 	view *istanbul.View
 }
+type proposalTimeoutEvent struct {
+	view *istanbul.View
+}
+type forceRoundChangeEvent struct {
+	view        *istanbul.View
+	targetRound *big.Int
+}
@@ -17,7 +17,9 @@
 package core
 
 import (
+	"errors"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -34,6 +36,17 @@ func (c *core) Start() error {
 
 	c.current = roundState
 	c.roundChangeSet = newRoundChangeSet(c.current.ValidatorSet())
+	c.handledMessages.clear()
+	if c.current.Round().Sign() > 0 {
+		c.restoreRoundChangeCertificate()
+	}
+
+	// Notify subscribers of the (possibly restored) view the core starts in
+	if c.current.Round().Sign() == 0 {
+		c.notifyStateTransition(istanbul.NewSequenceTransition)
+	} else {
+		c.notifyStateTransition(istanbul.RoundChangeTransition)
+	}
 
 	// Reset the Round Change timer for the current round to timeout.
 	// (If we've restored RoundState such that we are in StateWaitingForRoundChange,
@@ -60,6 +73,7 @@ func (c *core) Stop() error {
 	// Make sure the handler goroutine exits
 	c.handlerWg.Wait()
 
+	c.finishSequenceTrace(nil)
 	c.current = nil
 	return nil
 }
@@ -77,7 +91,9 @@ func (c *core) subscribeEvents() {
 	)
 	c.timeoutSub = c.backend.EventMux().Subscribe(
 		timeoutAndMoveToNextRoundEvent{},
+		proposalTimeoutEvent{},
 		resendRoundChangeEvent{},
+		forceRoundChangeEvent{},
 	)
 	c.finalCommittedSub = c.backend.EventMux().Subscribe(
 		istanbul.FinalCommittedEvent{},
@@ -117,14 +133,26 @@ func (c *core) handleEvents() {
 					c.storeRequestMsg(r)
 				}
 			case istanbul.MessageEvent:
-				if err := c.handleMsg(ev.Payload); err != nil && err != errFutureMessage && err != errOldMessage {
+				// Messages replayed from the backlog are not checked, as they were already handled once
+				hash, duplicate := c.handledMessages.isDuplicate(ev.Payload)
+				if duplicate {
+					logger.Trace("Dropping duplicate istanbul message", "hash", hash)
+					continue
+				}
+				validateFn := c.validateFn
+				if ev.Signer != nil {
+					validateFn = c.checkRecoveredSigner(*ev.Signer)
+				}
+				err := c.handleMsg(ev.Payload, validateFn)
+				c.handledMessages.handled(hash, err)
+				if err != nil && err != errFutureMessage && err != errOldMessage && !errors.Is(err, istanbul.ErrUnauthorizedAddress) {
 					logger.Warn("Error in handling istanbul message", "err", err)
 				}
 			case backlogEvent:
 				if payload, err := ev.msg.Payload(); err != nil {
 					logger.Error("Error in retrieving payload from istanbul message that was sent from a backlog event", "err", err)
 				} else {
-					if err := c.handleMsg(payload); err != nil && err != errFutureMessage && err != errOldMessage {
+					if err := c.handleMsg(payload, c.validateFn); err != nil && err != errFutureMessage && err != errOldMessage {
 						logger.Warn("Error in handling istanbul message that was sent from a backlog event", "err", err)
 					}
 				}
@@ -138,10 +166,18 @@ func (c *core) handleEvents() {
 				if err := c.handleTimeoutAndMoveToNextRound(ev.view); err != nil {
 					logger.Error("Error on handleTimeoutAndMoveToNextRound", "err", err)
 				}
+			case proposalTimeoutEvent:
+				if err := c.handleProposalTimeout(ev.view); err != nil {
+					logger.Error("Error on handleProposalTimeout", "err", err)
+				}
 			case resendRoundChangeEvent:
 				if err := c.handleResendRoundChangeEvent(ev.view); err != nil {
 					logger.Error("Error on handleResendRoundChangeEvent", "err", err)
 				}
+			case forceRoundChangeEvent:
+				if err := c.handleForceRoundChange(ev.view, ev.targetRound); err != nil {
+					logger.Error("Error on handleForceRoundChange", "err", err)
+				}
 			}
 		case event, ok := <-c.finalCommittedSub.Chan():
 			if !ok {
@@ -162,14 +198,24 @@ func (c *core) sendEvent(ev interface{}) {
 	c.backend.EventMux().Post(ev)
 }
 
-func (c *core) handleMsg(payload []byte) error {
+// handleMsg decodes a message, checks its signature with validateFn and handles it.
+func (c *core) handleMsg(payload []byte, validateFn func([]byte, []byte) (common.Address, error)) error {
 	logger := c.newLogger("func", "handleMsg")
 
 	// Decode message and check its signature
 	msg := new(istanbul.Message)
 	logger.Debug("Got new message", "payload", hexutil.Encode(payload))
-	if err := msg.FromPayload(payload, c.validateFn); err != nil {
-		logger.Debug("Failed to decode message from payload", "err", err)
+	if err := msg.FromPayload(payload, validateFn); err != nil {
+		if errors.Is(err, istanbul.ErrUnauthorizedAddress) {
+			if c.storeNextValidatorSetMsg(msg) {
+				c.sequenceTracer.record(msg, payload, errFutureMessage, time.Now())
+				return errFutureMessage
+			}
+			c.sequenceTracer.record(msg, payload, err, time.Now())
+			c.dropUnknownSenderMsg(msg, err)
+		} else {
+			logger.Debug("Failed to decode message from payload", "err", err)
+		}
 		return err
 	}
 
@@ -177,10 +223,24 @@ func (c *core) handleMsg(payload []byte) error {
 	_, src := c.current.ValidatorSet().GetByAddress(msg.Address)
 	if src == nil {
 		logger.Error("Invalid address in message", "m", msg)
+		c.sequenceTracer.record(msg, payload, istanbul.ErrUnauthorizedAddress, time.Now())
 		return istanbul.ErrUnauthorizedAddress
 	}
 
-	return c.handleCheckedMsg(msg, src)
+	err := c.handleCheckedMsg(msg, src)
+	c.sequenceTracer.record(msg, payload, err, time.Now())
+	return err
+}
+
+// dropUnknownSenderMsg counts a message that was dropped because it is signed by an address outside the
+// current validator set. Many of them usually mean that this node or the senders have the wrong validator set.
+func (c *core) dropUnknownSenderMsg(msg *istanbul.Message, err error) {
+	c.unknownSenderMeter.Mark(1)
+	logger := c.newLogger("func", "dropUnknownSenderMsg", "from", msg.Address, "code", msg.Code)
+	if view, viewErr := extractMessageView(msg); viewErr == nil && view != nil {
+		logger = logger.New("msg_seq", view.Sequence, "msg_round", view.Round)
+	}
+	logger.Debug("Dropping message from a sender outside the validator set", "err", err)
 }
 
 func (c *core) handleCheckedMsg(msg *istanbul.Message, src istanbul.Validator) error {
@@ -192,6 +252,7 @@ func (c *core) handleCheckedMsg(msg *istanbul.Message, src istanbul.Validator) e
 			// Store in backlog (if it's not from self)
 			if msg.Address != c.address {
 				c.backlog.store(msg)
+				c.observeFutureMessage(msg)
 			}
 		}
 		return err
@@ -222,9 +283,70 @@ func (c *core) handleTimeoutAndMoveToNextRound(timedOutView *istanbul.View) erro
 		return nil
 	}
 
+	// Don't change rounds for a sequence the network already moved past, the missing blocks are being synced
+	if c.isCatchingUp() {
+		logger.Debug("Timed out, but catching up with the network instead of moving to the next round")
+		c.resetRoundChangeTimer()
+		return nil
+	}
+
+	// Stay in the current round rather than thrash on round changes that can't reach quorum
+	if !c.backend.HasMinValidatorsToStart() {
+		logger.Debug("Timed out, but too few validators connected to move to the next round")
+		c.resetRoundChangeTimer()
+		return nil
+	}
+
+	c.timeoutMeter.Mark(1)
 	logger.Debug("Timed out, trying to wait for next round")
 	nextRound := new(big.Int).Add(timedOutView.Round, common.Big1)
-	return c.waitForDesiredRound(nextRound)
+	return c.waitForDesiredRound(nextRound, c.timeoutCause(timedOutView))
+}
+
+// handleProposalTimeout moves to the next round if the proposer of the round with the given view didn't
+// send its proposal within ProposalTimeout, without waiting for the round change timeout.
+func (c *core) handleProposalTimeout(view *istanbul.View) error {
+	logger := c.newLogger("func", "handleProposalTimeout", "timed_out_seq", view.Sequence, "timed_out_round", view.Round)
+
+	// Avoid races where message is enqueued then a later event advances sequence or desired round.
+	if c.current.Sequence().Cmp(view.Sequence) != 0 || c.current.DesiredRound().Cmp(view.Round) != 0 || c.current.Round().Cmp(view.Round) != 0 {
+		logger.Trace("Proposal timed out but now on a different view")
+		return nil
+	}
+	// A proposal was accepted, or received and waiting for its timestamp
+	if c.current.State() != StateAcceptRequest || c.futurePreprepareTimer != nil {
+		return nil
+	}
+	if !c.backend.HasMinValidatorsToStart() {
+		logger.Debug("Proposal timed out, but too few validators connected to move to the next round")
+		return nil
+	}
+
+	logger.Debug("No proposal received within the proposal timeout, trying to wait for next round", "proposer", c.current.Proposer())
+	nextRound := new(big.Int).Add(view.Round, common.Big1)
+	return c.waitForDesiredRound(nextRound, causeProposalTimeout)
+}
+
+// timeoutCause returns the cause of the round change after the round with the given view timed out, which
+// tells a proposer that sent a bad proposal apart from a slow one.
+func (c *core) timeoutCause(timedOutView *istanbul.View) roundChangeCause {
+	if c.rejectedProposalView != nil && c.rejectedProposalView.Cmp(timedOutView) == 0 {
+		return causeBadProposal
+	}
+	return causeTimeoutExpired
+}
+
+func (c *core) handleForceRoundChange(forcedAtView *istanbul.View, targetRound *big.Int) error {
+	logger := c.newLogger("func", "handleForceRoundChange", "forced_at_seq", forcedAtView.Sequence, "forced_at_round", forcedAtView.Round, "target_round", targetRound)
+
+	// The guardrails were checked against this view, so don't apply them to a later one.
+	if c.current.Sequence().Cmp(forcedAtView.Sequence) != 0 || c.current.DesiredRound().Cmp(forcedAtView.Round) != 0 {
+		logger.Warn("Not forcing round change, now on a different view")
+		return nil
+	}
+
+	logger.Warn("Forcing round change")
+	return c.waitForDesiredRound(targetRound, causeForced)
 }
 
 func (c *core) handleResendRoundChangeEvent(desiredView *istanbul.View) error {
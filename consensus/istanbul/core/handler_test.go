@@ -17,11 +17,14 @@
 package core
 
 import (
+	"errors"
 	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
 )
 
 // notice: the normal case have been tested in integration tests.
@@ -117,7 +120,46 @@ func TestHandleMsg(t *testing.T) {
 	}
 
 	// with malicious payload
-	if err := r0.handleMsg([]byte{1}); err == nil {
+	if err := r0.handleMsg([]byte{1}, r0.validateFn); err == nil {
 		t.Errorf("error mismatch: have %v, want nil", err)
 	}
+
+	// with a signer recovered by the backend
+	payload, _ := msg.Payload()
+	if err := r0.handleMsg(payload, r0.checkRecoveredSigner(common.HexToAddress("0x01"))); err == nil {
+		t.Errorf("error mismatch: have nil, want an error for a signer outside the validator set")
+	}
+	if err := r0.handleMsg(payload, r0.checkRecoveredSigner(sys.backends[1].Address())); err != istanbul.ErrInvalidSigner {
+		t.Errorf("error mismatch: have %v, want %v", err, istanbul.ErrInvalidSigner)
+	}
+}
+
+func TestHandleMsgFromUnknownSender(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 1)
+	closer := sys.Run(true)
+	defer closer()
+
+	c := sys.backends[0].engine.(*core)
+	c.unknownSenderMeter = metrics.NewMeterForced()
+	defer c.unknownSenderMeter.Stop()
+
+	key, _ := crypto.GenerateKey()
+	m, _ := Encode(&istanbul.Subject{
+		View:   &istanbul.View{Sequence: big.NewInt(1), Round: big.NewInt(0)},
+		Digest: common.BytesToHash([]byte("1234567890")),
+	})
+	msg := &istanbul.Message{Code: istanbul.MsgPrepare, Msg: m, Address: crypto.PubkeyToAddress(key.PublicKey)}
+	msg.Sign(func(data []byte) ([]byte, error) { return crypto.Sign(crypto.Keccak256(data), key) })
+	payload, _ := msg.Payload()
+
+	if err := c.handleMsg(payload, c.validateFn); !errors.Is(err, istanbul.ErrUnauthorizedAddress) {
+		t.Errorf("error mismatch: have %v, want %v", err, istanbul.ErrUnauthorizedAddress)
+	}
+	// Messages whose signer was already recovered are counted too
+	if err := c.handleMsg(payload, c.checkRecoveredSigner(msg.Address)); !errors.Is(err, istanbul.ErrUnauthorizedAddress) {
+		t.Errorf("error mismatch for a recovered signer: have %v, want %v", err, istanbul.ErrUnauthorizedAddress)
+	}
+	if count := c.unknownSenderMeter.Count(); count != 2 {
+		t.Errorf("unknown sender count mismatch: have %d, want 2", count)
+	}
 }
@@ -0,0 +1,72 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// The number of handled messages remembered to drop their duplicates
+const handledMessagesCacheSize = 4096
+
+// handledMessages remembers the consensus messages received from the network that were handled, so that
+// exact duplicates (e.g. sent again by a buggy peer, or relayed by several peers) are dropped before they
+// are decoded and reach the state machine. A message is identified by the hash of its payload, which
+// covers its sender, code, view and content, and since messages are signed deterministically a message
+// that differs in any of these is never dropped.
+type handledMessages struct {
+	hashes *lru.Cache
+
+	duplicatesMeter metrics.Meter
+}
+
+func newHandledMessages() *handledMessages {
+	hashes, _ := lru.New(handledMessagesCacheSize)
+	return &handledMessages{
+		hashes:          hashes,
+		duplicatesMeter: metrics.NewRegisteredMeter("consensus/istanbul/core/duplicatemessages", nil),
+	}
+}
+
+// isDuplicate returns the hash of the given message payload, and whether a message with the same payload
+// was already handled.
+func (h *handledMessages) isDuplicate(payload []byte) (common.Hash, bool) {
+	hash := crypto.Keccak256Hash(payload)
+	if h.hashes.Contains(hash) {
+		h.duplicatesMeter.Mark(1)
+		return hash, true
+	}
+	return hash, false
+}
+
+// handled records the outcome of handling the message with the given hash. Only the messages that were
+// processed, stored for a future view or discarded as old are remembered; a message that failed for any
+// other reason, e.g. because this node didn't know the validator set yet, is handled again if it is
+// received again.
+func (h *handledMessages) handled(hash common.Hash, err error) {
+	if err == nil || err == errFutureMessage || err == errOldMessage {
+		h.hashes.Add(hash, struct{}{})
+	}
+}
+
+// clear forgets all the handled messages.
+func (h *handledMessages) clear() {
+	h.hashes.Purge()
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+func TestHandledMessages(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 1)
+	sender := sys.backends[1]
+	roundChange := func(round uint64) []byte {
+		msg, err := sender.getRoundChangeMessage(*newView(1, round), istanbul.EmptyPreparedCertificate())
+		if err != nil {
+			t.Fatalf("failed to create round change message: %v", err)
+		}
+		payload, err := msg.Payload()
+		if err != nil {
+			t.Fatalf("failed to encode round change message: %v", err)
+		}
+		return payload
+	}
+
+	h := newHandledMessages()
+	first := roundChange(1)
+	hash, duplicate := h.isDuplicate(first)
+	if duplicate {
+		t.Fatalf("first message reported as a duplicate")
+	}
+	h.handled(hash, nil)
+
+	// Resending the same message is a duplicate
+	if _, duplicate := h.isDuplicate(roundChange(1)); !duplicate {
+		t.Errorf("resent message not reported as a duplicate")
+	}
+	// A round change for another round is a new message
+	if _, duplicate := h.isDuplicate(roundChange(2)); duplicate {
+		t.Errorf("round change for another round reported as a duplicate")
+	}
+
+	// A message that failed to be handled is handled again
+	failed := roundChange(3)
+	hash, _ = h.isDuplicate(failed)
+	h.handled(hash, errors.New("unknown validator set"))
+	if _, duplicate := h.isDuplicate(failed); duplicate {
+		t.Errorf("message that failed to be handled reported as a duplicate")
+	}
+	// Future messages are stored in the backlog, so their duplicates are dropped
+	future := roundChange(4)
+	hash, _ = h.isDuplicate(future)
+	h.handled(hash, errFutureMessage)
+	if _, duplicate := h.isDuplicate(future); !duplicate {
+		t.Errorf("future message not reported as a duplicate")
+	}
+
+	h.clear()
+	if _, duplicate := h.isDuplicate(first); duplicate {
+		t.Errorf("message reported as a duplicate after clearing")
+	}
+}
@@ -48,7 +48,7 @@ func (c *core) verifyPreparedCertificate(preparedCertificate istanbul.PreparedCe
 		return nil, errInvalidPreparedCertificateProposal
 	}
 
-	if len(preparedCertificate.PrepareOrCommitMessages) > c.current.ValidatorSet().Size() || len(preparedCertificate.PrepareOrCommitMessages) < c.current.ValidatorSet().MinQuorumSize() {
+	if len(preparedCertificate.PrepareOrCommitMessages) > c.current.ValidatorSet().Size() {
 		return nil, errInvalidPreparedCertificateNumMsgs
 	}
 
@@ -139,6 +139,10 @@ func (c *core) verifyPreparedCertificate(preparedCertificate istanbul.PreparedCe
 			}
 		}
 	}
+	// The messages are from distinct validators, which must reach a quorum
+	if !hasQuorum(c.current.ValidatorSet(), messageSenders(preparedCertificate.PrepareOrCommitMessages)) {
+		return nil, errInvalidPreparedCertificateNumMsgs
+	}
 	return view, nil
 }
 
@@ -146,7 +150,7 @@ func (c *core) verifyPreparedCertificate(preparedCertificate istanbul.PreparedCe
 func (c *core) getViewFromVerifiedPreparedCertificate(preparedCertificate istanbul.PreparedCertificate) (*istanbul.View, error) {
 	logger := c.newLogger("func", "getViewFromVerifiedPreparedCertificate", "proposal_number", preparedCertificate.Proposal.Number(), "proposal_hash", preparedCertificate.Proposal.Hash().String())
 
-	if len(preparedCertificate.PrepareOrCommitMessages) < c.current.ValidatorSet().MinQuorumSize() {
+	if !hasQuorum(c.current.ValidatorSet(), messageSenders(preparedCertificate.PrepareOrCommitMessages)) {
 		return nil, errInvalidPreparedCertificateNumMsgs
 	}
 
@@ -196,20 +200,21 @@ func (c *core) handlePrepare(msg *istanbul.Message) error {
 	}
 
 	preparesAndCommits := c.current.GetPrepareOrCommitSize()
-	minQuorumSize := c.current.ValidatorSet().MinQuorumSize()
+	valSet := c.current.ValidatorSet()
 	logger = logger.New("prepares_and_commits", preparesAndCommits, "commits", c.current.Commits().Size(), "prepares", c.current.Prepares().Size())
 	logger.Trace("Accepted prepare")
 
 	// Change to Prepared state if we've received enough PREPARE messages and we are in earlier state
 	// before Prepared state.
 	// TODO(joshua): Remove state comparisons (or change the cmp function)
-	if (preparesAndCommits >= minQuorumSize) && c.current.State().Cmp(StatePrepared) < 0 {
+	if hasQuorum(valSet, append(c.current.Prepares().Addresses(), c.current.Commits().Addresses()...)) && c.current.State().Cmp(StatePrepared) < 0 {
 
-		err := c.current.TransitionToPrepared(minQuorumSize)
+		err := c.current.TransitionToPrepared(valSet.MinQuorumWeight())
 		if err != nil {
 			logger.Error("Failed to create and set preprared certificate", "err", err)
 			return err
 		}
+		c.notifyStateTransition(istanbul.PreparedTransition)
 		logger.Trace("Got quorum prepares or commits", "tag", "stateTransition")
 
 		// Process Backlog Messages
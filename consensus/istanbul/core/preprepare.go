@@ -27,8 +27,28 @@ import (
 func (c *core) sendPreprepare(request *istanbul.Request, roundChangeCertificate istanbul.RoundChangeCertificate) {
 	logger := c.newLogger("func", "sendPreprepare")
 
+	// A shadow validator only logs the proposal it would have made, whether or not it is the proposer
+	if c.config.ShadowValidator {
+		if c.current.Sequence().Cmp(request.Proposal.Number()) == 0 {
+			logger.Info("Shadow validator would have proposed", "number", request.Proposal.Number(), "hash", request.Proposal.Hash(), "is_proposer", c.isProposer())
+		}
+		return
+	}
+
 	// If I'm the proposer and I have the same sequence with the proposal
 	if c.current.Sequence().Cmp(request.Proposal.Number()) == 0 && c.isProposer() {
+		if !c.backend.HasMinValidatorsToStart() {
+			logger.Debug("Too few validators connected, not sending preprepare")
+			return
+		}
+		if c.selfProposalBreaker.isPaused(c.current.Sequence().Uint64()) {
+			logger.Warn("Proposing is paused after repeated failed proposals, not sending preprepare", "paused_until_seq", c.selfProposalBreaker.pausedUntil)
+			return
+		}
+		if c.isSingleValidator() {
+			c.commitAlone(request.Proposal)
+			return
+		}
 		curView := c.current.View()
 		preprepare, err := Encode(&istanbul.Preprepare{
 			View:                   curView,
@@ -46,6 +66,7 @@ func (c *core) sendPreprepare(request *istanbul.Request, roundChangeCertificate
 		}
 		logger.Debug("Sending preprepare", "m", msg)
 		c.broadcast(msg)
+		c.selfProposalBreaker.proposed(curView)
 	}
 }
 
@@ -74,7 +95,7 @@ func (c *core) handlePreprepare(msg *istanbul.Message) error {
 			// Get validator set for the given proposal
 			valSet := c.backend.ParentBlockValidators(preprepare.Proposal)
 			prevBlockAuthor := c.backend.AuthorForBlock(preprepare.Proposal.Number().Uint64() - 1)
-			proposer := c.selectProposer(valSet, prevBlockAuthor, preprepare.View.Round.Uint64())
+			proposer := c.selectProposer(valSet, prevBlockAuthor, preprepare.Proposal.Number().Uint64(), preprepare.View.Round.Uint64())
 
 			// We no longer broadcast a COMMIT if this is a PREPREPARE from the correct proposer for an existing block.
 			// However, we log a WARN for potential future debugging value.
@@ -94,12 +115,17 @@ func (c *core) handlePreprepare(msg *istanbul.Message) error {
 		logger.Error("Could not determine head proposer")
 		return errNotFromProposer
 	}
-	proposerForMsgRound := c.selectProposer(c.current.ValidatorSet(), headProposer, preprepare.View.Round.Uint64())
+	proposerForMsgRound := c.selectProposer(c.current.ValidatorSet(), headProposer, preprepare.View.Sequence.Uint64(), preprepare.View.Round.Uint64())
 	if proposerForMsgRound.Address() != msg.Address {
 		logger.Warn("Ignore preprepare message from non-proposer", "actual_proposer", proposerForMsgRound.Address())
 		return errNotFromProposer
 	}
 
+	// Only handle the first proposal of the proposer in a round
+	if c.checkEquivocation(msg, preprepare) {
+		return errEquivocatingProposal
+	}
+
 	// If round > 0, handle the ROUND CHANGE certificate. If round = 0, it should not have a ROUND CHANGE certificate
 	if preprepare.View.Round.Cmp(common.Big0) > 0 {
 		if !preprepare.HasRoundChangeCertificate() {
@@ -132,6 +158,8 @@ func (c *core) handlePreprepare(msg *istanbul.Message) error {
 					msg: msg,
 				})
 			})
+		} else {
+			c.rejectedProposalView = preprepare.View
 		}
 		return err
 	}
@@ -0,0 +1,98 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+// quorumCounter adds up the weights of distinct validators of a validator set until they reach a quorum
+// weight. Every quorum check of the core goes through it, so that quorums are reached by the combined
+// weight of the validators when the validator set is weighted, and by their number otherwise.
+type quorumCounter struct {
+	valSet       istanbul.ValidatorSet
+	quorumWeight uint64
+	seen         map[common.Address]bool
+	weight       uint64
+}
+
+// newQuorumCounter returns a quorumCounter that reaches the given quorum weight of valSet, which is
+// usually valSet.MinQuorumWeight().
+func newQuorumCounter(valSet istanbul.ValidatorSet, quorumWeight uint64) *quorumCounter {
+	return &quorumCounter{
+		valSet:       valSet,
+		quorumWeight: quorumWeight,
+		seen:         make(map[common.Address]bool),
+	}
+}
+
+// add adds the weight of the validator with the given address, unless it isn't in the validator set
+// or was already added, and returns whether the quorum weight is reached.
+func (qc *quorumCounter) add(address common.Address) bool {
+	if !qc.seen[address] {
+		if _, val := qc.valSet.GetByAddress(address); val != nil {
+			qc.seen[address] = true
+			qc.weight += qc.valSet.GetWeight(address)
+		}
+	}
+	return qc.reached()
+}
+
+// reached returns whether the validators added so far reach the quorum weight.
+func (qc *quorumCounter) reached() bool {
+	return qc.weight >= qc.quorumWeight
+}
+
+// hasQuorum returns whether the distinct validators among senders reach the minimum quorum weight of valSet.
+func hasQuorum(valSet istanbul.ValidatorSet, senders []common.Address) bool {
+	qc := newQuorumCounter(valSet, valSet.MinQuorumWeight())
+	for _, sender := range senders {
+		if qc.add(sender) {
+			return true
+		}
+	}
+	return qc.reached()
+}
+
+// messageSenders returns the addresses of the senders of messages.
+func messageSenders(messages []istanbul.Message) []common.Address {
+	senders := make([]common.Address, len(messages))
+	for i, message := range messages {
+		senders[i] = message.Address
+	}
+	return senders
+}
+
+// maxNonSigners returns the largest number of validators of valSet that may be missing from a quorum,
+// whichever they are, i.e. the number of validators left once the lightest validators reach the
+// minimum quorum weight. Without weights, this is valSet.Size() - valSet.MinQuorumSize().
+func maxNonSigners(valSet istanbul.ValidatorSet) int {
+	validators := append([]istanbul.Validator{}, valSet.List()...)
+	sort.SliceStable(validators, func(i, j int) bool {
+		return valSet.GetWeight(validators[i].Address()) < valSet.GetWeight(validators[j].Address())
+	})
+	qc := newQuorumCounter(valSet, valSet.MinQuorumWeight())
+	for i, val := range validators {
+		if qc.add(val.Address()) {
+			return len(validators) - i - 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,161 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+// newWeightedTestValidatorSet returns a validator set of len(weights) validators with the given weights
+func newWeightedTestValidatorSet(weights ...uint64) (istanbul.ValidatorSet, []common.Address) {
+	valSet := newTestValidatorSet(len(weights))
+	addresses := make([]common.Address, len(weights))
+	weightsByAddress := make(map[common.Address]uint64)
+	for i, val := range valSet.List() {
+		addresses[i] = val.Address()
+		weightsByAddress[val.Address()] = weights[i]
+	}
+	valSet.SetWeights(weightsByAddress)
+	return valSet, addresses
+}
+
+func TestHasQuorum(t *testing.T) {
+	unweighted := newTestValidatorSet(4)
+	var unweightedAddresses []common.Address
+	for _, val := range unweighted.List() {
+		unweightedAddresses = append(unweightedAddresses, val.Address())
+	}
+	// 3 validators of weight 1 and one of weight 6, for a quorum weight of 6
+	weighted, weightedAddresses := newWeightedTestValidatorSet(1, 1, 1, 6)
+	outsider := common.HexToAddress("0x01")
+
+	testCases := []struct {
+		name    string
+		valSet  istanbul.ValidatorSet
+		senders []common.Address
+		want    bool
+	}{
+		{"unweighted quorum", unweighted, unweightedAddresses[:3], true},
+		{"unweighted minority", unweighted, unweightedAddresses[:2], false},
+		{"unweighted duplicated senders", unweighted, []common.Address{unweightedAddresses[0], unweightedAddresses[1], unweightedAddresses[1]}, false},
+		{"unweighted sender outside the set", unweighted, []common.Address{unweightedAddresses[0], unweightedAddresses[1], outsider}, false},
+		{"majority by count, minority by weight", weighted, weightedAddresses[:3], false},
+		{"minority by count, majority by weight", weighted, weightedAddresses[3:], true},
+		{"weighted duplicated senders", weighted, []common.Address{weightedAddresses[0], weightedAddresses[0], weightedAddresses[0], weightedAddresses[0], weightedAddresses[0], weightedAddresses[0]}, false},
+	}
+	for _, tc := range testCases {
+		if have := hasQuorum(tc.valSet, tc.senders); have != tc.want {
+			t.Errorf("%s: have %v, want %v", tc.name, have, tc.want)
+		}
+	}
+}
+
+func TestMaxNonSigners(t *testing.T) {
+	for n := 1; n <= 10; n++ {
+		valSet := newTestValidatorSet(n)
+		if have, want := maxNonSigners(valSet), valSet.Size()-valSet.MinQuorumSize(); have != want {
+			t.Errorf("unweighted set of %d validators: have %d, want %d", n, have, want)
+		}
+	}
+
+	testCases := []struct {
+		weights []uint64
+		want    int
+	}{
+		// Quorum weight 6, only reached with the validator of weight 6
+		{weights: []uint64{1, 1, 1, 6}, want: 0},
+		// Quorum weight 7, reached by the 3 lightest validators
+		{weights: []uint64{3, 3, 3, 1}, want: 1},
+		// Quorum weight 7, which the 5 light validators don't reach without the heavy one
+		{weights: []uint64{1, 1, 1, 1, 1, 5}, want: 0},
+		// Quorum weight 6, reached by the 4 lightest validators
+		{weights: []uint64{2, 2, 2, 2, 1}, want: 1},
+	}
+	for _, tc := range testCases {
+		valSet, _ := newWeightedTestValidatorSet(tc.weights...)
+		if have := maxNonSigners(valSet); have != tc.want {
+			t.Errorf("weights %v: have %d, want %d", tc.weights, have, tc.want)
+		}
+	}
+}
+
+func TestWeightedRoundChangeQuorum(t *testing.T) {
+	// 3 validators of weight 1 and one of weight 6, for a quorum weight of 6
+	valSet, addresses := newWeightedTestValidatorSet(1, 1, 1, 6)
+	rc := newRoundChangeSet(valSet)
+	addRoundChange := func(round int64, address common.Address) {
+		view := &istanbul.View{Sequence: big.NewInt(1), Round: big.NewInt(round)}
+		m, _ := Encode(&istanbul.Subject{View: view, Digest: common.Hash{}})
+		if err := rc.Add(view.Round, &istanbul.Message{Code: istanbul.MsgRoundChange, Msg: m, Address: address}); err != nil {
+			t.Fatalf("failed to add the round change: %v", err)
+		}
+	}
+
+	// The 3 light validators are a majority by count but not by weight
+	for _, address := range addresses[:3] {
+		addRoundChange(2, address)
+	}
+	if round := rc.MaxOnOneRound(valSet.MinQuorumWeight()); round != nil {
+		t.Errorf("unexpected quorum round %v", round)
+	}
+	if _, err := rc.getCertificate(big.NewInt(2), valSet.MinQuorumWeight()); err != errFailedCreateRoundChangeCertificate {
+		t.Errorf("error mismatch: have %v, want %v", err, errFailedCreateRoundChangeCertificate)
+	}
+
+	// The heavy validator alone is a quorum
+	addRoundChange(3, addresses[3])
+	if round := rc.MaxOnOneRound(valSet.MinQuorumWeight()); round == nil || round.Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("quorum round mismatch: have %v, want 3", round)
+	}
+	cert, err := rc.getCertificate(big.NewInt(3), valSet.MinQuorumWeight())
+	if err != nil {
+		t.Fatalf("failed to create the round change certificate: %v", err)
+	}
+	if len(cert.RoundChangeMessages) != 1 || cert.RoundChangeMessages[0].Address != addresses[3] {
+		t.Errorf("unexpected round change certificate messages: %v", cert.RoundChangeMessages)
+	}
+}
+
+func TestWeightedTransitionToPrepared(t *testing.T) {
+	// 3 validators of weight 1 and one of weight 6, for a quorum weight of 6
+	valSet, addresses := newWeightedTestValidatorSet(1, 1, 1, 6)
+	view := &istanbul.View{Sequence: big.NewInt(1), Round: big.NewInt(0)}
+	rs := newRoundState(view, valSet, valSet.GetByIndex(0))
+	rs.TransitionToPreprepared(&istanbul.Preprepare{View: view, Proposal: makeBlock(1)})
+
+	for _, address := range addresses[:3] {
+		rs.AddPrepare(&istanbul.Message{Code: istanbul.MsgPrepare, Address: address})
+	}
+	if err := rs.TransitionToPrepared(valSet.MinQuorumWeight()); err != errFailedCreatePreparedCertificate {
+		t.Errorf("error mismatch: have %v, want %v", err, errFailedCreatePreparedCertificate)
+	}
+
+	rs.AddCommit(&istanbul.Message{Code: istanbul.MsgCommit, Address: addresses[3]})
+	if err := rs.TransitionToPrepared(valSet.MinQuorumWeight()); err != nil {
+		t.Fatalf("failed to transition to prepared: %v", err)
+	}
+	if rs.State() != StatePrepared {
+		t.Errorf("state mismatch: have %v, want %v", rs.State(), StatePrepared)
+	}
+	if messages := rs.PreparedCertificate().PrepareOrCommitMessages; !hasQuorum(valSet, messageSenders(messages)) {
+		t.Errorf("prepared certificate messages don't reach a quorum: %v", messages)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+// RoundStateSnapshot is the round state of the core right after a state transition.
+type RoundStateSnapshot struct {
+	Transition istanbul.StateTransition `json:"transition"`
+	Timestamp  time.Time                `json:"timestamp"`
+	RoundState *RoundStateSummary       `json:"roundState"`
+}
+
+// roundStateHistory is a ring buffer of the round states recorded at the most recent state transitions,
+// kept to reconstruct what the core saw after a consensus incident. A nil history records nothing.
+type roundStateHistory struct {
+	snapshots []*RoundStateSnapshot
+	next      int // The index at which the next snapshot is recorded
+	full      bool
+	mu        sync.Mutex
+}
+
+func newRoundStateHistory(size uint64) *roundStateHistory {
+	if size == 0 {
+		return nil
+	}
+	return &roundStateHistory{snapshots: make([]*RoundStateSnapshot, size)}
+}
+
+// record adds the given round state to the history, replacing the oldest one if the history is full.
+func (h *roundStateHistory) record(transition istanbul.StateTransition, timestamp time.Time, roundState RoundState) {
+	if h == nil {
+		return
+	}
+	// The summary copies the message sets, so the snapshot isn't affected by later changes to the round state
+	snapshot := &RoundStateSnapshot{Transition: transition, Timestamp: timestamp, RoundState: roundState.Summary()}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.snapshots[h.next] = snapshot
+	h.next = (h.next + 1) % len(h.snapshots)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// last returns up to count of the most recently recorded round states, oldest first.
+func (h *roundStateHistory) last(count int) []*RoundStateSnapshot {
+	if h == nil || count <= 0 {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	recorded := h.next
+	if h.full {
+		recorded = len(h.snapshots)
+	}
+	if count > recorded {
+		count = recorded
+	}
+	snapshots := make([]*RoundStateSnapshot, count)
+	for i := range snapshots {
+		snapshots[i] = h.snapshots[(h.next-count+i+len(h.snapshots))%len(h.snapshots)]
+	}
+	return snapshots
+}
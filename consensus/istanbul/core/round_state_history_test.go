@@ -0,0 +1,93 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+func TestRoundStateHistory(t *testing.T) {
+	valSet := newTestValidatorSet(4)
+	roundState := func(seq uint64) RoundState {
+		return newTestRoundState(newView(seq, 0), valSet)
+	}
+	sequences := func(snapshots []*RoundStateSnapshot) []uint64 {
+		var seqs []uint64
+		for _, snapshot := range snapshots {
+			seqs = append(seqs, snapshot.RoundState.Sequence.Uint64())
+		}
+		return seqs
+	}
+
+	// A disabled history records nothing
+	disabled := newRoundStateHistory(0)
+	disabled.record(istanbul.NewSequenceTransition, time.Now(), roundState(1))
+	if snapshots := disabled.last(10); len(snapshots) != 0 {
+		t.Errorf("disabled history returned %d round states", len(snapshots))
+	}
+
+	history := newRoundStateHistory(3)
+	if snapshots := history.last(10); len(snapshots) != 0 {
+		t.Errorf("empty history returned %d round states", len(snapshots))
+	}
+	history.record(istanbul.NewSequenceTransition, time.Now(), roundState(1))
+	history.record(istanbul.CommittedTransition, time.Now(), roundState(2))
+	if have := sequences(history.last(10)); len(have) != 2 || have[0] != 1 || have[1] != 2 {
+		t.Errorf("history = %v, want [1 2]", have)
+	}
+
+	// Once full, the oldest round states are replaced
+	history.record(istanbul.NewSequenceTransition, time.Now(), roundState(3))
+	history.record(istanbul.NewSequenceTransition, time.Now(), roundState(4))
+	if have := sequences(history.last(10)); len(have) != 3 || have[0] != 2 || have[1] != 3 || have[2] != 4 {
+		t.Errorf("history = %v, want [2 3 4]", have)
+	}
+	if have := sequences(history.last(2)); len(have) != 2 || have[0] != 3 || have[1] != 4 {
+		t.Errorf("last 2 = %v, want [3 4]", have)
+	}
+}
+
+func TestRoundStateHistoryRecordsTransitions(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 1)
+
+	close := sys.Run(true)
+	defer close()
+
+	sys.backends[0].NewRequest(makeBlock(1))
+	<-time.After(1 * time.Second)
+
+	c := sys.backends[0].engine.(*core)
+	snapshots := c.RoundStateHistory(int(c.config.RoundStateHistorySize))
+	if len(snapshots) == 0 {
+		t.Fatalf("no round states recorded")
+	}
+	var committed *RoundStateSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.Transition == istanbul.CommittedTransition && snapshot.RoundState.Sequence.Uint64() == 1 {
+			committed = snapshot
+		}
+	}
+	if committed == nil {
+		t.Fatalf("no round state recorded when committing sequence 1")
+	}
+	if committed.RoundState.CommitCount < c.current.ValidatorSet().MinQuorumSize() {
+		t.Errorf("committed round state has %d commits, want at least %d", committed.RoundState.CommitCount, c.current.ValidatorSet().MinQuorumSize())
+	}
+}
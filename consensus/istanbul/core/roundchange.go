@@ -25,8 +25,59 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/syndtr/goleveldb/leveldb"
 )
 
+// roundChangeCause is the reason why this node moved on from a round.
+type roundChangeCause int
+
+const (
+	// causeTimeoutExpired is a round that timed out without a rejected proposal
+	causeTimeoutExpired roundChangeCause = iota
+	// causeBadProposal is a round whose proposal failed verification or couldn't be committed
+	causeBadProposal
+	// causeRoundChangeQuorum is a quorum of ROUND CHANGE messages for a later round
+	causeRoundChangeQuorum
+	// causeFutureRoundJump is F+1 ROUND CHANGE messages for a later round, which at least one honest validator is at
+	causeFutureRoundJump
+	// causeForced is a round change forced through the API
+	causeForced
+	// causeProposalTimeout is a round whose proposer didn't send a proposal within ProposalTimeout
+	causeProposalTimeout
+	// causeEquivocation is a round whose proposer sent two different proposals, with RoundChangeOnEquivocation
+	causeEquivocation
+)
+
+var roundChangeCauseNames = map[roundChangeCause]string{
+	causeTimeoutExpired:    "TimeoutExpired",
+	causeBadProposal:       "BadProposal",
+	causeRoundChangeQuorum: "RoundChangeQuorum",
+	causeFutureRoundJump:   "FutureRoundJump",
+	causeForced:            "Forced",
+	causeProposalTimeout:   "ProposalTimeout",
+	causeEquivocation:      "Equivocation",
+}
+
+func (cause roundChangeCause) String() string {
+	return roundChangeCauseNames[cause]
+}
+
+// newRoundChangeCauseMeters registers a meter for each round change cause.
+func newRoundChangeCauseMeters() map[roundChangeCause]metrics.Meter {
+	meters := make(map[roundChangeCause]metrics.Meter, len(roundChangeCauseNames))
+	for cause, name := range roundChangeCauseNames {
+		meters[cause] = metrics.NewRegisteredMeter("consensus/istanbul/core/roundchangecause/"+strings.ToLower(name), nil)
+	}
+	return meters
+}
+
+// markRoundChange logs and counts that this node moves on from the current round to the given round for the given cause.
+func (c *core) markRoundChange(round *big.Int, cause roundChangeCause) {
+	c.roundChangeCauseMeters[cause].Mark(1)
+	c.newLogger("func", "markRoundChange").Info("Round change", "cause", cause, "new_round", round)
+}
+
 // sendRoundChange broadcasts a ROUND CHANGE message with the current desired round.
 func (c *core) sendRoundChange() {
 	logger := c.newLogger("func", "sendRoundChange")
@@ -40,6 +91,37 @@ func (c *core) sendRoundChange() {
 	c.broadcast(msg)
 }
 
+// aggregatesRoundChange returns whether this node sends its ROUND CHANGE message for the current desired
+// round only to the proposer of that round. The proposer aggregates the ROUND CHANGE messages it receives
+// in the round change certificate of its PREPREPARE, which moves the other validators to the round, so
+// that they don't need to receive every ROUND CHANGE message.
+//
+// Only the round right after the current one is aggregated. If its proposer can't form the certificate,
+// e.g. because it is offline or doesn't have a quorum of ROUND CHANGE messages, the resent ROUND CHANGE
+// messages and those for the rounds after it are broadcast as usual, which is also what validators that
+// don't aggregate always do.
+func (c *core) aggregatesRoundChange() bool {
+	if !c.config.AggregateRoundChange {
+		return false
+	}
+	return c.current.DesiredRound().Cmp(new(big.Int).Add(c.current.Round(), common.Big1)) == 0
+}
+
+// sendRoundChangeToProposer sends a ROUND CHANGE message with the current desired round to the proposer
+// of that round only.
+func (c *core) sendRoundChangeToProposer() {
+	proposer := c.current.Proposer().Address()
+	logger := c.newLogger("func", "sendRoundChangeToProposer", "to", proposer)
+
+	msg, err := c.buildRoundChangeMsg(c.current.DesiredRound())
+	if err != nil {
+		logger.Error("Could not build round change message", "err", err)
+		return
+	}
+
+	c.unicast(msg, proposer)
+}
+
 // sendRoundChange sends a ROUND CHANGE message for the current desired round back to a single address
 func (c *core) sendRoundChangeAgain(addr common.Address) {
 	logger := c.newLogger("func", "sendRoundChangeAgain", "to", addr)
@@ -79,7 +161,7 @@ func (c *core) buildRoundChangeMsg(round *big.Int) (*istanbul.Message, error) {
 func (c *core) handleRoundChangeCertificate(proposal istanbul.Subject, roundChangeCertificate istanbul.RoundChangeCertificate) error {
 	logger := c.newLogger("func", "handleRoundChangeCertificate", "proposal_round", proposal.View.Round, "proposal_seq", proposal.View.Sequence, "proposal_digest", proposal.Digest.String())
 
-	if len(roundChangeCertificate.RoundChangeMessages) > c.current.ValidatorSet().Size() || len(roundChangeCertificate.RoundChangeMessages) < c.current.ValidatorSet().MinQuorumSize() {
+	if len(roundChangeCertificate.RoundChangeMessages) > c.current.ValidatorSet().Size() {
 		return errInvalidRoundChangeCertificateNumMsgs
 	}
 
@@ -91,49 +173,15 @@ func (c *core) handleRoundChangeCertificate(proposal istanbul.Subject, roundChan
 		// use a different variable each time since we'll store a pointer to the variable
 		message := roundChangeCertificate.RoundChangeMessages[i]
 
-		// Verify message signed by a validator
-		data, err := message.PayloadNoSig()
-		if err != nil {
-			return err
-		}
-
-		signer, err := c.validateFn(data, message.Signature)
+		// We have already called checkMessage by this point and checked the proposal's and PREPREPARE's sequence match.
+		roundChange, err := c.verifyRoundChangeCertificateMsg(message, proposal.View, seen)
 		if err != nil {
+			logger.Warn("Invalid ROUND CHANGE in certificate", "err", err)
 			return err
 		}
 
-		if signer != message.Address {
-			return errInvalidRoundChangeCertificateMsgSignature
-		}
-
-		// Check for duplicate ROUND CHANGE messages
-		if seen[signer] {
-			return errInvalidRoundChangeCertificateDuplicate
-		}
-		seen[signer] = true
-
-		// Check that the message is a ROUND CHANGE message
-		if istanbul.MsgRoundChange != message.Code {
-			return errInvalidRoundChangeCertificateMsgCode
-		}
-
-		var roundChange *istanbul.RoundChange
-		if err := message.Decode(&roundChange); err != nil {
-			logger.Warn("Failed to decode ROUND CHANGE in certificate", "err", err)
-			return err
-		} else if roundChange.View == nil || roundChange.View.Sequence == nil || roundChange.View.Round == nil {
-			return errInvalidRoundChangeCertificateMsgView
-		}
-
 		msgLogger := logger.New("msg_round", roundChange.View.Round, "msg_seq", roundChange.View.Sequence)
 
-		// Verify ROUND CHANGE message is for the same sequence AND an equal or subsequent round as the proposal.
-		// We have already called checkMessage by this point and checked the proposal's and PREPREPARE's sequence match.
-		if roundChange.View.Sequence.Cmp(proposal.View.Sequence) != 0 || roundChange.View.Round.Cmp(proposal.View.Round) < 0 {
-			msgLogger.Error("Round change in certificate for a different sequence or an earlier round", "err", err)
-			return errInvalidRoundChangeCertificateMsgView
-		}
-
 		if roundChange.HasPreparedCertificate() {
 			msgLogger.Trace("Round change message has prepared certificate")
 			preparedView, err := c.verifyPreparedCertificate(roundChange.PreparedCertificate)
@@ -157,9 +205,18 @@ func (c *core) handleRoundChangeCertificate(proposal istanbul.Subject, roundChan
 		}
 
 		decodedMessages[i] = *roundChange
+	}
+
+	// The messages are from distinct validators, which must reach a quorum
+	if !hasQuorum(c.current.ValidatorSet(), messageSenders(roundChangeCertificate.RoundChangeMessages)) {
+		return errInvalidRoundChangeCertificateNumMsgs
+	}
+	for i := range roundChangeCertificate.RoundChangeMessages {
+		// use a different variable each time since we'll store a pointer to the variable
+		message := roundChangeCertificate.RoundChangeMessages[i]
 		// TODO(joshua): startNewRound needs these round change messages to generate a
 		// round change certificate even if this node is not the next proposer
-		c.roundChangeSet.Add(roundChange.View.Round, &message)
+		c.roundChangeSet.Add(decodedMessages[i].View.Round, &message)
 	}
 
 	if maxRound.Cmp(big.NewInt(-1)) > 0 && proposal.Digest != preferredDigest {
@@ -168,10 +225,121 @@ func (c *core) handleRoundChangeCertificate(proposal istanbul.Subject, roundChan
 
 	// May have already moved to this round based on quorum round change messages.
 	logger.Trace("Trying to move to round change certificate's round", "target round", proposal.View.Round)
+	if proposal.View.Round.Cmp(c.current.DesiredRound()) > 0 {
+		c.markRoundChange(proposal.View.Round, causeRoundChangeQuorum)
+	}
 
 	return c.startNewRound(proposal.View.Round)
 }
 
+// verifyRoundChangeCertificateMsg checks that a message of a round change certificate for the given view is
+// a ROUND CHANGE message signed by a validator that hasn't been seen yet in the certificate, for the same
+// sequence and an equal or subsequent round as the view, and returns the decoded ROUND CHANGE.
+func (c *core) verifyRoundChangeCertificateMsg(message istanbul.Message, view *istanbul.View, seen map[common.Address]bool) (*istanbul.RoundChange, error) {
+	// Verify message signed by a validator
+	data, err := message.PayloadNoSig()
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := c.validateFn(data, message.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	if signer != message.Address {
+		return nil, errInvalidRoundChangeCertificateMsgSignature
+	}
+
+	// Check for duplicate ROUND CHANGE messages
+	if seen[signer] {
+		return nil, errInvalidRoundChangeCertificateDuplicate
+	}
+	seen[signer] = true
+
+	// Check that the message is a ROUND CHANGE message
+	if istanbul.MsgRoundChange != message.Code {
+		return nil, errInvalidRoundChangeCertificateMsgCode
+	}
+
+	var roundChange *istanbul.RoundChange
+	if err := message.Decode(&roundChange); err != nil {
+		return nil, err
+	} else if roundChange.View == nil || roundChange.View.Sequence == nil || roundChange.View.Round == nil {
+		return nil, errInvalidRoundChangeCertificateMsgView
+	}
+
+	// Verify ROUND CHANGE message is for the same sequence AND an equal or subsequent round as the view.
+	if roundChange.View.Sequence.Cmp(view.Sequence) != 0 || roundChange.View.Round.Cmp(view.Round) < 0 {
+		return nil, errInvalidRoundChangeCertificateMsgView
+	}
+	return roundChange, nil
+}
+
+// restoreRoundChangeCertificate adds the messages of the round change certificate stored for the current
+// view to the round change set, so that a restarted node can justify a proposal for its restored round
+// without waiting for the round change messages to be resent. The certificate is discarded if it was
+// stored for another view, or if it isn't valid for the current validator set anymore.
+func (c *core) restoreRoundChangeCertificate() {
+	logger := c.newLogger("func", "restoreRoundChangeCertificate")
+
+	view, roundChangeCertificate, err := c.rsdb.GetRoundChangeCertificate()
+	if err == leveldb.ErrNotFound {
+		return
+	} else if err != nil {
+		logger.Warn("Failed to fetch stored round change certificate", "err", err)
+		return
+	}
+	if view.Cmp(c.current.View()) != 0 {
+		logger.Debug("Discarding stored round change certificate", "reason", "different view", "stored_view", view)
+		return
+	}
+
+	if len(roundChangeCertificate.RoundChangeMessages) > c.current.ValidatorSet().Size() {
+		logger.Warn("Discarding stored round change certificate", "err", errInvalidRoundChangeCertificateNumMsgs)
+		return
+	}
+	seen := make(map[common.Address]bool)
+	rounds := make([]*big.Int, len(roundChangeCertificate.RoundChangeMessages))
+	for i, message := range roundChangeCertificate.RoundChangeMessages {
+		roundChange, err := c.verifyRoundChangeCertificateMsg(message, view, seen)
+		if err == nil && roundChange.HasPreparedCertificate() {
+			var preparedView *istanbul.View
+			if preparedView, err = c.verifyPreparedCertificate(roundChange.PreparedCertificate); err == nil && (preparedView == nil || preparedView.Round.Cmp(view.Round) > 0) {
+				err = errInvalidRoundChangeViewMismatch
+			}
+		}
+		if err != nil {
+			logger.Warn("Discarding stored round change certificate", "err", err)
+			return
+		}
+		rounds[i] = roundChange.View.Round
+	}
+	if !hasQuorum(c.current.ValidatorSet(), messageSenders(roundChangeCertificate.RoundChangeMessages)) {
+		logger.Warn("Discarding stored round change certificate", "err", errInvalidRoundChangeCertificateNumMsgs)
+		return
+	}
+
+	for i := range roundChangeCertificate.RoundChangeMessages {
+		// use a different variable each time since we'll store a pointer to the variable
+		message := roundChangeCertificate.RoundChangeMessages[i]
+		c.roundChangeSet.Add(rounds[i], &message)
+	}
+	logger.Info("Restored stored round change certificate", "view", view, "num_msgs", len(roundChangeCertificate.RoundChangeMessages))
+
+	// Propose again in the restored round if this node is its proposer and hadn't seen a proposal yet
+	if c.current.State() == StateAcceptRequest && (c.isProposer() || c.config.ShadowValidator) {
+		request, roundChangeCertificate, err := c.getPreprepareWithRoundChangeCertificate(view.Round)
+		if err != nil {
+			logger.Warn("Unable to produce round change certificate from the restored one", "err", err)
+			return
+		}
+		if request != nil {
+			c.sendPreprepare(request, roundChangeCertificate)
+		}
+	}
+}
+
 func (c *core) handleRoundChange(msg *istanbul.Message) error {
 	logger := c.newLogger("func", "handleRoundChange", "tag", "handleMsg", "from", msg.Address)
 
@@ -218,17 +386,20 @@ func (c *core) handleRoundChange(msg *istanbul.Message) error {
 	// Skip to the highest round we know F+1 (one honest validator) is at, but
 	// don't start a round until we have a quorum who want to start a given round.
 	ffRound := c.roundChangeSet.MaxRound(c.current.ValidatorSet().F() + 1)
-	quorumRound := c.roundChangeSet.MaxOnOneRound(c.current.ValidatorSet().MinQuorumSize())
+	quorumRound := c.roundChangeSet.MaxOnOneRound(c.current.ValidatorSet().MinQuorumWeight())
 	logger = logger.New("ffRound", ffRound, "quorumRound", quorumRound)
 	logger.Trace("Got round change message", "rcs", c.roundChangeSet.String())
 	// On f+1 round changes we send a round change and wait for the next round if we haven't done so already
 	// On quorum round change messages we go to the next round immediately.
 	if quorumRound != nil && quorumRound.Cmp(c.current.DesiredRound()) >= 0 {
 		logger.Debug("Got quorum round change messages, starting new round.")
+		if quorumRound.Cmp(c.current.DesiredRound()) > 0 {
+			c.markRoundChange(quorumRound, causeRoundChangeQuorum)
+		}
 		return c.startNewRound(quorumRound)
 	} else if ffRound != nil {
 		logger.Debug("Got f+1 round change messages, sending own round change message and waiting for next round.")
-		c.waitForDesiredRound(ffRound)
+		c.waitForDesiredRound(ffRound, causeFutureRoundJump)
 	}
 
 	return nil
@@ -323,8 +494,8 @@ func (rcs *roundChangeSet) MaxRound(num int) *big.Int {
 	return nil
 }
 
-// MaxOnOneRound returns the max round which the number of messages is >= num
-func (rcs *roundChangeSet) MaxOnOneRound(num int) *big.Int {
+// MaxOnOneRound returns the max round whose messages reach the given quorum weight
+func (rcs *roundChangeSet) MaxOnOneRound(quorumWeight uint64) *big.Int {
 	rcs.mu.Lock()
 	defer rcs.mu.Unlock()
 
@@ -336,9 +507,11 @@ func (rcs *roundChangeSet) MaxOnOneRound(num int) *big.Int {
 	sort.Slice(sortedRounds, func(i, j int) bool { return sortedRounds[i] > sortedRounds[j] })
 
 	for _, r := range sortedRounds {
-		rms := rcs.msgsForRound[r]
-		if rms.Size() >= num {
-			return new(big.Int).SetUint64(r)
+		qc := newQuorumCounter(rcs.validatorSet, quorumWeight)
+		for _, address := range rcs.msgsForRound[r].Addresses() {
+			if qc.add(address) {
+				return new(big.Int).SetUint64(r)
+			}
 		}
 	}
 	return nil
@@ -377,7 +550,7 @@ func (rcs *roundChangeSet) String() string {
 
 // Gets a round change certificate for a specific round. Includes quorumSize messages of that round or later.
 // If the total is less than quorumSize, returns an empty cert and errFailedCreateRoundChangeCertificate.
-func (rcs *roundChangeSet) getCertificate(minRound *big.Int, quorumSize int) (istanbul.RoundChangeCertificate, error) {
+func (rcs *roundChangeSet) getCertificate(minRound *big.Int, quorumWeight uint64) (istanbul.RoundChangeCertificate, error) {
 	rcs.mu.Lock()
 	defer rcs.mu.Unlock()
 
@@ -389,6 +562,7 @@ func (rcs *roundChangeSet) getCertificate(minRound *big.Int, quorumSize int) (is
 	sort.Slice(sortedRounds, func(i, j int) bool { return sortedRounds[i] > sortedRounds[j] })
 
 	var messages []istanbul.Message
+	qc := newQuorumCounter(rcs.validatorSet, quorumWeight)
 	for _, r := range sortedRounds {
 		if r < minRound.Uint64() {
 			break
@@ -397,7 +571,7 @@ func (rcs *roundChangeSet) getCertificate(minRound *big.Int, quorumSize int) (is
 			messages = append(messages, *message)
 
 			// Stop when we've added a quorum of the highest-round messages.
-			if len(messages) >= quorumSize {
+			if qc.add(message.Address) {
 				return istanbul.RoundChangeCertificate{
 					RoundChangeMessages: messages,
 				}, nil
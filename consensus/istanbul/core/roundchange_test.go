@@ -159,7 +159,7 @@ func TestRoundChangeSet(t *testing.T) {
 	for r := 1; r < vset.Size(); r += roundMultiplier {
 		expectedMsgsAtRound := vset.Size() - r + 1
 		for quorum := 1; quorum < 10; quorum++ {
-			cert, err := rc.getCertificate(big.NewInt(int64(r)), quorum)
+			cert, err := rc.getCertificate(big.NewInt(int64(r)), uint64(quorum))
 			if expectedMsgsAtRound < quorum {
 				// Expecting fewer than quorum.
 				if err != errFailedCreateRoundChangeCertificate || len(cert.RoundChangeMessages) != 0 {
@@ -247,6 +247,81 @@ func TestHandleRoundChangeCertificate(t *testing.T) {
 	}
 }
 
+func TestRestoreRoundChangeCertificate(t *testing.T) {
+	N := uint64(4)
+	F := uint64(1)
+	view := istanbul.View{
+		Round:    big.NewInt(1),
+		Sequence: big.NewInt(1),
+	}
+
+	testCases := []struct {
+		name           string
+		storedView     istanbul.View
+		getCertificate func(*testing.T, *testSystem) istanbul.RoundChangeCertificate
+		restored       bool
+	}{
+		{
+			"Valid round change certificate for the current view",
+			view,
+			func(t *testing.T, sys *testSystem) istanbul.RoundChangeCertificate {
+				return sys.getRoundChangeCertificate(t, []istanbul.View{view}, istanbul.EmptyPreparedCertificate())
+			},
+			true,
+		},
+		{
+			"Round change certificate for another view",
+			istanbul.View{Round: big.NewInt(2), Sequence: big.NewInt(1)},
+			func(t *testing.T, sys *testSystem) istanbul.RoundChangeCertificate {
+				return sys.getRoundChangeCertificate(t, []istanbul.View{view}, istanbul.EmptyPreparedCertificate())
+			},
+			false,
+		},
+		{
+			"Invalid round change certificate, duplicate message",
+			view,
+			func(t *testing.T, sys *testSystem) istanbul.RoundChangeCertificate {
+				roundChangeCertificate := sys.getRoundChangeCertificate(t, []istanbul.View{view}, istanbul.EmptyPreparedCertificate())
+				roundChangeCertificate.RoundChangeMessages[1] = roundChangeCertificate.RoundChangeMessages[0]
+				return roundChangeCertificate
+			},
+			false,
+		},
+		{
+			"Round change certificate signed by another validator set",
+			view,
+			func(t *testing.T, sys *testSystem) istanbul.RoundChangeCertificate {
+				return NewTestSystemWithBackend(N, F).getRoundChangeCertificate(t, []istanbul.View{view}, istanbul.EmptyPreparedCertificate())
+			},
+			false,
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			sys := NewTestSystemWithBackend(N, F)
+			backend := sys.backends[N-1]
+			c := backend.engine.(*core)
+			valSet := backend.peers
+			c.current = newRoundState(&view, valSet, valSet.GetByIndex(0))
+			c.roundChangeSet = newRoundChangeSet(valSet)
+
+			if err := c.rsdb.UpdateRoundChangeCertificate(&test.storedView, test.getCertificate(t, sys)); err != nil {
+				t.Fatalf("Failed to store the round change certificate: %v", err)
+			}
+			c.restoreRoundChangeCertificate()
+
+			quorumRound := c.roundChangeSet.MaxOnOneRound(valSet.MinQuorumWeight())
+			if test.restored && (quorumRound == nil || quorumRound.Cmp(view.Round) != 0) {
+				t.Errorf("expected the certificate to be restored for round %v, have quorum round %v", view.Round, quorumRound)
+			}
+			if !test.restored && quorumRound != nil {
+				t.Errorf("expected the certificate to be discarded, have quorum round %v", quorumRound)
+			}
+			close(sys.quit)
+		})
+	}
+}
+
 func TestHandleRoundChange(t *testing.T) {
 	N := uint64(4) // replica 0 is the proposer, it will send messages to others
 	F := uint64(1) // F does not affect tests
@@ -371,7 +446,8 @@ func (ts *testSystem) distributeIstMsgs(t *testing.T, sys *testSystem, istMsgDis
 		select {
 		case <-ts.quit:
 			return
-		case event := <-ts.queuedMessage:
+		case queuedMessage := <-ts.queuedMessage:
+			event := queuedMessage.event
 			msg := new(istanbul.Message)
 			if err := msg.FromPayload(event.Payload, nil); err != nil {
 				t.Errorf("Could not decode payload")
@@ -593,7 +669,7 @@ func TestPeriodicRoundChanges(t *testing.T) {
 	go sys.distributeIstMsgs(t, sys, istMsgDistribution)
 
 	for _, b := range sys.backends {
-		b.engine.(*core).waitForDesiredRound(big.NewInt(5))
+		b.engine.(*core).waitForDesiredRound(big.NewInt(5), causeForced)
 	}
 
 	// Expect at least one repeat RC before move to next round.
@@ -654,3 +730,58 @@ loop2:
 	}
 	close(sys.quit)
 }
+
+func TestTimeoutCause(t *testing.T) {
+	c := &core{}
+	if cause := c.timeoutCause(newView(1, 0)); cause != causeTimeoutExpired {
+		t.Errorf("cause mismatch: have %v, want %v", cause, causeTimeoutExpired)
+	}
+	c.rejectedProposalView = newView(1, 1)
+	if cause := c.timeoutCause(newView(1, 0)); cause != causeTimeoutExpired {
+		t.Errorf("cause mismatch: have %v, want %v", cause, causeTimeoutExpired)
+	}
+	if cause := c.timeoutCause(newView(1, 1)); cause != causeBadProposal {
+		t.Errorf("cause mismatch: have %v, want %v", cause, causeBadProposal)
+	}
+	if causeBadProposal.String() != "BadProposal" {
+		t.Errorf("cause name mismatch: have %v, want %v", causeBadProposal.String(), "BadProposal")
+	}
+}
+
+// A faulty validator claiming to be on a round far in the future must not drag honest validators
+// to that round on its own.
+func TestIgnoresSingleInflatedRoundChange(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 1)
+	sys.backends[0].engine.(*core).timingConfig.RequestTimeout = 10000
+
+	closer := sys.Run(false)
+	defer closer()
+	for _, v := range sys.backends {
+		v.engine.(*core).Start()
+	}
+
+	c := sys.backends[0].engine.(*core)
+	farView := &istanbul.View{Round: big.NewInt(1000), Sequence: c.current.Sequence()}
+	m, _ := Encode(&istanbul.RoundChange{View: farView, PreparedCertificate: istanbul.EmptyPreparedCertificate()})
+	sendInflatedRoundChange := func(from uint64) {
+		err := c.handleRoundChange(&istanbul.Message{Code: istanbul.MsgRoundChange, Msg: m, Address: sys.backends[from].Address()})
+		if err != nil {
+			t.Fatalf("failed to handle the round change of validator %d: %v", from, err)
+		}
+	}
+
+	sendInflatedRoundChange(3)
+	if c.current.DesiredRound().Sign() != 0 || c.current.Round().Sign() != 0 {
+		t.Errorf("moved to round %v (desired %v) on a single validator's round change", c.current.Round(), c.current.DesiredRound())
+	}
+
+	// F+1 validators include an honest one, so the round is worth waiting for, but it is only
+	// started with a quorum of round changes
+	sendInflatedRoundChange(2)
+	if c.current.DesiredRound().Cmp(farView.Round) != 0 {
+		t.Errorf("desired round = %v after F+1 round changes, want %v", c.current.DesiredRound(), farView.Round)
+	}
+	if c.current.Round().Sign() != 0 {
+		t.Errorf("started round %v without a quorum of round changes", c.current.Round())
+	}
+}
@@ -43,7 +43,7 @@ type RoundState interface {
 	TransitionToPreprepared(preprepare *istanbul.Preprepare) error
 	TransitionToWaitingForNewRound(r *big.Int, nextProposer istanbul.Validator) error
 	TransitionToCommitted() error
-	TransitionToPrepared(quorumSize int) error
+	TransitionToPrepared(quorumWeight uint64) error
 	AddCommit(msg *istanbul.Message) error
 	AddPrepare(msg *istanbul.Message) error
 	AddParentCommit(msg *istanbul.Message) error
@@ -120,6 +120,15 @@ type RoundStateSummary struct {
 	Prepares      []common.Address `json:"prepares"`
 	Commits       []common.Address `json:"commits"`
 	ParentCommits []common.Address `json:"parentCommits"`
+	PrepareCount  int              `json:"prepareCount"`
+	CommitCount   int              `json:"commitCount"`
+
+	// TimeoutRemaining is the time (in milliseconds) left until the round change timer fires.
+	// It is filled in by the core, since the timer is not part of the round state.
+	TimeoutRemaining uint64 `json:"timeoutRemaining"`
+	// Timeout is the effective timeout (in milliseconds) of the round change timer in the current round,
+	// after the backoff and the MaxRequestTimeout cap. It is filled in by the core as well.
+	Timeout uint64 `json:"timeout"`
 
 	Preprepare          *istanbul.PreprepareSummary          `json:"preprepare"`
 	PreparedCertificate *istanbul.PreparedCertificateSummary `json:"preparedCertificate"`
@@ -353,30 +362,24 @@ func (rs *roundStateImpl) TransitionToWaitingForNewRound(desiredRound *big.Int,
 	return nil
 }
 
-// TransitionToPrepared will create a PreparedCertificate and change state to Prepared
-func (rs *roundStateImpl) TransitionToPrepared(quorumSize int) error {
+// TransitionToPrepared will create a PreparedCertificate from PREPARE and COMMIT messages reaching
+// the given quorum weight, and change state to Prepared
+func (rs *roundStateImpl) TransitionToPrepared(quorumWeight uint64) error {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
 
-	messages := make([]istanbul.Message, quorumSize)
-	i := 0
-	for _, message := range rs.prepares.Values() {
-		if i == quorumSize {
-			break
-		}
-		messages[i] = *message
-		i++
-	}
-	for _, message := range rs.commits.Values() {
-		if i == quorumSize {
+	var messages []istanbul.Message
+	qc := newQuorumCounter(rs.validatorSet, quorumWeight)
+	for _, message := range append(rs.prepares.Values(), rs.commits.Values()...) {
+		if qc.reached() {
 			break
 		}
-		if rs.prepares.Get(message.Address) == nil {
-			messages[i] = *message
-			i++
+		if !qc.seen[message.Address] {
+			messages = append(messages, *message)
+			qc.add(message.Address)
 		}
 	}
-	if i != quorumSize {
+	if !qc.reached() {
 		return errFailedCreatePreparedCertificate
 	}
 	rs.preparedCertificate = istanbul.PreparedCertificate{
@@ -480,6 +483,8 @@ func (rs *roundStateImpl) Summary() *RoundStateSummary {
 		Prepares:      rs.prepares.Addresses(),
 		Commits:       rs.commits.Addresses(),
 		ParentCommits: rs.parentCommits.Addresses(),
+		PrepareCount:  rs.prepares.Size(),
+		CommitCount:   rs.commits.Size(),
 	}
 
 	if rs.pendingRequest != nil {
@@ -503,7 +508,12 @@ func (rs *roundStateImpl) newLogger(ctx ...interface{}) log.Logger {
 	return logger.New("cur_seq", rs.sequence, "cur_round", rs.round, "state", rs.state)
 }
 
+// roundStateVersion is the version of the RoundState encoding. It must be increased whenever
+// the encoding changes, so that RoundStates stored with a previous encoding are discarded.
+const roundStateVersion = 1
+
 type roundStateRLP struct {
+	Version             uint
 	State               State
 	Round               *big.Int
 	DesiredRound        *big.Int
@@ -579,6 +589,7 @@ func (rs *roundStateImpl) EncodeRLP(w io.Writer) error {
 	}
 
 	entry := roundStateRLP{
+		Version:             roundStateVersion,
 		State:               rs.state,
 		Round:               rs.round,
 		DesiredRound:        rs.desiredRound,
@@ -600,11 +611,25 @@ func (rs *roundStateImpl) EncodeRLP(w io.Writer) error {
 // Stream. It is not forbidden to read less or more, but it might
 // be confusing.
 func (rs *roundStateImpl) DecodeRLP(stream *rlp.Stream) error {
-	var data roundStateRLP
-	err := stream.Decode(&data)
+	raw, err := stream.Raw()
 	if err != nil {
 		return err
 	}
+	// Check the version before decoding the rest, since its encoding depends on the version
+	var versioned struct {
+		Version uint
+		Rest    []rlp.RawValue `rlp:"tail"`
+	}
+	if err = rlp.DecodeBytes(raw, &versioned); err != nil {
+		return err
+	}
+	if versioned.Version != roundStateVersion {
+		return errIncompatibleRoundStateVersion
+	}
+	var data roundStateRLP
+	if err = rlp.DecodeBytes(raw, &data); err != nil {
+		return err
+	}
 
 	rs.logger = log.New()
 	rs.mu = new(sync.RWMutex)
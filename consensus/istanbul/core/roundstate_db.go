@@ -27,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/task"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/syndtr/goleveldb/leveldb"
 	lvlerrors "github.com/syndtr/goleveldb/leveldb/errors"
@@ -36,10 +37,11 @@ import (
 )
 
 const (
-	dbVersion    = 2
+	dbVersion    = 3
 	dbVersionKey = "version"  // Version of the database to flush if changes
 	lastViewKey  = "lastView" // Last View that we know of
 	rsKey        = "rs"       // Database Key Pefix for RoundState
+	rccKey       = "rcc"      // Latest RoundChangeCertificate that justified a round
 )
 
 type RoundStateDB interface {
@@ -49,9 +51,18 @@ type RoundStateDB interface {
 	GetOldestValidView() (*istanbul.View, error)
 	GetRoundStateFor(view *istanbul.View) (RoundState, error)
 	UpdateLastRoundState(rs RoundState) error
+	// GetRoundChangeCertificate returns the latest stored round change certificate and the view it justified
+	GetRoundChangeCertificate() (*istanbul.View, istanbul.RoundChangeCertificate, error)
+	UpdateRoundChangeCertificate(view *istanbul.View, rcc istanbul.RoundChangeCertificate) error
 	Close() error
 }
 
+// roundChangeCertificateEntry is the stored form of a round change certificate.
+type roundChangeCertificateEntry struct {
+	View        *istanbul.View
+	Certificate istanbul.RoundChangeCertificate
+}
+
 // RoundStateDBOptions are the options for a RoundStateDB instance
 type RoundStateDBOptions struct {
 	withGarbageCollector   bool
@@ -64,6 +75,11 @@ type roundStateDBImpl struct {
 	stopGarbageCollector task.StopFn
 	opts                 RoundStateDBOptions
 	logger               log.Logger
+
+	// Approximate size on disk of the stored round states, updated after each garbage collection
+	sizeGauge metrics.Gauge
+	// Round states removed by the garbage collector
+	prunedMeter metrics.Meter
 }
 
 var defaultRoundStateDBOptions = RoundStateDBOptions{
@@ -105,9 +121,11 @@ func newRoundStateDB(path string, opts *RoundStateDBOptions) (RoundStateDB, erro
 	}
 
 	rsdb := &roundStateDBImpl{
-		db:     db,
-		opts:   coerceOptions(opts),
-		logger: logger,
+		db:          db,
+		opts:        coerceOptions(opts),
+		logger:      logger,
+		sizeGauge:   metrics.NewRegisteredGauge("consensus/istanbul/core/roundstatedb/size", nil),
+		prunedMeter: metrics.NewRegisteredMeter("consensus/istanbul/core/roundstatedb/pruned", nil),
 	}
 
 	if rsdb.opts.withGarbageCollector {
@@ -199,6 +217,8 @@ func (rsdb *roundStateDBImpl) GetLastView() (*istanbul.View, error) {
 	return key2View(rawEntry), nil
 }
 
+// GetOldestValidView keeps the round states of the last sequencesToSave sequences before the last view. Since
+// sequencesToSave is at least 1, the sequence of the last view, which is still pending, is always valid.
 func (rsdb *roundStateDBImpl) GetOldestValidView() (*istanbul.View, error) {
 	lastView, err := rsdb.GetLastView()
 	// If nothing stored all views are valid
@@ -230,6 +250,35 @@ func (rsdb *roundStateDBImpl) GetRoundStateFor(view *istanbul.View) (RoundState,
 	return &entry, nil
 }
 
+// UpdateRoundChangeCertificate stores the round change certificate that justified the given view,
+// replacing the previously stored one.
+func (rsdb *roundStateDBImpl) UpdateRoundChangeCertificate(view *istanbul.View, rcc istanbul.RoundChangeCertificate) error {
+	entryBytes, err := rlp.EncodeToBytes(&roundChangeCertificateEntry{View: view, Certificate: rcc})
+	if err != nil {
+		rsdb.logger.Error("Failed to save round change certificate", "reason", "rlp encoding", "err", err, "func", "UpdateRoundChangeCertificate")
+		return err
+	}
+
+	err = rsdb.db.Put([]byte(rccKey), entryBytes, nil)
+	if err != nil {
+		rsdb.logger.Error("Failed to save round change certificate", "reason", "levelDB write", "err", err, "func", "UpdateRoundChangeCertificate")
+	}
+	return err
+}
+
+func (rsdb *roundStateDBImpl) GetRoundChangeCertificate() (*istanbul.View, istanbul.RoundChangeCertificate, error) {
+	rawEntry, err := rsdb.db.Get([]byte(rccKey), nil)
+	if err != nil {
+		return nil, istanbul.RoundChangeCertificate{}, err
+	}
+
+	var entry roundChangeCertificateEntry
+	if err = rlp.DecodeBytes(rawEntry, &entry); err != nil {
+		return nil, istanbul.RoundChangeCertificate{}, err
+	}
+	return entry.View, entry.Certificate, nil
+}
+
 func (rsdb *roundStateDBImpl) Close() error {
 	if rsdb.opts.withGarbageCollector {
 		rsdb.stopGarbageCollector()
@@ -253,9 +302,29 @@ func (rsdb *roundStateDBImpl) garbageCollectEntries() {
 		return
 	}
 
+	rsdb.prunedMeter.Mark(int64(count))
+
+	// Reclaim the space of the removed entries right away instead of waiting for leveldb to compact them
+	if count > 0 {
+		if err := rsdb.db.CompactRange(util.Range{Limit: view2Key(oldestValidView)}); err != nil {
+			logger.Warn("Failed to compact RoundStateDB", "err", err)
+		}
+	}
+	rsdb.updateSizeGauge()
+
 	logger.Debug("Finished RoundStateDB GarbageCollect", "removed_entries", count)
 }
 
+// updateSizeGauge sets the size gauge to the approximate size on disk of the stored round states.
+func (rsdb *roundStateDBImpl) updateSizeGauge() {
+	sizes, err := rsdb.db.SizeOf([]util.Range{*util.BytesPrefix([]byte(rsKey))})
+	if err != nil {
+		rsdb.logger.Debug("Failed to compute the RoundStateDB size", "err", err)
+		return
+	}
+	rsdb.sizeGauge.Update(sizes.Sum())
+}
+
 func (rsdb *roundStateDBImpl) deleteEntriesOlderThan(lastView *istanbul.View) (int, error) {
 	fromViewKey := view2Key(&istanbul.View{Sequence: common.Big0, Round: common.Big0})
 	toViewKey := view2Key(lastView)
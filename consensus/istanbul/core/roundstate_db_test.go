@@ -11,6 +11,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
+	"github.com/syndtr/goleveldb/leveldb"
 )
 
 func TestRSDBRoundStateDB(t *testing.T) {
@@ -53,6 +54,29 @@ func TestRSDBRoundStateDB(t *testing.T) {
 		assertEqualView(t, view, rs.View())
 	})
 
+	t.Run("Should save the last round change certificate", func(t *testing.T) {
+		rsdb, _ := newRoundStateDB("", &RoundStateDBOptions{withGarbageCollector: false})
+		if _, _, err := rsdb.GetRoundChangeCertificate(); err != leveldb.ErrNotFound {
+			t.Errorf("error mismatch: have %v, want %v", err, leveldb.ErrNotFound)
+		}
+
+		rcc := istanbul.RoundChangeCertificate{RoundChangeMessages: []istanbul.Message{
+			{Code: istanbul.MsgRoundChange, Msg: []byte{1}, Address: common.BytesToAddress([]byte{2}), Signature: []byte{3}},
+		}}
+		finishOnError(t, rsdb.UpdateRoundChangeCertificate(newView(2, 1), istanbul.RoundChangeCertificate{}))
+		finishOnError(t, rsdb.UpdateRoundChangeCertificate(newView(2, 3), rcc))
+
+		view, savedRcc, err := rsdb.GetRoundChangeCertificate()
+		finishOnError(t, err)
+		assertEqualView(t, view, newView(2, 3))
+		if len(savedRcc.RoundChangeMessages) != 1 {
+			t.Fatalf("message count mismatch: have %d, want 1", len(savedRcc.RoundChangeMessages))
+		}
+		saved := savedRcc.RoundChangeMessages[0]
+		if saved.Code != istanbul.MsgRoundChange || !bytes.Equal(saved.Msg, []byte{1}) || saved.Address != rcc.RoundChangeMessages[0].Address || !bytes.Equal(saved.Signature, []byte{3}) {
+			t.Errorf("message mismatch: have %v, want %v", saved, rcc.RoundChangeMessages[0])
+		}
+	})
 }
 
 func TestRSDBDeleteEntriesOlderThan(t *testing.T) {
@@ -95,6 +119,36 @@ func TestRSDBDeleteEntriesOlderThan(t *testing.T) {
 
 }
 
+func TestRSDBGarbageCollectEntries(t *testing.T) {
+	pubkey1 := blscrypto.SerializedPublicKey{1, 2, 3}
+	pubkey2 := blscrypto.SerializedPublicKey{3, 1, 4}
+	valSet := validator.NewSet([]istanbul.ValidatorData{
+		{Address: common.BytesToAddress([]byte{2}), BLSPublicKey: pubkey1},
+		{Address: common.BytesToAddress([]byte{4}), BLSPublicKey: pubkey2},
+	})
+
+	rsdb, _ := newRoundStateDB("", &RoundStateDBOptions{withGarbageCollector: false, sequencesToSave: 3})
+	for seq := uint64(1); seq <= 10; seq++ {
+		for r := uint64(0); r < 2; r++ {
+			err := rsdb.UpdateLastRoundState(newRoundState(newView(seq, r), valSet, valSet.GetByIndex(0)))
+			finishOnError(t, err)
+		}
+	}
+	rsdb.(*roundStateDBImpl).garbageCollectEntries()
+
+	// The last 3 committed sequences and the pending one are kept
+	for seq := uint64(1); seq <= 10; seq++ {
+		for r := uint64(0); r < 2; r++ {
+			_, err := rsdb.GetRoundStateFor(newView(seq, r))
+			if seq < 7 && err != leveldb.ErrNotFound {
+				t.Errorf("Expected round state of %v to be pruned, got err %v", newView(seq, r), err)
+			} else if seq >= 7 && err != nil {
+				t.Errorf("Expected round state of %v to be kept, got err %v", newView(seq, r), err)
+			}
+		}
+	}
+}
+
 func TestRSDBKeyEncodingOrder(t *testing.T) {
 	iterations := 1000
 
@@ -61,8 +61,8 @@ func (rsp *rsSaveDecorator) TransitionToWaitingForNewRound(r *big.Int, nextPropo
 func (rsp *rsSaveDecorator) TransitionToCommitted() error {
 	return rsp.persistOnNoError(rsp.rs.TransitionToCommitted())
 }
-func (rsp *rsSaveDecorator) TransitionToPrepared(quorumSize int) error {
-	return rsp.persistOnNoError(rsp.rs.TransitionToPrepared(quorumSize))
+func (rsp *rsSaveDecorator) TransitionToPrepared(quorumWeight uint64) error {
+	return rsp.persistOnNoError(rsp.rs.TransitionToPrepared(quorumWeight))
 }
 func (rsp *rsSaveDecorator) AddCommit(msg *istanbul.Message) error {
 	return rsp.persistOnNoError(rsp.rs.AddCommit(msg))
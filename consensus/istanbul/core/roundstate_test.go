@@ -83,6 +83,29 @@ func TestRoundStateRLPEncoding(t *testing.T) {
 		assertEqualRoundState(t, rs, result)
 	})
 
+	t.Run("With an incompatible version", func(t *testing.T) {
+		rs := dummyRoundState()
+
+		rawVal, err := rlp.EncodeToBytes(rs)
+		if err != nil {
+			t.Errorf("Error %v", err)
+		}
+		var fields []rlp.RawValue
+		if err = rlp.DecodeBytes(rawVal, &fields); err != nil {
+			t.Errorf("Error %v", err)
+		}
+		if fields[0], err = rlp.EncodeToBytes(uint(roundStateVersion + 1)); err != nil {
+			t.Errorf("Error %v", err)
+		}
+		if rawVal, err = rlp.EncodeToBytes(fields); err != nil {
+			t.Errorf("Error %v", err)
+		}
+
+		var result *roundStateImpl
+		if err = rlp.DecodeBytes(rawVal, &result); err != errIncompatibleRoundStateVersion {
+			t.Errorf("error mismatch: have %v, want %v", err, errIncompatibleRoundStateVersion)
+		}
+	})
 }
 
 func TestRoundStateSummary(t *testing.T) {
@@ -209,6 +232,13 @@ func TestRoundStateSummary(t *testing.T) {
 		assertEqualAddressSet(t, "Commits", rsSummary.Commits, validatorAddresses[1:4])
 		assertEqualAddressSet(t, "ParentCommits", rsSummary.ParentCommits, validatorAddresses[3:6])
 
+		if rsSummary.PrepareCount != 4 {
+			t.Errorf("PrepareCount: Mismatch got %v expected %v", rsSummary.PrepareCount, 4)
+		}
+		if rsSummary.CommitCount != 3 {
+			t.Errorf("CommitCount: Mismatch got %v expected %v", rsSummary.CommitCount, 3)
+		}
+
 		if rsSummary.Preprepare != nil {
 			t.Errorf("Preprepare: Mismatch got %v expected %v", rsSummary.Preprepare, nil)
 		}
@@ -0,0 +1,135 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// checkSafety verifies the blocks committed by each validator over a run, in commit order by validator
+// id. It returns a description of each violation, or nil if there is none:
+//   - two validators committed different blocks at the same height, listing the block committed by
+//     each validator at that height
+//   - a validator's heights aren't consecutive, or a block's parent, when set, isn't the block that the
+//     validator committed before it, so that its log isn't a prefix of a single chain
+func checkSafety(logs map[uint64][]istanbul.Proposal) []string {
+	ids := make([]uint64, 0, len(logs))
+	for id := range logs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var violations []string
+	// The validators that committed each block, by height
+	committers := make(map[uint64]map[common.Hash][]uint64)
+	for _, id := range ids {
+		var last istanbul.Proposal
+		for _, proposal := range logs[id] {
+			number := proposal.Number().Uint64()
+			if last != nil {
+				lastNumber := last.Number().Uint64()
+				if number != lastNumber+1 {
+					violations = append(violations, fmt.Sprintf("validator %d committed height %d after height %d", id, number, lastNumber))
+				} else if parent := proposal.ParentHash(); parent != (common.Hash{}) && parent != last.Hash() {
+					violations = append(violations, fmt.Sprintf("validator %d committed %v at height %d on parent %v, but committed %v at height %d",
+						id, proposal.Hash().Hex(), number, parent.Hex(), last.Hash().Hex(), lastNumber))
+				}
+			}
+			last = proposal
+
+			if committers[number] == nil {
+				committers[number] = make(map[common.Hash][]uint64)
+			}
+			committers[number][proposal.Hash()] = append(committers[number][proposal.Hash()], id)
+		}
+	}
+
+	heights := make([]uint64, 0, len(committers))
+	for number := range committers {
+		heights = append(heights, number)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	for _, number := range heights {
+		if len(committers[number]) < 2 {
+			continue
+		}
+		blocks := make([]string, 0, len(committers[number]))
+		for hash, ids := range committers[number] {
+			blocks = append(blocks, fmt.Sprintf("validators %v committed %v", ids, hash.Hex()))
+		}
+		sort.Strings(blocks)
+		violations = append(violations, fmt.Sprintf("conflicting blocks at height %d:\n\t%s", number, strings.Join(blocks, "\n\t")))
+	}
+	return violations
+}
+
+// committedLogs returns the blocks committed by each backend so far, by backend id.
+func (t *testSystem) committedLogs() map[uint64][]istanbul.Proposal {
+	logs := make(map[uint64][]istanbul.Proposal)
+	for _, b := range t.backends {
+		for _, msg := range b.committedMsgs {
+			logs[b.id] = append(logs[b.id], msg.commitProposal)
+		}
+	}
+	return logs
+}
+
+// assertSafety fails the test if the blocks committed by the backends violate safety, see checkSafety.
+func (t *testSystem) assertSafety(test *testing.T) {
+	if violations := checkSafety(t.committedLogs()); len(violations) > 0 {
+		test.Errorf("safety violated:\n%s", strings.Join(violations, "\n"))
+	}
+}
+
+func TestCheckSafety(t *testing.T) {
+	block := func(number int64, parent common.Hash, gasUsed uint64) istanbul.Proposal {
+		return types.NewBlock(&types.Header{Number: big.NewInt(number), ParentHash: parent, GasUsed: gasUsed}, nil, nil, nil)
+	}
+	b1 := block(1, common.Hash{}, 0)
+	b2 := block(2, b1.Hash(), 0)
+	b3 := block(3, b2.Hash(), 0)
+	fork2 := block(2, b1.Hash(), 1)
+
+	cases := []struct {
+		name       string
+		logs       map[uint64][]istanbul.Proposal
+		violations int
+		contains   string
+	}{
+		{"no commits", map[uint64][]istanbul.Proposal{0: nil, 1: nil}, 0, ""},
+		{"prefixes of a chain", map[uint64][]istanbul.Proposal{0: {b1, b2, b3}, 1: {b1}, 2: {b1, b2}}, 0, ""},
+		{"conflicting blocks", map[uint64][]istanbul.Proposal{0: {b1, b2}, 1: {b1, fork2}, 2: {b1, b2}}, 1,
+			fmt.Sprintf("validators [1] committed %v", fork2.Hash().Hex())},
+		{"gap in a log", map[uint64][]istanbul.Proposal{0: {b1, b3}}, 1, "validator 0 committed height 3 after height 1"},
+		{"block not on the committed parent", map[uint64][]istanbul.Proposal{0: {b1, fork2, b3}}, 1,
+			fmt.Sprintf("on parent %v, but committed %v at height 2", b2.Hash().Hex(), fork2.Hash().Hex())},
+	}
+	for _, tc := range cases {
+		violations := checkSafety(tc.logs)
+		if len(violations) != tc.violations || !strings.Contains(strings.Join(violations, "\n"), tc.contains) {
+			t.Errorf("%s: violations = %q, want %d containing %q", tc.name, violations, tc.violations, tc.contains)
+		}
+	}
+}
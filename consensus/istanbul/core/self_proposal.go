@@ -0,0 +1,66 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+// selfProposalBreaker pauses proposing once this node's own proposals failed to be committed in a number
+// of consecutive turns, so that a proposer with e.g. a local state bug lets the other validators take over
+// instead of failing every one of its rounds. It only affects whether this node sends a PREPREPARE, the
+// rounds are changed by the other validators as usual.
+type selfProposalBreaker struct {
+	proposedView *istanbul.View // The view of the last PREPREPARE sent by this node
+	failures     uint64         // The number of consecutive turns in which this node's proposal wasn't committed
+	pausedUntil  uint64         // The sequence from which this node proposes again
+}
+
+// proposed records that this node sent a PREPREPARE in the given view.
+func (b *selfProposalBreaker) proposed(view *istanbul.View) {
+	b.proposedView = view
+}
+
+// roundEnded records the outcome of the round with the given view, where ownCommitted tells whether the
+// round ended by committing a block proposed by this node. If this node proposed in the round, a committed
+// proposal resets the count of failures, and any other outcome counts as a failure. Once the failures reach
+// the threshold, proposing is paused for cooldown sequences and true is returned. A zero threshold
+// disables the breaker.
+func (b *selfProposalBreaker) roundEnded(view *istanbul.View, ownCommitted bool, threshold, cooldown uint64) bool {
+	if threshold == 0 {
+		return false
+	}
+	if ownCommitted {
+		b.failures = 0
+		return false
+	}
+	if b.proposedView == nil || b.proposedView.Cmp(view) != 0 {
+		return false
+	}
+	b.failures++
+	if b.failures < threshold {
+		return false
+	}
+	b.failures = 0
+	b.pausedUntil = view.Sequence.Uint64() + cooldown
+	return true
+}
+
+// isPaused returns whether proposing is paused for the given sequence.
+func (b *selfProposalBreaker) isPaused(sequence uint64) bool {
+	return sequence < b.pausedUntil
+}
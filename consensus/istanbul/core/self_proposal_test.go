@@ -0,0 +1,56 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestSelfProposalBreaker(t *testing.T) {
+	const threshold, cooldown = 2, 10
+
+	t.Run("Pauses after consecutive failed proposals", func(t *testing.T) {
+		var b selfProposalBreaker
+		b.proposed(newView(5, 0))
+		if b.roundEnded(newView(5, 0), false, threshold, cooldown) {
+			t.Errorf("paused after a single failed proposal")
+		}
+		// Rounds in which this node didn't propose don't count
+		if b.roundEnded(newView(5, 1), false, threshold, cooldown) {
+			t.Errorf("paused after a round proposed by another node")
+		}
+		b.proposed(newView(5, 2))
+		if !b.roundEnded(newView(5, 2), false, threshold, cooldown) {
+			t.Errorf("not paused after %d failed proposals", threshold)
+		}
+		if !b.isPaused(5) || !b.isPaused(14) {
+			t.Errorf("expected proposing to be paused up to sequence %d", 5+cooldown-1)
+		}
+		if b.isPaused(15) {
+			t.Errorf("expected proposing to resume at sequence %d", 5+cooldown)
+		}
+	})
+
+	t.Run("Committed proposal resets the failures", func(t *testing.T) {
+		var b selfProposalBreaker
+		b.proposed(newView(5, 0))
+		b.roundEnded(newView(5, 0), false, threshold, cooldown)
+		b.proposed(newView(5, 1))
+		b.roundEnded(newView(5, 1), true, threshold, cooldown)
+		b.proposed(newView(6, 0))
+		if b.roundEnded(newView(6, 0), false, threshold, cooldown) {
+			t.Errorf("paused although the failures were not consecutive")
+		}
+	})
+
+	t.Run("Zero threshold never pauses", func(t *testing.T) {
+		var b selfProposalBreaker
+		for i := uint64(0); i < 5; i++ {
+			b.proposed(newView(5, i))
+			if b.roundEnded(newView(5, i), false, 0, cooldown) {
+				t.Errorf("paused with a zero threshold")
+			}
+		}
+		if b.isPaused(5) {
+			t.Errorf("expected proposing not to be paused with a zero threshold")
+		}
+	})
+}
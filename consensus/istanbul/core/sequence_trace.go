@@ -0,0 +1,131 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+// maxSequenceTraceEntries bounds the memory used by a sequence trace, e.g. when tracing a sequence that
+// the network is stuck on for a long time. Later messages are counted but not kept.
+const maxSequenceTraceEntries = 10000
+
+// SequenceTraceEntry is a consensus message received for a traced sequence.
+type SequenceTraceEntry struct {
+	Timestamp time.Time      `json:"timestamp"`
+	From      common.Address `json:"from"`
+	Code      uint64         `json:"code"`
+	Round     *big.Int       `json:"round"`
+	Payload   hexutil.Bytes  `json:"payload"`
+	Err       string         `json:"err,omitempty"` // The error handling the message returned, if any
+}
+
+// sequenceTracer captures every consensus message received for a single target sequence, and hands
+// them over once the core moves past the sequence or the trace is abandoned.
+type sequenceTracer struct {
+	target  *big.Int // nil if no sequence is traced
+	entries []*SequenceTraceEntry
+	dropped int // The messages received after maxSequenceTraceEntries
+	mu      sync.Mutex
+}
+
+// start traces the given sequence, and returns the entries of the trace it replaces, if any.
+func (t *sequenceTracer) start(seq *big.Int) (*big.Int, []*SequenceTraceEntry, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	target, entries, dropped := t.target, t.entries, t.dropped
+	t.target, t.entries, t.dropped = new(big.Int).Set(seq), nil, 0
+	return target, entries, dropped
+}
+
+// record adds the message, and the error handling it returned, to the trace if it is for the traced sequence.
+func (t *sequenceTracer) record(msg *istanbul.Message, payload []byte, err error, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.target == nil {
+		return
+	}
+	view, viewErr := extractMessageView(msg)
+	if viewErr != nil || view == nil || view.Sequence.Cmp(t.target) != 0 {
+		return
+	}
+	if len(t.entries) >= maxSequenceTraceEntries {
+		t.dropped++
+		return
+	}
+	entry := &SequenceTraceEntry{Timestamp: now, From: msg.Address, Code: msg.Code, Round: view.Round, Payload: payload}
+	if err != nil {
+		entry.Err = err.Error()
+	}
+	t.entries = append(t.entries, entry)
+}
+
+// finish ends the trace if the traced sequence is before the given sequence, or unconditionally if seq is
+// nil, and returns the traced sequence and its entries. It returns a nil sequence if no trace ended.
+func (t *sequenceTracer) finish(seq *big.Int) (*big.Int, []*SequenceTraceEntry, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.target == nil || (seq != nil && t.target.Cmp(seq) >= 0) {
+		return nil, nil, 0
+	}
+	target, entries, dropped := t.target, t.entries, t.dropped
+	t.target, t.entries, t.dropped = nil, nil, 0
+	return target, entries, dropped
+}
+
+// TraceSequence captures every consensus message received for the given sequence, which must not be
+// before the current sequence. The trace is logged once the sequence is committed, or when it is
+// abandoned because another sequence is traced or the core stops. Only one sequence is traced at a time.
+func (c *core) TraceSequence(seq *big.Int) error {
+	if view := c.CurrentView(); view != nil && seq.Cmp(view.Sequence) < 0 {
+		return errTraceSequenceInPast
+	}
+	previous, entries, dropped := c.sequenceTracer.start(seq)
+	if previous != nil {
+		c.logSequenceTrace(previous, "abandoned", entries, dropped)
+	}
+	c.logger.Info("Tracing the consensus messages of a sequence", "traced_seq", seq)
+	return nil
+}
+
+// finishSequenceTrace logs the trace of the traced sequence if it is before the given sequence, i.e. it was
+// committed, or if seq is nil, in which case it is abandoned.
+func (c *core) finishSequenceTrace(seq *big.Int) {
+	target, entries, dropped := c.sequenceTracer.finish(seq)
+	if target == nil {
+		return
+	}
+	outcome := "committed"
+	if seq == nil {
+		outcome = "abandoned"
+	}
+	c.logSequenceTrace(target, outcome, entries, dropped)
+}
+
+func (c *core) logSequenceTrace(seq *big.Int, outcome string, entries []*SequenceTraceEntry, dropped int) {
+	c.logger.Info("Sequence trace", "traced_seq", seq, "outcome", outcome, "msgs", len(entries), "dropped", dropped)
+	for _, entry := range entries {
+		c.logger.Info("Sequence trace message", "traced_seq", seq, "time", entry.Timestamp, "from", entry.From, "code", entry.Code,
+			"msg_round", entry.Round, "payload", entry.Payload, "err", entry.Err)
+	}
+}
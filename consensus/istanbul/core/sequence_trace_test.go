@@ -0,0 +1,81 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+func TestSequenceTracer(t *testing.T) {
+	var tracer sequenceTracer
+	prepare := func(seq, round uint64) *istanbul.Message {
+		m, _ := Encode(&istanbul.Subject{View: newView(seq, round), Digest: common.HexToHash("0x1")})
+		return &istanbul.Message{Code: istanbul.MsgPrepare, Msg: m, Address: common.HexToAddress("0x01")}
+	}
+	now := time.Now()
+
+	// Nothing is recorded until a sequence is traced
+	tracer.record(prepare(2, 0), []byte{1}, nil, now)
+	if previous, _, _ := tracer.start(big.NewInt(2)); previous != nil {
+		t.Errorf("started trace replaced trace of %v", previous)
+	}
+	tracer.record(prepare(1, 0), []byte{2}, nil, now)
+	tracer.record(prepare(2, 1), []byte{3}, errFutureMessage, now)
+	tracer.record(prepare(3, 0), []byte{4}, nil, now)
+
+	// The trace isn't finished while the core hasn't moved past the traced sequence
+	if target, _, _ := tracer.finish(big.NewInt(2)); target != nil {
+		t.Errorf("trace finished at the traced sequence")
+	}
+	target, entries, dropped := tracer.finish(big.NewInt(3))
+	if target == nil || target.Int64() != 2 || dropped != 0 {
+		t.Fatalf("finished trace = %v (dropped %d), want sequence 2", target, dropped)
+	}
+	if len(entries) != 1 || entries[0].Payload[0] != 3 || entries[0].Round.Int64() != 1 || entries[0].Err != errFutureMessage.Error() {
+		t.Errorf("entries = %+v, want the prepare of round 1 of sequence 2", entries)
+	}
+	if target, _, _ := tracer.finish(nil); target != nil {
+		t.Errorf("trace finished twice")
+	}
+
+	// Tracing another sequence hands over the current trace
+	tracer.start(big.NewInt(5))
+	tracer.record(prepare(5, 0), []byte{5}, nil, now)
+	previous, entries, _ := tracer.start(big.NewInt(6))
+	if previous == nil || previous.Int64() != 5 || len(entries) != 1 {
+		t.Errorf("replaced trace = %v with %d entries, want sequence 5 with 1 entry", previous, len(entries))
+	}
+}
+
+func TestTraceSequenceInPast(t *testing.T) {
+	sys := NewTestSystemWithBackend(1, 0)
+	c := sys.backends[0].engine.(*core)
+	c.Start()
+	defer c.Stop()
+
+	if err := c.TraceSequence(big.NewInt(0)); err != errTraceSequenceInPast {
+		t.Errorf("error = %v, want %v", err, errTraceSequenceInPast)
+	}
+	if err := c.TraceSequence(c.current.Sequence()); err != nil {
+		t.Errorf("failed to trace the current sequence: %v", err)
+	}
+}
@@ -0,0 +1,429 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
+)
+
+// newTestSimulation returns a test system of n validators whose rounds time out after the request
+// timeout, so that tests exercising round changes don't wait for the block period.
+func newTestSimulation(n, f uint64) *testSystem {
+	sys := NewTestSystemWithBackend(n, f)
+	// The config is shared by all the backends
+	sys.backends[0].engine.(*core).config.BlockPeriod = 0
+	return sys
+}
+
+// testMessageRule decides how the bus of the test system handles a consensus message sent by the
+// backend with id from to the backend with id to: whether to drop it, and otherwise how long to
+// delay it. Rules are consulted in the order they were added, and the first one that drops the
+// message or delays it wins.
+type testMessageRule func(from, to uint64, msg *istanbul.Message) (drop bool, delay time.Duration)
+
+// addMessageRule makes the bus apply the given rule to the messages queued from now on.
+func (t *testSystem) addMessageRule(rule testMessageRule) {
+	t.messageRulesMu.Lock()
+	defer t.messageRulesMu.Unlock()
+	t.messageRules = append(t.messageRules, rule)
+}
+
+// clearMessageRules makes the bus deliver every message queued from now on right away.
+func (t *testSystem) clearMessageRules() {
+	t.messageRulesMu.Lock()
+	defer t.messageRulesMu.Unlock()
+	t.messageRules = nil
+}
+
+// partition splits the backends into groups that can't exchange messages with each other, replacing
+// any previous partition. A backend that isn't in any of the groups is isolated from all the others.
+func (t *testSystem) partition(groups ...[]uint64) {
+	t.messageRulesMu.Lock()
+	defer t.messageRulesMu.Unlock()
+	t.partitionGroups = make(map[uint64]int)
+	for i, group := range groups {
+		for _, id := range group {
+			t.partitionGroups[id] = i
+		}
+	}
+}
+
+// heal ends the partition of the network.
+func (t *testSystem) heal() {
+	t.messageRulesMu.Lock()
+	defer t.messageRulesMu.Unlock()
+	t.partitionGroups = nil
+}
+
+// isPartitioned returns whether the partition of the network separates the given backends.
+func (t *testSystem) isPartitioned(from, to uint64) bool {
+	if t.partitionGroups == nil || from == to {
+		return false
+	}
+	fromGroup, ok := t.partitionGroups[from]
+	if !ok {
+		return true
+	}
+	toGroup, ok := t.partitionGroups[to]
+	return !ok || fromGroup != toGroup
+}
+
+// syncCommittedBlocks makes a backend commit the blocks that another one committed and it missed, as
+// the block sync of a node does once it reconnects after a partition.
+func (t *testSystem) syncCommittedBlocks(from, to uint64) {
+	source, target := t.backends[from], t.backends[to]
+	if len(target.committedMsgs) >= len(source.committedMsgs) {
+		return
+	}
+	target.committedMsgs = append(target.committedMsgs, source.committedMsgs[len(target.committedMsgs):]...)
+	go target.events.Post(istanbul.FinalCommittedEvent{})
+}
+
+// routeMessage applies the partition and the message rules to a message queued on the bus.
+func (t *testSystem) routeMessage(from, to uint64, payload []byte) (bool, time.Duration) {
+	t.messageRulesMu.Lock()
+	defer t.messageRulesMu.Unlock()
+	if t.isPartitioned(from, to) {
+		return true, 0
+	}
+	if len(t.messageRules) == 0 {
+		return false, 0
+	}
+	msg := new(istanbul.Message)
+	if err := msg.FromPayload(payload, nil); err != nil {
+		return false, 0
+	}
+	for _, rule := range t.messageRules {
+		if drop, delay := rule(from, to, msg); drop || delay > 0 {
+			return drop, delay
+		}
+	}
+	return false, 0
+}
+
+// dropMessagesFrom returns a rule that drops the messages the given backend sends to the others,
+// as if it was partitioned from the network.
+func dropMessagesFrom(id uint64) testMessageRule {
+	return func(from, to uint64, msg *istanbul.Message) (bool, time.Duration) {
+		return from == id && to != id, 0
+	}
+}
+
+// dropMessagesWithCode returns a rule that drops every message with the given code sent by a backend
+// to another.
+func dropMessagesWithCode(code uint64) testMessageRule {
+	return func(from, to uint64, msg *istanbul.Message) (bool, time.Duration) {
+		return msg.Code == code && from != to, 0
+	}
+}
+
+// delayMessagesTo returns a rule that delays every message delivered to the given backend.
+func delayMessagesTo(id uint64, delay time.Duration) testMessageRule {
+	return func(from, to uint64, msg *istanbul.Message) (bool, time.Duration) {
+		if to == id {
+			return false, delay
+		}
+		return false, 0
+	}
+}
+
+// newRequestToAll posts the request for the given block to every backend, so that whichever of them
+// is the proposer of the block proposes it.
+func (t *testSystem) newRequestToAll(number int64) {
+	block := makeBlock(number)
+	for _, b := range t.backends {
+		b.NewRequest(block)
+	}
+}
+
+// waitForCommittedBlocks waits until each of the given backends committed at least the given number
+// of blocks, and fails the test if that takes longer than the timeout.
+func (t *testSystem) waitForCommittedBlocks(test *testing.T, blocks int, timeout time.Duration, ids ...uint64) {
+	deadline := time.Now().Add(timeout)
+	for _, id := range ids {
+		for len(t.backends[id].committedMsgs) < blocks {
+			if time.Now().After(deadline) {
+				test.Fatalf("backend %d committed %d blocks within %v, want %d", id, len(t.backends[id].committedMsgs), timeout, blocks)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+// changeValidatorSet makes the first size backends the validators up to the given block, and all the
+// backends the validators after it, as if they were elected at an epoch boundary. The joining validators
+// are first in the new validator set, so that one of them proposes the first block after the change.
+func (t *testSystem) changeValidatorSet(size int, lastBlock uint64) {
+	var oldValidators, newValidators []istanbul.ValidatorData
+	for i, val := range t.backends[0].peers.List() {
+		data := istanbul.ValidatorData{Address: val.Address(), BLSPublicKey: val.BLSPublicKey()}
+		if i < size {
+			oldValidators = append(oldValidators, data)
+		} else {
+			newValidators = append(newValidators, data)
+		}
+	}
+	newValidators = append(newValidators, oldValidators...)
+	for _, b := range t.backends {
+		b.peers = validator.NewSet(oldValidators)
+		b.nextPeers = validator.NewSet(newValidators)
+		b.nextPeersAfter = lastBlock
+		// Check the message signatures against the validator set of the core, which changes with the sequence
+		b.engine.(*core).validateFn = b.engine.(*core).checkValidatorSignature
+	}
+}
+
+func TestSimulationCommitsWithPartitionedProposer(t *testing.T) {
+	sys := newTestSimulation(4, 1)
+	// The first proposer can't reach the other validators, so they must change round to commit
+	sys.addMessageRule(dropMessagesFrom(0))
+
+	close := sys.Run(true)
+	defer close()
+
+	sys.newRequestToAll(1)
+	sys.waitForCommittedBlocks(t, 1, 5*time.Second, 1, 2, 3)
+	sys.assertSafety(t)
+
+	for _, id := range []uint64{1, 2, 3} {
+		if round := sys.backends[id].committedMsgs[0].aggregatedSeal.Round; round.Sign() == 0 {
+			t.Errorf("backend %d committed block 1 in round 0 without the proposer's messages", id)
+		}
+	}
+}
+
+func TestSimulationProposalTimeout(t *testing.T) {
+	sys := newTestSimulation(4, 1)
+	config := sys.backends[0].engine.(*core).config
+	sys.setRequestTimeout(5000)
+	config.ProposalTimeout = 100
+	sys.addMessageRule(dropMessagesFrom(0))
+
+	close := sys.Run(true)
+	defer close()
+
+	// The validators give up on the silent proposer well before the round change timeout
+	sys.newRequestToAll(1)
+	sys.waitForCommittedBlocks(t, 1, 2*time.Second, 1, 2, 3)
+	sys.assertSafety(t)
+}
+
+func TestSimulationCommitsWithDelayedValidator(t *testing.T) {
+	sys := newTestSimulation(4, 1)
+	// One validator lags behind, the others still form a quorum and the lagging one catches up
+	sys.addMessageRule(delayMessagesTo(3, 100*time.Millisecond))
+
+	close := sys.Run(true)
+	defer close()
+
+	sys.newRequestToAll(1)
+	sys.waitForCommittedBlocks(t, 1, 5*time.Second, 0, 1, 2, 3)
+	sys.assertSafety(t)
+}
+
+func TestSimulationStallsWithoutCommits(t *testing.T) {
+	sys := newTestSimulation(4, 1)
+	sys.addMessageRule(dropMessagesWithCode(istanbul.MsgCommit))
+
+	close := sys.Run(true)
+	defer close()
+
+	sys.newRequestToAll(1)
+	<-time.After(500 * time.Millisecond)
+	for _, b := range sys.backends {
+		if len(b.committedMsgs) != 0 {
+			t.Errorf("backend %d committed %d blocks without receiving COMMIT messages", b.id, len(b.committedMsgs))
+		}
+	}
+
+	// Once the network heals, the validators commit the block in a later round
+	sys.clearMessageRules()
+	sys.waitForCommittedBlocks(t, 1, 10*time.Second, 0, 1, 2, 3)
+	sys.assertSafety(t)
+}
+
+func TestSimulationPartitionWithoutQuorum(t *testing.T) {
+	sys := newTestSimulation(4, 1)
+	// Neither half of the validators forms a quorum
+	sys.partition([]uint64{0, 1}, []uint64{2, 3})
+
+	close := sys.Run(true)
+	defer close()
+
+	sys.newRequestToAll(1)
+	<-time.After(500 * time.Millisecond)
+	for _, b := range sys.backends {
+		if len(b.committedMsgs) != 0 {
+			t.Errorf("backend %d committed %d blocks without a quorum", b.id, len(b.committedMsgs))
+		}
+	}
+
+	// Progress resumes once the partition heals
+	sys.heal()
+	sys.waitForCommittedBlocks(t, 1, 10*time.Second, 0, 1, 2, 3)
+	sys.assertSafety(t)
+}
+
+func TestSimulationReconvergesAfterPartition(t *testing.T) {
+	sys := newTestSimulation(4, 1)
+	// The majority keeps committing while a validator is cut off from it
+	sys.partition([]uint64{0, 1, 2}, []uint64{3})
+
+	close := sys.Run(true)
+	defer close()
+
+	for number := 1; number <= 3; number++ {
+		sys.newRequestToAll(int64(number))
+		sys.waitForCommittedBlocks(t, number, 10*time.Second, 0, 1, 2)
+	}
+	if committed := len(sys.backends[3].committedMsgs); committed != 0 {
+		t.Errorf("partitioned backend committed %d blocks", committed)
+	}
+
+	// Once healed, the partitioned validator syncs the blocks it missed and joins consensus again
+	sys.heal()
+	sys.syncCommittedBlocks(0, 3)
+	sys.newRequestToAll(4)
+	sys.waitForCommittedBlocks(t, 4, 10*time.Second, 0, 1, 2, 3)
+	sys.assertSafety(t)
+}
+
+// proposerID returns the id of the backend that proposes in the given round of the first sequence.
+func (t *testSystem) proposerID(round uint64) uint64 {
+	_, headAuthor := t.backends[0].GetCurrentHeadBlockAndAuthor()
+	proposer := t.backends[0].engine.(*core).selectProposer(t.backends[0].peers, headAuthor, 1, round)
+	for _, b := range t.backends {
+		if b.address == proposer.Address() {
+			return b.id
+		}
+	}
+	panic("proposer isn't a backend")
+}
+
+func TestSimulationAggregatedRoundChange(t *testing.T) {
+	sys := newTestSimulation(4, 1)
+	sys.backends[0].engine.(*core).config.AggregateRoundChange = true
+	sys.addMessageRule(dropMessagesFrom(0))
+	// Record which backends receive the ROUND CHANGE messages of the others
+	var mu sync.Mutex
+	roundChangeReceivers := make(map[uint64]bool)
+	sys.addMessageRule(func(from, to uint64, msg *istanbul.Message) (bool, time.Duration) {
+		if msg.Code == istanbul.MsgRoundChange && from != to {
+			mu.Lock()
+			roundChangeReceivers[to] = true
+			mu.Unlock()
+		}
+		return false, 0
+	})
+
+	close := sys.Run(true)
+	defer close()
+
+	sys.newRequestToAll(1)
+	sys.waitForCommittedBlocks(t, 1, 5*time.Second, 1, 2, 3)
+	sys.assertSafety(t)
+
+	// Only the next proposer received ROUND CHANGE messages, and its certificate moved the others to round 1
+	for _, id := range []uint64{1, 2, 3} {
+		if round := sys.backends[id].committedMsgs[0].aggregatedSeal.Round; round.Cmp(common.Big1) != 0 {
+			t.Errorf("backend %d committed block 1 in round %v, want 1", id, round)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	proposer := sys.proposerID(1)
+	for id := range roundChangeReceivers {
+		if id != proposer {
+			t.Errorf("backend %d received ROUND CHANGE messages, only the proposer %d of round 1 should", id, proposer)
+		}
+	}
+}
+
+func TestSimulationAggregatedRoundChangeFallback(t *testing.T) {
+	sys := newTestSimulation(4, 1)
+	sys.backends[0].engine.(*core).config.AggregateRoundChange = true
+	sys.addMessageRule(dropMessagesFrom(0))
+	// The proposer of round 1 doesn't get the ROUND CHANGE messages to aggregate
+	proposer := sys.proposerID(1)
+	sys.addMessageRule(func(from, to uint64, msg *istanbul.Message) (bool, time.Duration) {
+		return msg.Code == istanbul.MsgRoundChange && to == proposer && from != to, 0
+	})
+
+	close := sys.Run(true)
+	defer close()
+
+	// The validators broadcast their ROUND CHANGE messages for round 2 instead, and commit in that round
+	sys.newRequestToAll(1)
+	sys.waitForCommittedBlocks(t, 1, 5*time.Second, 1, 2, 3)
+	sys.assertSafety(t)
+	for _, id := range []uint64{1, 2, 3} {
+		if round := sys.backends[id].committedMsgs[0].aggregatedSeal.Round; round.Cmp(common.Big2) != 0 {
+			t.Errorf("backend %d committed block 1 in round %v, want 2", id, round)
+		}
+	}
+}
+
+func TestSimulationValidatorSetGrowsAtEpochBoundary(t *testing.T) {
+	sys := newTestSimulation(10, 3)
+	config := sys.backends[0].engine.(*core).config
+	config.Epoch = 2
+	// Any round change would be a timeout, so that all the blocks committing in round 0 shows that none was needed
+	sys.setRequestTimeout(5000)
+	// 4 validators validate the blocks of the first epoch, and all 10 validate the blocks after it
+	sys.changeValidatorSet(4, 2)
+	// All the validators of the first epoch but one get the COMMITs of its last block late, so that the
+	// joining validators start the next sequence before them
+	sys.addMessageRule(func(from, to uint64, msg *istanbul.Message) (bool, time.Duration) {
+		if to >= 1 && to < 4 && from != to && msg.Code == istanbul.MsgCommit {
+			if view, err := extractMessageView(msg); err == nil && view.Sequence.Uint64() == 2 {
+				return false, 100 * time.Millisecond
+			}
+		}
+		return false, 0
+	})
+
+	close := sys.Run(true)
+	defer close()
+
+	for number := int64(1); number <= 3; number++ {
+		sys.newRequestToAll(number)
+	}
+	// The joining validators aren't validators of the first epoch, and sync its blocks
+	sys.waitForCommittedBlocks(t, 2, 5*time.Second, 0)
+	for id := uint64(4); id < 10; id++ {
+		sys.syncCommittedBlocks(0, id)
+	}
+	sys.waitForCommittedBlocks(t, 3, 5*time.Second, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	sys.assertSafety(t)
+
+	// The first block after the change reaches the quorum of the larger validator set without a round change
+	for _, b := range sys.backends {
+		committed := b.committedMsgs[2]
+		if round := committed.aggregatedSeal.Round; round.Sign() != 0 {
+			t.Errorf("backend %d committed block 3 in round %v, want 0", b.id, round)
+		}
+		if signers := committed.aggregatedSeal.Bitmap.BitLen(); signers > 10 {
+			t.Errorf("backend %d committed block 3 with a bitmap of %d validators, want at most 10", b.id, signers)
+		}
+	}
+}
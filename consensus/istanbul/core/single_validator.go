@@ -0,0 +1,85 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+// isSingleValidator returns whether SingleValidatorMode applies to the current sequence, i.e. whether
+// it is enabled and this node is the only validator. The mode never applies to a larger validator set,
+// so that enabling it by mistake on a real network falls back to the full consensus.
+func (c *core) isSingleValidator() bool {
+	if !c.config.SingleValidatorMode {
+		return false
+	}
+	valSet := c.current.ValidatorSet()
+	if valSet.Size() != 1 {
+		if !c.singleValidatorModeIgnored {
+			c.singleValidatorModeIgnored = true
+			c.logger.Error("Ignoring SingleValidatorMode, the validator set has more than one validator", "validators", valSet.Size())
+		}
+		return false
+	}
+	return c.current.IsProposer(c.address)
+}
+
+// commitAlone commits this node's proposal without exchanging any consensus message, as the only
+// validator is a quorum on its own. The block is sealed the same way as by the full consensus, with
+// this node's committed seal aggregated for round 0, so it verifies under the normal rules. A proposal
+// whose timestamp is in the future is committed once its time is reached.
+func (c *core) commitAlone(proposal istanbul.Proposal) {
+	logger := c.newLogger("func", "commitAlone", "number", proposal.Number(), "hash", proposal.Hash())
+
+	if duration, err := c.verifyProposal(proposal); err != nil {
+		if err == consensus.ErrFutureBlock {
+			c.stopFuturePreprepareTimer()
+			c.futurePreprepareTimer = time.AfterFunc(duration, func() {
+				c.sendEvent(istanbul.RequestEvent{Proposal: proposal})
+			})
+			return
+		}
+		logger.Error("Failed to verify own proposal", "err", err)
+		return
+	}
+
+	c.consensusTimestamp = time.Now()
+	if err := c.current.TransitionToPreprepared(&istanbul.Preprepare{View: c.current.View(), Proposal: proposal}); err != nil {
+		logger.Error("Failed to accept own proposal", "err", err)
+		return
+	}
+
+	msg, err := c.newCommitMessage(c.current.Subject())
+	if err != nil {
+		logger.Error("Failed to create commit message", "err", err)
+		return
+	}
+	if _, err := c.finalizeMessage(msg); err != nil {
+		logger.Error("Failed to sign commit message", "err", err)
+		return
+	}
+	if err := c.current.AddCommit(msg); err != nil {
+		logger.Error("Failed to record commit message", "err", err)
+		return
+	}
+	if err := c.commit(); err != nil {
+		logger.Error("Failed to commit own proposal", "err", err)
+	}
+}
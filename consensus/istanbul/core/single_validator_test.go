@@ -0,0 +1,68 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	blscrypto "github.com/ethereum/go-ethereum/crypto/bls"
+)
+
+func TestSingleValidatorMode(t *testing.T) {
+	sys := NewTestSystemWithBackend(1, 0)
+	backend := sys.backends[0]
+	backend.engine.(*core).config.SingleValidatorMode = true
+
+	close := sys.Run(true)
+	defer close()
+
+	backend.NewRequest(makeBlock(1))
+	sys.waitForCommittedBlocks(t, 1, 2*time.Second, 0)
+	backend.NewRequest(makeBlock(2))
+	sys.waitForCommittedBlocks(t, 2, 2*time.Second, 0)
+
+	if len(backend.sentMsgs) != 0 {
+		t.Errorf("sent %d consensus messages, want none", len(backend.sentMsgs))
+	}
+	for i, committed := range backend.committedMsgs {
+		seal := committed.aggregatedSeal
+		if seal.Round.Sign() != 0 || seal.Bitmap.Uint64() != 1 || len(seal.Signature) == 0 {
+			t.Errorf("block %d: unexpected aggregated seal %v", i+1, seal)
+		}
+		publicKeys := []blscrypto.SerializedPublicKey{backend.peers.GetByIndex(0).BLSPublicKey()}
+		hashedSeal := PrepareCommittedSeal(committed.commitProposal.Hash(), seal.Round)
+		if err := blscrypto.VerifyAggregatedSignature(publicKeys, hashedSeal, []byte{}, seal.Signature, false); err != nil {
+			t.Errorf("block %d: invalid aggregated seal: %v", i+1, err)
+		}
+	}
+}
+
+func TestSingleValidatorModeIgnoredWithMoreValidators(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 1)
+	sys.backends[0].engine.(*core).config.SingleValidatorMode = true
+
+	close := sys.Run(true)
+	defer close()
+
+	sys.backends[0].NewRequest(makeBlock(1))
+	sys.waitForCommittedBlocks(t, 1, 2*time.Second, 0, 1, 2, 3)
+
+	if len(sys.backends[0].sentMsgs) == 0 {
+		t.Errorf("the proposer committed without sending consensus messages")
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"sync"
 	"testing"
 	"time"
 
@@ -50,8 +51,18 @@ type testSystemBackend struct {
 	peers  istanbul.ValidatorSet
 	events *event.TypeMux
 
-	committedMsgs []testCommittedMsgs
-	sentMsgs      [][]byte // store the message when Send is called by core
+	// The validator set after the block nextPeersAfter, if any, to simulate an epoch boundary
+	nextPeers      istanbul.ValidatorSet
+	nextPeersAfter uint64
+
+	committedMsgs    []testCommittedMsgs
+	sentMsgs         [][]byte // store the message when Send is called by core
+	stateTransitions []istanbul.StateTransitionEvent
+
+	// Whether HasMinValidatorsToStart returns false, so that tests can pause consensus
+	tooFewValidators bool
+	// The number of times the core asked to synchronise the chain
+	synchroniseCalls int
 
 	key     ecdsa.PrivateKey
 	blsKey  []byte
@@ -84,6 +95,17 @@ func (self *testSystemBackend) Address() common.Address {
 
 // Peers returns all connected peers
 func (self *testSystemBackend) Validators(proposal istanbul.Proposal) istanbul.ValidatorSet {
+	if proposal == nil {
+		return self.peers
+	}
+	return self.validatorsAfter(proposal.Number().Uint64())
+}
+
+// validatorsAfter returns the validator set that validates the block after the given one.
+func (self *testSystemBackend) validatorsAfter(number uint64) istanbul.ValidatorSet {
+	if self.nextPeers != nil && number >= self.nextPeersAfter {
+		return self.nextPeers
+	}
 	return self.peers
 }
 
@@ -100,8 +122,7 @@ func (self *testSystemBackend) IsPrimaryForSeq(seq *big.Int) bool {
 }
 
 func (self *testSystemBackend) NextBlockValidators(proposal istanbul.Proposal) (istanbul.ValidatorSet, error) {
-	//This doesn't really return the next block validators
-	return self.peers, nil
+	return self.validatorsAfter(proposal.Number().Uint64()), nil
 }
 
 func (self *testSystemBackend) EventMux() *event.TypeMux {
@@ -111,8 +132,9 @@ func (self *testSystemBackend) EventMux() *event.TypeMux {
 func (self *testSystemBackend) Send(message []byte, target common.Address) error {
 	testLogger.Info("enqueuing a message...", "address", self.Address())
 	self.sentMsgs = append(self.sentMsgs, message)
-	self.sys.queuedMessage <- istanbul.MessageEvent{
-		Payload: message,
+	self.sys.queuedMessage <- testQueuedMessage{
+		from:  self.id,
+		event: istanbul.MessageEvent{Payload: message},
 	}
 	return nil
 }
@@ -120,9 +142,18 @@ func (self *testSystemBackend) Send(message []byte, target common.Address) error
 func (self *testSystemBackend) Multicast(validators []common.Address, message []byte, msgCode uint64, sendToSelf bool) error {
 	testLogger.Info("enqueuing a message...", "address", self.Address())
 	self.sentMsgs = append(self.sentMsgs, message)
+	to := make(map[common.Address]bool, len(validators)+1)
+	for _, addr := range validators {
+		to[addr] = true
+	}
+	if sendToSelf {
+		to[self.address] = true
+	}
 	send := func() {
-		self.sys.queuedMessage <- istanbul.MessageEvent{
-			Payload: message,
+		self.sys.queuedMessage <- testQueuedMessage{
+			from:  self.id,
+			to:    to,
+			event: istanbul.MessageEvent{Payload: message},
 		}
 	}
 	go send()
@@ -234,11 +265,26 @@ func (self *testSystemBackend) AuthorForBlock(number uint64) common.Address {
 }
 
 func (self *testSystemBackend) ParentBlockValidators(proposal istanbul.Proposal) istanbul.ValidatorSet {
-	return self.peers
+	if proposal.Number().Sign() == 0 {
+		return self.peers
+	}
+	return self.validatorsAfter(proposal.Number().Uint64() - 1)
 }
 
 func (self *testSystemBackend) UpdateReplicaState(seq *big.Int) { /* pass */ }
 
+func (self *testSystemBackend) HasMinValidatorsToStart() bool {
+	return !self.tooFewValidators
+}
+
+func (self *testSystemBackend) Synchronise() {
+	self.synchroniseCalls++
+}
+
+func (self *testSystemBackend) NotifyStateTransition(ev istanbul.StateTransitionEvent) {
+	self.stateTransitions = append(self.stateTransitions, ev)
+}
+
 func (self *testSystemBackend) finalizeAndReturnMessage(msg *istanbul.Message) (istanbul.Message, error) {
 	message := new(istanbul.Message)
 	data, err := self.engine.(*core).finalizeMessage(msg)
@@ -348,8 +394,21 @@ type testSystem struct {
 	n              uint64
 	validatorsKeys [][]byte
 
-	queuedMessage chan istanbul.MessageEvent
+	queuedMessage chan testQueuedMessage
 	quit          chan struct{}
+
+	// Rules deciding whether the messages queued on the bus are dropped or delayed
+	messageRules []testMessageRule
+	// The group of each backend while the network is partitioned, nil otherwise
+	partitionGroups map[uint64]int
+	messageRulesMu  sync.Mutex
+}
+
+// testQueuedMessage is a message queued on the bus of the test system by one of its backends.
+type testQueuedMessage struct {
+	from  uint64
+	to    map[common.Address]bool // The addresses the message is delivered to, every backend if nil
+	event istanbul.MessageEvent
 }
 
 func newTestSystem(n uint64, f uint64, keys [][]byte) *testSystem {
@@ -360,7 +419,7 @@ func newTestSystem(n uint64, f uint64, keys [][]byte) *testSystem {
 		f:              f,
 		n:              n,
 
-		queuedMessage: make(chan istanbul.MessageEvent),
+		queuedMessage: make(chan testQueuedMessage),
 		quit:          make(chan struct{}),
 	}
 }
@@ -420,7 +479,8 @@ func NewTestSystemWithBackend(n, f uint64) *testSystem {
 	return sys
 }
 
-// listen will consume messages from queue and deliver a message to core
+// listen will consume messages from queue and deliver a message to core, unless a message rule
+// drops or delays it
 func (t *testSystem) listen() {
 	for {
 		select {
@@ -429,7 +489,21 @@ func (t *testSystem) listen() {
 		case queuedMessage := <-t.queuedMessage:
 			testLogger.Info("consuming a queue message...")
 			for _, backend := range t.backends {
-				go backend.EventMux().Post(queuedMessage)
+				if queuedMessage.to != nil && !queuedMessage.to[backend.address] {
+					continue
+				}
+				drop, delay := t.routeMessage(queuedMessage.from, backend.id, queuedMessage.event.Payload)
+				if drop {
+					continue
+				}
+				post := func(backend *testSystemBackend, ev istanbul.MessageEvent) func() {
+					return func() { backend.EventMux().Post(ev) }
+				}(backend, queuedMessage.event)
+				if delay > 0 {
+					time.AfterFunc(delay, post)
+				} else {
+					go post()
+				}
 			}
 		}
 	}
@@ -482,6 +556,13 @@ func (t *testSystem) NewBackend(id uint64) *testSystemBackend {
 	return backend
 }
 
+// setRequestTimeout sets the request timeout in effect in the cores of all the backends
+func (t *testSystem) setRequestTimeout(requestTimeout uint64) {
+	for _, b := range t.backends {
+		b.engine.(*core).timingConfig.RequestTimeout = requestTimeout
+	}
+}
+
 func (t *testSystem) F() uint64 {
 	return t.f
 }
@@ -17,6 +17,9 @@
 package core
 
 import (
+	"math/big"
+	"time"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -32,8 +35,32 @@ type Engine interface {
 	SetAddress(common.Address)
 	// Validator -> CommittedSeal from Parent Block
 	ParentCommits() MessageSet
-	// ForceRoundChange will force round change to the current desiredRound + 1
-	ForceRoundChange()
+	// ForceRoundChange will force round change to the given round, or to the current desiredRound + 1
+	// if nil, and returns the round moved to
+	ForceRoundChange(targetRound *big.Int) (*big.Int, error)
+	// RoundChangeTimeoutRemaining returns the time left until the current round change timer fires
+	RoundChangeTimeoutRemaining() time.Duration
+	// RoundChangeTimeout returns the effective timeout of the current round change timer, after the
+	// backoff and the MaxRequestTimeout cap, or zero if the timer isn't running
+	RoundChangeTimeout() time.Duration
+	// SetTimingConfig schedules a timing config change to be applied at the next round boundary
+	SetTimingConfig(TimingConfig)
+	// TimingConfig returns the timing config currently in effect
+	TimingConfig() TimingConfig
+	// RoundStateHistory returns up to count of the most recent round states recorded at state transitions
+	RoundStateHistory(count int) []*RoundStateSnapshot
+	// DoubleSignEvidence returns the evidence of the validators detected committing to two blocks in a round
+	DoubleSignEvidence() []*DoubleSignEvidence
+	// EquivocationEvidence returns the evidence of the proposers detected proposing two blocks in a round
+	EquivocationEvidence() []*EquivocationEvidence
+	// TraceSequence captures every consensus message received for the given sequence, and logs them once
+	// the sequence is committed or abandoned
+	TraceSequence(seq *big.Int) error
+}
+
+// TimingConfig holds the istanbul config fields that can be changed while the engine is running
+type TimingConfig struct {
+	RequestTimeout uint64 // The timeout for each Istanbul round in milliseconds
 }
 
 // State represents the IBFT state
@@ -0,0 +1,80 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+// storeNextValidatorSetMsg stores in the backlog a message for the next sequence signed by a validator
+// that is only in the validator set after the current proposal, and returns whether it did.
+//
+// The validators joining at an epoch boundary start the first sequence of the epoch as soon as they
+// commit the last block of the previous one. Without this, their messages would be dropped by the
+// validators still committing that block, which would then need a round change to reach the larger
+// quorum. The message is verified again against the current validator set when the backlog replays it.
+func (c *core) storeNextValidatorSetMsg(msg *istanbul.Message) bool {
+	proposal := c.current.Proposal()
+	if proposal == nil {
+		return false
+	}
+	view, err := extractMessageView(msg)
+	if err != nil || view == nil || view.Sequence == nil || view.Sequence.Cmp(new(big.Int).Add(c.current.Sequence(), common.Big1)) != 0 {
+		return false
+	}
+	nextValSet, err := c.backend.NextBlockValidators(proposal)
+	if err != nil {
+		return false
+	}
+	data, err := msg.PayloadNoSig()
+	if err != nil {
+		return false
+	}
+	if signer, err := istanbul.CheckValidatorSignature(nextValSet, data, msg.Signature); err != nil || signer != msg.Address {
+		return false
+	}
+	c.backlog.store(msg)
+	return true
+}
+
+// logValidatorSetChange logs the sizes and quorums of the validator sets before and after the given
+// sequence if they differ, i.e. at the epoch boundaries where validators join or leave.
+func (c *core) logValidatorSetChange(oldValSet, newValSet istanbul.ValidatorSet, seq *big.Int) {
+	if oldValSet == nil || !validatorSetChanged(oldValSet, newValSet) {
+		return
+	}
+	c.logger.Info("Validator set changed", "new_seq", seq, "old_size", oldValSet.Size(), "new_size", newValSet.Size(),
+		"old_quorum", oldValSet.MinQuorumSize(), "new_quorum", newValSet.MinQuorumSize())
+}
+
+// validatorSetChanged returns whether the given validator sets have different validators, or the same
+// validators in a different order.
+func validatorSetChanged(oldValSet, newValSet istanbul.ValidatorSet) bool {
+	if oldValSet.Size() != newValSet.Size() {
+		return true
+	}
+	newVals := newValSet.List()
+	for i, val := range oldValSet.List() {
+		if val.Address() != newVals[i].Address() {
+			return true
+		}
+	}
+	return false
+}
@@ -16,7 +16,13 @@
 
 package istanbul
 
-import "github.com/ethereum/go-ethereum/p2p/enode"
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
 
 // RequestEvent is posted to propose a proposal
 type RequestEvent struct {
@@ -26,6 +32,9 @@ type RequestEvent struct {
 // MessageEvent is posted for Istanbul engine communication
 type MessageEvent struct {
 	Payload []byte
+	// Signer is the address recovered from the message signature, if the backend already verified it.
+	// The core then only checks that the signer is a validator, otherwise it verifies the signature itself.
+	Signer *common.Address
 }
 
 // MessageWithPeerIDEvent is a MessageEvent with the peerID that sent the message
@@ -37,3 +46,27 @@ type MessageWithPeerIDEvent struct {
 // FinalCommittedEvent is posted when a proposal is committed
 type FinalCommittedEvent struct {
 }
+
+// StateTransition identifies a consensus state transition of the Istanbul core
+type StateTransition string
+
+const (
+	// NewSequenceTransition is the transition to round 0 of a new sequence
+	NewSequenceTransition StateTransition = "newSequence"
+	// RoundChangeTransition is the transition to a new round of the current sequence
+	RoundChangeTransition StateTransition = "roundChange"
+	// PreparedTransition is the transition to the prepared state
+	PreparedTransition StateTransition = "prepared"
+	// CommittedTransition is the transition to the committed state
+	CommittedTransition StateTransition = "committed"
+)
+
+// StateTransitionEvent is posted when the Istanbul core enters a new sequence or round,
+// or the prepared or committed state
+type StateTransitionEvent struct {
+	Transition StateTransition `json:"transition"`
+	Sequence   *big.Int        `json:"sequence"`
+	Round      *big.Int        `json:"round"`
+	Proposer   common.Address  `json:"proposer"`
+	Timestamp  time.Time       `json:"timestamp"`
+}
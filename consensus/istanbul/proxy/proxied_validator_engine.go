@@ -79,6 +79,9 @@ type BackendForProxiedValidatorEngine interface {
 	GetProxiedValidatorEngine() ProxiedValidatorEngine
 }
 
+// maxProxyReconnectBackoff is the maximum time between two reconnection attempts to a disconnected proxy
+const maxProxyReconnectBackoff = 5 * time.Minute
+
 type fwdMsgInfo struct {
 	destAddresses []common.Address
 	ethMsgCode    uint64
@@ -382,6 +385,9 @@ func (pv *proxiedValidatorEngine) threadRun() {
 		// The duration of time between thread update, which are occasional check-ins to ensure proxy/validator assignments are as intended
 		schedulerPeriod time.Duration = 30 * time.Second
 
+		// The duration of time between health checks of the proxy connections
+		healthCheckPeriod time.Duration = time.Duration(pv.config.ProxyHealthCheckInterval) * time.Second
+
 		// Used to keep track of proxies & validators the proxies are associated with
 		ps *proxySet = newProxySet(newConsistentHashingPolicy())
 	)
@@ -393,6 +399,9 @@ func (pv *proxiedValidatorEngine) threadRun() {
 	schedulerTicker := time.NewTicker(schedulerPeriod)
 	defer schedulerTicker.Stop()
 
+	healthCheckTicker := time.NewTicker(healthCheckPeriod)
+	defer healthCheckTicker.Stop()
+
 	pv.updateValidatorAssignments(ps)
 
 loop:
@@ -405,16 +414,27 @@ loop:
 		case addProxyNodes := <-pv.addProxies:
 			// Got command to add proxy nodes.
 			// Add any unseen proxies to the proxy set and add p2p static connections to them.
+			// For proxies that are already in the proxy set, update their external node if it changed,
+			// and update the announce version so that the other validators learn the new enode.
+			externalNodesChanged := false
 			for _, proxyNode := range addProxyNodes {
 				proxyID := proxyNode.InternalNode.ID()
 				if ps.getProxy(proxyID) != nil {
-					logger.Debug("Proxy is already in the proxy set", "proxyNode", proxyNode, "proxyID", proxyID, "chan", "addProxies")
+					if ps.updateProxyExternalNode(proxyID, proxyNode.ExternalNode) {
+						logger.Info("Updated the external node of a proxy", "proxyNode", proxyNode, "proxyID", proxyID, "chan", "addProxies")
+						externalNodesChanged = true
+					} else {
+						logger.Debug("Proxy is already in the proxy set", "proxyNode", proxyNode, "proxyID", proxyID, "chan", "addProxies")
+					}
 					continue
 				}
 				log.Info("Adding proxy node", "proxyNode", proxyNode, "proxyID", proxyID)
 				ps.addProxy(proxyNode)
 				pv.backend.AddPeer(proxyNode.InternalNode, p2p.ProxyPurpose)
 			}
+			if externalNodesChanged {
+				pv.backend.UpdateAnnounceVersion()
+			}
 
 		case rmProxyNodes := <-pv.removeProxies:
 			// Got command to remove proxy nodes.
@@ -462,7 +482,7 @@ loop:
 			// Proxied peer just disconnected.
 			peerID := disconnectedPeer.Node().ID()
 			if ps.getProxy(peerID) != nil {
-				logger.Debug("Disconnected proxy peer", "peerID", peerID, "chan", "removeProxyPeer")
+				logger.Warn("Lost connection to proxy", "peerID", peerID, "chan", "removeProxyPeer")
 				ps.removeProxyPeer(peerID)
 			}
 
@@ -515,10 +535,46 @@ loop:
 				// Share the enode certs with the proxies
 				pv.sendEnodeCerts(ps, proxyEnodeCertMsgs)
 			}
+
+		case <-healthCheckTicker.C:
+			pv.reconnectDisconnectedProxies(ps, healthCheckPeriod)
 		}
 	}
 }
 
+// reconnectDisconnectedProxies will redial every proxy that is not peered and whose reconnection backoff
+// has elapsed.  The backoff starts at the health check period and doubles with every failed attempt,
+// up to maxProxyReconnectBackoff.
+func (pv *proxiedValidatorEngine) reconnectDisconnectedProxies(ps *proxySet, healthCheckPeriod time.Duration) {
+	logger := pv.logger.New("func", "reconnectDisconnectedProxies")
+
+	now := time.Now()
+	for _, proxy := range ps.getDisconnectedProxiesToReconnect(now) {
+		backoff := proxyReconnectBackoff(healthCheckPeriod, proxy.reconnectAttempts)
+		logger.Warn("Proxy is not connected, attempting to reconnect", "proxy", proxy.String(), "attempt", proxy.reconnectAttempts+1, "nextAttemptIn", backoff)
+
+		// Removing and re-adding the static peer recreates the p2p server's dial task for the proxy,
+		// so that a dial task that has stalled is restarted.
+		pv.backend.RemovePeer(proxy.node, p2p.ProxyPurpose)
+		pv.backend.AddPeer(proxy.node, p2p.ProxyPurpose)
+
+		proxy.reconnectAttempts++
+		proxy.nextReconnectTS = now.Add(backoff)
+	}
+}
+
+// proxyReconnectBackoff returns the time to wait after the given number of failed reconnection attempts.
+func proxyReconnectBackoff(healthCheckPeriod time.Duration, attempts uint) time.Duration {
+	backoff := healthCheckPeriod
+	for i := uint(0); i < attempts && backoff < maxProxyReconnectBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxProxyReconnectBackoff {
+		backoff = maxProxyReconnectBackoff
+	}
+	return backoff
+}
+
 func (pv *proxiedValidatorEngine) sendValEnodeShareMsgs(ps *proxySet) {
 	logger := pv.logger.New("func", "sendValEnodeShareMsgs")
 
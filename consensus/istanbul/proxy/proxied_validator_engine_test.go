@@ -17,15 +17,18 @@
 package proxy
 
 import (
+	"net"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/consensustest"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/consensus/istanbul/backend/backendtest"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
 )
 
 func TestAddProxy(t *testing.T) {
@@ -134,3 +137,99 @@ func TestAddProxy(t *testing.T) {
 		t.Errorf("Proxied validator announce version was not updated.  announceVersion: %d, valBE.GetAnnounceVersion(): %d", announceVersion, valBE.GetAnnounceVersion())
 	}
 }
+
+func TestUpdateProxyExternalNode(t *testing.T) {
+	numValidators := 2
+	genesisCfg, nodeKeys := backendtest.GetGenesisAndKeys(numValidators, true)
+
+	valBEi, _ := backendtest.NewTestBackend(false, common.Address{}, true, genesisCfg, nodeKeys[0])
+	valBE := valBEi.(BackendForProxiedValidatorEngine)
+	valPeer := consensustest.NewMockPeer(valBE.SelfNode(), p2p.ValidatorPurpose)
+
+	proxyBEi, _ := backendtest.NewTestBackend(true, valBE.Address(), false, genesisCfg, nil)
+	proxyBE := proxyBEi.(BackendForProxyEngine)
+	proxyPeer := consensustest.NewMockPeer(proxyBE.SelfNode(), p2p.ProxyPurpose)
+
+	remoteValBEi, _ := backendtest.NewTestBackend(false, common.Address{}, false, genesisCfg, nodeKeys[1])
+	remoteValBE := remoteValBEi.(BackendForProxiedValidatorEngine)
+
+	pvi := valBE.GetProxiedValidatorEngine()
+	pv := pvi.(*proxiedValidatorEngine)
+
+	// Add and connect the proxy, and wait for the proxy to be assigned the remote validator
+	pv.AddProxy(proxyBE.SelfNode(), proxyBE.SelfNode())
+	pv.RegisterProxyPeer(proxyPeer)
+	time.Sleep(6 * time.Second)
+
+	// Re-add the proxy with a new external node, as if its public IP address changed.  The announce version is a
+	// timestamp in seconds, so wait for it to be able to increase.
+	time.Sleep(1 * time.Second)
+	announceVersion := valBE.GetAnnounceVersion()
+	newExternalNode := enode.NewV4(proxyBE.SelfNode().Pubkey(), net.ParseIP("10.0.0.1"), 30303, 30303)
+	if err := pv.AddProxy(proxyBE.SelfNode(), newExternalNode); err != nil {
+		t.Fatalf("Error in re-adding the proxy.  Error: %v", err)
+	}
+
+	// Sleep for a sec since the proxy update is asynchronous
+	time.Sleep(1 * time.Second)
+
+	proxies, _, err := pv.GetProxiesAndValAssignments()
+	if err != nil {
+		t.Fatalf("Error in retrieving proxies and val assignments. Error: %v", err)
+	}
+	if len(proxies) != 1 || proxies[0].ExternalNode().URLv4() != newExternalNode.URLv4() {
+		t.Errorf("Unexpected proxies value.  proxies: %v, expected external node: %v", proxies, newExternalNode)
+	}
+
+	// Make sure that the announce version is incremented
+	if valBE.GetAnnounceVersion() <= announceVersion {
+		t.Errorf("Proxied validator announce version was not updated.  announceVersion: %d, valBE.GetAnnounceVersion(): %d", announceVersion, valBE.GetAnnounceVersion())
+	}
+
+	// Make sure that the remote validator updates its val enode table entry with the new external node when it
+	// receives the new enode certificate
+	ecMsg := valBE.RetrieveEnodeCertificateMsgMap()[proxyBE.SelfNode().ID()]
+	if ecMsg == nil {
+		t.Fatalf("Missing enode certificate for the proxy")
+	}
+	ecPayload, _ := ecMsg.Msg.Payload()
+	p2pMsg, err := backendtest.CreateP2PMsg(istanbul.EnodeCertificateMsg, ecPayload)
+	if err != nil {
+		t.Fatalf("Error in creating p2p message.  Error: %v", err)
+	}
+	if handled, err := remoteValBEi.HandleMsg(valBE.Address(), p2pMsg, valPeer); !handled || err != nil {
+		t.Errorf("Error in handling enode certificate msg.  Handled: %v, Error: %v", handled, err)
+	}
+
+	// Sleep for a sec since the enode certificate is handled asynchronously
+	time.Sleep(1 * time.Second)
+
+	entries, err := remoteValBE.GetValEnodeTableEntries([]common.Address{valBE.Address()})
+	if err != nil {
+		t.Fatalf("Error in retrieving val enode table entries.  Error: %v", err)
+	}
+	if entry := entries[valBE.Address()]; entry == nil || entry.Node.URLv4() != newExternalNode.URLv4() || entry.Version != valBE.GetAnnounceVersion() {
+		t.Errorf("Incorrect val enode table entry for the proxied validator.  Have: %v, want node %v", entry, newExternalNode)
+	}
+}
+
+func TestProxyReconnectBackoff(t *testing.T) {
+	period := 10 * time.Second
+
+	tests := []struct {
+		attempts uint
+		want     time.Duration
+	}{
+		{0, 10 * time.Second},
+		{1, 20 * time.Second},
+		{2, 40 * time.Second},
+		{4, 160 * time.Second},
+		{5, maxProxyReconnectBackoff},
+		{100, maxProxyReconnectBackoff},
+	}
+	for _, tt := range tests {
+		if have := proxyReconnectBackoff(period, tt.attempts); have != tt.want {
+			t.Errorf("proxyReconnectBackoff(%v, %d) = %v, want %v", period, tt.attempts, have, tt.want)
+		}
+	}
+}
@@ -74,6 +74,17 @@ func (ps *proxySet) getProxy(proxyID enode.ID) *Proxy {
 	return nil
 }
 
+// updateProxyExternalNode sets the external node of the proxy with ID proxyID, e.g. after the proxy's
+// public IP address changed.  Will return true if the external node is different from the previous one.
+func (ps *proxySet) updateProxyExternalNode(proxyID enode.ID, externalNode *enode.Node) bool {
+	proxy := ps.getProxy(proxyID)
+	if proxy == nil || externalNode == nil || proxy.externalNode.URLv4() == externalNode.URLv4() {
+		return false
+	}
+	proxy.externalNode = externalNode
+	return true
+}
+
 // removeProxy removes a proxy with ID proxyID from the proxySet and valAssigner.
 // Will return true if any of the validators got reassigned to a different proxy.
 func (ps *proxySet) removeProxy(proxyID enode.ID) bool {
@@ -96,6 +107,7 @@ func (ps *proxySet) setProxyPeer(proxyID enode.ID, peer consensus.Peer) bool {
 	valsReassigned := false
 	if proxy != nil {
 		proxy.peer = peer
+		proxy.reconnectAttempts = 0
 		logger.Trace("Assigning validators to proxy", "proxyID", proxyID)
 		valsReassigned = ps.valAssigner.assignProxy(proxy, ps.valAssignments)
 	}
@@ -109,7 +121,21 @@ func (ps *proxySet) removeProxyPeer(proxyID enode.ID) {
 	if proxy != nil {
 		proxy.peer = nil
 		proxy.disconnectTS = time.Now()
+		proxy.reconnectAttempts = 0
+		proxy.nextReconnectTS = time.Time{}
+	}
+}
+
+// getDisconnectedProxiesToReconnect returns the proxies that are not peered and whose
+// reconnection backoff has elapsed.
+func (ps *proxySet) getDisconnectedProxiesToReconnect(now time.Time) []*Proxy {
+	var proxies []*Proxy
+	for _, proxy := range ps.proxiesByID {
+		if !proxy.IsPeered() && !now.Before(proxy.nextReconnectTS) {
+			proxies = append(proxies, proxy)
+		}
 	}
+	return proxies
 }
 
 // addRemoteValidators adds remote validators to be assigned by the valAssigner
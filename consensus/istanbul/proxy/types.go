@@ -137,6 +137,9 @@ type Proxy struct {
 	externalNode *enode.Node    // Enode for the external network interface
 	peer         consensus.Peer // Connected proxy peer.  Is nil if this node is not connected to the proxy
 	disconnectTS time.Time      // Timestamp when this proxy's peer last disconnected. Initially set to the timestamp of when the proxy was added
+
+	reconnectAttempts uint      // Number of reconnection attempts made by the health check since the proxy's peer last disconnected
+	nextReconnectTS   time.Time // Timestamp after which the health check will make the next reconnection attempt
 }
 
 func (p *Proxy) ID() enode.ID {
@@ -160,8 +163,10 @@ type ProxyInfo struct {
 	InternalNode             *enode.Node      `json:"internalEnodeUrl"`
 	ExternalNode             *enode.Node      `json:"externalEnodeUrl"`
 	IsPeered                 bool             `json:"isPeered"`
-	AssignedRemoteValidators []common.Address `json:"validators"`            // All validator addresses assigned to the proxy
-	DisconnectTS             int64            `json:"disconnectedTimestamp"` // Unix time of the last disconnect of the peer
+	AssignedRemoteValidators []common.Address `json:"validators"`             // All validator addresses assigned to the proxy
+	DisconnectTS             int64            `json:"disconnectedTimestamp"`  // Unix time of the last disconnect of the peer
+	ReconnectAttempts        uint             `json:"reconnectAttempts"`      // Reconnection attempts made since the last disconnect of the peer
+	NextReconnectTS          int64            `json:"nextReconnectTimestamp"` // Unix time of the next reconnection attempt. Only meaningful if the proxy is not peered
 }
 
 func NewProxyInfo(p *Proxy, assignedVals []common.Address) *ProxyInfo {
@@ -170,6 +175,8 @@ func NewProxyInfo(p *Proxy, assignedVals []common.Address) *ProxyInfo {
 		ExternalNode:             p.ExternalNode(),
 		IsPeered:                 p.IsPeered(),
 		DisconnectTS:             p.disconnectTS.Unix(),
+		ReconnectAttempts:        p.reconnectAttempts,
+		NextReconnectTS:          p.nextReconnectTS.Unix(),
 		AssignedRemoteValidators: assignedVals,
 	}
 }
@@ -184,6 +191,20 @@ type ProxiedValidatorInfo struct {
 	Node     *enode.Node    `json:"enodeURL"`
 }
 
+// ==============================================
+//
+// define the proxy topology info object
+
+// TopologyInfo describes the place of a node in the proxy topology, and is used to provide it via an RPC.
+// On a proxy it holds the proxied validator that the proxy serves, and on a proxied validator its proxies.
+type TopologyInfo struct {
+	Proxy                   bool                    `json:"proxy"`                             // Whether this node is a proxy
+	Proxied                 bool                    `json:"proxied"`                           // Whether this node is a proxied validator
+	ProxiedValidatorAddress *common.Address         `json:"proxiedValidatorAddress,omitempty"` // The address of the validator that the proxy serves
+	ProxiedValidators       []*ProxiedValidatorInfo `json:"proxiedValidators,omitempty"`       // The proxied validators connected to the proxy
+	Proxies                 []*ProxyInfo            `json:"proxies,omitempty"`                 // The configured proxies of the proxied validator, with their connection state
+}
+
 // ==============================================
 //
 // define the validator enode share message
@@ -0,0 +1,91 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	blscrypto "github.com/ethereum/go-ethereum/crypto/bls"
+)
+
+var (
+	// ErrInvalidAggregatedSeal is returned if an aggregated seal is malformed, or its bitmap refers to
+	// validators outside the validator set
+	ErrInvalidAggregatedSeal = errors.New("invalid aggregated seal")
+	// ErrInvalidAggregatedSignature is returned if the aggregated signature of a seal isn't the one of its signers
+	ErrInvalidAggregatedSignature = errors.New("invalid aggregated signature")
+)
+
+// PrepareCommittedSeal returns the data that validators sign in their COMMIT message for the block with
+// the given hash committed in the given round, and that the aggregated seal of the block aggregates.
+func PrepareCommittedSeal(hash common.Hash, round *big.Int) []byte {
+	var buf bytes.Buffer
+	buf.Write(hash.Bytes())
+	buf.Write(round.Bytes())
+	buf.Write([]byte{byte(MsgCommit)})
+	return buf.Bytes()
+}
+
+// VerifyAggregatedSeal verifies the aggregated seal of the header against the given validator set, which
+// must be the one that committed the header, i.e. the validator set after its parent. It returns an error
+// if the seal is malformed or its signature isn't the aggregate of the signers in its bitmap, and whether
+// the signers reach the quorum of the validator set otherwise.
+//
+// It only depends on the header and the validator set, so that a header can be verified without the
+// consensus engine, e.g. by light clients and offline tools.
+func VerifyAggregatedSeal(header *types.Header, validators ValidatorSet) (bool, error) {
+	extra, err := types.ExtractIstanbulExtra(header)
+	if err != nil {
+		return false, err
+	}
+	return VerifyAggregatedSealOf(header.Hash(), extra.AggregatedSeal, validators)
+}
+
+// VerifyAggregatedSealOf verifies seal as the aggregated seal of the block with the given hash, e.g. the
+// parent aggregated seal carried by its child, the same way as VerifyAggregatedSeal. The signature of a
+// seal whose signers don't reach the quorum isn't verified.
+func VerifyAggregatedSealOf(hash common.Hash, seal types.IstanbulAggregatedSeal, validators ValidatorSet) (bool, error) {
+	if seal.Bitmap == nil || seal.Round == nil || len(seal.Signature) != types.IstanbulExtraBlsSignature {
+		return false, ErrInvalidAggregatedSeal
+	}
+	if seal.Bitmap.Sign() < 0 || seal.Bitmap.BitLen() > validators.Size() {
+		return false, ErrInvalidAggregatedSeal
+	}
+
+	publicKeys := []blscrypto.SerializedPublicKey{}
+	var signersWeight uint64
+	for i := 0; i < validators.Size(); i++ {
+		if seal.Bitmap.Bit(i) == 1 {
+			val := validators.GetByIndex(uint64(i))
+			publicKeys = append(publicKeys, val.BLSPublicKey())
+			signersWeight += validators.GetWeight(val.Address())
+		}
+	}
+	if len(publicKeys) == 0 || signersWeight < validators.MinQuorumWeight() {
+		return false, nil
+	}
+
+	sealData := PrepareCommittedSeal(hash, seal.Round)
+	if err := blscrypto.VerifyAggregatedSignature(publicKeys, sealData, []byte{}, seal.Signature, false); err != nil {
+		return false, ErrInvalidAggregatedSignature
+	}
+	return true, nil
+}
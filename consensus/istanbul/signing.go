@@ -0,0 +1,84 @@
+// Copyright 2020 The celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	// VersionCertificateSalt is signed along with the version of a version certificate.
+	VersionCertificateSalt = []byte("versionCertificate")
+
+	// PublicKeyRecoveryPayload is signed to recover the public key of a validator whose key is held by an
+	// external signer, which doesn't expose public keys.
+	PublicKeyRecoveryPayload = []byte("celo public key recovery")
+
+	errUnknownSigningPayload = errors.New("not an Istanbul message, version certificate or public key recovery payload")
+	errSealedHeader          = errors.New("header to seal already carries a seal")
+)
+
+// versionCertificatePayload is the signed payload of a version certificate.
+type versionCertificatePayload struct {
+	Salt    []byte
+	Version uint
+}
+
+// DescribeSigningPayload checks that data, signed by signer with the accounts.MimetypeIstanbul mimetype, is
+// the payload of an Istanbul message sent by signer, of a version certificate or of the public key recovery,
+// and returns a description of it. The payload is signed without a prefix, so external signers must reject
+// anything else, such as the RLP encoding of a transaction.
+func DescribeSigningPayload(signer common.Address, data []byte) (string, error) {
+	if bytes.Equal(data, PublicKeyRecoveryPayload) {
+		return "public key recovery", nil
+	}
+	var vc versionCertificatePayload
+	if err := rlp.DecodeBytes(data, &vc); err == nil && bytes.Equal(vc.Salt, VersionCertificateSalt) {
+		return fmt.Sprintf("version certificate %d", vc.Version), nil
+	}
+	var msg Message
+	if err := rlp.DecodeBytes(data, &msg); err == nil && len(msg.Signature) == 0 {
+		if msg.Address != signer {
+			return "", fmt.Errorf("Istanbul message of %v signed by %v", msg.Address.Hex(), signer.Hex())
+		}
+		return fmt.Sprintf("Istanbul message with code %d", msg.Code), nil
+	}
+	return "", errUnknownSigningPayload
+}
+
+// DescribeHeaderSigningPayload checks that data, signed with the accounts.MimetypeIstanbulHeader mimetype,
+// is the RLP encoding of an Istanbul header with a clean seal, and returns a description of it.
+func DescribeHeaderSigningPayload(data []byte) (string, error) {
+	var header types.Header
+	if err := rlp.DecodeBytes(data, &header); err != nil {
+		return "", err
+	}
+	extra, err := types.ExtractIstanbulExtra(&header)
+	if err != nil {
+		return "", err
+	}
+	if len(extra.Seal) != 0 {
+		return "", errSealedHeader
+	}
+	return fmt.Sprintf("seal of block %d with parent %v", header.Number, header.ParentHash.Hex()), nil
+}
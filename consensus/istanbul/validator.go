@@ -20,9 +20,11 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
 
 	blscrypto "github.com/ethereum/go-ethereum/crypto/bls"
+	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -95,6 +97,12 @@ type ValidatorSet interface {
 	// Sets the randomness for use in the proposer policy
 	GetRandomness() common.Hash
 
+	// Sets the validators that the StickyWithFallback proposer policy skips.
+	// This is injected into the ValidatorSet when we call `getOrderedValidators`
+	SetDemotedProposers(demoted []common.Address)
+	// Gets the validators that the StickyWithFallback proposer policy skips
+	GetDemotedProposers() []common.Address
+
 	// Return the validator size
 	Size() int
 	// Get the maximum number of faulty nodes
@@ -102,6 +110,18 @@ type ValidatorSet interface {
 	// Get the minimum quorum size
 	MinQuorumSize() int
 
+	// Sets the weights of the validators, which makes the quorum computations use the combined weight
+	// of the validators rather than their number. A nil map makes them count the validators again.
+	SetWeights(weights map[common.Address]uint64)
+	// Returns whether the quorum computations use the weights of the validators
+	IsWeighted() bool
+	// Get the weight of the validator with the given address, which is 1 if the set isn't weighted
+	GetWeight(addr common.Address) uint64
+	// Get the combined weight of all the validators
+	TotalWeight() uint64
+	// Get the minimum combined weight of a quorum, which is the minimum quorum size if the set isn't weighted
+	MinQuorumWeight() uint64
+
 	// List returns all the validators
 	List() []Validator
 	// Return the validator index
@@ -126,14 +146,63 @@ type ValidatorSet interface {
 }
 
 type ValidatorSetData struct {
-	Validators []ValidatorData
-	Randomness common.Hash
+	Validators       []ValidatorData
+	Randomness       common.Hash
+	DemotedProposers []common.Address `json:",omitempty"`
+	Weights          []uint64         `json:",omitempty"` // The weights of the validators in the order of Validators, or nil if the set isn't weighted
+}
+
+// EncodeRLP serializes vsd into the Ethereum RLP format. DemotedProposers and Weights are left out
+// when they are not set, so that such validator sets have the same encoding as before they existed.
+func (vsd *ValidatorSetData) EncodeRLP(w io.Writer) error {
+	fields := []interface{}{vsd.Validators, vsd.Randomness}
+	if len(vsd.DemotedProposers) > 0 || len(vsd.Weights) > 0 {
+		fields = append(fields, vsd.DemotedProposers)
+	}
+	if len(vsd.Weights) > 0 {
+		fields = append(fields, vsd.Weights)
+	}
+	return rlp.Encode(w, fields)
+}
+
+// DecodeRLP implements rlp.Decoder, and load the vsd fields from a RLP stream.
+func (vsd *ValidatorSetData) DecodeRLP(s *rlp.Stream) error {
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	if err := s.Decode(&vsd.Validators); err != nil {
+		return err
+	}
+	if err := s.Decode(&vsd.Randomness); err != nil {
+		return err
+	}
+	for _, field := range []interface{}{&vsd.DemotedProposers, &vsd.Weights} {
+		if err := s.Decode(field); err == rlp.EOL {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	return s.ListEnd()
 }
 
 // ----------------------------------------------------------------------------
 
-// ProposerSelector returns the block proposer for a round given the last proposer, round number, and randomness.
-type ProposerSelector func(validatorSet ValidatorSet, lastBlockProposer common.Address, currentRound uint64) Validator
+// ProposerSelector selects the block proposer for a round. Each proposer policy is implemented by a
+// ProposerSelector, and custom ones can be registered with validator.RegisterProposerSelector.
+type ProposerSelector interface {
+	// Select returns the block proposer for a round given the last proposer, round number, and the
+	// randomness of the validator set, or nil if the validator set is empty.
+	Select(validatorSet ValidatorSet, lastBlockProposer common.Address, currentRound uint64) Validator
+}
+
+// ProposerSelectorFunc is an adapter to allow the use of ordinary functions as a ProposerSelector.
+type ProposerSelectorFunc func(validatorSet ValidatorSet, lastBlockProposer common.Address, currentRound uint64) Validator
+
+// Select calls f(validatorSet, lastBlockProposer, currentRound).
+func (f ProposerSelectorFunc) Select(validatorSet ValidatorSet, lastBlockProposer common.Address, currentRound uint64) Validator {
+	return f(validatorSet, lastBlockProposer, currentRound)
+}
 
 // ----------------------------------------------------------------------------
 
@@ -18,6 +18,7 @@ package validator
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -31,6 +32,8 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
+var errInvalidWeights = errors.New("validator set weights don't match its validators")
+
 type defaultValidator struct {
 	address      common.Address
 	blsPublicKey blscrypto.SerializedPublicKey
@@ -89,6 +92,10 @@ type defaultSet struct {
 	// This is set when we call `getOrderedValidators`
 	// TODO Rename to `EpochState` that has validators & randomness
 	randomness common.Hash
+	// This is set when we call `getOrderedValidators` with the StickyWithFallback proposer policy
+	demotedProposers []common.Address
+	// The validator weights for stake weighted quorums, or nil to count the validators
+	weights map[common.Address]uint64
 }
 
 func newDefaultSet(validators []istanbul.ValidatorData) *defaultSet {
@@ -124,6 +131,38 @@ func (valSet *defaultSet) MinQuorumSize() int { return int(math.Ceil(float64(2*v
 func (valSet *defaultSet) SetRandomness(seed common.Hash) { valSet.randomness = seed }
 func (valSet *defaultSet) GetRandomness() common.Hash     { return valSet.randomness }
 
+func (valSet *defaultSet) SetDemotedProposers(demoted []common.Address) {
+	valSet.demotedProposers = demoted
+}
+func (valSet *defaultSet) GetDemotedProposers() []common.Address { return valSet.demotedProposers }
+
+func (valSet *defaultSet) SetWeights(weights map[common.Address]uint64) { valSet.weights = weights }
+func (valSet *defaultSet) IsWeighted() bool                             { return valSet.weights != nil }
+
+func (valSet *defaultSet) GetWeight(addr common.Address) uint64 {
+	if !valSet.IsWeighted() {
+		return 1
+	}
+	return valSet.weights[addr]
+}
+
+func (valSet *defaultSet) TotalWeight() uint64 {
+	var total uint64
+	for _, val := range valSet.List() {
+		total += valSet.GetWeight(val.Address())
+	}
+	return total
+}
+
+// MinQuorumWeight applies the minimum quorum size formula to the combined weight of the validators, so
+// that any two quorums overlap by more than the weight of the validators that may be faulty.
+func (valSet *defaultSet) MinQuorumWeight() uint64 {
+	if !valSet.IsWeighted() {
+		return uint64(valSet.MinQuorumSize())
+	}
+	return (2*valSet.TotalWeight() + 2) / 3
+}
+
 func (valSet *defaultSet) String() string {
 	var buf strings.Builder
 	if _, err := buf.WriteString("["); err != nil {
@@ -239,6 +278,8 @@ func (valSet *defaultSet) Copy() istanbul.ValidatorSet {
 	defer valSet.validatorMu.RUnlock()
 	newValSet := NewSet(MapValidatorsToData(valSet.validators))
 	newValSet.SetRandomness(valSet.randomness)
+	newValSet.SetDemotedProposers(valSet.demotedProposers)
+	newValSet.SetWeights(valSet.weights)
 	return newValSet
 }
 
@@ -246,9 +287,25 @@ func (valSet *defaultSet) AsData() *istanbul.ValidatorSetData {
 	valSet.validatorMu.RLock()
 	defer valSet.validatorMu.RUnlock()
 	return &istanbul.ValidatorSetData{
-		Validators: MapValidatorsToData(valSet.validators),
-		Randomness: valSet.randomness,
+		Validators:       MapValidatorsToData(valSet.validators),
+		Randomness:       valSet.randomness,
+		DemotedProposers: valSet.demotedProposers,
+		Weights:          MapWeightsToData(valSet.validators, valSet.weights),
+	}
+}
+
+// setData sets the randomness, demoted proposers and weights of data on the validator set
+func (valSet *defaultSet) setData(data *istanbul.ValidatorSetData) error {
+	valSet.SetRandomness(data.Randomness)
+	if len(data.DemotedProposers) > 0 {
+		valSet.SetDemotedProposers(data.DemotedProposers)
 	}
+	weights, err := MapDataToWeights(data.Validators, data.Weights)
+	if err != nil {
+		return err
+	}
+	valSet.SetWeights(weights)
+	return nil
 }
 
 // JSON Encoding -----------------------------------------------------------------------
@@ -261,8 +318,7 @@ func (val *defaultSet) UnmarshalJSON(b []byte) error {
 		return err
 	}
 	*val = *newDefaultSet(data.Validators)
-	val.SetRandomness(data.Randomness)
-	return nil
+	return val.setData(&data)
 }
 
 // RLP Encoding -----------------------------------------------------------------------
@@ -275,8 +331,7 @@ func (val *defaultSet) DecodeRLP(stream *rlp.Stream) error {
 		return err
 	}
 	*val = *newDefaultSet(data.Validators)
-	val.SetRandomness(data.Randomness)
-	return nil
+	return val.setData(&data)
 }
 
 func (val *defaultSet) Serialize() ([]byte, error) { return rlp.EncodeToBytes(val) }
@@ -291,6 +346,34 @@ func MapValidatorsToData(validators []istanbul.Validator) []istanbul.ValidatorDa
 	return validatorsData
 }
 
+// MapWeightsToData returns the weights of the given validators in their order, or nil if weights is nil
+func MapWeightsToData(validators []istanbul.Validator, weights map[common.Address]uint64) []uint64 {
+	if weights == nil {
+		return nil
+	}
+	weightsData := make([]uint64, len(validators))
+	for i, v := range validators {
+		weightsData[i] = weights[v.Address()]
+	}
+	return weightsData
+}
+
+// MapDataToWeights returns the weights of the given validators by address, given in their order, or nil
+// if there are no weights
+func MapDataToWeights(validators []istanbul.ValidatorData, weightsData []uint64) (map[common.Address]uint64, error) {
+	if len(weightsData) == 0 {
+		return nil, nil
+	}
+	if len(weightsData) != len(validators) {
+		return nil, errInvalidWeights
+	}
+	weights := make(map[common.Address]uint64, len(validators))
+	for i, v := range validators {
+		weights[v.Address] = weightsData[i]
+	}
+	return weights, nil
+}
+
 func mapDataToValidators(data []istanbul.ValidatorData) []istanbul.Validator {
 	validators := make([]istanbul.Validator, len(data))
 	for i, v := range data {
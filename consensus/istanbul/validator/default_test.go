@@ -17,6 +17,7 @@
 package validator
 
 import (
+	"encoding/json"
 	"math/big"
 	"reflect"
 	"testing"
@@ -40,6 +41,7 @@ func TestValidatorSet(t *testing.T) {
 	t.Run("EmptyValSet", testEmptyValSet)
 	t.Run("AddAndRemoveValidator", testAddAndRemoveValidator)
 	t.Run("QuorumSizes", testQuorumSizes)
+	t.Run("WeightedQuorum", testWeightedQuorum)
 }
 
 func testNewValidatorSet(t *testing.T) {
@@ -213,6 +215,52 @@ func testQuorumSizes(t *testing.T) {
 	}
 }
 
+func testWeightedQuorum(t *testing.T) {
+	vals, _ := generateValidators(4)
+	valSet := newDefaultSet(vals)
+
+	// Without weights every validator weighs 1
+	if valSet.IsWeighted() || valSet.TotalWeight() != 4 || valSet.MinQuorumWeight() != uint64(valSet.MinQuorumSize()) {
+		t.Errorf("unweighted set mismatch: weighted %v, total weight %d, quorum weight %d", valSet.IsWeighted(), valSet.TotalWeight(), valSet.MinQuorumWeight())
+	}
+
+	weights := map[common.Address]uint64{
+		vals[0].Address: 1,
+		vals[1].Address: 1,
+		vals[2].Address: 1,
+		vals[3].Address: 6,
+	}
+	valSet.SetWeights(weights)
+	if !valSet.IsWeighted() || valSet.TotalWeight() != 9 || valSet.MinQuorumWeight() != 6 {
+		t.Fatalf("weighted set mismatch: weighted %v, total weight %d, quorum weight %d", valSet.IsWeighted(), valSet.TotalWeight(), valSet.MinQuorumWeight())
+	}
+
+	weightOf := func(signers []istanbul.ValidatorData) uint64 {
+		var weight uint64
+		for _, signer := range signers {
+			weight += valSet.GetWeight(signer.Address)
+		}
+		return weight
+	}
+	// A majority by count that is a minority by weight can't reach quorum
+	if weight := weightOf(vals[:3]); weight >= valSet.MinQuorumWeight() {
+		t.Errorf("3 of 4 validators with weight %d reached the quorum weight %d", weight, valSet.MinQuorumWeight())
+	}
+	// A minority by count that is a majority by weight can
+	if weight := weightOf(vals[3:]); weight < valSet.MinQuorumWeight() {
+		t.Errorf("1 of 4 validators with weight %d didn't reach the quorum weight %d", weight, valSet.MinQuorumWeight())
+	}
+
+	if copied := valSet.Copy(); !copied.IsWeighted() || copied.GetWeight(vals[3].Address) != 6 {
+		t.Errorf("copy lost the weights")
+	}
+
+	valSet.SetWeights(nil)
+	if valSet.IsWeighted() || valSet.GetWeight(vals[3].Address) != 1 {
+		t.Errorf("set still weighted after clearing the weights")
+	}
+}
+
 func TestValidatorRLPEncoding(t *testing.T) {
 
 	val := New(common.BytesToAddress([]byte(string(2))), blscrypto.SerializedPublicKey{1, 2, 3})
@@ -253,3 +301,86 @@ func TestValidatorSetRLPEncoding(t *testing.T) {
 		t.Errorf("validatorSet mismatch: have %v, want %v", valSet, result)
 	}
 }
+
+func TestValidatorSetRLPEncodingWithDemotedProposers(t *testing.T) {
+	valSet := NewSet([]istanbul.ValidatorData{
+		{Address: common.HexToAddress("0x02"), BLSPublicKey: blscrypto.SerializedPublicKey{1, 2, 3}},
+		{Address: common.HexToAddress("0x04"), BLSPublicKey: blscrypto.SerializedPublicKey{3, 1, 4}},
+	})
+	withoutDemoted, err := rlp.EncodeToBytes(valSet)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	valSet.SetDemotedProposers([]common.Address{common.HexToAddress("0x04")})
+	rawVal, err := rlp.EncodeToBytes(valSet)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	var result *defaultSet
+	if err = rlp.DecodeBytes(rawVal, &result); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if !reflect.DeepEqual(valSet.GetDemotedProposers(), result.GetDemotedProposers()) {
+		t.Errorf("demoted proposers mismatch: have %v, want %v", result.GetDemotedProposers(), valSet.GetDemotedProposers())
+	}
+
+	// Encodings without demoted proposers are still decoded
+	if err = rlp.DecodeBytes(withoutDemoted, &result); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if len(result.GetDemotedProposers()) != 0 {
+		t.Errorf("unexpected demoted proposers: %v", result.GetDemotedProposers())
+	}
+}
+
+func TestValidatorSetEncodingWithWeights(t *testing.T) {
+	addresses := []common.Address{common.HexToAddress("0x02"), common.HexToAddress("0x04")}
+	valSet := NewSet([]istanbul.ValidatorData{
+		{Address: addresses[0], BLSPublicKey: blscrypto.SerializedPublicKey{1, 2, 3}},
+		{Address: addresses[1], BLSPublicKey: blscrypto.SerializedPublicKey{3, 1, 4}},
+	})
+	valSet.SetWeights(map[common.Address]uint64{addresses[0]: 2, addresses[1]: 7})
+
+	checkWeights := func(name string, result istanbul.ValidatorSet) {
+		if !result.IsWeighted() {
+			t.Fatalf("%s: decoded validator set isn't weighted", name)
+		}
+		for _, address := range addresses {
+			if have, want := result.GetWeight(address), valSet.GetWeight(address); have != want {
+				t.Errorf("%s: weight mismatch for %v: have %d, want %d", name, address, have, want)
+			}
+		}
+	}
+
+	rawVal, err := rlp.EncodeToBytes(valSet)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	var rlpResult *defaultSet
+	if err = rlp.DecodeBytes(rawVal, &rlpResult); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	checkWeights("rlp", rlpResult)
+
+	jsonVal, err := json.Marshal(valSet)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	var jsonResult *defaultSet
+	if err = json.Unmarshal(jsonVal, &jsonResult); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	checkWeights("json", jsonResult)
+
+	// Weights that don't match the validators are rejected
+	data := valSet.(*defaultSet).AsData()
+	data.Weights = data.Weights[:1]
+	if rawVal, err = rlp.EncodeToBytes(data); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if err = rlp.DecodeBytes(rawVal, &rlpResult); err != errInvalidWeights {
+		t.Errorf("error mismatch: have %v, want %v", err, errInvalidWeights)
+	}
+}
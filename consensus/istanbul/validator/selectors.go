@@ -18,6 +18,8 @@ package validator
 
 import (
 	"fmt"
+	"math/big"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
@@ -31,7 +33,19 @@ func proposerIndex(valSet istanbul.ValidatorSet, proposer common.Address) uint64
 	return 0
 }
 
+// ShuffledOrder returns the order in which the ShuffledRoundRobin policy takes turns between the given
+// validators, which are in validator set storage order, when the validator set is shuffled with the given seed.
+func ShuffledOrder(validators []common.Address, seed common.Hash) []common.Address {
+	shuffle := random.Permutation(seed, len(validators))
+	order := make([]common.Address, len(shuffle))
+	for i, n := range shuffle {
+		order[i] = validators[n]
+	}
+	return order
+}
+
 // ShuffledRoundRobinProposer selects the next proposer with a round robin strategy according to a shuffled order.
+// The order is the ShuffledOrder of the validator set with the validator set's randomness as the seed.
 func ShuffledRoundRobinProposer(valSet istanbul.ValidatorSet, proposer common.Address, round uint64) istanbul.Validator {
 	if valSet.Size() == 0 {
 		return nil
@@ -62,6 +76,44 @@ func RoundRobinProposer(valSet istanbul.ValidatorSet, proposer common.Address, r
 	return valSet.List()[idx%uint64(valSet.Size())]
 }
 
+// WeightedRoundRobinProposer selects the proposer of round 0 with a probability proportional to its weight
+// in the validator set, by drawing from the validator set's randomness, which must differ from block to
+// block. Later rounds advance from that proposer in storage order like RoundRobinProposer, so that a
+// failing proposer is not drawn again. Without weights, or if all validators weigh the same, it selects
+// like RoundRobinProposer.
+func WeightedRoundRobinProposer(valSet istanbul.ValidatorSet, proposer common.Address, round uint64) istanbul.Validator {
+	if valSet.Size() == 0 {
+		return nil
+	}
+	validators := valSet.List()
+	equalWeights := true
+	for _, val := range validators[1:] {
+		if valSet.GetWeight(val.Address()) != valSet.GetWeight(validators[0].Address()) {
+			equalWeights = false
+			break
+		}
+	}
+	if equalWeights {
+		return RoundRobinProposer(valSet, proposer, round)
+	}
+
+	seed := valSet.GetRandomness()
+	draw := new(big.Int).Mod(new(big.Int).SetBytes(seed[:]), new(big.Int).SetUint64(valSet.TotalWeight())).Uint64()
+	drawn := validators[0]
+	for _, val := range validators {
+		weight := valSet.GetWeight(val.Address())
+		if draw < weight {
+			drawn = val
+			break
+		}
+		draw -= weight
+	}
+	if round == 0 {
+		return drawn
+	}
+	return RoundRobinProposer(valSet, drawn.Address(), round-1)
+}
+
 // StickyProposer selects the next proposer with a sticky strategy, advancing on round change.
 func StickyProposer(valSet istanbul.ValidatorSet, proposer common.Address, round uint64) istanbul.Validator {
 	if valSet.Size() == 0 {
@@ -74,17 +126,163 @@ func StickyProposer(valSet istanbul.ValidatorSet, proposer common.Address, round
 	return valSet.List()[idx%uint64(valSet.Size())]
 }
 
+// StickyWithFallbackProposer selects the next proposer with a sticky strategy, advancing on round change,
+// while skipping the validators that are demoted in the validator set.  If every validator is demoted,
+// none of them is skipped.
+func StickyWithFallbackProposer(valSet istanbul.ValidatorSet, proposer common.Address, round uint64) istanbul.Validator {
+	if valSet.Size() == 0 {
+		return nil
+	}
+	demoted := make(map[common.Address]bool)
+	for _, address := range valSet.GetDemotedProposers() {
+		demoted[address] = true
+	}
+	// Stick to the first eligible validator at or after the last proposer
+	stickyIdx := uint64(0)
+	if proposer != (common.Address{}) {
+		stickyIdx = proposerIndex(valSet, proposer)
+	}
+	eligible := make([]istanbul.Validator, 0, valSet.Size())
+	start := -1
+	for i, val := range valSet.List() {
+		if demoted[val.Address()] {
+			continue
+		}
+		if start < 0 && uint64(i) >= stickyIdx {
+			start = len(eligible)
+		}
+		eligible = append(eligible, val)
+	}
+	if len(eligible) == 0 {
+		return StickyProposer(valSet, proposer, round)
+	}
+	if start < 0 {
+		start = 0
+	}
+	return eligible[(uint64(start)+round)%uint64(len(eligible))]
+}
+
+// StickyFallbackTurn describes how a block was agreed on, which is the chain data that the
+// StickyWithFallback policy uses to demote proposers.
+type StickyFallbackTurn struct {
+	Author common.Address // The proposer of the block
+	Round  uint64         // The round in which the block was committed
+}
+
+// StickyFallbackDemotedProposers returns the validators to demote for the block that follows the given
+// blocks, which are ordered from oldest to newest and should span the cooldown period.
+// With a sticky policy the expected proposer of a block at round 0 is the author of the previous block,
+// so a block committed at a later round is a failed turn for that author, while a block committed at
+// round 0 is a successful one.  A validator that had `threshold` consecutive failed turns within the
+// given blocks is demoted.
+func StickyFallbackDemotedProposers(turns []StickyFallbackTurn, threshold uint64) []common.Address {
+	failedTurns := make(map[common.Address]uint64)
+	isDemoted := make(map[common.Address]bool)
+	var demoted []common.Address
+	for i := 1; i < len(turns); i++ {
+		if turns[i].Round == 0 {
+			failedTurns[turns[i].Author] = 0
+			continue
+		}
+		expected := turns[i-1].Author
+		failedTurns[expected]++
+		if failedTurns[expected] >= threshold && !isDemoted[expected] {
+			isDemoted[expected] = true
+			demoted = append(demoted, expected)
+		}
+	}
+	return demoted
+}
+
+// ProposerBanRound describes a round of the chain's history, which is the chain data used to ban the
+// proposers that keep failing their rounds.
+type ProposerBanRound struct {
+	Proposer common.Address // The validator selected to propose in the round
+	Failed   bool           // Whether the round ended with a round change instead of committing a block
+}
+
+// BannedProposers returns the validators that failed more than threshold of the given rounds, in the
+// order of the given rounds.
+func BannedProposers(rounds []ProposerBanRound, threshold uint64) []common.Address {
+	failedRounds := make(map[common.Address]uint64)
+	isBanned := make(map[common.Address]bool)
+	var banned []common.Address
+	for _, round := range rounds {
+		if !round.Failed || round.Proposer == (common.Address{}) {
+			continue
+		}
+		failedRounds[round.Proposer]++
+		if failedRounds[round.Proposer] > threshold && !isBanned[round.Proposer] {
+			isBanned[round.Proposer] = true
+			banned = append(banned, round.Proposer)
+		}
+	}
+	return banned
+}
+
+// SelectProposer returns the address of the proposer for the given round, given the proposer of the
+// last block and the proposer selection policy. It has no side effects, so the same inputs always
+// select the same proposer. The zero address is returned for an empty validator set.
+//
+// A validator that is demoted in the validator set is replaced by the proposer the policy selects for
+// the next rounds, unless every validator is demoted.
+func SelectProposer(valSet istanbul.ValidatorSet, lastProposer common.Address, round uint64, policy istanbul.ProposerPolicy) common.Address {
+	selector := GetProposerSelector(policy)
+	proposer := selector.Select(valSet, lastProposer, round)
+	if proposer == nil {
+		return common.Address{}
+	}
+	if demotedProposers := valSet.GetDemotedProposers(); len(demotedProposers) > 0 {
+		demoted := make(map[common.Address]bool, len(demotedProposers))
+		for _, address := range demotedProposers {
+			demoted[address] = true
+		}
+		for i := 1; demoted[proposer.Address()] && i < valSet.Size(); i++ {
+			if next := selector.Select(valSet, lastProposer, round+uint64(i)); !demoted[next.Address()] {
+				return next.Address()
+			}
+		}
+	}
+	return proposer.Address()
+}
+
+// proposerSelectors maps each proposer policy to the selector that implements it, guarded by
+// proposerSelectorsMu since custom policies may be registered with RegisterProposerSelector.
+var (
+	proposerSelectors = map[istanbul.ProposerPolicy]istanbul.ProposerSelector{
+		istanbul.RoundRobin:         istanbul.ProposerSelectorFunc(RoundRobinProposer),
+		istanbul.Sticky:             istanbul.ProposerSelectorFunc(StickyProposer),
+		istanbul.ShuffledRoundRobin: istanbul.ProposerSelectorFunc(ShuffledRoundRobinProposer),
+		istanbul.StickyWithFallback: istanbul.ProposerSelectorFunc(StickyWithFallbackProposer),
+		istanbul.WeightedRoundRobin: istanbul.ProposerSelectorFunc(WeightedRoundRobinProposer),
+	}
+	proposerSelectorsMu sync.RWMutex
+)
+
+// RegisterProposerSelector registers a custom proposer policy with the given name and selector, so that
+// it can be configured like the built-in policies. Every validator must run the same selector for the
+// policy, or they will disagree on the proposers, so it is typically called from an init function.
+func RegisterProposerSelector(policy istanbul.ProposerPolicy, name string, selector istanbul.ProposerSelector) error {
+	if selector == nil {
+		return fmt.Errorf("nil selector for proposer policy %s", name)
+	}
+	proposerSelectorsMu.Lock()
+	defer proposerSelectorsMu.Unlock()
+	if err := istanbul.RegisterProposerPolicy(policy, name); err != nil {
+		return err
+	}
+	proposerSelectors[policy] = selector
+	return nil
+}
+
 // GetProposerSelector returns the ProposerSelector for the given Policy
 func GetProposerSelector(pp istanbul.ProposerPolicy) istanbul.ProposerSelector {
-	switch pp {
-	case istanbul.Sticky:
-		return StickyProposer
-	case istanbul.RoundRobin:
-		return RoundRobinProposer
-	case istanbul.ShuffledRoundRobin:
-		return ShuffledRoundRobinProposer
-	default:
+	proposerSelectorsMu.RLock()
+	selector, ok := proposerSelectors[pp]
+	proposerSelectorsMu.RUnlock()
+	if !ok {
 		// Programming error.
 		panic(fmt.Sprintf("unknown proposer selection policy: %v", pp))
 	}
+	return selector
 }
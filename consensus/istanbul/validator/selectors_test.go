@@ -18,6 +18,7 @@ package validator
 
 import (
 	"fmt"
+	"math/big"
 	"reflect"
 	"testing"
 
@@ -83,7 +84,7 @@ func TestStickyProposer(t *testing.T) {
 	for i, c := range cases {
 		t.Run(fmt.Sprintf("case:%d", i), func(t *testing.T) {
 			t.Logf("selectProposer(%s, %d)", c.lastProposer.String(), c.round)
-			proposer := selector(valSet, c.lastProposer, c.round)
+			proposer := selector.Select(valSet, c.lastProposer, c.round)
 			if val := proposer; !reflect.DeepEqual(val, c.want) {
 				t.Errorf("proposer mismatch: have %v, want %v", val, c.want)
 			}
@@ -140,7 +141,7 @@ func TestRoundRobinProposer(t *testing.T) {
 	for i, c := range cases {
 		t.Run(fmt.Sprintf("case:%d", i), func(t *testing.T) {
 			t.Logf("selectProposer(%s, %d)", c.lastProposer.String(), c.round)
-			proposer := selector(valSet, c.lastProposer, c.round)
+			proposer := selector.Select(valSet, c.lastProposer, c.round)
 			if val := proposer; !reflect.DeepEqual(val, c.want) {
 				t.Errorf("proposer mismatch: have %v, want %v", val, c.want)
 			}
@@ -226,7 +227,7 @@ func TestShuffledRoundRobinProposer(t *testing.T) {
 			t.Logf("SetRandomness(%s)", c.seed.String())
 			valSet.SetRandomness(c.seed)
 			t.Logf("selectProposer(%s, %d)", c.lastProposer.String(), c.round)
-			proposer := selector(valSet, c.lastProposer, c.round)
+			proposer := selector.Select(valSet, c.lastProposer, c.round)
 			if val := proposer; !reflect.DeepEqual(val, c.want) {
 				t.Errorf("proposer mismatch: have %v, want %v", val, c.want)
 			}
@@ -239,7 +240,7 @@ func TestShuffledRoundRobinProposer(t *testing.T) {
 		var lastProposer common.Address
 		order := make([]common.Address, len(validators))
 		for round := uint64(0); round < 100; round++ {
-			proposer := selector(valSet, lastProposer, round)
+			proposer := selector.Select(valSet, lastProposer, round)
 			index := round % uint64(len(validators))
 			if want := order[index]; want != (common.Address{}) {
 				if proposer.Address() != want {
@@ -256,7 +257,7 @@ func TestShuffledRoundRobinProposer(t *testing.T) {
 		var lastProposer common.Address
 		order := make([]common.Address, len(validators))
 		for seq := 0; seq < 100; seq++ {
-			proposer := selector(valSet, lastProposer, 0)
+			proposer := selector.Select(valSet, lastProposer, 0)
 			index := seq % len(validators)
 			if want := order[index]; want != (common.Address{}) {
 				if proposer.Address() != want {
@@ -268,3 +269,339 @@ func TestShuffledRoundRobinProposer(t *testing.T) {
 		}
 	})
 }
+
+func TestShuffledOrder(t *testing.T) {
+	var addrs []common.Address
+	for _, strAddr := range testAddresses {
+		addrs = append(addrs, common.HexToAddress(strAddr))
+	}
+
+	v, err := istanbul.CombineIstanbulExtraToValidatorData(addrs, make([]blscrypto.SerializedPublicKey, len(addrs)))
+	if err != nil {
+		t.Fatalf("CombineIstanbulExtraToValidatorData(...): %v", err)
+	}
+	valSet := newDefaultSet(v)
+	seed := common.HexToHash("f36aa9716b892ec8")
+	valSet.SetRandomness(seed)
+
+	// The proposers take turns in the shuffled order
+	order := ShuffledOrder(addrs, seed)
+	if len(order) != len(addrs) {
+		t.Fatalf("order length mismatch: have %d, want %d", len(order), len(addrs))
+	}
+	for i, want := range order {
+		if proposer := ShuffledRoundRobinProposer(valSet, common.Address{}, uint64(i)); proposer.Address() != want {
+			t.Errorf("proposer mismatch at round %d: have %v, want %v", i, proposer.Address().Hex(), want.Hex())
+		}
+	}
+
+	if order := ShuffledOrder(nil, seed); len(order) != 0 {
+		t.Errorf("expected an empty order for no validators, have %v", order)
+	}
+}
+
+func TestWeightedRoundRobinProposer(t *testing.T) {
+	var addrs []common.Address
+	for _, strAddr := range testAddresses {
+		addrs = append(addrs, common.HexToAddress(strAddr))
+	}
+	v, err := istanbul.CombineIstanbulExtraToValidatorData(addrs, make([]blscrypto.SerializedPublicKey, len(addrs)))
+	if err != nil {
+		t.Fatalf("CombineIstanbulExtraToValidatorData(...): %v", err)
+	}
+	valSet := newDefaultSet(v)
+	selector := GetProposerSelector(istanbul.WeightedRoundRobin)
+
+	// Without weights, or with equal weights, the selection is the one of RoundRobin
+	for _, weights := range []map[common.Address]uint64{nil, {addrs[0]: 3, addrs[1]: 3, addrs[2]: 3, addrs[3]: 3, addrs[4]: 3}} {
+		valSet.SetWeights(weights)
+		for i, lastProposer := range append(addrs, common.Address{}) {
+			valSet.SetRandomness(common.BigToHash(big.NewInt(int64(i))))
+			for round := uint64(0); round < 3; round++ {
+				want := RoundRobinProposer(valSet, lastProposer, round)
+				if got := selector.Select(valSet, lastProposer, round); got.Address() != want.Address() {
+					t.Errorf("weights %v, last proposer %v, round %d: proposer = %v, want %v", weights, lastProposer.Hex(), round, got.Address().Hex(), want.Address().Hex())
+				}
+			}
+		}
+	}
+
+	// The last validator outweighs the other four together
+	valSet.SetWeights(map[common.Address]uint64{addrs[0]: 1, addrs[1]: 1, addrs[2]: 1, addrs[3]: 1, addrs[4]: 6})
+	counts := make(map[common.Address]int)
+	for i := 0; i < 1000; i++ {
+		valSet.SetRandomness(istanbul.RLPHash(uint64(i)))
+		drawn := selector.Select(valSet, addrs[i%len(addrs)], 0)
+		if again := selector.Select(valSet, addrs[0], 0); again.Address() != drawn.Address() {
+			t.Fatalf("seed %d: proposer depends on the last proposer, %v != %v", i, again.Address().Hex(), drawn.Address().Hex())
+		}
+		if next := selector.Select(valSet, addrs[0], 1); next.Address() == drawn.Address() {
+			t.Errorf("seed %d: proposer %v drawn again for round 1", i, drawn.Address().Hex())
+		}
+		counts[drawn.Address()]++
+	}
+	if counts[addrs[4]] < 500 || counts[addrs[4]] > 700 {
+		t.Errorf("validator with 60%% of the weight proposed %d of 1000 blocks", counts[addrs[4]])
+	}
+	for _, addr := range addrs[:4] {
+		if counts[addr] < 50 || counts[addr] > 150 {
+			t.Errorf("validator with 10%% of the weight proposed %d of 1000 blocks", counts[addr])
+		}
+	}
+}
+
+func TestStickyWithFallbackProposer(t *testing.T) {
+	var addrs []common.Address
+	var validators []istanbul.Validator
+	for _, strAddr := range testAddresses {
+		addr := common.HexToAddress(strAddr)
+		addrs = append(addrs, addr)
+		validators = append(validators, New(addr, blscrypto.SerializedPublicKey{}))
+	}
+
+	v, err := istanbul.CombineIstanbulExtraToValidatorData(addrs, make([]blscrypto.SerializedPublicKey, len(addrs)))
+	if err != nil {
+		t.Fatalf("CombineIstanbulExtraToValidatorData(...): %v", err)
+	}
+	valSet := newDefaultSet(v)
+	selector := GetProposerSelector(istanbul.StickyWithFallback)
+
+	cases := []struct {
+		demoted      []common.Address
+		lastProposer common.Address
+		round        uint64
+		want         istanbul.Validator
+	}{{
+		// Without demotions it behaves like the sticky policy
+		lastProposer: addrs[2],
+		round:        3,
+		want:         validators[0],
+	}, {
+		demoted:      []common.Address{addrs[1]},
+		lastProposer: addrs[0],
+		round:        0,
+		want:         validators[0],
+	}, {
+		demoted:      []common.Address{addrs[1]},
+		lastProposer: addrs[0],
+		round:        1,
+		want:         validators[2],
+	}, {
+		// The demoted last proposer is replaced by the next eligible validator
+		demoted:      []common.Address{addrs[1], addrs[2]},
+		lastProposer: addrs[1],
+		round:        0,
+		want:         validators[3],
+	}, {
+		demoted:      []common.Address{addrs[4]},
+		lastProposer: addrs[4],
+		round:        1,
+		want:         validators[1],
+	}, {
+		demoted:      []common.Address{addrs[0]},
+		lastProposer: common.Address{},
+		round:        0,
+		want:         validators[1],
+	}, {
+		// If all validators are demoted, none is skipped
+		demoted:      addrs,
+		lastProposer: addrs[2],
+		round:        1,
+		want:         validators[3],
+	}}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case:%d", i), func(t *testing.T) {
+			valSet.SetDemotedProposers(c.demoted)
+			proposer := selector.Select(valSet, c.lastProposer, c.round)
+			if val := proposer; !reflect.DeepEqual(val, c.want) {
+				t.Errorf("proposer mismatch: have %v, want %v", val, c.want)
+			}
+		})
+	}
+}
+
+func TestStickyFallbackDemotedProposers(t *testing.T) {
+	a, b, c := common.HexToAddress(testAddresses[0]), common.HexToAddress(testAddresses[1]), common.HexToAddress(testAddresses[2])
+
+	cases := []struct {
+		turns []StickyFallbackTurn
+		want  []common.Address
+	}{{
+		// a keeps proposing at round 0
+		turns: []StickyFallbackTurn{{a, 0}, {a, 0}, {a, 0}},
+		want:  nil,
+	}, {
+		// a fails twice in a row, with b and c taking over. b's failed turn in between gives a its next turn
+		turns: []StickyFallbackTurn{{a, 0}, {b, 1}, {a, 4}, {c, 1}},
+		want:  []common.Address{a},
+	}, {
+		// a's successful turn in between resets its count
+		turns: []StickyFallbackTurn{{a, 0}, {b, 1}, {a, 1}, {a, 0}, {c, 2}},
+		want:  nil,
+	}}
+
+	for i, tc := range cases {
+		if have := StickyFallbackDemotedProposers(tc.turns, 2); !reflect.DeepEqual(have, tc.want) {
+			t.Errorf("case %d: demoted mismatch: have %v, want %v", i, have, tc.want)
+		}
+	}
+}
+
+func TestBannedProposers(t *testing.T) {
+	a, b, c := common.HexToAddress(testAddresses[0]), common.HexToAddress(testAddresses[1]), common.HexToAddress(testAddresses[2])
+
+	cases := []struct {
+		rounds []ProposerBanRound
+		want   []common.Address
+	}{{
+		// Every round commits a block
+		rounds: []ProposerBanRound{{a, false}, {b, false}, {c, false}},
+		want:   nil,
+	}, {
+		// a fails three rounds, whether in a row or not, b fails two
+		rounds: []ProposerBanRound{{c, false}, {a, true}, {b, true}, {a, true}, {b, false}, {a, true}, {b, true}},
+		want:   []common.Address{a},
+	}, {
+		// Successful rounds don't make up for failed ones
+		rounds: []ProposerBanRound{{b, true}, {b, false}, {b, true}, {b, false}, {b, true}, {c, true}, {c, true}, {c, true}},
+		want:   []common.Address{b, c},
+	}}
+
+	for i, tc := range cases {
+		if have := BannedProposers(tc.rounds, 2); !reflect.DeepEqual(have, tc.want) {
+			t.Errorf("case %d: banned mismatch: have %v, want %v", i, have, tc.want)
+		}
+	}
+}
+
+func TestSelectProposer(t *testing.T) {
+	var addrs []common.Address
+	for _, strAddr := range testAddresses {
+		addrs = append(addrs, common.HexToAddress(strAddr))
+	}
+
+	v, err := istanbul.CombineIstanbulExtraToValidatorData(addrs, make([]blscrypto.SerializedPublicKey, len(addrs)))
+	if err != nil {
+		t.Fatalf("CombineIstanbulExtraToValidatorData(...): %v", err)
+	}
+	valSet := newDefaultSet(v)
+	valSet.SetRandomness(common.HexToHash("0x123"))
+
+	cases := []struct {
+		policy       istanbul.ProposerPolicy
+		lastProposer common.Address
+		round        uint64
+		want         common.Address
+	}{
+		{istanbul.Sticky, addrs[2], 0, addrs[2]},
+		{istanbul.Sticky, addrs[2], 3, addrs[0]},
+		{istanbul.RoundRobin, addrs[2], 0, addrs[3]},
+		{istanbul.RoundRobin, addrs[2], 3, addrs[1]},
+		{istanbul.RoundRobin, common.Address{}, 1, addrs[1]},
+		{istanbul.StickyWithFallback, addrs[4], 1, addrs[0]},
+		{istanbul.ShuffledRoundRobin, addrs[2], 0, ShuffledRoundRobinProposer(valSet, addrs[2], 0).Address()},
+		{istanbul.ShuffledRoundRobin, addrs[2], 7, ShuffledRoundRobinProposer(valSet, addrs[2], 7).Address()},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case:%d", i), func(t *testing.T) {
+			proposer := SelectProposer(valSet, c.lastProposer, c.round, c.policy)
+			if proposer != c.want {
+				t.Errorf("proposer mismatch: have %v, want %v", proposer.Hex(), c.want.Hex())
+			}
+			// The same inputs always select the same proposer
+			if again := SelectProposer(valSet, c.lastProposer, c.round, c.policy); again != proposer {
+				t.Errorf("non-deterministic selection: have %v, previously %v", again.Hex(), proposer.Hex())
+			}
+		})
+	}
+
+	if proposer := SelectProposer(newDefaultSet(nil), addrs[0], 0, istanbul.RoundRobin); proposer != (common.Address{}) {
+		t.Errorf("expected the zero address for an empty validator set, have %v", proposer.Hex())
+	}
+}
+
+func TestSelectProposerSkipsDemoted(t *testing.T) {
+	var addrs []common.Address
+	for _, strAddr := range testAddresses {
+		addrs = append(addrs, common.HexToAddress(strAddr))
+	}
+
+	v, err := istanbul.CombineIstanbulExtraToValidatorData(addrs, make([]blscrypto.SerializedPublicKey, len(addrs)))
+	if err != nil {
+		t.Fatalf("CombineIstanbulExtraToValidatorData(...): %v", err)
+	}
+	valSet := newDefaultSet(v)
+	valSet.SetDemotedProposers([]common.Address{addrs[3], addrs[4]})
+
+	cases := []struct {
+		policy       istanbul.ProposerPolicy
+		lastProposer common.Address
+		round        uint64
+		want         common.Address
+	}{
+		{istanbul.RoundRobin, addrs[1], 0, addrs[2]},
+		// addrs[3] and addrs[4] are replaced by the proposer of the next eligible round
+		{istanbul.RoundRobin, addrs[2], 0, addrs[0]},
+		{istanbul.RoundRobin, addrs[2], 1, addrs[0]},
+		{istanbul.Sticky, addrs[3], 0, addrs[0]},
+	}
+	for i, c := range cases {
+		if proposer := SelectProposer(valSet, c.lastProposer, c.round, c.policy); proposer != c.want {
+			t.Errorf("case %d: proposer mismatch: have %v, want %v", i, proposer.Hex(), c.want.Hex())
+		}
+	}
+
+	// If every validator is demoted, none is skipped
+	valSet.SetDemotedProposers(addrs)
+	if proposer := SelectProposer(valSet, addrs[2], 0, istanbul.RoundRobin); proposer != addrs[3] {
+		t.Errorf("proposer mismatch with every validator demoted: have %v, want %v", proposer.Hex(), addrs[3].Hex())
+	}
+}
+
+func TestRegisterProposerSelector(t *testing.T) {
+	var addrs []common.Address
+	for _, strAddr := range testAddresses {
+		addrs = append(addrs, common.HexToAddress(strAddr))
+	}
+
+	v, err := istanbul.CombineIstanbulExtraToValidatorData(addrs, make([]blscrypto.SerializedPublicKey, len(addrs)))
+	if err != nil {
+		t.Fatalf("CombineIstanbulExtraToValidatorData(...): %v", err)
+	}
+	valSet := newDefaultSet(v)
+
+	lastValidator := istanbul.ProposerSelectorFunc(func(valSet istanbul.ValidatorSet, proposer common.Address, round uint64) istanbul.Validator {
+		if valSet.Size() == 0 {
+			return nil
+		}
+		return valSet.List()[valSet.Size()-1]
+	})
+	policy := istanbul.ProposerPolicy(100)
+	if err := RegisterProposerSelector(policy, "LastValidator", lastValidator); err != nil {
+		t.Fatalf("RegisterProposerSelector(...): %v", err)
+	}
+
+	if proposer := SelectProposer(valSet, addrs[0], 0, policy); proposer != addrs[len(addrs)-1] {
+		t.Errorf("proposer mismatch: have %v, want %v", proposer.Hex(), addrs[len(addrs)-1].Hex())
+	}
+	config := *istanbul.DefaultConfig
+	config.ProposerPolicy = policy
+	if err := config.Validate(); err != nil {
+		t.Errorf("config with a registered proposer policy should be valid: %v", err)
+	}
+	if policy.String() != "LastValidator" {
+		t.Errorf("policy name mismatch: have %v, want %v", policy.String(), "LastValidator")
+	}
+
+	if err := RegisterProposerSelector(policy, "Other", lastValidator); err == nil {
+		t.Errorf("expected an error registering an already registered policy")
+	}
+	if err := RegisterProposerSelector(istanbul.ProposerPolicy(101), "RoundRobin", lastValidator); err == nil {
+		t.Errorf("expected an error registering an already registered policy name")
+	}
+	if err := RegisterProposerSelector(istanbul.ProposerPolicy(102), "Nil", nil); err == nil {
+		t.Errorf("expected an error registering a nil selector")
+	}
+}
@@ -29,6 +29,8 @@ type Broadcaster interface {
 	Enqueue(id string, block *types.Block)
 	// FindPeers retrives peers by addresses
 	FindPeers(targets map[enode.ID]bool, purpose p2p.PurposeFlag) map[enode.ID]Peer
+	// Synchronise starts synchronising the chain with the best peer, if that peer is ahead
+	Synchronise()
 }
 
 // P2PServer defines the interface for a p2p.server to get the local node's enode and to add/remove for static/trusted peers
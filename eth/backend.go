@@ -18,6 +18,7 @@
 package eth
 
 import (
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"math/big"
@@ -40,6 +41,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -288,10 +290,29 @@ func CreateConsensusEngine(ctx *node.ServiceContext, chainConfig *params.ChainCo
 		if chainConfig.Istanbul.LookbackWindow != 0 {
 			config.Istanbul.LookbackWindow = chainConfig.Istanbul.LookbackWindow
 		}
+		config.Istanbul.NewValidatorGraceBlocks = chainConfig.Istanbul.NewValidatorGraceBlocks
+		config.Istanbul.NewValidatorGraceForkBlock = chainConfig.Istanbul.NewValidatorGraceForkBlock
 		if chainConfig.Istanbul.LookbackWindow >= chainConfig.Istanbul.Epoch-1 {
 			log.Crit("istanbul.lookbackwindow must be less than istanbul.epoch-1")
 		}
 		config.Istanbul.ProposerPolicy = istanbul.ProposerPolicy(chainConfig.Istanbul.ProposerPolicy)
+		config.Istanbul.ShuffleSeedSource = istanbul.ShuffleSeedSource(chainConfig.Istanbul.ShuffleSeedSource)
+		config.Istanbul.FreezeProposerOrderWithinEpoch = chainConfig.Istanbul.FreezeProposerOrderWithinEpoch
+		if chainConfig.Istanbul.StickyFallbackThreshold != 0 {
+			config.Istanbul.StickyFallbackThreshold = chainConfig.Istanbul.StickyFallbackThreshold
+		}
+		if chainConfig.Istanbul.StickyFallbackCooldown != 0 {
+			config.Istanbul.StickyFallbackCooldown = chainConfig.Istanbul.StickyFallbackCooldown
+		}
+		config.Istanbul.ProposerBanWindow = chainConfig.Istanbul.ProposerBanWindow
+		if chainConfig.Istanbul.ProposerBanThreshold != 0 {
+			config.Istanbul.ProposerBanThreshold = chainConfig.Istanbul.ProposerBanThreshold
+		}
+		config.Istanbul.ProposerBanForkBlock = chainConfig.Istanbul.ProposerBanForkBlock
+		if chainConfig.Istanbul.ProposerPolicyForkBlock != 0 {
+			config.Istanbul.LegacyProposerPolicy = istanbul.ProposerPolicy(chainConfig.Istanbul.LegacyProposerPolicy)
+			config.Istanbul.ProposerPolicyForkBlock = chainConfig.Istanbul.ProposerPolicyForkBlock
+		}
 		return istanbulBackend.New(&config.Istanbul, db)
 	}
 	log.Error(fmt.Sprintf("Only Istanbul Consensus is supported: %v", chainConfig))
@@ -443,6 +464,24 @@ func (s *Ethereum) SetEtherbase(etherbase common.Address) {
 	s.miner.SetEtherbase(etherbase)
 }
 
+// recoverPublicKey recovers the public key of an account from a signature of its wallet, for wallets that don't
+// expose public keys, such as external signers.
+func recoverPublicKey(wallet accounts.Wallet, account accounts.Account) (*ecdsa.PublicKey, error) {
+	data := istanbul.PublicKeyRecoveryPayload
+	sig, err := wallet.SignData(account, accounts.MimetypeIstanbul, data)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := crypto.SigToPub(crypto.Keccak256(data), sig)
+	if err != nil {
+		return nil, err
+	}
+	if crypto.PubkeyToAddress(*publicKey) != account.Address {
+		return nil, fmt.Errorf("signature of %v recovered to %v", account.Address.Hex(), crypto.PubkeyToAddress(*publicKey).Hex())
+	}
+	return publicKey, nil
+}
+
 // StartMining starts the miner with the given number of CPU threads. If mining
 // is already running, this method adjust the number of threads allowed to use
 // and updates the minimum price required by the transaction pool.
@@ -486,6 +525,11 @@ func (s *Ethereum) StartMining(threads int) error {
 				return fmt.Errorf("signer missing: %v", err)
 			}
 			publicKey, err := wallet.GetPublicKey(ebAccount)
+			if err == accounts.ErrNotSupported {
+				// External signers don't expose public keys, nor decrypt the enode URLs announced to this validator
+				log.Warn("Etherbase is held by a signer that can't decrypt, the enode URLs announced to this validator will be dropped", "etherbase", eb)
+				publicKey, err = recoverPublicKey(wallet, ebAccount)
+			}
 			if err != nil {
 				return fmt.Errorf("ECDSA public key missing: %v", err)
 			}
@@ -621,6 +665,18 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
+	// Let a validator take part in committing the current sequence before the chain is stopped
+	if istanbul, isIstanbul := s.engine.(*istanbulBackend.Backend); isIstanbul && istanbul.IsValidating() {
+		if err := istanbul.GracefulStopValidating(); err != nil {
+			log.Warn("Error in gracefully stopping validating", "err", err)
+		}
+	}
+	// Flush the pending announce messages while the peers are still connected
+	if istanbul, isIstanbul := s.engine.(*istanbulBackend.Backend); isIstanbul {
+		istanbul.GracefulStopAnnouncing()
+	} else {
+		s.stopAnnounce()
+	}
 	s.bloomIndexer.Close()
 	s.blockchain.Stop()
 	s.engine.Close()
@@ -628,7 +684,6 @@ func (s *Ethereum) Stop() error {
 	if s.lesServer != nil {
 		s.lesServer.Stop()
 	}
-	s.stopAnnounce()
 	s.txPool.Stop()
 	s.miner.Stop()
 	s.eventMux.Stop()
@@ -888,6 +888,11 @@ func (pm *ProtocolManager) Enqueue(id string, block *types.Block) {
 	pm.blockFetcher.Enqueue(id, block)
 }
 
+// Synchronise implements consensus.Broadcaster.Synchronise
+func (pm *ProtocolManager) Synchronise() {
+	go pm.synchronise(pm.peers.BestPeer())
+}
+
 // BroadcastBlock will either propagate a block to a subset of its peers, or
 // will only announce its availability (depending what's requested).
 func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
@@ -105,6 +105,16 @@ web3._extend({
 			call: 'admin_sleepBlocks',
 			params: 2
 		}),
+		new web3._extend.Method({
+			name: 'setIstanbulConfig',
+			call: 'admin_setIstanbulConfig',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'migrateValidatorEnodeDB',
+			call: 'admin_migrateValidatorEnodeDB',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'startRPC',
 			call: 'admin_startRPC',
@@ -744,6 +754,12 @@ web3._extend({
 			params: 1,
 			inputFormatter: [null]
 		}),
+		new web3._extend.Method({
+			name: 'getValidatorUptime',
+			call: 'istanbul_getValidatorUptime',
+			params: 1,
+			inputFormatter: [null]
+		}),
 		new web3._extend.Method({
 			name: 'getValidatorsBLSPublicKeys',
 			call: 'istanbul_getValidatorsBLSPublicKeys',
@@ -756,6 +772,29 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new web3._extend.Method({
+			name: 'getBannedProposers',
+			call: 'istanbul_getBannedProposers',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getBlockProposer',
+			call: 'istanbul_getBlockProposer',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getCommitSigners',
+			call: 'istanbul_getCommitSigners',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'predictProposers',
+			call: 'istanbul_predictProposers',
+			params: 2
+		}),
 		new web3._extend.Method({
 			name: 'addProxy',
 			call: 'istanbul_addProxy',
@@ -778,6 +817,70 @@ web3._extend({
 			params: 1,
 			inputFormatter: [null]
 		}),
+		new web3._extend.Method({
+			name: 'getDoubleSignEvidence',
+			call: 'istanbul_getDoubleSignEvidence',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getEquivocationEvidence',
+			call: 'istanbul_getEquivocationEvidence',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getProposerFairness',
+			call: 'istanbul_getProposerFairness',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorSetChanges',
+			call: 'istanbul_getValidatorSetChanges',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'dumpRoundStateHistory',
+			call: 'istanbul_dumpRoundStateHistory',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'traceSequence',
+			call: 'istanbul_traceSequence',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'selfTest',
+			call: 'istanbul_selfTest',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'excludeProposer',
+			call: 'istanbul_excludeProposer',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'forceRoundChange',
+			call: 'istanbul_forceRoundChange',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorEnodeTable',
+			call: 'istanbul_getValidatorEnodeTable',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'exportValidatorEnodes',
+			call: 'istanbul_exportValidatorEnodes',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'importValidatorEnodes',
+			call: 'istanbul_importValidatorEnodes',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'start',
 			call: 'istanbul_startValidating',
@@ -796,10 +899,22 @@ web3._extend({
 			name: 'versionCertificateTableInfo',
 			getter: 'istanbul_getVersionCertificateTableInfo',
 		}),
+		new web3._extend.Property({
+			name: 'announceStats',
+			getter: 'istanbul_getAnnounceStats',
+		}),
+		new web3._extend.Property({
+			name: 'observerStats',
+			getter: 'istanbul_getObserverStats',
+		}),
 		new web3._extend.Property({
 			name: 'currentRoundState',
 			getter: 'istanbul_getCurrentRoundState',
 		}),
+		new web3._extend.Property({
+			name: 'proposerStatus',
+			getter: 'istanbul_proposerStatus',
+		}),
 		new web3._extend.Property({
 			name: 'proxies',
 			getter: 'istanbul_getProxiesInfo',
@@ -808,6 +923,10 @@ web3._extend({
 			name: 'proxiedValidators',
 			getter: 'istanbul_getProxiedValidators',
 		}),
+		new web3._extend.Property({
+			name: 'proxyInfo',
+			getter: 'istanbul_getProxyInfo',
+		}),
 		new web3._extend.Property({
 			name: 'validating',
 			getter: 'istanbul_isValidating',
@@ -249,6 +249,23 @@ type IstanbulConfig struct {
 	LookbackWindow uint64 `json:"lookbackwindow"`           // The number of blocks to look back when calculating uptime
 	BlockPeriod    uint64 `json:"blockperiod,omitempty"`    // Default minimum difference between two consecutive block's timestamps in second
 	RequestTimeout uint64 `json:"requesttimeout,omitempty"` // The timeout for each Istanbul round in milliseconds.
+
+	StickyFallbackThreshold uint64 `json:"stickyfallbackthreshold,omitempty"` // The number of consecutive failed turns after which the StickyWithFallback policy demotes a proposer
+	StickyFallbackCooldown  uint64 `json:"stickyfallbackcooldown,omitempty"`  // The number of blocks that the StickyWithFallback policy looks back for failed turns of a proposer
+
+	ProposerBanWindow    uint64 `json:"proposerbanwindow,omitempty"`    // The number of most recent rounds in which the failed rounds of each proposer are counted. Zero disables the proposer ban
+	ProposerBanThreshold uint64 `json:"proposerbanthreshold,omitempty"` // The number of failed rounds within ProposerBanWindow above which a proposer is skipped
+	ProposerBanForkBlock uint64 `json:"proposerbanforkblock,omitempty"` // The first block whose proposer is selected with the proposer ban. Zero applies it from genesis
+
+	ShuffleSeedSource uint64 `json:"shuffleseedsource,omitempty"` // The source of the seed with which the ShuffledRoundRobin policy shuffles the validator set: 0 for the randomness beacon, 1 for the hash of the last block of the previous epoch
+
+	FreezeProposerOrderWithinEpoch bool `json:"freezeproposerorderwithinepoch,omitempty"` // Whether the ShuffledRoundRobin policy uses the order seeded at the epoch block for every block of the epoch, including the first one
+
+	LegacyProposerPolicy    uint64 `json:"legacypolicy,omitempty"`    // The policy for proposer selection before ProposerPolicyForkBlock
+	ProposerPolicyForkBlock uint64 `json:"policyforkblock,omitempty"` // The first block whose proposer is selected with ProposerPolicy instead of LegacyProposerPolicy. Zero uses ProposerPolicy from genesis
+
+	NewValidatorGraceBlocks    uint64 `json:"newvalidatorgraceblocks,omitempty"`    // The number of blocks after a validator enters the validator set during which missing votes don't lower its uptime
+	NewValidatorGraceForkBlock uint64 `json:"newvalidatorgraceforkblock,omitempty"` // The first block at which the uptime of the epoch it ends is scored with NewValidatorGraceBlocks. Zero applies it from genesis
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -216,6 +217,42 @@ func (api *SignerAPI) determineSignatureFormat(ctx context.Context, contentType
 			},
 		}
 		req = &SignDataRequest{ContentType: mediaType, Rawdata: []byte(msg), Messages: messages, Hash: sighash}
+	case accounts.MimetypeIstanbul, accounts.MimetypeIstanbulHeader:
+		// Istanbul consensus messages and block seals, signed by validators that keep their key in the signer
+		stringData, ok := data.(string)
+		if !ok {
+			return nil, useEthereumV, fmt.Errorf("input for %v must be an hex-encoded string", mediaType)
+		}
+		istanbulData, err := hexutil.Decode(stringData)
+		if err != nil {
+			return nil, useEthereumV, err
+		}
+		// Istanbul data is signed without a prefix, so only the Istanbul payloads are accepted, lest a
+		// transaction of the validator's account gets signed
+		var description string
+		if mediaType == accounts.MimetypeIstanbulHeader {
+			description, err = istanbul.DescribeHeaderSigningPayload(istanbulData)
+		} else {
+			description, err = istanbul.DescribeSigningPayload(addr.Address(), istanbulData)
+		}
+		if err != nil {
+			return nil, useEthereumV, fmt.Errorf("invalid input for %v: %v", mediaType, err)
+		}
+		messages := []*NameValueType{
+			{
+				Name:  "Istanbul consensus data",
+				Typ:   "description",
+				Value: description,
+			},
+			{
+				Name:  "Full data for signing",
+				Typ:   "hexdata",
+				Value: stringData,
+			},
+		}
+		// Istanbul signs with V on the form 0 or 1
+		useEthereumV = false
+		req = &SignDataRequest{ContentType: mediaType, Rawdata: istanbulData, Messages: messages, Hash: accounts.DataHash(mediaType, istanbulData)}
 	default: // also case TextPlain.Mime:
 		// Calculates an Ethereum ECDSA signature for:
 		// hash = keccak256("\x19${byteVersion}Ethereum Signed Message:\n${message length}${message}")
@@ -21,15 +21,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"path"
 	"strings"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/signer/core"
 )
 
@@ -218,6 +223,40 @@ func TestSignData(t *testing.T) {
 	if signature == nil || len(signature) != 65 {
 		t.Errorf("Expected 65 byte signature (got %d bytes)", len(signature))
 	}
+	// application/x-istanbul-msg
+	control.approveCh <- "Y"
+	control.inputCh <- "a_long_password"
+	istanbulMsg, err := (&istanbul.Message{Code: istanbul.ConsensusMsg, Msg: []byte("payload"), Address: a.Address()}).PayloadNoSig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err = api.SignData(context.Background(), accounts.MimetypeIstanbul, a, hexutil.Encode(istanbulMsg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pubkey, err := crypto.SigToPub(crypto.Keccak256(istanbulMsg), signature); err != nil || crypto.PubkeyToAddress(*pubkey) != a.Address() {
+		t.Errorf("Istanbul signature not recoverable to %v: %v", a.Address().Hex(), err)
+	}
+	// application/x-istanbul-header
+	control.approveCh <- "Y"
+	control.inputCh <- "a_long_password"
+	extra, err := rlp.EncodeToBytes(&types.IstanbulExtra{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &types.Header{Number: big.NewInt(1), Extra: append(make([]byte, types.IstanbulExtraVanity), extra...)}
+	headerData, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err = api.SignData(context.Background(), accounts.MimetypeIstanbulHeader, a, hexutil.Encode(headerData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealHash := crypto.Keccak256(header.Hash().Bytes())
+	if pubkey, err := crypto.SigToPub(sealHash, signature); err != nil || crypto.PubkeyToAddress(*pubkey) != a.Address() {
+		t.Errorf("Istanbul header seal not recoverable to %v: %v", a.Address().Hex(), err)
+	}
 	// data/typed
 	control.approveCh <- "Y"
 	control.inputCh <- "a_long_password"
@@ -230,6 +269,38 @@ func TestSignData(t *testing.T) {
 	}
 }
 
+func TestSignIstanbulDataRejectsTransactions(t *testing.T) {
+	api, control := setup(t)
+	createAccount(control, api, t)
+	control.approveCh <- "A"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := common.NewMixedcaseAddress(list[0])
+
+	// The signing hash of a transaction is the keccak256 of this RLP encoding, which the signer must not sign as
+	// Istanbul data
+	to := common.HexToAddress("0x1")
+	txData, err := rlp.EncodeToBytes([]interface{}{uint64(0), big.NewInt(1), uint64(21000), (*common.Address)(nil), (*common.Address)(nil), big.NewInt(0), &to, big.NewInt(1e18), []byte{}, big.NewInt(1), uint(0), uint(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mimeType := range []string{accounts.MimetypeIstanbul, accounts.MimetypeIstanbulHeader} {
+		if signature, err := api.SignData(context.Background(), mimeType, a, hexutil.Encode(txData)); err == nil {
+			t.Errorf("%v: signed a transaction: %x", mimeType, signature)
+		}
+	}
+	// Neither are messages of other validators
+	otherMsg, err := (&istanbul.Message{Code: istanbul.ConsensusMsg, Address: to}).PayloadNoSig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature, err := api.SignData(context.Background(), accounts.MimetypeIstanbul, a, hexutil.Encode(otherMsg)); err == nil {
+		t.Errorf("signed a message of another validator: %x", signature)
+	}
+}
+
 func TestDomainChainId(t *testing.T) {
 	withoutChainID := core.TypedData{
 		Types: core.Types{